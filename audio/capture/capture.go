@@ -0,0 +1,225 @@
+// Package capture 提供麦克风 PCM 采集
+//
+// 通过系统录音程序（arecord / parec / sox rec / ffmpeg）的标准输出读取 16-bit 小端 PCM，
+// 不引入 cgo 依赖；未安装任何录音程序时返回 ErrNoRecorder。
+package capture
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNoRecorder 未找到可用的系统录音程序
+var ErrNoRecorder = errors.New("no audio recorder found (install alsa-utils, pulseaudio-utils, sox or ffmpeg)")
+
+// DefaultRecorders 自动探测顺序
+var DefaultRecorders = []string{"arecord", "parec", "rec", "ffmpeg"}
+
+// stderrLimit 保留的录音程序错误输出长度（用于设备不可用等错误信息）
+const stderrLimit = 4096
+
+// Options 采集选项
+type Options struct {
+	Device     string // 输入设备（arecord -D / parec --device / sox AUDIODEV / ffmpeg -i）；空为系统默认
+	SampleRate int    // 采样率（默认 16000）
+	Channels   int    // 声道数（默认 1）
+	Recorder   string // 指定录音程序（arecord/parec/rec/ffmpeg）；空则按 DefaultRecorders 自动探测
+}
+
+// normalize 填充默认值
+func (o *Options) normalize() {
+	if o.SampleRate <= 0 {
+		o.SampleRate = 16000
+	}
+	if o.Channels <= 0 {
+		o.Channels = 1
+	}
+}
+
+// Recorder 录音进程（实现 io.ReadCloser，读出 16-bit 小端 PCM）
+type Recorder struct {
+	ctx    context.Context
+	name   string
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr *limitedBuffer
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	waitOnce  sync.Once
+	waitErr   error
+}
+
+// Open 启动录音进程；ctx 取消时采集停止，Read 返回 io.EOF
+func Open(ctx context.Context, opts Options) (*Recorder, error) {
+	opts.normalize()
+
+	name, path, err := findRecorder(opts.Recorder)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, path, recorderArgs(name, opts)...)
+	if name == "rec" && opts.Device != "" {
+		cmd.Env = append(cmd.Environ(), "AUDIODEV="+opts.Device)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("recorder stdout: %w", err)
+	}
+	stderr := &limitedBuffer{limit: stderrLimit}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", name, err)
+	}
+
+	slog.Debug("Recorder started", "component", "capture", "recorder", name,
+		"device", opts.Device, "sample_rate", opts.SampleRate, "channels", opts.Channels)
+
+	return &Recorder{ctx: ctx, name: name, cmd: cmd, stdout: stdout, stderr: stderr, closed: make(chan struct{})}, nil
+}
+
+// Name 返回实际使用的录音程序名称
+func (r *Recorder) Name() string {
+	return r.name
+}
+
+// Read 读取采集到的 PCM 数据（按实时速度产生，无数据时阻塞）
+//
+// 采集被 Close 或 ctx 取消时返回 io.EOF；录音程序异常退出（如设备不可用）时返回其错误输出。
+func (r *Recorder) Read(p []byte) (int, error) {
+	n, err := r.stdout.Read(p)
+	if err == nil {
+		return n, nil
+	}
+	if werr := r.wait(); werr != nil && !r.stopped() {
+		return n, werr
+	}
+	return n, io.EOF
+}
+
+// Close 停止采集并回收录音进程
+func (r *Recorder) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+		if r.cmd.Process != nil {
+			r.cmd.Process.Kill()
+		}
+	})
+	if err := r.wait(); err != nil && !r.stopped() {
+		return err
+	}
+	return nil
+}
+
+// stopped 采集是否由调用方停止（Close 或 ctx 取消），此时进程退出码不是错误
+func (r *Recorder) stopped() bool {
+	select {
+	case <-r.closed:
+		return true
+	default:
+		return r.ctx.Err() != nil
+	}
+}
+
+// wait 等待进程退出（只执行一次），失败时附带错误输出
+func (r *Recorder) wait() error {
+	r.waitOnce.Do(func() {
+		if err := r.cmd.Wait(); err != nil {
+			if msg := strings.TrimSpace(r.stderr.String()); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			r.waitErr = fmt.Errorf("%s: %w", r.name, err)
+		}
+	})
+	return r.waitErr
+}
+
+// findRecorder 查找录音程序可执行文件
+func findRecorder(name string) (string, string, error) {
+	candidates := DefaultRecorders
+	if name != "" {
+		candidates = []string{name}
+	}
+	for _, c := range candidates {
+		if path, err := exec.LookPath(c); err == nil {
+			return c, path, nil
+		}
+	}
+	if name != "" {
+		return "", "", fmt.Errorf("recorder %q: %w", name, ErrNoRecorder)
+	}
+	return "", "", ErrNoRecorder
+}
+
+// recorderArgs 构建各录音程序向标准输出写原始 16-bit 小端 PCM 的参数
+func recorderArgs(name string, o Options) []string {
+	rate := strconv.Itoa(o.SampleRate)
+	ch := strconv.Itoa(o.Channels)
+
+	switch name {
+	case "arecord":
+		args := []string{"-q", "-t", "raw", "-f", "S16_LE", "-r", rate, "-c", ch}
+		if o.Device != "" {
+			args = append(args, "-D", o.Device)
+		}
+		return append(args, "-")
+	case "parec":
+		args := []string{"--raw", "--format=s16le", "--rate=" + rate, "--channels=" + ch}
+		if o.Device != "" {
+			args = append(args, "--device="+o.Device)
+		}
+		return args
+	case "rec":
+		return []string{"-q", "-t", "raw", "-r", rate, "-e", "signed-integer",
+			"-b", "16", "-c", ch, "-L", "-"}
+	default: // ffmpeg
+		input, device := ffmpegInput()
+		if o.Device != "" {
+			device = o.Device
+		}
+		return []string{"-nostdin", "-loglevel", "error", "-f", input, "-i", device,
+			"-f", "s16le", "-ar", rate, "-ac", ch, "-"}
+	}
+}
+
+// ffmpegInput 返回当前系统 ffmpeg 的采集输入格式及默认设备（macOS 为 avfoundation，其余为 ALSA）
+func ffmpegInput() (string, string) {
+	if runtime.GOOS == "darwin" {
+		return "avfoundation", ":0"
+	}
+	return "alsa", "default"
+}
+
+// limitedBuffer 只保留前 limit 字节的写入缓冲（并发安全）
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write 实现 io.Writer 接口，超出 limit 的部分被丢弃
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// String 返回已保留的内容
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
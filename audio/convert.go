@@ -145,6 +145,25 @@ func CalculateChunkSize(durationMs, sampleRate, channels, bitsPerSample int) int
 	return samplesPerChunk * channels * bytesPerSample
 }
 
+// FrameSize 返回一个采样帧（所有声道各一个采样点）的字节数
+func FrameSize(channels, bitsPerSample int) int {
+	return channels * bitsPerSample / 8
+}
+
+// ValidatePCMSize 校验 PCM 字节数与声明的声道数、位深对齐
+// 无头 PCM 没有格式信息，字节数不是帧大小的整数倍通常意味着格式参数声明错误
+func ValidatePCMSize(size, channels, bitsPerSample int) error {
+	if channels <= 0 || bitsPerSample <= 0 || bitsPerSample%8 != 0 {
+		return fmt.Errorf("invalid PCM format: channels=%d bits=%d", channels, bitsPerSample)
+	}
+	frame := FrameSize(channels, bitsPerSample)
+	if size%frame != 0 {
+		return fmt.Errorf("PCM size %d is not a multiple of frame size %d (channels=%d bits=%d)",
+			size, frame, channels, bitsPerSample)
+	}
+	return nil
+}
+
 // CalculateDuration 计算PCM数据时长（毫秒）
 func CalculateDuration(pcmSize, sampleRate, channels, bitsPerSample int) int {
 	bytesPerSample := bitsPerSample / 8
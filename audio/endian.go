@@ -0,0 +1,106 @@
+// Package audio PCM 字节序处理
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// SwapPCM 原地翻转每个采样点的字节序（大端 <-> 小端）
+// bitsPerSample 为 8 时无需翻转；末尾不足一个采样点的字节保持不变
+func SwapPCM(pcm []byte, bitsPerSample int) {
+	width := bitsPerSample / 8
+	if width <= 1 {
+		return
+	}
+	for i := 0; i+width <= len(pcm); i += width {
+		for a, b := i, i+width-1; a < b; a, b = a+1, b-1 {
+			pcm[a], pcm[b] = pcm[b], pcm[a]
+		}
+	}
+}
+
+// ReadPCM16 从 Reader 读取全部 16-bit PCM 并按指定字节序解码
+func ReadPCM16(r io.Reader, order binary.ByteOrder) ([]int16, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read pcm: %w", err)
+	}
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("read pcm: odd byte count %d for 16-bit samples", len(data))
+	}
+	return BytesToInt16(data, order), nil
+}
+
+// WritePCM16 按指定字节序将 16-bit 采样写入 Writer
+func WritePCM16(w io.Writer, samples []int16, order binary.ByteOrder) error {
+	if _, err := w.Write(Int16ToBytes(samples, order)); err != nil {
+		return fmt.Errorf("write pcm: %w", err)
+	}
+	return nil
+}
+
+// ByteSwapper 流式字节序转换器
+// 分块输入时采样点可能跨块，不完整的尾部字节会保留到下一次 Convert
+type ByteSwapper struct {
+	width   int
+	pending []byte
+}
+
+// NewByteSwapper 创建字节序转换器
+func NewByteSwapper(bitsPerSample int) *ByteSwapper {
+	return &ByteSwapper{width: bitsPerSample / 8}
+}
+
+// Convert 返回翻转字节序后的完整采样点（新分配，不修改输入）
+func (s *ByteSwapper) Convert(p []byte) []byte {
+	buf := append(s.pending, p...)
+	n := len(buf)
+	if s.width > 1 {
+		n -= n % s.width
+	}
+	out := make([]byte, n)
+	copy(out, buf[:n])
+	s.pending = append([]byte(nil), buf[n:]...)
+	SwapPCM(out, s.width*8)
+	return out
+}
+
+// Pending 返回尚未凑满一个采样点的字节数
+func (s *ByteSwapper) Pending() int {
+	return len(s.pending)
+}
+
+// byteSwapReader 读取时翻转字节序的 Reader
+type byteSwapReader struct {
+	r       io.Reader
+	swapper *ByteSwapper
+	buf     []byte
+}
+
+// NewByteSwapReader 包装 Reader，读出的数据已翻转字节序（如大端采集 -> 小端）
+func NewByteSwapReader(r io.Reader, bitsPerSample int) io.Reader {
+	return &byteSwapReader{r: r, swapper: NewByteSwapper(bitsPerSample)}
+}
+
+// Read 实现 io.Reader 接口
+func (r *byteSwapReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for len(r.buf) == 0 {
+		chunk := make([]byte, len(p))
+		n, err := r.r.Read(chunk)
+		r.buf = r.swapper.Convert(chunk[:n])
+		if err != nil {
+			if len(r.buf) > 0 {
+				break
+			}
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,144 @@
+// Package audio 响度归一化
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// DefaultTargetDBFS 默认目标响度（RMS，dBFS）
+const DefaultTargetDBFS = -20.0
+
+const (
+	normFrameMs     = 10    // 增益更新粒度
+	normSilenceDBFS = -50.0 // 低于此电平视为静音，保持当前增益（避免放大底噪）
+	normMaxGainDB   = 20.0  // 最大提升
+	normMinGainDB   = -20.0 // 最大衰减
+)
+
+// Normalizer 流式响度归一化（AGC），处理 16-bit 小端单声道 PCM
+//
+// 按 10ms 帧跟踪 RMS 包络（上升快、下降慢），增益向"目标电平 / 包络"平滑靠拢，
+// 帧内线性插值避免增益跳变产生咔哒声；输出饱和截断，不会回绕。
+// 首个非静音帧直接采用目标增益，使每轮开头即达到目标响度。
+// 跨 Process 调用保留包络、增益和不足一个采样点的字节，可逐块处理音频流。
+type Normalizer struct {
+	targetRMS float64 // 目标 RMS（线性，满幅为 1）
+	frame     int     // 每帧采样数
+	active    bool    // 是否已遇到非静音帧
+	env       float64 // RMS 包络
+	gain      float64 // 当前增益
+	pending   []byte  // 上次调用剩余的不完整采样字节
+}
+
+// NewNormalizer 创建归一化器；targetDBFS 为 0 时使用 DefaultTargetDBFS
+func NewNormalizer(targetDBFS float64, sampleRate int) *Normalizer {
+	if targetDBFS == 0 {
+		targetDBFS = DefaultTargetDBFS
+	}
+	if sampleRate <= 0 {
+		sampleRate = 8000
+	}
+	frame := sampleRate * normFrameMs / 1000
+	if frame < 1 {
+		frame = 1
+	}
+	return &Normalizer{
+		targetRMS: dbToLinear(targetDBFS),
+		frame:     frame,
+		gain:      1,
+	}
+}
+
+// Process 归一化一块 PCM，返回处理后的数据（长度为完整采样点的字节数）
+func (n *Normalizer) Process(pcm []byte) []byte {
+	if len(n.pending) > 0 {
+		pcm = append(n.pending, pcm...)
+		n.pending = nil
+	}
+	if len(pcm)%2 != 0 {
+		n.pending = []byte{pcm[len(pcm)-1]}
+		pcm = pcm[:len(pcm)-1]
+	}
+
+	out := make([]byte, len(pcm))
+	samples := len(pcm) / 2
+	for start := 0; start < samples; start += n.frame {
+		end := start + n.frame
+		if end > samples {
+			end = samples
+		}
+		n.processFrame(pcm[2*start:2*end], out[2*start:2*end])
+	}
+	return out
+}
+
+// processFrame 更新增益并处理一帧
+func (n *Normalizer) processFrame(in, out []byte) {
+	count := len(in) / 2
+	level := rms(in)
+
+	prev := n.gain
+	switch {
+	case level < dbToLinear(normSilenceDBFS):
+		// 静音：保持增益
+	case !n.active:
+		n.active = true
+		n.env = level
+		n.gain = clampGain(n.targetRMS / n.env)
+		prev = n.gain
+	default:
+		if level > n.env {
+			n.env = 0.5*n.env + 0.5*level // 快速跟上突然变响
+		} else {
+			n.env = 0.9*n.env + 0.1*level
+		}
+		n.gain += (clampGain(n.targetRMS/n.env) - n.gain) * 0.2
+	}
+
+	for i := 0; i < count; i++ {
+		g := prev + (n.gain-prev)*float64(i+1)/float64(count)
+		v := float64(int16(binary.LittleEndian.Uint16(in[2*i:]))) * g
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(saturate16(v)))
+	}
+}
+
+// clampGain 将增益限制在 [normMinGainDB, normMaxGainDB]
+func clampGain(g float64) float64 {
+	return math.Max(dbToLinear(normMinGainDB), math.Min(dbToLinear(normMaxGainDB), g))
+}
+
+// saturate16 将采样值四舍五入并饱和截断到 int16
+func saturate16(v float64) int16 {
+	switch {
+	case v >= math.MaxInt16:
+		return math.MaxInt16
+	case v <= math.MinInt16:
+		return math.MinInt16
+	}
+	return int16(math.Round(v))
+}
+
+// dbToLinear dBFS 转线性幅度
+func dbToLinear(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// RMSDBFS 返回 16-bit 小端 PCM 的 RMS 电平（dBFS）；空数据或全零返回 -Inf
+func RMSDBFS(pcm []byte) float64 {
+	if len(pcm) < 2 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(rms(pcm))
+}
+
+// rms 返回 16-bit 小端 PCM 的线性 RMS（满幅为 1）
+func rms(pcm []byte) float64 {
+	count := len(pcm) / 2
+	var sum float64
+	for i := 0; i < count; i++ {
+		v := float64(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / 32768
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(count))
+}
@@ -0,0 +1,181 @@
+// Package playback 提供 PCM 音频播放
+//
+// 通过系统播放器（aplay / paplay / sox play / ffplay）的标准输入流式播放，
+// 不引入 cgo 依赖；未安装任何播放器时返回 ErrNoPlayer。
+package playback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strconv"
+)
+
+// ErrNoPlayer 未找到可用的系统播放器
+var ErrNoPlayer = errors.New("no audio player found (install alsa-utils, pulseaudio-utils, sox or ffmpeg)")
+
+// DefaultPlayers 自动探测顺序
+var DefaultPlayers = []string{"aplay", "paplay", "play", "ffplay"}
+
+// Options 播放选项
+type Options struct {
+	Device        string // 输出设备（aplay -D / paplay --device / sox AUDIODEV，ffplay 不支持）；空为系统默认
+	SampleRate    int    // 采样率（默认 8000）
+	Channels      int    // 声道数（默认 1）
+	BitsPerSample int    // 位深：8 或 16（默认 16）
+	Player        string // 指定播放器（aplay/paplay/play/ffplay）；空则按 DefaultPlayers 自动探测
+}
+
+// normalize 填充默认值并校验
+func (o *Options) normalize() error {
+	if o.SampleRate <= 0 {
+		o.SampleRate = 8000
+	}
+	if o.Channels <= 0 {
+		o.Channels = 1
+	}
+	if o.BitsPerSample <= 0 {
+		o.BitsPerSample = 16
+	}
+	if o.BitsPerSample != 8 && o.BitsPerSample != 16 {
+		return fmt.Errorf("unsupported bits per sample for playback: %d", o.BitsPerSample)
+	}
+	return nil
+}
+
+// Player 播放器进程（实现 io.WriteCloser，写入的 PCM 数据实时播放）
+type Player struct {
+	name  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// Open 启动播放器进程；ctx 取消时播放立即停止
+func Open(ctx context.Context, opts Options) (*Player, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	name, path, err := findPlayer(opts.Player)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, path, playerArgs(name, opts)...)
+	if name == "play" && opts.Device != "" {
+		cmd.Env = append(cmd.Environ(), "AUDIODEV="+opts.Device)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("player stdin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", name, err)
+	}
+
+	slog.Debug("Player started", "component", "playback", "player", name,
+		"sample_rate", opts.SampleRate, "channels", opts.Channels, "bits", opts.BitsPerSample)
+
+	return &Player{name: name, cmd: cmd, stdin: stdin}, nil
+}
+
+// Name 返回实际使用的播放器名称
+func (p *Player) Name() string {
+	return p.name
+}
+
+// Write 写入 PCM 数据（播放器缓冲满时阻塞，即按实时速度消费）
+func (p *Player) Write(b []byte) (int, error) {
+	return p.stdin.Write(b)
+}
+
+// Close 结束输入并等待已写入的音频播放完毕
+func (p *Player) Close() error {
+	p.stdin.Close()
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	return nil
+}
+
+// Play 播放 Reader 中的全部 PCM 数据，播放完毕后返回
+func Play(ctx context.Context, r io.Reader, opts Options) error {
+	p, err := Open(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(p, r); err != nil {
+		p.Close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("play: %w", err)
+	}
+	if err := p.Close(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// findPlayer 查找播放器可执行文件
+func findPlayer(name string) (string, string, error) {
+	candidates := DefaultPlayers
+	if name != "" {
+		candidates = []string{name}
+	}
+	for _, c := range candidates {
+		if path, err := exec.LookPath(c); err == nil {
+			return c, path, nil
+		}
+	}
+	if name != "" {
+		return "", "", fmt.Errorf("player %q: %w", name, ErrNoPlayer)
+	}
+	return "", "", ErrNoPlayer
+}
+
+// playerArgs 构建各播放器读取标准输入原始 PCM 的参数
+func playerArgs(name string, o Options) []string {
+	rate := strconv.Itoa(o.SampleRate)
+	ch := strconv.Itoa(o.Channels)
+
+	switch name {
+	case "aplay":
+		format := "S16_LE"
+		if o.BitsPerSample == 8 {
+			format = "U8"
+		}
+		args := []string{"-q", "-t", "raw", "-f", format, "-r", rate, "-c", ch}
+		if o.Device != "" {
+			args = append(args, "-D", o.Device)
+		}
+		return append(args, "-")
+	case "paplay":
+		format := "s16le"
+		if o.BitsPerSample == 8 {
+			format = "u8"
+		}
+		args := []string{"--raw", "--format=" + format, "--rate=" + rate, "--channels=" + ch}
+		if o.Device != "" {
+			args = append(args, "--device="+o.Device)
+		}
+		return args
+	case "play":
+		encoding := "signed-integer"
+		if o.BitsPerSample == 8 {
+			encoding = "unsigned-integer"
+		}
+		return []string{"-q", "-t", "raw", "-r", rate, "-e", encoding,
+			"-b", strconv.Itoa(o.BitsPerSample), "-c", ch, "-L", "-"}
+	default: // ffplay
+		format := "s16le"
+		if o.BitsPerSample == 8 {
+			format = "u8"
+		}
+		return []string{"-nodisp", "-autoexit", "-loglevel", "error",
+			"-f", format, "-ar", rate, "-ac", ch, "-i", "-"}
+	}
+}
@@ -0,0 +1,142 @@
+// Package audio 流式重采样
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// resampleHalfTaps 降采样抗混叠 FIR 半长（总长 2*resampleHalfTaps+1）
+const resampleHalfTaps = 16
+
+// Resampler 流式重采样，处理 16-bit 小端单声道 PCM
+//
+// 降采样时先经加窗 sinc 低通滤波（截止频率为目标奈奎斯特频率的 90%）抑制混叠，再线性插值；
+// 升采样直接线性插值。输入/输出位置用整数比例跟踪，跨 Process 调用无累积误差，
+// 分块处理与整段处理结果一致。流结束时调用 Flush 取出滤波器与插值器中剩余的采样。
+type Resampler struct {
+	from, to int
+	taps     []float64 // 低通滤波器系数（升采样时为 nil）
+	hist     []float64 // 滤波器输入历史（len(taps)-1 个采样）
+	skip     int       // 尚需丢弃的滤波器延迟采样数
+	buf      []float64 // 待插值的采样
+	pos      int64     // 下一个输出采样在 buf 中的位置（单位 1/to 个输入采样）
+	pending  []byte    // 上次调用剩余的不完整采样字节
+}
+
+// NewResampler 创建 fromRate → toRate 的流式重采样器
+func NewResampler(fromRate, toRate int) *Resampler {
+	r := &Resampler{from: fromRate, to: toRate}
+	if toRate < fromRate {
+		r.taps = lowpassTaps(0.9*float64(toRate)/2/float64(fromRate), resampleHalfTaps)
+		r.hist = make([]float64, len(r.taps)-1)
+		r.skip = resampleHalfTaps
+	}
+	return r
+}
+
+// Process 重采样一块 PCM；from == to 时原样返回
+func (r *Resampler) Process(pcm []byte) []byte {
+	if r.from == r.to {
+		return pcm
+	}
+	if len(r.pending) > 0 {
+		pcm = append(r.pending, pcm...)
+		r.pending = nil
+	}
+	if len(pcm)%2 != 0 {
+		r.pending = []byte{pcm[len(pcm)-1]}
+		pcm = pcm[:len(pcm)-1]
+	}
+
+	samples := make([]float64, len(pcm)/2)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(pcm[2*i:])))
+	}
+	r.feed(samples)
+	return r.interpolate(false)
+}
+
+// Flush 输出剩余采样（流结束时调用一次）
+func (r *Resampler) Flush() []byte {
+	if r.from == r.to {
+		return nil
+	}
+	r.pending = nil
+	if r.taps != nil {
+		// 补零推出滤波器延迟中的采样
+		r.feed(make([]float64, resampleHalfTaps))
+	}
+	return r.interpolate(true)
+}
+
+// feed 滤波（降采样时）并追加到待插值缓冲
+func (r *Resampler) feed(samples []float64) {
+	if r.taps == nil {
+		r.buf = append(r.buf, samples...)
+		return
+	}
+	x := append(r.hist, samples...)
+	n := len(r.taps)
+	for i := n - 1; i < len(x); i++ {
+		if r.skip > 0 {
+			r.skip--
+			continue
+		}
+		var y float64
+		for k, t := range r.taps {
+			y += t * x[i-k]
+		}
+		r.buf = append(r.buf, y)
+	}
+	r.hist = append(r.hist[:0], x[len(x)-(n-1):]...)
+}
+
+// interpolate 线性插值输出；final 为 true 时用最后一个采样补齐末尾
+func (r *Resampler) interpolate(final bool) []byte {
+	to := int64(r.to)
+	var out []byte
+	for {
+		i := r.pos / to
+		if i >= int64(len(r.buf)) || (!final && i+1 >= int64(len(r.buf))) {
+			break
+		}
+		v := r.buf[i]
+		if i+1 < int64(len(r.buf)) {
+			f := float64(r.pos%to) / float64(to)
+			v = v*(1-f) + r.buf[i+1]*f
+		}
+		out = binary.LittleEndian.AppendUint16(out, uint16(saturate16(v)))
+		r.pos += int64(r.from)
+	}
+
+	// 丢弃已越过的采样
+	drop := r.pos / to
+	if drop > int64(len(r.buf)) {
+		drop = int64(len(r.buf))
+	}
+	r.buf = append(r.buf[:0], r.buf[drop:]...)
+	r.pos -= drop * to
+	return out
+}
+
+// lowpassTaps 生成 Hamming 窗 sinc 低通滤波器（cutoff 为归一化截止频率，单位：采样率）
+func lowpassTaps(cutoff float64, half int) []float64 {
+	n := 2*half + 1
+	taps := make([]float64, n)
+	var sum float64
+	for i := range taps {
+		m := float64(i - half)
+		v := 2 * cutoff
+		if m != 0 {
+			v = math.Sin(2*math.Pi*cutoff*m) / (math.Pi * m)
+		}
+		v *= 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+		taps[i] = v
+		sum += v
+	}
+	for i := range taps {
+		taps[i] /= sum // 直流增益归一
+	}
+	return taps
+}
@@ -0,0 +1,69 @@
+// Package audio 采样格式转换
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// PCM16ToFloat32 将 16-bit 小端 PCM 转换为 [-1, 1) 范围的 float32 采样
+// 末尾不足一个采样点的字节被忽略
+func PCM16ToFloat32(pcm []byte) []float32 {
+	out := make([]float32, len(pcm)/2)
+	for i := range out {
+		out[i] = float32(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / 32768
+	}
+	return out
+}
+
+// Float32ToPCM16 将 float32 采样转换为 16-bit 小端 PCM
+// 超出 [-1, 1] 的值被截断（不回绕），NaN 视为 0
+func Float32ToPCM16(samples []float32) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, v := range samples {
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(floatToInt16(v)))
+	}
+	return out
+}
+
+// FloatPCMToPCM16 将 32-bit 浮点小端 PCM（如浮点 WAV 的 data 块）转换为 16-bit 小端 PCM
+// 末尾不足一个采样点的字节被忽略
+func FloatPCMToPCM16(data []byte) []byte {
+	samples := make([]float32, len(data)/4)
+	for i := range samples {
+		samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return Float32ToPCM16(samples)
+}
+
+// floatToInt16 将 [-1, 1] 的浮点采样量化为 int16（饱和截断）
+func floatToInt16(v float32) int16 {
+	switch {
+	case v != v: // NaN
+		return 0
+	case v >= 1:
+		return math.MaxInt16
+	case v <= -1:
+		return math.MinInt16
+	}
+	return int16(math.Round(float64(v) * 32768))
+}
+
+// BytesToInt16 按指定字节序将 PCM 字节解码为 int16 采样
+// 末尾不足一个采样点的字节被忽略
+func BytesToInt16(pcm []byte, order binary.ByteOrder) []int16 {
+	out := make([]int16, len(pcm)/2)
+	for i := range out {
+		out[i] = int16(order.Uint16(pcm[2*i:]))
+	}
+	return out
+}
+
+// Int16ToBytes 按指定字节序将 int16 采样编码为 PCM 字节
+func Int16ToBytes(samples []int16, order binary.ByteOrder) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, v := range samples {
+		order.PutUint16(out[2*i:], uint16(v))
+	}
+	return out
+}
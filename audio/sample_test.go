@@ -0,0 +1,289 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestSampleConversions 验证 int16/float32/字节之间转换的边界与字节序
+//
+// WHY: DSP 集成最常见的问题是字节序搞反（播放为噪声）和满幅值溢出回绕（爆音），
+// 这里锁定两端极值与大小端编码。
+func TestSampleConversions(t *testing.T) {
+	samples := []int16{0, 1, -1, math.MaxInt16, math.MinInt16}
+
+	le := Int16ToBytes(samples, binary.LittleEndian)
+	be := Int16ToBytes(samples, binary.BigEndian)
+	if le[2] != 0x01 || le[3] != 0x00 || be[2] != 0x00 || be[3] != 0x01 {
+		t.Fatalf("byte order wrong: le=%x be=%x", le, be)
+	}
+	for i, v := range BytesToInt16(be, binary.BigEndian) {
+		if v != samples[i] {
+			t.Fatalf("big-endian round trip [%d]: got %d, want %d", i, v, samples[i])
+		}
+	}
+
+	floats := PCM16ToFloat32(le)
+	if floats[3] >= 1 || floats[4] != -1 {
+		t.Fatalf("float range wrong: max=%v min=%v", floats[3], floats[4])
+	}
+	back := BytesToInt16(Float32ToPCM16(floats), binary.LittleEndian)
+	for i, v := range back {
+		if v != samples[i] {
+			t.Fatalf("float round trip [%d]: got %d, want %d", i, v, samples[i])
+		}
+	}
+
+	clipped := BytesToInt16(Float32ToPCM16([]float32{1.5, -2, float32(math.NaN())}), binary.LittleEndian)
+	if clipped[0] != math.MaxInt16 || clipped[1] != math.MinInt16 || clipped[2] != 0 {
+		t.Fatalf("clipping wrong: %v", clipped)
+	}
+}
+
+// TestByteSwapperAcrossChunks 验证大端输入分块时采样点跨块仍能正确翻转
+//
+// WHY: 网络/文件读取的块边界不保证落在采样点上，逐块独立翻转会把
+// 半个采样点与下一个采样点错位拼接，送入 STT 后表现为满屏噪声。
+func TestByteSwapperAcrossChunks(t *testing.T) {
+	be := Int16ToBytes([]int16{0x0102, 0x0304, 0x0506}, binary.BigEndian)
+	want := Int16ToBytes([]int16{0x0102, 0x0304, 0x0506}, binary.LittleEndian)
+
+	sw := NewByteSwapper(16)
+	var got []byte
+	for _, chunk := range [][]byte{be[:1], be[1:4], be[4:]} {
+		got = append(got, sw.Convert(chunk)...)
+	}
+	if !bytes.Equal(got, want) || sw.Pending() != 0 {
+		t.Fatalf("swapper: got %x, want %x (pending %d)", got, want, sw.Pending())
+	}
+
+	read, err := io.ReadAll(NewByteSwapReader(bytes.NewReader(be), 16))
+	if err != nil || !bytes.Equal(read, want) {
+		t.Fatalf("swap reader: got %x, want %x (err %v)", read, want, err)
+	}
+}
+
+// TestNormalizerReachesTarget 验证：轻声与响亮的输入经归一化后都接近目标电平；
+// 按奇数字节切块处理时不丢失、不错位采样。
+// WHY：不同提供商/音色的输出响度相差 10dB 以上，混排播放时需要忽大忽小地调音量。
+func TestNormalizerReachesTarget(t *testing.T) {
+	sine := func(dbfs float64, samples int) []byte {
+		amp := math.Pow(10, dbfs/20) * math.Sqrt2 * 32767 // RMS 为 dbfs 的正弦
+		out := make([]byte, samples*2)
+		for i := 0; i < samples; i++ {
+			binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(amp*math.Sin(2*math.Pi*440*float64(i)/8000))))
+		}
+		return out
+	}
+
+	for _, in := range []float64{-40, -3} {
+		pcm := sine(in, 8000) // 1 秒
+		whole := NewNormalizer(-20, 8000).Process(pcm)
+
+		n := NewNormalizer(-20, 8000)
+		var chunked []byte
+		for off := 0; off < len(pcm); off += 333 {
+			end := off + 333
+			if end > len(pcm) {
+				end = len(pcm)
+			}
+			chunked = append(chunked, n.Process(pcm[off:end])...)
+		}
+		if len(chunked) != len(pcm) {
+			t.Fatalf("input %v dBFS: chunked output %d bytes, want %d", in, len(chunked), len(pcm))
+		}
+		for name, out := range map[string][]byte{"whole": whole, "chunked": chunked} {
+			if got := RMSDBFS(out[len(out)/2:]); math.Abs(got-(-20)) > 1.5 {
+				t.Errorf("input %v dBFS (%s): output %.1f dBFS, want about -20", in, name, got)
+			}
+		}
+	}
+}
+
+// TestResamplerStreaming 验证：24kHz → 8kHz 按奇数字节切块重采样与整段结果一致、长度正确，
+// 通带内的音调电平不变，高于目标奈奎斯特频率的音调被抑制而不是混叠进通带。
+// WHY：电话侧需要 8kHz，而提供商返回 24kHz；整段 Resample 无法用于流式输出且线性插值会混叠。
+func TestResamplerStreaming(t *testing.T) {
+	tone := func(freq float64, rate, samples int) []byte {
+		out := make([]byte, samples*2)
+		for i := 0; i < samples; i++ {
+			binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(8000*math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))))
+		}
+		return out
+	}
+	resample := func(pcm []byte, chunk int) []byte {
+		r := NewResampler(24000, 8000)
+		var out []byte
+		for off := 0; off < len(pcm); off += chunk {
+			out = append(out, r.Process(pcm[off:min(off+chunk, len(pcm))])...)
+		}
+		return append(out, r.Flush()...)
+	}
+
+	pcm := tone(1000, 24000, 24000) // 1 秒
+	whole := resample(pcm, len(pcm))
+	chunked := resample(pcm, 333)
+	if !bytes.Equal(whole, chunked) {
+		t.Fatalf("chunked output differs from whole (%d vs %d bytes)", len(chunked), len(whole))
+	}
+	if len(whole) != 8000*2 {
+		t.Fatalf("output %d bytes, want %d", len(whole), 8000*2)
+	}
+	in, out := RMSDBFS(pcm), RMSDBFS(whole[1000:len(whole)-1000])
+	if math.Abs(in-out) > 0.5 {
+		t.Fatalf("1kHz tone level %.1f dBFS, want %.1f", out, in)
+	}
+
+	alias := resample(tone(6000, 24000, 24000), 480)
+	if got := RMSDBFS(alias[1000 : len(alias)-1000]); got > in-30 {
+		t.Fatalf("6kHz tone after downsampling %.1f dBFS, want below %.1f (aliasing)", got, in-30)
+	}
+}
+
+// TestProfileDistance 验证：同一信号改变音量和时长后频谱距离很小，换成不同音色（谐波结构）后距离显著；
+// 静音段不影响轮廓。
+// WHY：黄金音频回归要在提供商静默更换音色模型时报警，而不是因音量、停顿的细微差异误报。
+func TestProfileDistance(t *testing.T) {
+	voice := func(f0 float64, harmonics []float64, amp float64, ms int) []byte {
+		const rate = 16000
+		n := rate * ms / 1000
+		out := make([]byte, n*2)
+		for i := 0; i < n; i++ {
+			var v float64
+			for h, a := range harmonics {
+				v += a * math.Sin(2*math.Pi*f0*float64(h+1)*float64(i)/rate)
+			}
+			binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(amp*v)))
+		}
+		return out
+	}
+	bright := []float64{1, 0.8, 0.7, 0.6, 0.5, 0.4}
+	dark := []float64{1, 0.3, 0.05}
+
+	golden := NewProfile(voice(180, bright, 6000, 1000), 16000)
+	quieter := voice(180, bright, 3000, 1200)
+	padded := append(make([]byte, 16000), quieter...) // 前置 0.5 秒静音
+	same := NewProfile(padded, 16000)
+	other := NewProfile(voice(120, dark, 6000, 1000), 16000)
+
+	if d := golden.Distance(same); d > 1.5 {
+		t.Fatalf("distance to quieter/longer copy %.2f dB, want < 1.5", d)
+	}
+	if d := golden.Distance(other); d < 6 {
+		t.Fatalf("distance to different voice %.2f dB, want > 6", d)
+	}
+	if diff := golden.LevelDBFS - same.LevelDBFS; math.Abs(diff-6) > 0.5 {
+		t.Fatalf("level difference %.1f dB, want about 6", diff)
+	}
+	if v := same.Voiced; v < 1150*time.Millisecond || v > 1250*time.Millisecond {
+		t.Fatalf("voiced duration %v, want about 1.2s (silence excluded)", v)
+	}
+}
+
+// TestVADDropsLongSilence 验证：长静音只保留 hangover 与 preroll，语音完整保留；
+// OriginalTime 把上传音频上的时间换算回原始时间轴；按任意大小分块处理结果一致。
+// WHY：客户端 VAD 为节省上行带宽丢弃静音，但识别结果的时间戳必须仍对应用户的原始音频。
+func TestVADDropsLongSilence(t *testing.T) {
+	const rate = 8000
+	speech := func(ms int) []byte {
+		out := make([]byte, rate*ms/1000*2)
+		for i := 0; i < len(out)/2; i++ {
+			binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(5000*math.Sin(2*math.Pi*300*float64(i)/rate))))
+		}
+		return out
+	}
+	silence := func(ms int) []byte { return make([]byte, rate*ms/1000*2) }
+
+	// 1s 静音 + 0.4s 语音 + 3s 静音 + 0.4s 语音
+	var input []byte
+	for _, part := range [][]byte{silence(1000), speech(400), silence(3000), speech(400)} {
+		input = append(input, part...)
+	}
+	process := func(chunk int) ([]byte, *VAD) {
+		v := NewVAD(0, rate, 1)
+		var out []byte
+		for off := 0; off < len(input); off += chunk {
+			out = append(out, v.Process(input[off:min(off+chunk, len(input))])...)
+		}
+		return out, v
+	}
+
+	whole, v := process(len(input))
+	chunked, _ := process(333)
+	if !bytes.Equal(whole, chunked) {
+		t.Fatalf("chunked output %d bytes differs from whole %d bytes", len(chunked), len(whole))
+	}
+
+	// 输出 = preroll 200ms + 语音 400ms + hangover 500ms + preroll 200ms + 语音 400ms
+	if got, want := len(whole)/2*1000/rate, 1700; got != want {
+		t.Fatalf("output %d ms, want %d ms", got, want)
+	}
+	if got := v.Dropped(); got != 3100*time.Millisecond {
+		t.Fatalf("dropped %v, want 3.1s", got)
+	}
+	// 第二段语音在上传音频中从 1.3s 开始，原始音频中从 4.4s 开始
+	if got := v.OriginalTime(1300 * time.Millisecond); got != 4400*time.Millisecond {
+		t.Fatalf("OriginalTime(1.3s) = %v, want 4.4s", got)
+	}
+	if got := v.OriginalTime(300 * time.Millisecond); got != 1100*time.Millisecond {
+		t.Fatalf("OriginalTime(0.3s) = %v, want 1.1s", got)
+	}
+}
+
+// TestReadWAVHeaderChunks 验证：带 LIST/fact 块（含奇数长度填充）和 WAVE_FORMAT_EXTENSIBLE 浮点 fmt 块的 WAV
+// 被正确解析，WAVToPCM 只返回 data 块内容（不含其后的块）；浮点数据可转换为 16-bit。
+// WHY：编辑器导出的 WAV 头常不是 44 字节，固定跳过 44 字节会把元数据当作音频上传、格式也会读错。
+func TestReadWAVHeaderChunks(t *testing.T) {
+	chunk := func(id string, body []byte) []byte {
+		out := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+		out = append(out, body...)
+		if len(body)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	fmtBody := make([]byte, 40)
+	binary.LittleEndian.PutUint16(fmtBody[0:], wavFormatExtensible)
+	binary.LittleEndian.PutUint16(fmtBody[2:], 1)      // 单声道
+	binary.LittleEndian.PutUint32(fmtBody[4:], 8000)   // 采样率
+	binary.LittleEndian.PutUint32(fmtBody[8:], 8000*4) // ByteRate
+	binary.LittleEndian.PutUint16(fmtBody[12:], 4)     // BlockAlign
+	binary.LittleEndian.PutUint16(fmtBody[14:], 32)    // 位深
+	binary.LittleEndian.PutUint16(fmtBody[24:], WAVFormatFloat)
+	var samples []byte
+	for _, v := range []float32{0.5, -0.5, 1.5} {
+		samples = binary.LittleEndian.AppendUint32(samples, math.Float32bits(v))
+	}
+
+	var body []byte
+	body = append(body, "WAVE"...)
+	body = append(body, chunk("LIST", []byte("abc"))...)
+	body = append(body, chunk("fmt ", fmtBody)...)
+	body = append(body, chunk("fact", []byte{3, 0, 0, 0})...)
+	body = append(body, chunk("data", samples)...)
+	body = append(body, chunk("LIST", []byte("trailing"))...)
+	wav := chunk("RIFF", body)
+
+	pcm, header, err := WAVToPCM(wav)
+	if err != nil {
+		t.Fatalf("WAVToPCM: %v", err)
+	}
+	if header.AudioFormat != WAVFormatFloat || header.SampleRate != 8000 || header.BitsPerSample != 32 {
+		t.Fatalf("header = format %d, %d Hz, %d-bit; want float, 8000 Hz, 32-bit",
+			header.AudioFormat, header.SampleRate, header.BitsPerSample)
+	}
+	if err := CheckWAVFormat(header); err != nil {
+		t.Fatalf("CheckWAVFormat: %v", err)
+	}
+	if !bytes.Equal(pcm, samples) {
+		t.Fatalf("data = %d bytes, want the %d-byte data chunk only", len(pcm), len(samples))
+	}
+	got := BytesToInt16(FloatPCMToPCM16(pcm), binary.LittleEndian)
+	if len(got) != 3 || got[0] != 16384 || got[1] != -16384 || got[2] != math.MaxInt16 {
+		t.Fatalf("16-bit samples = %v, want [16384 -16384 32767]", got)
+	}
+}
@@ -0,0 +1,183 @@
+// Package audio 频谱轮廓（合成音频回归比对）
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/cmplx"
+	"time"
+)
+
+const (
+	profileBands   = 24    // 对数频带数
+	profileFrameMs = 32    // 分析帧长（向上取整到 2 的幂个采样）
+	profileMinHz   = 100.0 // 最低频带下限
+	profileFloorDB = 50.0  // 频带能量相对最强频带的下限
+)
+
+// Profile 音频的长时平均频谱轮廓，处理 16-bit 小端单声道 PCM
+//
+// 只统计非静音帧（低于 -50 dBFS 的帧跳过），按对数间隔频带汇总 Hann 窗 FFT 能量。
+// 轮廓与停顿位置、语速细节基本无关，而对音色模型的变化（共振峰、明暗、带宽）敏感，
+// 用于比较同一文本/音色在不同时间的合成结果。
+type Profile struct {
+	SampleRate int
+	Duration   time.Duration // 音频总时长
+	Voiced     time.Duration // 非静音时长
+	LevelDBFS  float64       // 非静音部分 RMS 电平（无非静音帧时为 -Inf）
+	Bands      []float64     // 各频带平均能量（dB；无非静音帧时为 nil）
+}
+
+// NewProfile 计算 PCM 的频谱轮廓
+func NewProfile(pcm []byte, sampleRate int) *Profile {
+	samples := make([]float64, len(pcm)/2)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / 32768
+	}
+	p := &Profile{
+		SampleRate: sampleRate,
+		Duration:   time.Duration(len(samples)) * time.Second / time.Duration(sampleRate),
+		LevelDBFS:  math.Inf(-1),
+	}
+
+	n := 1
+	for n < sampleRate*profileFrameMs/1000 {
+		n <<= 1
+	}
+	hop := n / 2
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+	bands := bandBins(n, sampleRate)
+	power := make([]float64, profileBands)
+
+	buf := make([]complex128, n)
+	var voicedFrames, voicedSamples int
+	var sumSq float64
+	for start := 0; start < len(samples); start += hop {
+		frame := samples[start:min(start+n, len(samples))]
+		var sq float64
+		for _, v := range frame {
+			sq += v * v
+		}
+		if math.Sqrt(sq/float64(len(frame))) < dbToLinear(normSilenceDBFS) {
+			continue
+		}
+		voicedFrames++
+		// 只累计帧前半段（hop 个采样）避免重叠部分重复计入电平与时长
+		for _, v := range frame[:min(hop, len(frame))] {
+			sumSq += v * v
+			voicedSamples++
+		}
+
+		for i := range buf {
+			var v float64
+			if i < len(frame) {
+				v = frame[i] * window[i]
+			}
+			buf[i] = complex(v, 0)
+		}
+		fft(buf)
+		for b, bins := range bands {
+			var e float64
+			for _, k := range bins {
+				a := cmplx.Abs(buf[k])
+				e += a * a
+			}
+			power[b] += e / float64(len(bins))
+		}
+	}
+
+	if voicedFrames == 0 {
+		return p
+	}
+	p.Voiced = time.Duration(voicedSamples) * time.Second / time.Duration(sampleRate)
+	p.LevelDBFS = 10 * math.Log10(sumSq/float64(voicedSamples))
+	p.Bands = make([]float64, profileBands)
+	peak := math.Inf(-1)
+	for b := range power {
+		p.Bands[b] = 10 * math.Log10(power[b]/float64(voicedFrames)+1e-12)
+		peak = math.Max(peak, p.Bands[b])
+	}
+	// 低于最强频带 profileFloorDB 的频带按下限计，避免量化噪声、窗泄漏主导距离
+	for b := range p.Bands {
+		p.Bands[b] = math.Max(p.Bands[b], peak-profileFloorDB)
+	}
+	return p
+}
+
+// Distance 返回两个轮廓的频谱形状距离（dB，各频带去除平均电平后的均方根差）
+//
+// 与整体音量无关（音量变化看 LevelDBFS）；任一方没有非静音帧时返回 +Inf。
+func (p *Profile) Distance(q *Profile) float64 {
+	if len(p.Bands) == 0 || len(p.Bands) != len(q.Bands) {
+		return math.Inf(1)
+	}
+	pm, qm := mean(p.Bands), mean(q.Bands)
+	var sum float64
+	for i := range p.Bands {
+		d := (p.Bands[i] - pm) - (q.Bands[i] - qm)
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(p.Bands)))
+}
+
+// bandBins 返回每个频带包含的 FFT bin
+//
+// 频带在 [profileMinHz, 奈奎斯特频率) 上按对数等分；低采样率下过窄、不含 bin 的频带
+// 取离其几何中心最近的 bin，保证每个频带都有能量。
+func bandBins(n, sampleRate int) [][]int {
+	nyquist := float64(sampleRate) / 2
+	binHz := float64(sampleRate) / float64(n)
+	edge := func(b int) float64 {
+		return profileMinHz * math.Pow(nyquist/profileMinHz, float64(b)/profileBands)
+	}
+
+	bands := make([][]int, profileBands)
+	for b := range bands {
+		lo, hi := edge(b), edge(b+1)
+		for k := int(math.Ceil(lo / binHz)); k <= n/2 && float64(k)*binHz < hi; k++ {
+			bands[b] = append(bands[b], k)
+		}
+		if len(bands[b]) == 0 {
+			bands[b] = []int{min(int(math.Round(math.Sqrt(lo*hi)/binHz)), n/2)}
+		}
+	}
+	return bands
+}
+
+// fft 原地基 2 快速傅里叶变换（len(x) 须为 2 的幂）
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*wk
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+}
+
+// mean 返回平均值
+func mean(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x
+	}
+	return sum / float64(len(v))
+}
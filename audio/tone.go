@@ -0,0 +1,43 @@
+// Package audio 静音与提示音生成
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// toneFade 提示音首尾的线性淡入淡出时长（避免起止处的爆音）
+const toneFade = 5 * time.Millisecond
+
+// samplesFor d 时长在 sampleRate 下的采样点数
+func samplesFor(sampleRate int, d time.Duration) int {
+	if sampleRate <= 0 || d <= 0 {
+		return 0
+	}
+	return int(int64(d) * int64(sampleRate) / int64(time.Second))
+}
+
+// Silence 生成 d 时长的静音（16-bit 小端单声道 PCM）
+func Silence(sampleRate int, d time.Duration) []byte {
+	return make([]byte, 2*samplesFor(sampleRate, d))
+}
+
+// Tone 生成 d 时长、频率 freq Hz 的正弦波（16-bit 小端单声道 PCM），amplitude 为峰值幅度（0-1）
+// 首尾各 5ms 线性淡入淡出
+func Tone(sampleRate int, freq float64, d time.Duration, amplitude float64) []byte {
+	n := samplesFor(sampleRate, d)
+	fade := min(samplesFor(sampleRate, toneFade), n/2)
+	samples := make([]float32, n)
+	for i := range samples {
+		gain := amplitude
+		if fade > 0 {
+			if i < fade {
+				gain *= float64(i) / float64(fade)
+			} else if n-1-i < fade {
+				gain *= float64(n-1-i) / float64(fade)
+			}
+		}
+		samples[i] = float32(gain * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	return Float32ToPCM16(samples)
+}
@@ -0,0 +1,133 @@
+// Package audio 基于能量的语音活动检测（上传前静音过滤）
+package audio
+
+import "time"
+
+// DefaultVADThresholdDBFS 默认语音判定电平（帧 RMS，dBFS）
+const DefaultVADThresholdDBFS = -45.0
+
+const (
+	vadFrameMs  = 20                     // 判定粒度
+	vadHangover = 500 * time.Millisecond // 语音结束后继续发送的静音（保留提供商断句所需的停顿）
+	vadPreroll  = 200 * time.Millisecond // 语音开始前补发的音频（避免截掉弱起音）
+)
+
+// VAD 流式静音过滤，处理 16-bit 小端 PCM（多声道按交织数据整体计算电平）
+//
+// 按 20ms 帧计算 RMS：高于阈值为语音，语音后 500ms 内的静音照常输出，超出部分丢弃；
+// 语音开始时补发之前缓存的 200ms。丢弃位置与长度被记录下来，
+// OriginalTime 据此把提供商基于已上传音频的时间戳换算回原始音频时间轴。
+type VAD struct {
+	threshold      float64 // 语音判定 RMS（线性，满幅为 1）
+	frameBytes     int
+	bytesPerSecond int64
+	hangFrames     int
+	prerollFrames  int
+
+	hang    int      // 剩余的 hangover 帧数
+	preroll [][]byte // 缓存的静音帧（语音开始时补发）
+	pending []byte   // 不足一帧的数据
+	out     int64    // 已输出字节数
+	dropped int64    // 已丢弃字节数
+	drops   []vadDrop
+}
+
+// vadDrop 一段被丢弃的静音：输出第 at 字节之前丢弃了 bytes 字节
+type vadDrop struct {
+	at, bytes int64
+}
+
+// NewVAD 创建静音过滤器；thresholdDBFS 为 0 时使用 DefaultVADThresholdDBFS
+func NewVAD(thresholdDBFS float64, sampleRate, channels int) *VAD {
+	if thresholdDBFS == 0 {
+		thresholdDBFS = DefaultVADThresholdDBFS
+	}
+	if channels <= 0 {
+		channels = 1
+	}
+	frame := sampleRate * vadFrameMs / 1000 * channels * 2
+	return &VAD{
+		threshold:      dbToLinear(thresholdDBFS),
+		frameBytes:     frame,
+		bytesPerSecond: int64(sampleRate * channels * 2),
+		hangFrames:     int(vadHangover / (vadFrameMs * time.Millisecond)),
+		prerollFrames:  int(vadPreroll / (vadFrameMs * time.Millisecond)),
+	}
+}
+
+// Process 过滤一块 PCM，返回应上传的数据（可能为空）
+func (v *VAD) Process(pcm []byte) []byte {
+	if len(v.pending) > 0 {
+		pcm = append(v.pending, pcm...)
+		v.pending = nil
+	}
+	var out []byte
+	for len(pcm) >= v.frameBytes {
+		frame := pcm[:v.frameBytes]
+		pcm = pcm[v.frameBytes:]
+
+		switch {
+		case rms(frame) >= v.threshold:
+			for _, f := range v.preroll {
+				out = append(out, f...)
+			}
+			v.preroll = v.preroll[:0]
+			v.hang = v.hangFrames
+			out = append(out, frame...)
+		case v.hang > 0:
+			v.hang--
+			out = append(out, frame...)
+		case v.prerollFrames == 0:
+			v.drop(int64(len(out)))
+		default:
+			// 缓存满时最早的一帧被丢弃（位于缓存帧之前）
+			if len(v.preroll) == v.prerollFrames {
+				v.drop(int64(len(out)))
+				v.preroll = append(v.preroll[:0], v.preroll[1:]...)
+			}
+			v.preroll = append(v.preroll, append([]byte(nil), frame...))
+		}
+	}
+	if len(pcm) > 0 {
+		v.pending = append([]byte(nil), pcm...)
+	}
+	v.out += int64(len(out))
+	return out
+}
+
+// drop 记录丢弃一帧（pos 为本次 Process 中已输出的字节数）
+func (v *VAD) drop(pos int64) {
+	at := v.out + pos
+	v.dropped += int64(v.frameBytes)
+	if n := len(v.drops); n > 0 && v.drops[n-1].at == at {
+		v.drops[n-1].bytes += int64(v.frameBytes)
+		return
+	}
+	v.drops = append(v.drops, vadDrop{at: at, bytes: int64(v.frameBytes)})
+}
+
+// Dropped 返回已丢弃的音频时长
+func (v *VAD) Dropped() time.Duration {
+	return v.duration(v.dropped)
+}
+
+// OriginalTime 将已上传音频时间轴上的时间换算为原始音频时间（加上之前丢弃的静音）
+func (v *VAD) OriginalTime(t time.Duration) time.Duration {
+	pos := int64(t) * v.bytesPerSecond / int64(time.Second)
+	var shift int64
+	for _, d := range v.drops {
+		if d.at >= pos {
+			break
+		}
+		shift += d.bytes
+	}
+	return t + v.duration(shift)
+}
+
+// duration 字节数转时长
+func (v *VAD) duration(bytes int64) time.Duration {
+	if v.bytesPerSecond == 0 {
+		return 0
+	}
+	return time.Duration(bytes * int64(time.Second) / v.bytesPerSecond)
+}
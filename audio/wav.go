@@ -2,6 +2,7 @@
 package audio
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -28,12 +29,30 @@ type WAVHeader struct {
 	Subchunk2Size uint32  // 数据大小
 }
 
+// WAV 编码格式（WAVHeader.AudioFormat）
+const (
+	WAVFormatPCM        = 1      // 整型 PCM
+	WAVFormatFloat      = 3      // IEEE 浮点
+	wavFormatExtensible = 0xFFFE // WAVE_FORMAT_EXTENSIBLE，实际格式为子格式 GUID 的前 2 字节
+)
+
+// wavUnknownSize 流式写出的 WAV 在 data 块长度未知时填写的值
+const wavUnknownSize = 0xFFFFFFFF
+
 // ReadWAVHeader 读取WAV文件头
+//
+// 按 RIFF 块结构解析，不假定 44 字节标准头：跳过 LIST、fact 等非音频块（含奇数长度的填充字节），
+// 返回时 r 停在 data 块数据起始处。WAVE_FORMAT_EXTENSIBLE 的 AudioFormat 取子格式
+// （WAVFormatPCM / WAVFormatFloat），Subchunk1Size 为 fmt 块实际长度。
 func ReadWAVHeader(r io.Reader) (*WAVHeader, error) {
 	header := &WAVHeader{}
-	if err := binary.Read(r, binary.LittleEndian, header); err != nil {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
 		return nil, fmt.Errorf("read WAV header: %w", err)
 	}
+	copy(header.ChunkID[:], riff[0:4])
+	header.ChunkSize = binary.LittleEndian.Uint32(riff[4:8])
+	copy(header.Format[:], riff[8:12])
 
 	// 验证头部
 	if string(header.ChunkID[:]) != "RIFF" {
@@ -43,7 +62,62 @@ func ReadWAVHeader(r io.Reader) (*WAVHeader, error) {
 		return nil, fmt.Errorf("invalid WAV: expected WAVE, got %s", header.Format)
 	}
 
-	return header, nil
+	haveFmt := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("read WAV header: no data chunk: %w", err)
+		}
+		id, size := string(chunk[:4]), binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return nil, fmt.Errorf("invalid WAV: fmt chunk size %d", size)
+			}
+			buf := make([]byte, size+size&1)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, fmt.Errorf("read WAV fmt chunk: %w", err)
+			}
+			copy(header.Subchunk1ID[:], chunk[:4])
+			header.Subchunk1Size = size
+			header.AudioFormat = binary.LittleEndian.Uint16(buf[0:2])
+			header.NumChannels = binary.LittleEndian.Uint16(buf[2:4])
+			header.SampleRate = binary.LittleEndian.Uint32(buf[4:8])
+			header.ByteRate = binary.LittleEndian.Uint32(buf[8:12])
+			header.BlockAlign = binary.LittleEndian.Uint16(buf[12:14])
+			header.BitsPerSample = binary.LittleEndian.Uint16(buf[14:16])
+			if header.AudioFormat == wavFormatExtensible && size >= 26 {
+				header.AudioFormat = binary.LittleEndian.Uint16(buf[24:26])
+			}
+			haveFmt = true
+		case "data":
+			if !haveFmt {
+				return nil, fmt.Errorf("invalid WAV: data chunk before fmt chunk")
+			}
+			copy(header.Subchunk2ID[:], chunk[:4])
+			header.Subchunk2Size = size
+			return header, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size&1)); err != nil {
+				return nil, fmt.Errorf("skip WAV %q chunk: %w", id, err)
+			}
+		}
+	}
+}
+
+// CheckWAVFormat 校验 WAV 为可识别的 PCM 编码：整型 8/16/24/32-bit 或 32-bit 浮点
+func CheckWAVFormat(header *WAVHeader) error {
+	if header.NumChannels == 0 || header.SampleRate == 0 {
+		return fmt.Errorf("invalid WAV: %d channels, %d Hz", header.NumChannels, header.SampleRate)
+	}
+	switch {
+	case header.AudioFormat == WAVFormatPCM && header.BitsPerSample%8 == 0 && header.BitsPerSample > 0 && header.BitsPerSample <= 32:
+		return nil
+	case header.AudioFormat == WAVFormatFloat && header.BitsPerSample == 32:
+		return nil
+	}
+	return fmt.Errorf("unsupported WAV encoding: format %d, %d-bit", header.AudioFormat, header.BitsPerSample)
 }
 
 // WriteWAVHeader 写入WAV文件头
@@ -80,7 +154,14 @@ func ReadWAVFile(path string) ([]byte, *WAVHeader, error) {
 		return nil, nil, err
 	}
 
-	// 读取PCM数据
+	// 读取PCM数据（长度未知时读到文件末尾）
+	if header.Subchunk2Size == 0 || header.Subchunk2Size == wavUnknownSize {
+		pcm, err := io.ReadAll(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read PCM data: %w", err)
+		}
+		return pcm, header, nil
+	}
 	pcm := make([]byte, header.Subchunk2Size)
 	n, err := io.ReadFull(file, pcm)
 	if err != nil && err != io.ErrUnexpectedEOF {
@@ -151,34 +232,24 @@ func PCMToWAV(pcm []byte, sampleRate, numChannels, bitsPerSample int) ([]byte, e
 	return wav, nil
 }
 
-// WAVToPCM 从WAV数据提取PCM（跳过头部）
+// WAVToPCM 从WAV数据提取PCM（跳过头部及非音频块）
 func WAVToPCM(wav []byte) ([]byte, *WAVHeader, error) {
 	if len(wav) < WAVHeaderSize {
 		return nil, nil, fmt.Errorf("WAV data too short")
 	}
 
-	// 解析头部
-	header := &WAVHeader{}
-	copy(header.ChunkID[:], wav[0:4])
-	header.ChunkSize = binary.LittleEndian.Uint32(wav[4:8])
-	copy(header.Format[:], wav[8:12])
-	copy(header.Subchunk1ID[:], wav[12:16])
-	header.Subchunk1Size = binary.LittleEndian.Uint32(wav[16:20])
-	header.AudioFormat = binary.LittleEndian.Uint16(wav[20:22])
-	header.NumChannels = binary.LittleEndian.Uint16(wav[22:24])
-	header.SampleRate = binary.LittleEndian.Uint32(wav[24:28])
-	header.ByteRate = binary.LittleEndian.Uint32(wav[28:32])
-	header.BlockAlign = binary.LittleEndian.Uint16(wav[32:34])
-	header.BitsPerSample = binary.LittleEndian.Uint16(wav[34:36])
-	copy(header.Subchunk2ID[:], wav[36:40])
-	header.Subchunk2Size = binary.LittleEndian.Uint32(wav[40:44])
-
-	// 验证
-	if string(header.ChunkID[:]) != "RIFF" || string(header.Format[:]) != "WAVE" {
-		return nil, nil, fmt.Errorf("invalid WAV format")
-	}
-
-	return wav[WAVHeaderSize:], header, nil
+	r := bytes.NewReader(wav)
+	header, err := ReadWAVHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// data 块之后可能还有其他块；长度未知或超出实际数据时取到末尾
+	pcm := wav[len(wav)-r.Len():]
+	if size := header.Subchunk2Size; size != 0 && size != wavUnknownSize && int64(size) < int64(len(pcm)) {
+		pcm = pcm[:size]
+	}
+	return pcm, header, nil
 }
 
 // GetWAVInfo 获取WAV文件信息
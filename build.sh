@@ -10,46 +10,71 @@ cd "$SCRIPT_DIR"
 # 创建 bin 目录
 mkdir -p bin
 
+# 版本信息（注入 version 包）
+VERSION_PKG="github.com/jinbozhan/tengen-speech-sdk-go/version"
+VERSION="${VERSION:-$(git describe --tags --always --dirty 2>/dev/null || echo dev)}"
+COMMIT="$(git rev-parse --short HEAD 2>/dev/null || true)"
+BUILD_DATE="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+LDFLAGS="-X ${VERSION_PKG}.Version=${VERSION} -X ${VERSION_PKG}.Commit=${COMMIT} -X ${VERSION_PKG}.BuildDate=${BUILD_DATE}"
+
 echo "=========================================="
 echo "  Tengen Speech SDK Build"
+echo "  Version: ${VERSION} (${COMMIT})"
 echo "=========================================="
 echo ""
 
 # 1. 依赖管理
-echo "[1/7] 更新依赖..."
+echo "[1/10] 更新依赖..."
 go mod tidy
 
 # 2. 编译所有包
-echo "[2/7] 编译所有包..."
+echo "[2/10] 编译所有包..."
 go build ./...
 
 # 3. 编译 TTS SDK Demo
-echo "[3/7] 编译 TTS Demo..."
-go build -o bin/tts_stream ./examples/tts_stream
+echo "[3/10] 编译 TTS Demo..."
+go build -ldflags "$LDFLAGS" -o bin/tts_stream ./examples/tts_stream
 echo "      -> bin/tts_stream"
-go build -o bin/tts_pipeline ./examples/tts_pipeline
+go build -ldflags "$LDFLAGS" -o bin/tts_pipeline ./examples/tts_pipeline
 echo "      -> bin/tts_pipeline"
 
 # 4. 编译 STT SDK Demo
-echo "[4/7] 编译 STT Demo..."
-go build -o bin/stt_stream ./examples/stt_stream
+echo "[4/10] 编译 STT Demo..."
+go build -ldflags "$LDFLAGS" -o bin/stt_stream ./examples/stt_stream
 echo "      -> bin/stt_stream"
+go build -ldflags "$LDFLAGS" -o bin/stt_microphone ./examples/stt_microphone
+echo "      -> bin/stt_microphone"
 
 # 5. 编译 TTS Benchmark
-echo "[5/7] 编译 TTS Benchmark..."
-go build -o bin/tts_benchmark ./cmd/tts_benchmark
+echo "[5/10] 编译 TTS Benchmark..."
+go build -ldflags "$LDFLAGS" -o bin/tts_benchmark ./cmd/tts_benchmark
 echo "      -> bin/tts_benchmark"
 
 # 6. 编译 TTS Detailed Timing
-echo "[6/7] 编译 TTS Detailed Timing..."
-go build -o bin/tts_detailed_timing ./cmd/tts_detailed_timing
+echo "[6/10] 编译 TTS Detailed Timing..."
+go build -ldflags "$LDFLAGS" -o bin/tts_detailed_timing ./cmd/tts_detailed_timing
 echo "      -> bin/tts_detailed_timing"
 
-# 7. 编译 VAD-Clip ASR 测试工具
-echo "[7/7] 编译 VAD-Clip ASR 测试工具..."
-go build -o bin/test_vad_clip_asr ./cmd/test_vad_clip_asr
+# 7. 编译 STT Detailed Timing
+echo "[7/10] 编译 STT Detailed Timing..."
+go build -ldflags "$LDFLAGS" -o bin/stt_detailed_timing ./cmd/stt_detailed_timing
+echo "      -> bin/stt_detailed_timing"
+
+# 8. 编译 VAD-Clip ASR 测试工具
+echo "[8/10] 编译 VAD-Clip ASR 测试工具..."
+go build -ldflags "$LDFLAGS" -o bin/test_vad_clip_asr ./cmd/test_vad_clip_asr
 echo "      -> bin/test_vad_clip_asr"
 
+# 9. 编译 TTS 黄金音频回归工具
+echo "[9/10] 编译 TTS Golden..."
+go build -ldflags "$LDFLAGS" -o bin/tts_golden ./cmd/tts_golden
+echo "      -> bin/tts_golden"
+
+# 10. 编译 STT 事件重放工具
+echo "[10/10] 编译 STT Replay..."
+go build -ldflags "$LDFLAGS" -o bin/stt_replay ./cmd/stt_replay
+echo "      -> bin/stt_replay"
+
 echo ""
 echo "=========================================="
 echo "  构建完成!"
@@ -64,6 +89,7 @@ echo ""
 echo "  STT Demo:"
 echo "    ./bin/stt_stream audio.wav"
 echo "    ./bin/stt_stream -h  # 查看帮助"
+echo "    ./bin/stt_microphone -language en-NG  # 麦克风实时识别（Ctrl+C 结束）"
 echo ""
 echo "  TTS Benchmark (性能测试):"
 echo "    ./bin/tts_benchmark -provider tengen -voice en-NG-OkunNeutral -iterations 10"
@@ -73,8 +99,18 @@ echo "  TTS Detailed Timing (详细耗时分析):"
 echo "    ./bin/tts_detailed_timing -provider tengen -voice en-NG-OkunNeutral -iterations 3"
 echo "    ./bin/tts_detailed_timing -h  # 查看帮助"
 echo ""
+echo "  STT Detailed Timing (详细耗时分析):"
+echo "    ./bin/stt_detailed_timing -provider azure -language en-NG -audio sample.wav -iterations 3"
+echo "    ./bin/stt_detailed_timing -h  # 查看帮助"
+echo ""
 echo "  VAD-Clip ASR 测试:"
 echo "    ./bin/test_vad_clip_asr -sample-dir ../../sample"
 echo "    ./bin/test_vad_clip_asr -gateway ws://localhost:7861 -provider azure -language en-NG"
 echo "    ./bin/test_vad_clip_asr -output results.md  # 输出 Markdown 报告"
+echo "    ./bin/test_vad_clip_asr -min-success 0.95 -failures failures.json  # 发布闸门（失败时退出码 2）"
+echo "    ./bin/test_vad_clip_asr -manifest https://bucket.example.com/speech-samples/manifest.json  # 下载并校验语料"
+echo ""
+echo "  TTS Golden (黄金音频回归):"
+echo "    ./bin/tts_golden -voices en-NG-RoseSerious -update  # 生成黄金音频"
+echo "    ./bin/tts_golden -voices en-NG-RoseSerious          # 比对（漂移时退出码 2）"
 echo ""
@@ -0,0 +1,189 @@
+// Package clock 提供可注入的时钟
+//
+// SDK 内的时延计算（建连耗时、TTFB、RTF 等）以及所有依赖时间的逻辑
+// （STT 识别空闲超时、Close 握手等待、重连/续传退避、保活间隔）统一通过 Clock 取时间和计时器。
+// 系统时钟返回的 time.Time 带单调时钟读数，两次读数相减不受 NTP 校时跳变影响；
+// 注意不要对参与计算的时间调用 Round(0)/UTC()/In()，这些操作会丢弃单调读数。
+// 测试可注入 Fake 手动推进时间，无需真实 sleep。
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// TimingSource 时间源
+type TimingSource interface {
+	Now() time.Time
+}
+
+// Clock 时钟：时间源 + 计时器
+type Clock interface {
+	TimingSource
+	// After 等价于 time.After
+	After(d time.Duration) <-chan time.Time
+	// NewTimer 等价于 time.NewTimer
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 计时器（语义同 *time.Timer）
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// System 系统时钟
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) NewTimer(d time.Duration) Timer         { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// Or 返回 c，为 nil 时返回 System
+func Or(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
+
+// Since 返回按 src 计时自 t 起经过的时长
+func Since(src TimingSource, t time.Time) time.Duration {
+	return src.Now().Sub(t)
+}
+
+// Fake 手动推进的时钟（测试用）
+// 计时器只在 Advance 推进到期时触发
+type Fake struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer // 未到期的计时器
+}
+
+// NewFake 创建起始于 start 的 Fake 时钟
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now 返回当前假时间
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After 返回在假时间推进 d 后收到当前时间的 channel
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer 创建假计时器
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{f: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	f.schedule(t, d)
+	f.mu.Unlock()
+	return t
+}
+
+// Advance 将假时间推进 d，并按到期顺序触发到期的计时器
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	sort.SliceStable(f.timers, func(i, j int) bool {
+		return f.timers[i].deadline.Before(f.timers[j].deadline)
+	})
+	pending := f.timers[:0]
+	for _, t := range f.timers {
+		if t.deadline.After(f.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.fire(f.now)
+	}
+	f.timers = pending
+}
+
+// WaitForTimers 阻塞直到至少有 n 个未到期的计时器
+// 用于等待被测 goroutine 进入 select 后再 Advance，避免时序竞争
+func (f *Fake) WaitForTimers(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+// schedule 安排计时器在 d 后到期（调用方持有 f.mu）
+func (f *Fake) schedule(t *fakeTimer, d time.Duration) {
+	t.deadline = f.now.Add(d)
+	if d <= 0 {
+		t.fire(f.now)
+		return
+	}
+	t.active = true
+	f.timers = append(f.timers, t)
+	f.cond.Broadcast()
+}
+
+// unschedule 移除计时器，返回其是否仍未到期（调用方持有 f.mu）
+func (f *Fake) unschedule(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, other := range f.timers {
+		if other == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type fakeTimer struct {
+	f        *Fake
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+// fire 触发计时器（非阻塞，channel 已满时丢弃，同 time.Timer）
+func (t *fakeTimer) fire(now time.Time) {
+	t.active = false
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	return t.f.unschedule(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	active := t.f.unschedule(t)
+	t.f.schedule(t, d)
+	return active
+}
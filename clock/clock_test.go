@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFakeTimers 验证 Fake 计时器只在 Advance 到期时触发，Stop/Reset 语义与 time.Timer 一致。
+// WHY: SDK 的空闲超时、Close 等待、退避都依赖这些语义；Fake 行为偏差会让测试通过而线上超时失效。
+func TestFakeTimers(t *testing.T) {
+	f := NewFake(time.Unix(1700000000, 0))
+
+	after := f.After(2 * time.Second)
+	timer := f.NewTimer(time.Second)
+
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	f.Advance(time.Millisecond)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("timer did not fire at deadline")
+	}
+	if timer.Stop() {
+		t.Fatal("Stop on fired timer should return false")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Fatal("Reset on fired timer should return false")
+	}
+	if !timer.Stop() {
+		t.Fatal("Stop on pending timer should return true")
+	}
+
+	f.Advance(5 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+	select {
+	case got := <-after:
+		if want := time.Unix(1700000000, 0).Add(6 * time.Second); !got.Equal(want) {
+			t.Fatalf("After delivered %v, want %v", got, want)
+		}
+	default:
+		t.Fatal("After did not fire")
+	}
+}
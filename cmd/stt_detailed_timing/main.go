@@ -0,0 +1,523 @@
+// Package main 提供STT详细时延分析工具
+//
+// 用于分析单个STT请求各阶段的详细时间戳（建连、配置、首包发送、首个 partial、
+// 每个 final、input 结束、session.ended），帮助识别性能瓶颈
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/gatewaydebug"
+	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+	"github.com/jinbozhan/tengen-speech-sdk-go/stt"
+)
+
+// 颜色代码
+const (
+	colorReset  = "\033[0m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+	colorRed    = "\033[31m"
+)
+
+const (
+	chunkMs     = 100              // 每个音频块时长
+	idleTimeout = 10 * time.Second // EndInput 后无新事件即认为识别完成
+)
+
+func main() {
+	var (
+		gateway    string
+		provider   string
+		apiKey     string
+		language   string
+		audioPath  string
+		iterations int
+		realtime   bool
+		debugStats bool
+		debugPath  string
+		format     string
+		rawRate    int
+		rawChans   int
+		rawBits    int
+		pctList    string
+		kpiPct     float64
+	)
+
+	flag.StringVar(&gateway, "gateway", "ws://localhost:7861", "Gateway WebSocket URL")
+	flag.StringVar(&provider, "provider", "azure", "STT provider (azure, qwen)")
+	flag.StringVar(&apiKey, "api-key", os.Getenv("GATEWAY_API_KEY"), "API Key")
+	flag.StringVar(&language, "language", "en-NG", "Recognition language")
+	flag.StringVar(&audioPath, "audio", "", "Audio file to recognize (WAV, or headerless PCM with -format raw)")
+	flag.StringVar(&format, "format", "", "Input format: wav or raw (headerless PCM); default by file extension")
+	flag.IntVar(&rawRate, "rate", 16000, "Sample rate of raw PCM input")
+	flag.IntVar(&rawChans, "channels", 1, "Channels of raw PCM input")
+	flag.IntVar(&rawBits, "bits", 16, "Bits per sample of raw PCM input")
+	flag.IntVar(&iterations, "iterations", 1, "Number of iterations to run")
+	flag.BoolVar(&realtime, "realtime", true, "Pace audio at real-time speed (false: send as fast as possible)")
+	flag.BoolVar(&debugStats, "gateway-debug", false, "Fetch server-side timings/VAD stats from the gateway debug endpoint after each request")
+	flag.StringVar(&debugPath, "debug-endpoint", gatewaydebug.DefaultEndpoint, "Gateway debug endpoint path template ({session_id} is substituted)")
+	flag.StringVar(&pctList, "percentiles", "50,90,95,99", "Comma-separated percentiles reported for first-partial and finalization latency")
+	flag.Float64Var(&kpiPct, "kpi-percentile", 95, "Percentile of first-partial latency reported as the primary KPI")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "STT Detailed Timing - STT request latency analysis tool\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s -audio <file.wav> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  # Test Azure STT with real-time pacing\n")
+		fmt.Fprintf(os.Stderr, "  %s -provider azure -language en-NG -audio sample.wav -iterations 3\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Send audio as fast as possible\n")
+		fmt.Fprintf(os.Stderr, "  %s -audio sample.wav -realtime=false\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	logging.Setup(logging.LevelInfo)
+
+	// 检查参数
+	if audioPath == "" {
+		logging.Error("Audio file is required (-audio)")
+		os.Exit(1)
+	}
+	percentiles, err := parsePercentiles(pctList, kpiPct)
+	if err != nil {
+		logging.Error("Invalid percentiles", "error", err)
+		os.Exit(1)
+	}
+
+	pcm, sampleRate, channels, bitsPerSample, err := readInput(audioPath, format, rawRate, rawChans, rawBits)
+	if err != nil {
+		logging.Error("Failed to read audio file", "path", audioPath, "error", err)
+		os.Exit(1)
+	}
+	audioMs := audio.CalculateDuration(len(pcm), sampleRate, channels, bitsPerSample)
+	chunkSize := audio.CalculateChunkSize(chunkMs, sampleRate, channels, bitsPerSample)
+
+	fmt.Printf("\n%s========================================%s\n", colorCyan, colorReset)
+	fmt.Printf("%s  STT Detailed Timing Analysis%s\n", colorCyan, colorReset)
+	fmt.Printf("%s========================================%s\n", colorCyan, colorReset)
+	fmt.Printf("Gateway:    %s\n", gateway)
+	fmt.Printf("Provider:   %s\n", provider)
+	fmt.Printf("Language:   %s\n", language)
+	fmt.Printf("Audio:      %s (%d ms, %d Hz)\n", audioPath, audioMs, sampleRate)
+	fmt.Printf("Realtime:   %v\n", realtime)
+	fmt.Printf("Iterations: %d\n", iterations)
+	fmt.Printf("%s========================================%s\n\n", colorCyan, colorReset)
+
+	config := &stt.Config{
+		GatewayURL:     gateway,
+		Provider:       provider,
+		APIKey:         apiKey,
+		Language:       language,
+		SampleRate:     sampleRate,
+		AudioFormat:    "pcm",
+		Channels:       channels,
+		BitsPerSample:  bitsPerSample,
+		ConnectTimeout: 30 * time.Second,
+		ReadTimeout:    120 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxReconnects:  -1, // 不重连：重试会把建连失败计入建连耗时
+	}
+
+	// 服务端统计拉取（可选）
+	var fetcher *gatewaydebug.Fetcher
+	if debugStats {
+		fetcher, err = gatewaydebug.NewFetcher(gateway, debugPath, apiKey)
+		if err != nil {
+			logging.Error("Invalid gateway debug settings", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// 运行测试
+	var (
+		sum       phaseTimings
+		latencies []stt.Latencies
+	)
+	successCount := 0
+
+	for i := 0; i < iterations; i++ {
+		fmt.Printf("%s[Iteration %d/%d]%s\n", colorYellow, i+1, iterations, colorReset)
+
+		result, err := runSingleRequest(config, pcm, chunkSize, realtime)
+		if err != nil {
+			fmt.Printf("%s✗ Error: %v%s\n\n", colorRed, err, colorReset)
+			continue
+		}
+
+		// 打印详细时间戳
+		printDetailedTimings(result)
+		if fetcher != nil {
+			printServerStats(fetcher, result.SessionID)
+		}
+
+		sum.add(result.phases())
+		latencies = append(latencies, result.Latencies)
+		successCount++
+
+		if i < iterations-1 {
+			fmt.Println()
+			time.Sleep(500 * time.Millisecond) // 请求间隔
+		}
+	}
+
+	// 打印汇总统计
+	if successCount > 0 {
+		n := int64(successCount)
+		fmt.Printf("\n%s========================================%s\n", colorCyan, colorReset)
+		fmt.Printf("%s  Summary Statistics (%d successful)%s\n", colorCyan, successCount, colorReset)
+		fmt.Printf("%s========================================%s\n", colorCyan, colorReset)
+		fmt.Printf("Average Connect:        %s%6d ms%s\n", colorBlue, sum.ConnectMs/n, colorReset)
+		fmt.Printf("Average Config:         %s%6d ms%s\n", colorBlue, sum.ConfigMs/n, colorReset)
+		fmt.Printf("Average First Partial:  %s%6d ms%s\n", colorGreen, sum.FirstPartialMs/n, colorReset)
+		fmt.Printf("Average First Final:    %s%6d ms%s\n", colorGreen, sum.FirstFinalMs/n, colorReset)
+		fmt.Printf("Average Finalization:   %s%6d ms%s\n", colorYellow, sum.FinalizeMs/n, colorReset)
+		fmt.Printf("Average Total:          %s%6d ms%s\n", colorCyan, sum.TotalMs/n, colorReset)
+		printKPIs(stt.SummarizeLatencies(latencies, percentiles...), percentiles, kpiPct)
+		fmt.Printf("%s========================================%s\n\n", colorCyan, colorReset)
+	}
+}
+
+// parsePercentiles 解析 -percentiles，并确保包含 KPI 分位数
+func parsePercentiles(list string, kpi float64) ([]float64, error) {
+	var percentiles []float64
+	hasKPI := false
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(field, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %q must be in (0, 100]", field)
+		}
+		percentiles = append(percentiles, p)
+		hasKPI = hasKPI || p == kpi
+	}
+	if kpi <= 0 || kpi > 100 {
+		return nil, fmt.Errorf("kpi percentile %v must be in (0, 100]", kpi)
+	}
+	if !hasKPI {
+		percentiles = append(percentiles, kpi)
+	}
+	return percentiles, nil
+}
+
+// printKPIs 打印响应性 KPI：首个 partial 延迟（主 KPI）与定稿延迟的分位数
+func printKPIs(sum stt.LatencySummary, percentiles []float64, kpi float64) {
+	fmt.Printf("%s----------------------------------------%s\n", colorCyan, colorReset)
+	fmt.Printf("%sPrimary KPI  First Partial P%s: %6d ms%s\n",
+		colorGreen, formatPercentile(kpi), sum.FirstPartial.Percentile(kpi).Milliseconds(), colorReset)
+	printLatencyStats("First Partial", sum.FirstPartial, percentiles)
+	printLatencyStats("Finalization", sum.Finalization, percentiles)
+}
+
+// printLatencyStats 打印一项 KPI 的分位数（无有效样本时注明）
+func printLatencyStats(name string, s stt.LatencyStats, percentiles []float64) {
+	if s.Count == 0 {
+		fmt.Printf("%-14s no samples\n", name)
+		return
+	}
+	var b strings.Builder
+	for _, p := range percentiles {
+		fmt.Fprintf(&b, "  P%s %5d ms", formatPercentile(p), s.Percentile(p).Milliseconds())
+	}
+	fmt.Printf("%-14s n=%d%s  max %5d ms\n", name, s.Count, b.String(), s.Max.Milliseconds())
+}
+
+// formatPercentile 格式化分位数（95 → "95"，99.9 → "99.9"）
+func formatPercentile(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// readInput 读取输入音频：WAV 从文件头取格式，无头 PCM 使用命令行声明的格式并校验对齐
+func readInput(path, format string, rate, channels, bits int) ([]byte, int, int, int, error) {
+	if format == "" {
+		format = "raw"
+		if audio.DetectFormat(path) == audio.FormatWAV {
+			format = "wav"
+		}
+	}
+	switch format {
+	case "wav":
+		return audio.ReadAudioFile(path)
+	case "raw", "pcm":
+		pcm, err := os.ReadFile(path)
+		if err != nil {
+			return nil, 0, 0, 0, err
+		}
+		if err := audio.ValidatePCMSize(len(pcm), channels, bits); err != nil {
+			return nil, 0, 0, 0, err
+		}
+		return pcm, rate, channels, bits, nil
+	default:
+		return nil, 0, 0, 0, fmt.Errorf("unsupported format %q (use wav or raw)", format)
+	}
+}
+
+// RequestResult 请求结果
+type RequestResult struct {
+	SessionID string // gateway 会话ID（用于关联服务端统计）
+
+	StartTime      time.Time
+	ConnectedAt    time.Time
+	ReadyAt        time.Time
+	ConfigSentAt   time.Time
+	FirstSendAt    time.Time
+	FirstPartialAt time.Time
+	FinalAts       []time.Time
+	EndInputAt     time.Time
+	SessionEndedAt time.Time
+	CompleteAt     time.Time
+
+	DialMs     int64
+	ChunkCount int
+	Text       string
+	Latencies  stt.Latencies // 响应性 KPI（phases 中的 FirstPartialMs/FinalizeMs 取自此处）
+	FirstFinal time.Duration // 首包发送 -> 首个 final（session.FirstFinalLatency）
+}
+
+// phaseTimings 各阶段耗时（毫秒）
+type phaseTimings struct {
+	ConnectMs      int64 // TCP+TLS+WebSocket 握手
+	ConfigMs       int64 // 建连完成 -> session.config 发送（含等待 session.ready）
+	FirstPartialMs int64 // 首包发送 -> 首个 partial
+	FirstFinalMs   int64 // 首包发送 -> 首个 final
+	FinalizeMs     int64 // input 结束 -> 最后一个 final
+	TotalMs        int64 // 请求开始 -> 完成
+}
+
+// add 累加各阶段耗时
+func (p *phaseTimings) add(o phaseTimings) {
+	p.ConnectMs += o.ConnectMs
+	p.ConfigMs += o.ConfigMs
+	p.FirstPartialMs += o.FirstPartialMs
+	p.FirstFinalMs += o.FirstFinalMs
+	p.FinalizeMs += o.FinalizeMs
+	p.TotalMs += o.TotalMs
+}
+
+// phases 计算各阶段耗时（缺失的时间戳记为 0）
+func (r *RequestResult) phases() phaseTimings {
+	p := phaseTimings{
+		ConnectMs: r.DialMs,
+		ConfigMs:  msBetween(r.ConnectedAt, r.ConfigSentAt),
+		TotalMs:   msBetween(r.StartTime, r.CompleteAt),
+	}
+	p.FirstPartialMs = r.Latencies.FirstPartial.Milliseconds()
+	p.FirstFinalMs = r.FirstFinal.Milliseconds()
+	p.FinalizeMs = r.Latencies.Finalization.Milliseconds()
+	return p
+}
+
+// msBetween 返回 from -> to 的毫秒数；任一时间为零或顺序倒置时返回 0
+func msBetween(from, to time.Time) int64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return to.Sub(from).Milliseconds()
+}
+
+// runSingleRequest 执行单次识别请求
+func runSingleRequest(config *stt.Config, pcm []byte, chunkSize int, realtime bool) (*RequestResult, error) {
+	ctx := context.Background()
+	result := &RequestResult{StartTime: time.Now()}
+
+	client, err := stt.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("create client: %w", err)
+	}
+	defer client.Close()
+
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+	defer session.Close()
+
+	// goroutine: 分块发送音频 + EndInput
+	sendDoneCh := make(chan error, 1)
+	go func() {
+		if realtime {
+			if err := session.SendRealtime(ctx, bytes.NewReader(pcm)); err != nil {
+				sendDoneCh <- err
+				return
+			}
+		} else {
+			for off := 0; off < len(pcm); off += chunkSize {
+				if err := session.Send(pcm[off:min(off+chunkSize, len(pcm))]); err != nil {
+					sendDoneCh <- err
+					return
+				}
+			}
+		}
+		sendDoneCh <- session.EndInput()
+	}()
+
+	// 事件循环：EndInput 后启动空闲计时器，每收到事件重置
+	committed := false
+	idleTimer := time.NewTimer(0)
+	if !idleTimer.Stop() {
+		<-idleTimer.C
+	}
+	defer idleTimer.Stop()
+
+	var eventErr error
+loop:
+	for {
+		select {
+		case err := <-sendDoneCh:
+			if err != nil {
+				return nil, fmt.Errorf("send audio: %w", err)
+			}
+			committed = true
+			sendDoneCh = nil
+			idleTimer.Reset(idleTimeout)
+
+		case event, ok := <-session.Events():
+			if !ok {
+				break loop
+			}
+			if committed {
+				idleTimer.Reset(idleTimeout)
+			}
+			switch event.Type {
+			case stt.EventTranscriptFinal:
+				result.Text += event.Text
+			case stt.EventError:
+				eventErr = event.Error
+				break loop
+			case stt.EventSessionEnded:
+				break loop
+			}
+
+		case <-idleTimer.C:
+			fmt.Printf("  %s! Idle timeout (%v) after input end, no session.ended%s\n", colorYellow, idleTimeout, colorReset)
+			break loop
+		}
+	}
+	result.CompleteAt = time.Now()
+
+	// 获取时间戳
+	result.ChunkCount = (len(pcm) + chunkSize - 1) / chunkSize
+	result.SessionID = session.ID
+	result.DialMs = session.DialDuration().Milliseconds()
+	result.ConnectedAt = session.ConnectedAt()
+	result.ReadyAt = session.ReadyAt()
+	result.ConfigSentAt = session.ConfigSentAt()
+	result.FirstSendAt = session.FirstSendAt()
+	result.FirstPartialAt = session.FirstPartialAt()
+	result.FinalAts = session.FinalAts()
+	result.EndInputAt = session.EndInputAt()
+	result.SessionEndedAt = session.SessionEndedAt()
+	result.Latencies = session.Latencies()
+	result.FirstFinal = session.FirstFinalLatency()
+
+	if eventErr != nil {
+		return nil, fmt.Errorf("recognition: %w", eventErr)
+	}
+	return result, nil
+}
+
+// printServerStats 拉取并打印服务端统计（调试接口不可用时打印原因，不影响客户端结果）
+func printServerStats(fetcher *gatewaydebug.Fetcher, sessionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := fetcher.Fetch(ctx, sessionID)
+	if err != nil {
+		fmt.Printf("\n  %sServer stats unavailable: %v%s\n", colorYellow, err, colorReset)
+		return
+	}
+	fmt.Println()
+	gatewaydebug.WriteText(os.Stdout, stats, "  ")
+}
+
+// printTimestamp 打印单个相对时间戳（零值跳过）
+func printTimestamp(color, tag string, t, base time.Time, desc string) {
+	if t.IsZero() {
+		return
+	}
+	fmt.Printf("  %s%-16s%s %s (+%dms) - %s\n",
+		color, tag, colorReset, t.Format("15:04:05.000"), t.Sub(base).Milliseconds(), desc)
+}
+
+// printDetailedTimings 打印详细时间戳
+func printDetailedTimings(result *RequestResult) {
+	base := result.StartTime
+	fmt.Printf("  %s%-16s%s %s (baseline)\n",
+		colorBlue, "[START]", colorReset, base.Format("15:04:05.000"))
+	printTimestamp(colorBlue, "[CONNECTED]", result.ConnectedAt, base, "WebSocket connected")
+	printTimestamp(colorBlue, "[READY]", result.ReadyAt, base, "session.ready received")
+	printTimestamp(colorBlue, "[CONFIG_SENT]", result.ConfigSentAt, base, "session.config sent")
+	printTimestamp(colorYellow, "[FIRST_SEND]", result.FirstSendAt, base, "First audio chunk sent")
+	printTimestamp(colorGreen, "[FIRST_PARTIAL]", result.FirstPartialAt, base, "First partial transcript")
+	for i, t := range result.FinalAts {
+		printTimestamp(colorGreen, fmt.Sprintf("[FINAL #%d]", i+1), t, base, "Final transcript")
+	}
+	printTimestamp(colorYellow, "[INPUT_END]", result.EndInputAt, base, "session.end sent (audio complete)")
+	printTimestamp(colorCyan, "[SESSION_ENDED]", result.SessionEndedAt, base, "session.ended received")
+	printTimestamp(colorCyan, "[COMPLETE]", result.CompleteAt, base, "Recognition complete")
+
+	p := result.phases()
+
+	// Key metrics
+	fmt.Printf("\n  %sKey Metrics:%s\n", colorCyan, colorReset)
+	fmt.Printf("    • Connect Time:       %s%6d ms%s (TCP+TLS+WebSocket handshake)\n",
+		colorBlue, p.ConnectMs, colorReset)
+	fmt.Printf("    • Config Time:        %s%6d ms%s (connected to session.config sent)\n",
+		colorBlue, p.ConfigMs, colorReset)
+	fmt.Printf("    • First Partial:      %s%6d ms%s (first audio sent to first partial)\n",
+		colorGreen, p.FirstPartialMs, colorReset)
+	fmt.Printf("    • First Final:        %s%6d ms%s (first audio sent to first final)\n",
+		colorGreen, p.FirstFinalMs, colorReset)
+	fmt.Printf("    • Finalization:       %s%6d ms%s (input end to last final)\n",
+		colorYellow, p.FinalizeMs, colorReset)
+	fmt.Printf("    • Total Time:         %s%6d ms%s (request start to complete)\n",
+		colorCyan, p.TotalMs, colorReset)
+	fmt.Printf("    • Audio Sent:         %d chunks, %d finals\n", result.ChunkCount, len(result.FinalAts))
+
+	// Time breakdown
+	if p.TotalMs > 0 {
+		sendMs := msBetween(result.FirstSendAt, result.EndInputAt)
+		tailMs := msBetween(result.EndInputAt, result.CompleteAt)
+		fmt.Printf("\n  %sTime Breakdown:%s\n", colorCyan, colorReset)
+		fmt.Printf("    • Connect:         %6d ms (%.1f%%)\n", p.ConnectMs, pct(p.ConnectMs, p.TotalMs))
+		fmt.Printf("    • Config:          %6d ms (%.1f%%)\n", p.ConfigMs, pct(p.ConfigMs, p.TotalMs))
+		fmt.Printf("    • Streaming:       %6d ms (%.1f%%) (first send to input end)\n", sendMs, pct(sendMs, p.TotalMs))
+		fmt.Printf("    • Tail:            %6d ms (%.1f%%) (input end to complete)\n", tailMs, pct(tailMs, p.TotalMs))
+	}
+
+	if result.Text != "" {
+		fmt.Printf("\n  %sText:%s %s\n", colorCyan, colorReset, truncateText(result.Text, 80))
+	}
+}
+
+// pct 计算百分比
+func pct(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// truncateText 截断文本
+func truncateText(text string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
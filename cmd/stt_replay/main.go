@@ -0,0 +1,108 @@
+// Package main STT 识别事件重放工具
+//
+// 按原始节奏重放 stt.EventLog 记录的事件日志（JSONL），输出与实时识别相同的 partial / final 事件流，
+// 用于在没有 gateway 和音频的情况下检查录制的事件、演示或调试字幕渲染。
+//
+// 用法:
+//
+//	./stt_stream -record events.jsonl audio.wav   # 录制
+//	./stt_replay events.jsonl                     # 原始节奏重放
+//	./stt_replay -speed 4 -loop events.jsonl      # 四倍速循环重放
+//	./stt_replay -json events.jsonl | my-caption-dev   # 以 JSONL 输出到标准输出，供其他程序读取
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+	"github.com/jinbozhan/tengen-speech-sdk-go/stt"
+)
+
+func main() {
+	var (
+		speed   float64
+		loop    bool
+		jsonOut bool
+	)
+	flag.Float64Var(&speed, "speed", 1, "Playback speed (2 = twice as fast)")
+	flag.BoolVar(&loop, "loop", false, "Replay the log repeatedly until interrupted")
+	flag.BoolVar(&jsonOut, "json", false, "Print events as JSONL (with replay offsets) instead of text")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "STT Replay - replay recorded recognition events at original timing\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  %s [options] <events.jsonl>\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	logging.Setup(logging.LevelInfo)
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var out *stt.EventLog
+	if jsonOut {
+		out = stt.NewEventLog(os.Stdout, nil)
+	}
+	for {
+		if err := replay(ctx, path, speed, out); err != nil {
+			logging.Error("Replay failed", "path", path, "error", err)
+			os.Exit(1)
+		}
+		if !loop || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// replay 重放一遍事件日志；out 非 nil 时以 JSONL 输出，否则打印文本
+func replay(ctx context.Context, path string, speed float64, out *stt.EventLog) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rp, err := stt.Replay(ctx, f, &stt.ReplayOptions{Speed: speed})
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	for event := range rp.Events() {
+		if out != nil {
+			if err := out.Record(event); err != nil {
+				return err
+			}
+			continue
+		}
+		printEvent(time.Since(start), event)
+	}
+	return nil
+}
+
+// printEvent 按 stt_stream 的格式打印事件
+func printEvent(at time.Duration, event *stt.RecognitionEvent) {
+	stamp := fmt.Sprintf("[%7.3fs]", at.Seconds())
+	switch event.Type {
+	case stt.EventTranscriptPartial:
+		fmt.Printf("%s [Partial] %s\n", stamp, event.Text)
+	case stt.EventTranscriptFinal:
+		fmt.Printf("%s [Final] [%.3fs-%.3fs] %s\n", stamp, event.StartTime.Seconds(), event.EndTime.Seconds(), event.Text)
+	case stt.EventError:
+		fmt.Printf("%s [Error] %v\n", stamp, event.Error)
+	case stt.EventSessionReady:
+		fmt.Printf("%s [%s] session_id=%s\n", stamp, event.Type, event.SessionID)
+	default:
+		fmt.Printf("%s [%s]\n", stamp, event.Type)
+	}
+}
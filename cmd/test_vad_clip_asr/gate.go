@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// 退出码（供 CI 作为发布闸门）
+const (
+	exitSetupError = 1 // 参数/环境错误，未能运行测试
+	exitGateFailed = 2 // 有文件出错或成功率低于 -min-success
+)
+
+// failure 失败文件条目（-failures 输出）
+type failure struct {
+	Filename  string `json:"filename"`
+	Reason    string `json:"reason"` // error / empty
+	Error     string `json:"error,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Attempts  int    `json:"attempts"`
+}
+
+// gateResult 闸门判定结果（-failures 输出的 JSON 结构）
+type gateResult struct {
+	Passed      bool      `json:"passed"`
+	Total       int       `json:"total"`
+	Succeeded   int       `json:"succeeded"`
+	Errored     int       `json:"errored"`
+	SuccessRate float64   `json:"success_rate"`
+	MinSuccess  float64   `json:"min_success"`
+	Cancelled   bool      `json:"cancelled,omitempty"`
+	Failures    []failure `json:"failures"`
+	Recovered   []string  `json:"recovered,omitempty"` // 首次出错、重试后成功的文件
+}
+
+// evaluateGate 判定测试是否通过：任一文件出错、成功率低于 minSuccess 或测试被中断均视为失败
+func evaluateGate(results []fileResult, total int, minSuccess float64, cancelled bool) gateResult {
+	g := gateResult{Total: total, MinSuccess: minSuccess, Cancelled: cancelled, Failures: []failure{}}
+	for _, r := range results {
+		if r.Recovered {
+			g.Recovered = append(g.Recovered, r.Filename)
+		}
+		switch {
+		case r.Error != "":
+			g.Errored++
+			g.Failures = append(g.Failures, failure{Filename: r.Filename, Reason: "error", Error: r.Error, SessionID: r.SessionID, Attempts: r.Attempts})
+		case r.Text == "":
+			g.Failures = append(g.Failures, failure{Filename: r.Filename, Reason: "empty", SessionID: r.SessionID, Attempts: r.Attempts})
+		default:
+			g.Succeeded++
+		}
+	}
+	if total > 0 {
+		g.SuccessRate = float64(g.Succeeded) / float64(total)
+	}
+	g.Passed = !cancelled && g.Errored == 0 && g.SuccessRate >= minSuccess
+	return g
+}
+
+// printGate 输出闸门判定
+func printGate(g gateResult) {
+	status := "PASS"
+	if !g.Passed {
+		status = "FAIL"
+	}
+	fmt.Printf("Gate: %s (success %.1f%%, min %.1f%%, errors %d", status, g.SuccessRate*100, g.MinSuccess*100, g.Errored)
+	if g.Cancelled {
+		fmt.Printf(", cancelled after %d/%d files", len(g.Failures)+g.Succeeded, g.Total)
+	}
+	fmt.Println(")")
+}
+
+// writeFailures 将闸门结果与失败列表写入 JSON 文件
+func writeFailures(path string, g gateResult) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -8,13 +8,26 @@
 //	./test_vad_clip_asr
 //	./test_vad_clip_asr -gateway ws://localhost:7861 -provider azure -language en-NG
 //	./test_vad_clip_asr -sample-dir /path/to/wav/files
+//	./test_vad_clip_asr -min-success 0.95 -failures failures.json  # 作为发布闸门
+//	./test_vad_clip_asr -retry-attempts 2 -retry-backoff 5s         # 出错文件重试两轮
+//	./test_vad_clip_asr -manifest https://bucket.example.com/speech-samples/manifest.json
+//	./test_vad_clip_asr -manifest manifest.json -sync-only          # 只下载/校验语料
+//
+// -manifest 指定语料清单（URL 或本地路径，格式见 internal/corpus）时，先将样本下载到 -sample-dir
+// 并校验 SHA-256，已存在且校验通过的文件不重复下载，新机器上无需手工拷贝音频。
+//
+// 首轮结束后，出错的文件（不含空结果）按 -retry-attempts 重试，每轮前等待 -retry-backoff（逐轮翻倍），
+// 汇总与报告中标注重试次数及重试后恢复的文件。
+//
+// 退出码：0 通过；1 参数/环境错误；2 有文件出错、成功率低于 -min-success 或测试被中断。
+// -failures 输出机器可读的判定结果与失败文件列表（JSON）。
 package main
 
 import (
 	"context"
-	"encoding/binary"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -22,12 +35,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/corpus"
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/gatewaydebug"
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/reports"
 	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
 	"github.com/jinbozhan/tengen-speech-sdk-go/stt"
 )
 
 const (
-	wavHeaderSize      = 44              // WAV 文件头大小（字节）
 	connectTimeout     = 30 * time.Second // WebSocket 连接超时
 	readTimeout        = 120 * time.Second // 识别读超时（长文件需要更久）
 	writeTimeout       = 10 * time.Second  // WebSocket 写超时
@@ -46,6 +62,19 @@ var (
 	sampleRate int
 	sampleDir  string
 	outputFile string
+	gzipOver   int
+
+	minSuccess   float64
+	failuresFile string
+
+	retryAttempts int
+	retryBackoff  time.Duration
+
+	manifest string
+	syncOnly bool
+
+	gatewayDebug  bool
+	debugEndpoint string
 )
 
 func init() {
@@ -56,6 +85,15 @@ func init() {
 	flag.IntVar(&sampleRate, "sample-rate", 8000, "Audio sample rate in Hz")
 	flag.StringVar(&sampleDir, "sample-dir", "", "WAV files directory (default: <repo>/sample/)")
 	flag.StringVar(&outputFile, "output", "", "Output results to file (markdown format)")
+	flag.IntVar(&gzipOver, "gzip-threshold", reports.DefaultGzipThreshold, "Gzip the report when larger than this many bytes (saved as <output>.gz; negative disables)")
+	flag.Float64Var(&minSuccess, "min-success", 0, "Minimum success rate (0-1; non-empty result without error) required to exit 0")
+	flag.StringVar(&failuresFile, "failures", "", "Write gate result and failed files to this JSON file")
+	flag.IntVar(&retryAttempts, "retry-attempts", 1, "Retry passes over files that errored (0 disables)")
+	flag.DurationVar(&retryBackoff, "retry-backoff", 2*time.Second, "Wait before the first retry pass (doubles each pass)")
+	flag.StringVar(&manifest, "manifest", "", "Corpus manifest (URL or path): download and verify sample WAVs into -sample-dir before testing")
+	flag.BoolVar(&syncOnly, "sync-only", false, "With -manifest: only download/verify the corpus, then exit")
+	flag.BoolVar(&gatewayDebug, "gateway-debug", false, "Fetch server-side VAD/timing stats from the gateway debug endpoint after each file")
+	flag.StringVar(&debugEndpoint, "debug-endpoint", gatewaydebug.DefaultEndpoint, "Gateway debug endpoint path template ({session_id} is substituted)")
 }
 
 // fileResult 单个文件的识别结果
@@ -66,26 +104,49 @@ type fileResult struct {
 	Text        string
 	Segments    []stt.Segment // 分段结果（含时间戳）
 	Error       string
+	SessionID   string                     // gateway 会话ID
+	ServerStats *gatewaydebug.SessionStats // 服务端统计（-gateway-debug 开启且接口可用时）
+	Attempts    int                        // 识别次数（含重试）
+	Recovered   bool                       // 首次出错、重试后成功
 }
 
 func main() {
 	flag.Parse()
 	logging.Setup(logging.LevelInfo)
 
+	if minSuccess < 0 || minSuccess > 1 {
+		logging.Error("Invalid -min-success, want 0-1", "min_success", minSuccess)
+		os.Exit(exitSetupError)
+	}
+
 	// 默认 sample 目录：基于可执行文件位置或当前目录
 	if sampleDir == "" {
 		sampleDir = findSampleDir()
 	}
 
+	// 按清单同步语料
+	if manifest != "" {
+		if err := syncCorpus(manifest, sampleDir); err != nil {
+			logging.Error("Failed to sync corpus", "manifest", manifest, "error", err)
+			os.Exit(exitSetupError)
+		}
+		if syncOnly {
+			return
+		}
+	} else if syncOnly {
+		logging.Error("-sync-only requires -manifest")
+		os.Exit(exitSetupError)
+	}
+
 	// 查找 WAV 文件
 	wavFiles, err := filepath.Glob(filepath.Join(sampleDir, "*.wav"))
 	if err != nil {
 		logging.Error("Failed to find WAV files", "error", err)
-		os.Exit(1)
+		os.Exit(exitSetupError)
 	}
 	if len(wavFiles) == 0 {
 		logging.Error("No WAV files found", "dir", sampleDir)
-		os.Exit(1)
+		os.Exit(exitSetupError)
 	}
 
 	// 排序
@@ -117,41 +178,21 @@ func main() {
 		cancel()
 	}()
 
+	// 服务端统计拉取（可选）
+	var fetcher *gatewaydebug.Fetcher
+	if gatewayDebug {
+		fetcher, err = gatewaydebug.NewFetcher(gatewayURL, debugEndpoint, apiKey)
+		if err != nil {
+			logging.Error("Invalid gateway debug settings", "error", err)
+			os.Exit(exitSetupError)
+		}
+	}
+
 	// 逐文件识别
 	results := make([]fileResult, 0, len(wavFiles))
 
 	for i, wavPath := range wavFiles {
-		filename := filepath.Base(wavPath)
-		durSec := getWavDurationSec(wavPath)
-
-		fmt.Printf("[%d/%d] %s (%.1fs)\n", i+1, len(wavFiles), filename, durSec)
-
-		start := time.Now()
-		text, segments, recErr := recognizeFile(ctx, wavPath)
-		elapsed := time.Since(start).Seconds()
-
-		r := fileResult{
-			Filename:    filename,
-			DurationSec: durSec,
-			ElapsedSec:  elapsed,
-			Text:        text,
-			Segments:    segments,
-		}
-		if recErr != nil {
-			r.Error = recErr.Error()
-		}
-		results = append(results, r)
-
-		// 实时输出
-		if r.Error != "" {
-			fmt.Printf("  ERROR: %s\n", r.Error)
-		} else if r.Text != "" {
-			preview := truncate(r.Text, textPreviewLen)
-			fmt.Printf("  Text: %s\n", preview)
-		} else {
-			fmt.Printf("  (empty)\n")
-		}
-		fmt.Printf("  Elapsed: %.2fs\n\n", elapsed)
+		results = append(results, processFile(ctx, fetcher, wavPath, fmt.Sprintf("%d/%d", i+1, len(wavFiles))))
 
 		// 检查 context 是否已取消
 		if ctx.Err() != nil {
@@ -160,21 +201,117 @@ func main() {
 		}
 	}
 
+	// 重试出错的文件（gateway 瞬时故障不应污染准确率结果）
+	if ctx.Err() == nil {
+		retryErrored(ctx, fetcher, wavFiles, results)
+	}
+
 	// 汇总报告
 	printSummary(results)
+	gate := evaluateGate(results, len(wavFiles), minSuccess, ctx.Err() != nil)
+	printGate(gate)
 
 	// 输出到文件
 	if outputFile != "" {
-		if err := writeMarkdownReport(outputFile, results); err != nil {
+		if path, err := writeMarkdownReport(outputFile, results); err != nil {
 			logging.Warn("Failed to write report", "error", err)
 		} else {
-			fmt.Printf("\nReport saved to: %s\n", outputFile)
+			fmt.Printf("\nReport saved to: %s\n", path)
+		}
+	}
+	if failuresFile != "" {
+		if err := writeFailures(failuresFile, gate); err != nil {
+			logging.Warn("Failed to write failures", "error", err)
+		} else {
+			fmt.Printf("Failures saved to: %s\n", failuresFile)
 		}
 	}
+
+	if !gate.Passed {
+		os.Exit(exitGateFailed)
+	}
 }
 
-// recognizeFile 识别单个文件
-func recognizeFile(ctx context.Context, audioPath string) (string, []stt.Segment, error) {
+// processFile 识别单个文件并实时输出结果（label 如 "3/20"）
+func processFile(ctx context.Context, fetcher *gatewaydebug.Fetcher, wavPath, label string) fileResult {
+	filename := filepath.Base(wavPath)
+	durSec := getWavDurationSec(wavPath)
+
+	fmt.Printf("[%s] %s (%.1fs)\n", label, filename, durSec)
+
+	start := time.Now()
+	sessionID, text, segments, recErr := recognizeFile(ctx, wavPath)
+	elapsed := time.Since(start).Seconds()
+
+	r := fileResult{
+		Filename:    filename,
+		DurationSec: durSec,
+		ElapsedSec:  elapsed,
+		Text:        text,
+		Segments:    segments,
+		SessionID:   sessionID,
+		Attempts:    1,
+	}
+	if recErr != nil {
+		r.Error = recErr.Error()
+	}
+	if fetcher != nil && sessionID != "" {
+		r.ServerStats = fetchServerStats(ctx, fetcher, sessionID)
+	}
+
+	// 实时输出
+	if r.Error != "" {
+		fmt.Printf("  ERROR: %s\n", r.Error)
+	} else if r.Text != "" {
+		preview := truncate(r.Text, textPreviewLen)
+		fmt.Printf("  Text: %s\n", preview)
+	} else {
+		fmt.Printf("  (empty)\n")
+	}
+	fmt.Printf("  Elapsed: %.2fs\n", elapsed)
+	gatewaydebug.WriteText(os.Stdout, r.ServerStats, "  ")
+	fmt.Println()
+	return r
+}
+
+// retryErrored 对出错的文件（不含空结果）最多重试 -retry-attempts 轮，每轮前等待退避时间（逐轮翻倍）
+// results[i] 对应 wavFiles[i]，重试结果原地替换
+func retryErrored(ctx context.Context, fetcher *gatewaydebug.Fetcher, wavFiles []string, results []fileResult) {
+	backoff := retryBackoff
+	for pass := 1; pass <= retryAttempts; pass++ {
+		var pending []int
+		for i, r := range results {
+			if r.Error != "" {
+				pending = append(pending, i)
+			}
+		}
+		if len(pending) == 0 {
+			return
+		}
+
+		fmt.Printf("Retry pass %d/%d: %d errored files (after %v)\n\n", pass, retryAttempts, len(pending), backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		for n, i := range pending {
+			r := processFile(ctx, fetcher, wavFiles[i], fmt.Sprintf("retry %d/%d", n+1, len(pending)))
+			r.Attempts = results[i].Attempts + 1
+			r.Recovered = r.Error == ""
+			results[i] = r
+			if ctx.Err() != nil {
+				fmt.Println("Test cancelled")
+				return
+			}
+		}
+	}
+}
+
+// recognizeFile 识别单个文件，返回会话ID（即使识别失败也尽量返回，便于关联服务端日志）
+func recognizeFile(ctx context.Context, audioPath string) (string, string, []stt.Segment, error) {
 	config := &stt.Config{
 		GatewayURL:     gatewayURL,
 		Provider:       provider,
@@ -188,19 +325,36 @@ func recognizeFile(ctx context.Context, audioPath string) (string, []stt.Segment
 
 	client, err := stt.NewClient(config)
 	if err != nil {
-		return "", nil, fmt.Errorf("create client: %w", err)
+		return "", "", nil, fmt.Errorf("create client: %w", err)
 	}
 	defer client.Close()
 
 	result, err := client.RecognizeFile(ctx, audioPath)
+	sessionID := ""
+	if result != nil {
+		sessionID = result.SessionID
+	}
 	if err != nil {
-		return "", nil, err
+		return sessionID, "", nil, err
 	}
 	if result.Error != nil {
-		return "", nil, result.Error
+		return sessionID, "", nil, result.Error
 	}
 
-	return strings.TrimSpace(result.Text), result.Segments, nil
+	return sessionID, strings.TrimSpace(result.Text), result.Segments, nil
+}
+
+// fetchServerStats 拉取服务端统计；接口不可用或失败时返回 nil（仅记录日志）
+func fetchServerStats(ctx context.Context, fetcher *gatewaydebug.Fetcher, sessionID string) *gatewaydebug.SessionStats {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	stats, err := fetcher.Fetch(ctx, sessionID)
+	if err != nil {
+		logging.Warn("Failed to fetch gateway stats", "session_id", sessionID, "error", err)
+		return nil
+	}
+	return stats
 }
 
 // printSummary 输出汇总表格
@@ -229,17 +383,31 @@ func printSummary(results []fileResult) {
 		} else {
 			status = "(empty)"
 		}
+		if r.Attempts > 1 {
+			status = fmt.Sprintf("[attempt %d] %s", r.Attempts, status)
+		}
 		fmt.Printf("%-3d %-47s %6s %6s  %s\n", i+1, shortName, dur, elp, status)
 	}
 
 	fmt.Println(dash)
 	fmt.Printf("Success: %d/%d\n", okCount, len(results))
+	var recovered []string
+	for _, r := range results {
+		if r.Recovered {
+			recovered = append(recovered, r.Filename)
+		}
+	}
+	if len(recovered) > 0 {
+		fmt.Printf("Recovered on retry: %d (%s)\n", len(recovered), strings.Join(recovered, ", "))
+	}
 	fmt.Println()
-	fmt.Println("Note: Check gateway logs for VAD filter stats (speech_pct) to verify VAD effectiveness")
+	if !gatewayDebug {
+		fmt.Println("Note: Check gateway logs for VAD filter stats (speech_pct) to verify VAD effectiveness (or run with -gateway-debug)")
+	}
 }
 
-// writeMarkdownReport 输出 Markdown 格式报告
-func writeMarkdownReport(path string, results []fileResult) error {
+// writeMarkdownReport 输出 Markdown 格式报告，返回实际写入的路径（超过 -gzip-threshold 时为 .gz）
+func writeMarkdownReport(path string, results []fileResult) (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString("# VAD-Clip ASR Test Results\n\n")
@@ -277,6 +445,27 @@ func writeMarkdownReport(path string, results []fileResult) error {
 	sb.WriteString("\n## Detailed Results\n\n")
 	for i, r := range results {
 		sb.WriteString(fmt.Sprintf("### %d. %s\n\n", i+1, r.Filename))
+		if r.SessionID != "" {
+			sb.WriteString(fmt.Sprintf("- **Session**: `%s`\n", r.SessionID))
+		}
+		if r.Attempts > 1 {
+			note := "still failing"
+			if r.Recovered {
+				note = "recovered on retry"
+			}
+			sb.WriteString(fmt.Sprintf("- **Attempts**: %d (%s)\n", r.Attempts, note))
+		}
+		if r.ServerStats != nil {
+			for _, k := range gatewaydebug.SortedKeys(r.ServerStats.VAD) {
+				sb.WriteString(fmt.Sprintf("- **VAD %s**: %.1f\n", k, r.ServerStats.VAD[k]))
+			}
+			for _, k := range gatewaydebug.SortedKeys(r.ServerStats.Timings) {
+				sb.WriteString(fmt.Sprintf("- **Server %s**: %.1f ms\n", k, r.ServerStats.Timings[k]))
+			}
+		}
+		if r.SessionID != "" || r.ServerStats != nil || r.Attempts > 1 {
+			sb.WriteString("\n")
+		}
 		if r.Error != "" {
 			sb.WriteString(fmt.Sprintf("**ERROR**: %s\n\n", r.Error))
 		} else if r.Text != "" {
@@ -296,10 +485,11 @@ func writeMarkdownReport(path string, results []fileResult) error {
 		}
 	}
 
-	return os.WriteFile(path, []byte(sb.String()), 0644)
+	return reports.WriteFile(path, []byte(sb.String()), gzipOver)
 }
 
 // getWavDurationSec 读取 WAV 文件时长（秒）
+// 按块结构解析头部（LIST/fact 等块不计入时长）；data 块长度未知时按文件剩余大小估算
 func getWavDurationSec(path string) float64 {
 	f, err := os.Open(path)
 	if err != nil {
@@ -307,34 +497,35 @@ func getWavDurationSec(path string) float64 {
 	}
 	defer f.Close()
 
-	// 读取 WAV 文件头
-	header := make([]byte, wavHeaderSize)
-	n, err := f.Read(header)
-	if err != nil || n < wavHeaderSize {
+	header, err := audio.ReadWAVHeader(f)
+	if err != nil || header.ByteRate == 0 {
 		return 0
 	}
-
-	// WAV 格式: 偏移 24-27 为采样率（little-endian uint32）
-	rate := binary.LittleEndian.Uint32(header[24:28])
-	// 偏移 34-35 为 bits per sample
-	bitsPerSample := binary.LittleEndian.Uint16(header[34:36])
-	// 偏移 22-23 为 channels
-	channels := binary.LittleEndian.Uint16(header[22:24])
-
-	if rate == 0 || bitsPerSample == 0 || channels == 0 {
-		return 0
+	if header.Subchunk2Size == 0 || header.Subchunk2Size == 0xFFFFFFFF {
+		offset, _ := f.Seek(0, io.SeekCurrent)
+		info, err := f.Stat()
+		if err != nil {
+			return 0
+		}
+		return float64(info.Size()-offset) / float64(header.ByteRate)
 	}
+	return audio.GetAudioDuration(header)
+}
 
-	// 文件大小减去头部 = PCM 数据大小
-	info, err := f.Stat()
+// syncCorpus 按清单下载并校验样本到 dir
+func syncCorpus(location, dir string) error {
+	ctx := context.Background()
+	syncer := corpus.NewSyncer(0)
+	m, err := syncer.LoadManifest(ctx, location)
 	if err != nil {
-		return 0
+		return err
 	}
-	dataSize := info.Size() - int64(wavHeaderSize)
-	bytesPerSample := int64(bitsPerSample) / 8 * int64(channels)
-	totalSamples := dataSize / bytesPerSample
-
-	return float64(totalSamples) / float64(rate)
+	fmt.Printf("Syncing %d corpus files into %s\n", len(m.Files), dir)
+	res, err := syncer.Sync(ctx, m, dir)
+	if res != nil {
+		fmt.Printf("Corpus: %d downloaded, %d already verified\n\n", len(res.Downloaded), len(res.Verified))
+	}
+	return err
 }
 
 // findSampleDir 查找 sample 目录
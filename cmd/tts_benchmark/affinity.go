@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 会话亲和分类（RequestMetrics.Affinity，仅 -affinity 时）
+const (
+	affinityCold = "cold" // 未携带令牌（worker 的首个请求，或之前的会话未返回令牌）
+	affinityWarm = "warm" // 携带令牌且 gateway 返回同一令牌（路由到同一后端）
+	affinityMiss = "miss" // 携带令牌但被路由到其他后端
+)
+
+// classifyAffinity 按发送与返回的亲和令牌对请求分类
+func classifyAffinity(sent, got string) string {
+	switch {
+	case sent == "":
+		return affinityCold
+	case got == sent:
+		return affinityWarm
+	default:
+		return affinityMiss
+	}
+}
+
+// AffinityStats 一类请求的 TTFB 统计（仅成功请求）
+type AffinityStats struct {
+	Requests int           `json:"requests"`
+	TTFBMs   *LatencyStats `json:"ttfb_ms,omitempty"`
+}
+
+// AffinitySummary 会话亲和测试结果（summary JSON 的 affinity 字段）
+//
+// warm 与 cold 的 TTFB 差值即 gateway 热缓存的实际收益；miss 较多说明粘性路由未生效。
+type AffinitySummary struct {
+	Classes       map[string]*AffinityStats `json:"classes"`
+	WarmSavingP50 int64                     `json:"warm_saving_p50_ms"` // cold P50 - warm P50（无 warm 请求时为 0）
+}
+
+// summarizeAffinity 按亲和分类汇总 TTFB；未启用 -affinity 时返回 nil
+func summarizeAffinity(metrics []RequestMetrics) *AffinitySummary {
+	values := make(map[string][]int64)
+	enabled := false
+	for _, m := range metrics {
+		if m.Affinity == "" {
+			continue
+		}
+		enabled = true
+		if _, ok := values[m.Affinity]; !ok {
+			values[m.Affinity] = nil
+		}
+		if m.Success && m.TTFBMs > 0 {
+			values[m.Affinity] = append(values[m.Affinity], m.TTFBMs)
+		}
+	}
+	if !enabled {
+		return nil
+	}
+
+	s := &AffinitySummary{Classes: make(map[string]*AffinityStats)}
+	for class, v := range values {
+		s.Classes[class] = &AffinityStats{Requests: len(v), TTFBMs: latencyStats(v)}
+	}
+	if cold, warm := s.Classes[affinityCold], s.Classes[affinityWarm]; cold != nil && warm != nil && cold.TTFBMs != nil && warm.TTFBMs != nil {
+		s.WarmSavingP50 = cold.TTFBMs.P50 - warm.TTFBMs.P50
+	}
+	return s
+}
+
+// latencyStats 计算延迟统计；values 为空时返回 nil
+func latencyStats(values []int64) *LatencyStats {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	return &LatencyStats{
+		Min: sorted[0],
+		Max: sorted[len(sorted)-1],
+		Avg: sum / int64(len(sorted)),
+		P50: percentile(sorted, 50),
+		P95: percentile(sorted, 95),
+		P99: percentile(sorted, 99),
+	}
+}
+
+// printAffinity 打印 warm / cold TTFB 对比
+func (r *Reporter) printAffinity(s *AffinitySummary) {
+	fmt.Printf("\nSession Affinity (TTFB of successful requests):\n")
+	for _, class := range []string{affinityCold, affinityWarm, affinityMiss} {
+		c, ok := s.Classes[class]
+		if !ok {
+			continue
+		}
+		if c.TTFBMs == nil {
+			fmt.Printf("  %-5s %5d requests\n", class, c.Requests)
+			continue
+		}
+		fmt.Printf("  %-5s %5d requests  P50 %5d ms  P95 %5d ms  Avg %5d ms\n",
+			class, c.Requests, c.TTFBMs.P50, c.TTFBMs.P95, c.TTFBMs.Avg)
+	}
+	switch {
+	case s.Classes[affinityWarm] == nil && s.Classes[affinityMiss] == nil:
+		fmt.Println("  No affinity token returned by the gateway (affinity not supported, or one request per worker)")
+	case s.Classes[affinityWarm] != nil:
+		fmt.Printf("  Warm P50 saving: %d ms\n", s.WarmSavingP50)
+	}
+	fmt.Println(strings.Repeat("=", 80))
+}
@@ -6,25 +6,40 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/audiosave"
 	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
 	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
 )
 
 // BenchmarkConfig 测试配置
 type BenchmarkConfig struct {
-	GatewayURL string        // Gateway WebSocket URL
-	Provider   string        // 提供商
-	APIKey     string        // API Key
-	Voices     []VoiceConfig // 音色配置列表
-	Requests   int           // 每个 worker 的请求数
-	RampUp     time.Duration // 预热时间
-	OutputDir  string        // 输出目录
-	SaveAudio  bool          // 是否保存音频
-	Verbose    bool          // 详细日志
+	GatewayURL    string        // Gateway WebSocket URL
+	Provider      string        // 提供商
+	APIKey        string        // API Key
+	Voices        []VoiceConfig // 音色配置列表
+	Requests      int           // 每个 worker 的请求数
+	RampUp        time.Duration // 预热时间
+	OutputDir     string        // 输出目录
+	Instance      string        // 实例名（多实例共享 OutputDir 时区分报告与音频，见 -merge）
+	SaveAudio     bool          // 是否保存音频（保存后逐个文件做质量检查，见 checkAudioFile）
+	AudioTemplate string        // 音频文件名模板（相对 OutputDir/audio，见 audiosave.DefaultTemplate）
+	AudioFormat   string        // 请求的音频格式（pcm 时保存为 WAV；空使用 gateway 默认 mp3）
+	SampleRate    int           // 请求的采样率（0 使用提供商默认，不检查保存音频的采样率）
+	Priority      string        // 会话调度优先级提示（realtime / bulk，空不发送）
+	Affinity      bool          // 同一 worker 的后续请求携带上次返回的亲和令牌，分别统计 warm / cold TTFB
+	Retries       int           // 建连失败后的重试次数（0 不重试），首次成功与经重试的请求分别统计
+	RetryBackoff  time.Duration // 重试退避基数（0 使用 SDK 默认值）
+	SharedLoop    bool          // 所有会话共用一个 transport.EventLoop（高并发下减少 goroutine）
+	GzipThreshold int           // 报告超过此字节数时 gzip 保存为 .gz（0 使用默认 1MB，<0 不压缩）
+	Verbose       bool          // 详细日志
 }
 
 // VoiceConfig 音色配置
@@ -38,6 +53,8 @@ type Benchmark struct {
 	config    *BenchmarkConfig
 	collector *MetricsCollector
 	texts     *TextProvider
+	saver     *audiosave.Saver     // SaveAudio 时按模板保存音频
+	eventLoop *transport.EventLoop // SharedLoop 时所有会话共用
 
 	// 统计
 	activeWorkers int64
@@ -67,12 +84,18 @@ func NewBenchmark(config *BenchmarkConfig) *Benchmark {
 
 // Run 执行测试
 func (b *Benchmark) Run(ctx context.Context) error {
-	// 确保音频输出目录存在
+	// 创建音频保存器（同时创建输出目录）
 	if b.config.SaveAudio {
-		audioDir := filepath.Join(b.config.OutputDir, "audio")
-		if err := os.MkdirAll(audioDir, 0755); err != nil {
-			return fmt.Errorf("create audio dir: %w", err)
+		// 多实例共享输出目录时各自保存到 audio/<instance>，清单互不覆盖
+		saver, err := audiosave.New(filepath.Join(b.config.OutputDir, "audio", b.config.Instance), b.config.AudioTemplate)
+		if err != nil {
+			return err
 		}
+		b.saver = saver
+	}
+	if b.config.SharedLoop {
+		b.eventLoop = transport.NewEventLoop(0, nil)
+		defer b.eventLoop.Close()
 	}
 
 	// 计算总并发数
@@ -88,7 +111,8 @@ func (b *Benchmark) Run(ctx context.Context) error {
 		"concurrency", totalConcurrency,
 		"requests_per_worker", b.config.Requests,
 		"total_requests", b.totalRequests,
-		"save_audio", b.config.SaveAudio)
+		"save_audio", b.config.SaveAudio,
+		"shared_loop", b.config.SharedLoop)
 
 	b.collector.Start()
 
@@ -125,6 +149,14 @@ func (b *Benchmark) Run(ctx context.Context) error {
 
 	b.collector.End()
 
+	if b.saver != nil {
+		path, err := b.saver.WriteManifest()
+		if err != nil {
+			return fmt.Errorf("write audio manifest: %w", err)
+		}
+		logging.Info("Audio manifest written", "path", path)
+	}
+
 	return nil
 }
 
@@ -134,6 +166,7 @@ func (b *Benchmark) runWorker(ctx context.Context, wg *sync.WaitGroup, workerID
 	atomic.AddInt64(&b.activeWorkers, 1)
 	defer atomic.AddInt64(&b.activeWorkers, -1)
 
+	var affinityToken string // 上次会话返回的亲和令牌（-affinity）
 	for reqID := 0; reqID < b.config.Requests; reqID++ {
 		select {
 		case <-ctx.Done():
@@ -143,7 +176,10 @@ func (b *Benchmark) runWorker(ctx context.Context, wg *sync.WaitGroup, workerID
 		default:
 		}
 
-		metrics := b.executeRequest(ctx, workerID, reqID, voiceID)
+		metrics := b.executeRequest(ctx, workerID, reqID, voiceID, affinityToken)
+		if b.config.Affinity && metrics.AffinityToken != "" {
+			affinityToken = metrics.AffinityToken
+		}
 		b.collector.Record(metrics)
 		atomic.AddInt64(&b.completedReqs, 1)
 
@@ -157,12 +193,13 @@ func (b *Benchmark) runWorker(ctx context.Context, wg *sync.WaitGroup, workerID
 	}
 }
 
-// executeRequest 执行单次请求
-func (b *Benchmark) executeRequest(ctx context.Context, workerID, reqID int, voiceID string) RequestMetrics {
+// executeRequest 执行单次请求；affinityToken 非空时随 session.config 发送
+func (b *Benchmark) executeRequest(ctx context.Context, workerID, reqID int, voiceID, affinityToken string) RequestMetrics {
 	metrics := RequestMetrics{
 		VoiceID:   voiceID,
 		WorkerID:  workerID,
 		RequestID: reqID,
+		Instance:  b.config.Instance,
 		StartTime: time.Now(),
 	}
 
@@ -184,9 +221,20 @@ func (b *Benchmark) executeRequest(ctx context.Context, workerID, reqID int, voi
 		APIKey:         b.config.APIKey,
 		VoiceID:        voiceID,
 		Speed:          1.0,
+		AudioFormat:    b.config.AudioFormat,
+		SampleRate:     b.config.SampleRate,
+		Priority:       protocol.Priority(b.config.Priority),
+		AffinityToken:  affinityToken,
+		EventLoop:      b.eventLoop,
 		ConnectTimeout: 30 * time.Second,
 		ReadTimeout:    120 * time.Second,
 		WriteTimeout:   10 * time.Second,
+		MaxReconnects:  -1, // 默认不重连；-retries 时各次尝试分别记录（见 recordAttempts）
+	}
+
+	if b.config.Retries > 0 {
+		clientConfig.MaxReconnects = b.config.Retries
+		clientConfig.ReconnectBackoff = b.config.RetryBackoff
 	}
 
 	// 创建客户端
@@ -215,6 +263,11 @@ func (b *Benchmark) executeRequest(ctx context.Context, workerID, reqID int, voi
 	// 获取建连时间（从 stream 暴露的 session 方法）
 	metrics.ConnectMs = stream.ConnectDuration().Milliseconds()
 	metrics.ConnectedAt = stream.ConnectedAt()
+	recordAttempts(&metrics, stream.ConnectAttempts())
+	if b.config.Affinity {
+		metrics.AffinityToken = stream.AffinityToken()
+		metrics.Affinity = classifyAffinity(affinityToken, metrics.AffinityToken)
+	}
 
 	if verboseTiming && !metrics.ConnectedAt.IsZero() {
 		logging.Info("Connected", "worker_id", workerID, "req_id", reqID, "connect_ms", metrics.ConnectMs, "time", metrics.ConnectedAt.Format("2006-01-02 15:04:05.000"))
@@ -281,6 +334,8 @@ func (b *Benchmark) executeRequest(ctx context.Context, workerID, reqID int, voi
 
 	metrics.CompleteAt = time.Now()
 	metrics.TotalMs = metrics.CompleteAt.Sub(metrics.StartTime).Milliseconds()
+	metrics.AudioMs = stream.AudioDuration().Milliseconds()
+	metrics.RTF = stream.RealTimeFactor()
 
 	if verboseTiming && !metrics.CompleteAt.IsZero() {
 		logging.Info("Request complete", "worker_id", workerID, "req_id", reqID, "total_ms", metrics.TotalMs, "total_bytes", metrics.TotalBytes, "chunk_count", metrics.ChunkCount, "time", metrics.CompleteAt.Format("2006-01-02 15:04:05.000"))
@@ -294,14 +349,9 @@ func (b *Benchmark) executeRequest(ctx context.Context, workerID, reqID int, voi
 		// 至少收到了一个音频块
 		metrics.Success = true
 
-		// 保存音频文件
+		// 保存音频文件并检查质量：未通过检查（静音、削波、采样率不符等）的请求计为失败
 		if b.config.SaveAudio && len(audioData) > 0 {
-			audioFile, err := b.saveAudio(voiceID, workerID, reqID, audioData)
-			if err != nil {
-				logging.Warn("Failed to save audio", "error", err)
-			} else {
-				metrics.AudioFile = audioFile
-			}
+			b.saveAudio(&metrics, stream.SampleRate(), audioData)
 		}
 	} else {
 		metrics.Success = false
@@ -311,17 +361,40 @@ func (b *Benchmark) executeRequest(ctx context.Context, workerID, reqID int, voi
 	return metrics
 }
 
-// saveAudio 保存音频文件
-func (b *Benchmark) saveAudio(voiceID string, workerID, reqID int, data []byte) (string, error) {
-	// 文件名格式: {voice}_{worker}_{req}.mp3
-	filename := fmt.Sprintf("%s_w%d_r%d.mp3", voiceID, workerID, reqID)
-	filepath := filepath.Join(b.config.OutputDir, "audio", filename)
+// saveAudio 保存音频并做质量检查；PCM 加 WAV 头保存，便于直接播放
+func (b *Benchmark) saveAudio(metrics *RequestMetrics, sampleRate int, data []byte) {
+	ext := b.config.AudioFormat
+	switch ext {
+	case "":
+		ext = "mp3" // 未指定 AudioFormat 时 gateway 默认输出 mp3
+	case "pcm":
+		wav, err := audio.PCMToWAV(data, sampleRate, 1, 16)
+		if err != nil {
+			logging.Warn("Failed to wrap PCM as WAV", "error", err)
+			return
+		}
+		data, ext = wav, "wav"
+	}
 
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
-		return "", err
+	audioFile, err := b.saver.Save(audiosave.Fields{
+		Voice:    metrics.VoiceID,
+		Provider: b.config.Provider,
+		Worker:   metrics.WorkerID,
+		Req:      metrics.RequestID,
+		Ext:      ext,
+		Text:     metrics.Text,
+	}, data)
+	if err != nil {
+		logging.Warn("Failed to save audio", "error", err)
+		return
 	}
+	metrics.AudioFile = audioFile
 
-	return filepath, nil
+	metrics.Quality = checkAudioFile(audioFile, b.config.SampleRate)
+	if !metrics.Quality.OK() {
+		metrics.Success = false
+		metrics.Error = "audio quality: " + strings.Join(metrics.Quality.Issues, ", ") // 按问题类型聚合错误分布
+	}
 }
 
 // reportProgress 报告进度
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+)
+
+// 分布式压测：单台机器的连接数/CPU 不足以压满 gateway 时，一个 controller 把并发按音色拆分给
+// 多个 agent，同时开始，结束后收集各 agent 的逐请求指标合并出报告。
+//
+//	controller:  ./tts_benchmark -controller :9090 -agents 3 -voices "en-NG-RoseSerious:120" ...
+//	agent × 3:   ./tts_benchmark -agent http://controller:9090 -instance host1
+//
+// 协议为 HTTP + JSON：agent POST /register 长轮询，全部 agent 注册后同时收到各自的任务
+// （agentAssignment）；压测结束后 POST /results 上报 agentResult。API Key 不经 controller 下发，
+// 每个 agent 使用自己的 -api-key / GATEWAY_API_KEY。
+
+// agentAssignment controller 分配给 agent 的任务
+type agentAssignment struct {
+	Instance    string        `json:"instance"` // 实例名（未指定 -instance 的 agent 由 controller 分配）
+	GatewayURL  string        `json:"gateway"`
+	Provider    string        `json:"provider"`
+	Voices      []VoiceConfig `json:"voices"` // 本 agent 承担的并发
+	Requests    int           `json:"requests"`
+	RampUp      time.Duration `json:"rampup"`
+	AudioFormat string        `json:"audio_format,omitempty"`
+	SampleRate  int           `json:"sample_rate,omitempty"`
+	Priority    string        `json:"priority,omitempty"`
+	Affinity    bool          `json:"affinity,omitempty"`
+	SharedLoop  bool          `json:"shared_loop,omitempty"`
+
+	Retries      int           `json:"retries,omitempty"`
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+}
+
+// agentRegistration agent 注册请求
+type agentRegistration struct {
+	Instance string `json:"instance"`
+}
+
+// agentResult agent 上报的压测结果
+type agentResult struct {
+	Instance    string           `json:"instance"`
+	DurationSec float64          `json:"duration_sec"`
+	Metrics     []RequestMetrics `json:"metrics"`
+	Error       string           `json:"error,omitempty"` // 压测未能执行的原因
+}
+
+// agentRequestTimeout agent 调用 controller 的超时（注册为长轮询，等待所有 agent 就绪）
+const agentRequestTimeout = 30 * time.Minute
+
+// splitVoices 把每个音色的并发尽量均分给 n 个 agent（余数分给前面的 agent），返回各 agent 的音色配置
+func splitVoices(voices []VoiceConfig, n int) [][]VoiceConfig {
+	shares := make([][]VoiceConfig, n)
+	for _, v := range voices {
+		for i := 0; i < n; i++ {
+			c := v.Concurrency / n
+			if i < v.Concurrency%n {
+				c++
+			}
+			if c > 0 {
+				shares[i] = append(shares[i], VoiceConfig{DisplayID: v.DisplayID, Concurrency: c})
+			}
+		}
+	}
+	return shares
+}
+
+// controller 协调多个 agent 的压测
+type controller struct {
+	config *BenchmarkConfig
+	agents int
+
+	mu          sync.Mutex
+	assignments []agentAssignment // 按注册顺序分配
+	registered  []string
+	ready       chan struct{} // 全部 agent 注册后关闭
+	results     map[string]agentResult
+	done        chan struct{} // 全部 agent 上报后关闭
+}
+
+// newController 创建 controller，按 agents 拆分 config 中的并发
+func newController(config *BenchmarkConfig, agents int) *controller {
+	c := &controller{
+		config:  config,
+		agents:  agents,
+		ready:   make(chan struct{}),
+		results: make(map[string]agentResult),
+		done:    make(chan struct{}),
+	}
+	for _, voices := range splitVoices(config.Voices, agents) {
+		c.assignments = append(c.assignments, agentAssignment{
+			GatewayURL:  config.GatewayURL,
+			Provider:    config.Provider,
+			Voices:      voices,
+			Requests:    config.Requests,
+			RampUp:      config.RampUp,
+			AudioFormat: config.AudioFormat,
+			SampleRate:  config.SampleRate,
+			Priority:    config.Priority,
+			Affinity:    config.Affinity,
+			SharedLoop:  config.SharedLoop,
+
+			Retries:      config.Retries,
+			RetryBackoff: config.RetryBackoff,
+		})
+	}
+	return c
+}
+
+// runController 在 addr 上等待 agent 注册、下发任务并收集结果，返回合并后的指标
+//
+// ctx 取消（如 Ctrl+C）时停止等待，返回已收到的结果。
+func runController(ctx context.Context, addr string, agents int, config *BenchmarkConfig) (*MetricsCollector, error) {
+	c := newController(config, agents)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/register", c.handleRegister)
+	mux.HandleFunc("/results", c.handleResults)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	logging.Info("Controller waiting for agents", "component", "controller", "addr", listener.Addr().String(), "agents", agents)
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		logging.Warn("Controller interrupted", "component", "controller", "error", ctx.Err())
+	}
+	return c.collect()
+}
+
+// handleRegister 注册 agent；阻塞到所有 agent 注册后返回其任务
+func (c *controller) handleRegister(w http.ResponseWriter, req *http.Request) {
+	var reg agentRegistration
+	if err := json.NewDecoder(req.Body).Decode(&reg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	if len(c.registered) == c.agents {
+		c.mu.Unlock()
+		http.Error(w, "all agents already registered", http.StatusConflict)
+		return
+	}
+	idx := len(c.registered)
+	instance := reg.Instance
+	if instance == "" || c.isRegistered(instance) {
+		instance = fmt.Sprintf("agent%d", idx+1)
+	}
+	c.registered = append(c.registered, instance)
+	a := c.assignments[idx]
+	a.Instance = instance
+	if len(c.registered) == c.agents {
+		close(c.ready)
+	}
+	c.mu.Unlock()
+
+	logging.Info("Agent registered", "component", "controller", "instance", instance,
+		"remote", req.RemoteAddr, "registered", idx+1, "agents", c.agents)
+
+	select {
+	case <-c.ready:
+	case <-req.Context().Done():
+		return // agent 断开，其任务不会重新分配（controller 最终只汇总已上报的结果）
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// isRegistered 实例名是否已被使用（调用方持有 mu）
+func (c *controller) isRegistered(instance string) bool {
+	for _, name := range c.registered {
+		if name == instance {
+			return true
+		}
+	}
+	return false
+}
+
+// handleResults 接收 agent 上报的结果
+func (c *controller) handleResults(w http.ResponseWriter, req *http.Request) {
+	var result agentResult
+	if err := json.NewDecoder(req.Body).Decode(&result); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.isRegistered(result.Instance) {
+		http.Error(w, "unknown instance "+result.Instance, http.StatusBadRequest)
+		return
+	}
+	if _, dup := c.results[result.Instance]; dup {
+		http.Error(w, "duplicate results for "+result.Instance, http.StatusConflict)
+		return
+	}
+	c.results[result.Instance] = result
+	logging.Info("Agent finished", "component", "controller", "instance", result.Instance,
+		"requests", len(result.Metrics), "duration_sec", result.DurationSec, "error", result.Error)
+	if len(c.results) == c.agents {
+		close(c.done)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// collect 合并已上报的结果；测试时长取各 agent 的最大值（agent 同时开始并行压测）
+func (c *controller) collect() (*MetricsCollector, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	collector := NewMetricsCollector()
+	var (
+		duration time.Duration
+		errs     []error
+	)
+	for _, instance := range c.registered {
+		result, ok := c.results[instance]
+		if !ok {
+			errs = append(errs, fmt.Errorf("agent %s: no results", instance))
+			continue
+		}
+		if result.Error != "" {
+			errs = append(errs, fmt.Errorf("agent %s: %s", instance, result.Error))
+		}
+		for _, m := range result.Metrics {
+			m.Instance = instance
+			collector.Record(m)
+		}
+		duration = max(duration, time.Duration(result.DurationSec*float64(time.Second)))
+	}
+	collector.endTime = collector.startTime.Add(duration)
+
+	if len(c.results) == 0 {
+		return nil, errors.Join(append(errs, fmt.Errorf("no agent reported results"))...)
+	}
+	return collector, errors.Join(errs...)
+}
+
+// runAgent 向 controller 注册、执行分配的压测并上报结果
+//
+// local 为 agent 本地配置：API Key、音频保存、输出目录等不由 controller 决定。
+// 压测被 ctx 取消时仍上报已完成的请求。
+func runAgent(ctx context.Context, controllerURL string, local *BenchmarkConfig) error {
+	controllerURL = strings.TrimSuffix(controllerURL, "/")
+	client := &http.Client{Timeout: agentRequestTimeout}
+
+	var a agentAssignment
+	if err := postJSON(ctx, client, controllerURL+"/register", agentRegistration{Instance: local.Instance}, &a); err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+
+	config := *local
+	config.Instance = a.Instance
+	config.GatewayURL = a.GatewayURL
+	config.Provider = a.Provider
+	config.Voices = a.Voices
+	config.Requests = a.Requests
+	config.RampUp = a.RampUp
+	config.AudioFormat = a.AudioFormat
+	config.SampleRate = a.SampleRate
+	config.Priority = a.Priority
+	config.Affinity = a.Affinity
+	config.SharedLoop = a.SharedLoop
+	config.Retries = a.Retries
+	config.RetryBackoff = a.RetryBackoff
+	printConfig(&config)
+
+	result := agentResult{Instance: a.Instance}
+	if len(config.Voices) > 0 { // 并发数少于 agent 数时可能分不到任务
+		benchmark := NewBenchmark(&config)
+		if err := benchmark.Run(ctx); err != nil {
+			result.Error = err.Error()
+		}
+		result.Metrics = benchmark.Collector().GetAll()
+		result.DurationSec = benchmark.Collector().Duration().Seconds()
+	}
+
+	// 上报不随 ctx 取消：中断的压测也应把已完成的请求交给 controller
+	if err := postJSON(context.Background(), client, controllerURL+"/results", result, nil); err != nil {
+		return fmt.Errorf("report results: %w", err)
+	}
+	logging.Info("Results reported", "component", "agent", "instance", a.Instance, "requests", len(result.Metrics))
+	if result.Error != "" {
+		return fmt.Errorf("benchmark: %s", result.Error)
+	}
+	return nil
+}
+
+// postJSON POST JSON 请求，out 非 nil 时解析响应
+func postJSON(ctx context.Context, client *http.Client, url string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var msg bytes.Buffer
+		msg.ReadFrom(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(msg.String()))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// defaultInstance agent 未指定 -instance 时的实例名（主机名）
+func defaultInstance() string {
+	host, _ := os.Hostname()
+	return host
+}
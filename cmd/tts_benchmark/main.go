@@ -7,7 +7,14 @@
 //	  -api-key "YOUR_API_KEY" \
 //	  -voices "en-NG-RoseSerious:40,en-NG-OkunSerious:40" \
 //	  -requests 50 \
-//	  -save-audio
+//	  -save-audio \
+//	  -audio-template "{voice}/{date}/{worker}-{req}.{ext}" \
+//	  -audio-format pcm -sample-rate 16000 \
+//	  -priority bulk
+//
+// 分布式压测时多个实例共享输出目录：各实例以不同的 -instance 运行（报告带实例后缀，写入时加锁），
+// 全部结束后执行 ./tts_benchmark -merge -output <dir> 合并为一份聚合报告。
+// 也可由一个 -controller 实例统一调度多个 -agent 实例并直接输出合并报告（见 distributed.go）。
 package main
 
 import (
@@ -16,11 +23,15 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/audiosave"
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/reports"
 	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
 )
 
 func main() {
@@ -33,7 +44,21 @@ func main() {
 		requests    int
 		rampUp      time.Duration
 		outputDir   string
+		instance    string
+		merge       bool
+		ctrlAddr    string
+		agents      int
+		agentURL    string
 		saveAudio   bool
+		audioTmpl   string
+		audioFormat string
+		sampleRate  int
+		priority    string
+		gzipOver    int
+		affinity    bool
+		sharedLoop  bool
+		retries     int
+		retryDelay  time.Duration
 		verbose     bool
 	)
 
@@ -45,7 +70,29 @@ func main() {
 	flag.IntVar(&requests, "requests", 50, "Number of requests per worker")
 	flag.DurationVar(&rampUp, "rampup", 5*time.Second, "Ramp-up time for workers")
 	flag.StringVar(&outputDir, "output", "./benchmark_results", "Output directory for results")
+	flag.StringVar(&instance, "instance", "",
+		"Instance name appended to report files and audio dir (set a unique name per instance sharing -output)")
+	flag.BoolVar(&merge, "merge", false, "Merge per-instance reports in -output into one aggregate report and exit")
+	flag.StringVar(&ctrlAddr, "controller", "", "Run as distributed controller listening on this address (e.g. :9090)")
+	flag.IntVar(&agents, "agents", 1, "Number of agents the controller waits for (with -controller)")
+	flag.StringVar(&agentURL, "agent", "", "Run as agent of the controller at this URL (e.g. http://controller:9090)")
 	flag.BoolVar(&saveAudio, "save-audio", false, "Save synthesized audio files")
+	flag.StringVar(&audioTmpl, "audio-template", audiosave.DefaultTemplate,
+		"Saved audio path template under <output>/audio (placeholders: {voice} {provider} {date} {time} {worker} {req} {seq} {ext})")
+	flag.StringVar(&audioFormat, "audio-format", "",
+		"Requested audio format (pcm saves WAV with sample-level quality checks; empty for gateway default mp3)")
+	flag.IntVar(&sampleRate, "sample-rate", 0, "Requested sample rate in Hz (0 for provider default; also checked on saved audio)")
+	flag.StringVar(&priority, "priority", string(protocol.PriorityBulk),
+		"Session priority hint sent to the gateway (realtime, bulk; empty to omit)")
+	flag.IntVar(&gzipOver, "gzip-threshold", reports.DefaultGzipThreshold,
+		"Gzip report files larger than this many bytes (saved as .gz; negative disables)")
+	flag.BoolVar(&affinity, "affinity", false,
+		"Reuse the gateway's session affinity token across requests of the same worker and report warm vs cold TTFB")
+	flag.BoolVar(&sharedLoop, "shared-loop", false,
+		"Dispatch all sessions on one shared event loop instead of per-session goroutines (high-density mode)")
+	flag.IntVar(&retries, "retries", 0,
+		"Retry failed connects up to this many times and report first-attempt vs retried latencies (0: no retries)")
+	flag.DurationVar(&retryDelay, "retry-backoff", 0, "Base backoff between connect retries (0: SDK default)")
 	flag.BoolVar(&verbose, "verbose", false, "Verbose logging")
 
 	flag.Usage = func() {
@@ -66,6 +113,18 @@ func main() {
 	flag.Parse()
 	logging.Setup(logging.LevelInfo)
 
+	if merge {
+		if err := mergeReports(outputDir, gzipOver); err != nil {
+			logging.Error("Failed to merge reports", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if instance != "" && (instance != filepath.Base(instance) || instance == mergedInstance) {
+		logging.Error("Invalid instance name", "instance", instance)
+		os.Exit(1)
+	}
+
 	// 解析 voice 配置
 	voices, err := parseVoiceConfig(voiceConfig)
 	if err != nil {
@@ -73,26 +132,38 @@ func main() {
 		os.Exit(1)
 	}
 
+	if !protocol.Priority(priority).Valid() {
+		logging.Error("Invalid priority", "priority", priority)
+		os.Exit(1)
+	}
+
 	// 检查 API Key
 	if apiKey == "" {
 		logging.Warn("No API key provided. Set -api-key or GATEWAY_API_KEY environment variable.")
 	}
 
 	config := &BenchmarkConfig{
-		GatewayURL: gateway,
-		Provider:   provider,
-		APIKey:     apiKey,
-		Voices:     voices,
-		Requests:   requests,
-		RampUp:     rampUp,
-		OutputDir:  outputDir,
-		SaveAudio:  saveAudio,
-		Verbose:    verbose,
+		GatewayURL:    gateway,
+		Provider:      provider,
+		APIKey:        apiKey,
+		Voices:        voices,
+		Requests:      requests,
+		RampUp:        rampUp,
+		OutputDir:     outputDir,
+		Instance:      instance,
+		SaveAudio:     saveAudio,
+		AudioTemplate: audioTmpl,
+		AudioFormat:   audioFormat,
+		SampleRate:    sampleRate,
+		Priority:      priority,
+		GzipThreshold: gzipOver,
+		Affinity:      affinity,
+		SharedLoop:    sharedLoop,
+		Retries:       retries,
+		RetryBackoff:  retryDelay,
+		Verbose:       verbose,
 	}
 
-	// 打印配置
-	printConfig(config)
-
 	// 创建可取消的 context
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -101,6 +172,39 @@ func main() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	switch {
+	case agentURL != "":
+		go func() {
+			<-sigCh
+			fmt.Println("\nReceived interrupt, stopping and reporting partial results...")
+			cancel()
+		}()
+		if config.Instance == "" {
+			config.Instance = defaultInstance()
+		}
+		if err := runAgent(ctx, agentURL, config); err != nil {
+			logging.Error("Agent failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	case ctrlAddr != "":
+		if agents < 1 {
+			logging.Error("Invalid agent count", "agents", agents)
+			os.Exit(1)
+		}
+		go func() {
+			<-sigCh
+			fmt.Println("\nReceived interrupt, reporting results received so far...")
+			cancel()
+		}()
+		printConfig(config)
+		runDistributed(ctx, ctrlAddr, agents, config)
+		return
+	}
+
+	// 打印配置
+	printConfig(config)
+
 	benchmark := NewBenchmark(config)
 
 	go func() {
@@ -122,13 +226,32 @@ func main() {
 	logging.Info("Benchmark completed", "duration", elapsed.Round(time.Second))
 
 	// 生成报告
-	reporter := NewReporter(config.OutputDir)
+	reporter := NewReporter(config.OutputDir, config.Instance)
 	if err := reporter.GenerateReport(benchmark.Collector(), config); err != nil {
 		logging.Error("Failed to generate report", "error", err)
 		os.Exit(1)
 	}
 }
 
+// runDistributed 以 controller 身份协调 agent 压测并生成合并报告
+func runDistributed(ctx context.Context, addr string, agents int, config *BenchmarkConfig) {
+	collector, err := runController(ctx, addr, agents, config)
+	if collector == nil {
+		logging.Error("Distributed benchmark failed", "error", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		logging.Warn("Some agents did not complete", "error", err)
+	}
+	logging.Info("Distributed benchmark completed", "duration", collector.Duration().Round(time.Second))
+
+	reporter := NewReporter(config.OutputDir, config.Instance)
+	if err := reporter.GenerateReport(collector, config); err != nil {
+		logging.Error("Failed to generate report", "error", err)
+		os.Exit(1)
+	}
+}
+
 // parseVoiceConfig 解析音色配置字符串
 // 格式: "voice1:concurrency1,voice2:concurrency2"
 func parseVoiceConfig(s string) ([]VoiceConfig, error) {
@@ -184,7 +307,31 @@ func printConfig(config *BenchmarkConfig) {
 	fmt.Printf("  Requests:    %d per worker\n", config.Requests)
 	fmt.Printf("  Ramp-up:     %v\n", config.RampUp)
 	fmt.Printf("  Output:      %s\n", config.OutputDir)
+	if config.Instance != "" {
+		fmt.Printf("  Instance:    %s\n", config.Instance)
+	}
+	if config.Priority != "" {
+		fmt.Printf("  Priority:    %s\n", config.Priority)
+	}
+	if config.Affinity {
+		fmt.Printf("  Affinity:    reuse session affinity token per worker\n")
+	}
+	if config.SharedLoop {
+		fmt.Printf("  Event Loop:  shared\n")
+	}
+	if config.Retries > 0 {
+		fmt.Printf("  Retries:     %d connect retries\n", config.Retries)
+	}
 	fmt.Printf("  Save Audio:  %v\n", config.SaveAudio)
+	if config.SaveAudio {
+		fmt.Printf("  Audio Path:  %s\n", config.AudioTemplate)
+	}
+	if config.AudioFormat != "" {
+		fmt.Printf("  Format:      %s\n", config.AudioFormat)
+	}
+	if config.SampleRate > 0 {
+		fmt.Printf("  Sample Rate: %d Hz\n", config.SampleRate)
+	}
 	fmt.Println("  Voices:")
 
 	total := 0
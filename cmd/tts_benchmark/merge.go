@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/reports"
+)
+
+// mergedInstance 合并报告的实例名（detail_<时间>_merged.csv），合并时跳过此类文件
+const mergedInstance = "merged"
+
+// mergeReports 将 dir 下各实例的 detail_*.csv / summary_*.json（含 .gz）合并为一份聚合报告
+//
+// 延迟分位数由合并后的逐请求明细重新计算（各实例的 P95 不能直接平均）；
+// 各实例并行压测，测试时长取各实例的最大值，RPS 即为整体吞吐。
+// 合并期间持有报告锁，不会读到其他实例写了一半的报告。
+func mergeReports(dir string, gzipThreshold int) error {
+	r := NewReporter(dir, mergedInstance)
+	r.gzipThreshold = gzipThreshold
+
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	details, err := instanceReports(dir, "detail", ".csv")
+	if err != nil {
+		return err
+	}
+	if len(details) == 0 {
+		return fmt.Errorf("no detail_*.csv reports in %s", dir)
+	}
+	var (
+		metrics   []RequestMetrics
+		instances []string
+	)
+	for _, path := range details {
+		m, err := readDetailCSV(path)
+		if err != nil {
+			return err
+		}
+		metrics = append(metrics, m...)
+		if len(m) > 0 {
+			instances = append(instances, m[0].Instance)
+		}
+	}
+
+	summaries, err := instanceReports(dir, "summary", ".json")
+	if err != nil {
+		return err
+	}
+	var (
+		first    *SummaryReport
+		duration time.Duration
+		voices   []string
+		quality  *QualitySummary
+	)
+	for _, path := range summaries {
+		data, err := reports.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var s SummaryReport
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if first == nil {
+			first = &s
+		}
+		duration = max(duration, time.Duration(s.DurationSec*float64(time.Second)))
+		voices = append(voices, s.Config.Voices...)
+		quality = mergeQuality(quality, s.Quality)
+	}
+
+	collector := NewMetricsCollector()
+	collector.metrics = metrics
+	collector.endTime = collector.startTime.Add(duration)
+	aggregated := collector.Aggregate()
+
+	config := &BenchmarkConfig{Instance: mergedInstance}
+	if first != nil {
+		config.GatewayURL = first.Config.Gateway
+		config.Provider = first.Config.Provider
+		config.Requests = first.Config.RequestsPerWorker
+		config.SaveAudio = first.Config.SaveAudio
+	}
+
+	fmt.Printf("Merging %d detail reports (%d requests) and %d summaries from %s\n",
+		len(details), len(metrics), len(summaries), dir)
+	affinity := summarizeAffinity(metrics)
+	retries := summarizeRetries(metrics)
+	r.printSummary(aggregated, config, duration)
+	if quality != nil {
+		r.printQuality(quality)
+	}
+	if affinity != nil {
+		r.printAffinity(affinity)
+	}
+	if retries != nil {
+		r.printRetries(retries)
+	}
+
+	if err := r.writeDetailCSV(metrics); err != nil {
+		return fmt.Errorf("write detail csv: %w", err)
+	}
+	report := buildSummaryReport(aggregated, quality, config, duration)
+	report.Config.Voices = voices
+	report.Config.Instances = instances
+	report.Affinity = affinity
+	report.Retries = retries
+	if err := r.writeSummaryJSON(report); err != nil {
+		return fmt.Errorf("write aggregated json: %w", err)
+	}
+	return nil
+}
+
+// instanceReports 列出 dir 下 prefix_*ext[.gz] 报告（不含合并报告），按文件名排序
+func instanceReports(dir, prefix, ext string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, prefix+"_*"+ext+"*"))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, path := range matches {
+		name := strings.TrimSuffix(filepath.Base(path), reports.GzipExt)
+		if !strings.HasSuffix(name, ext) || strings.HasSuffix(name, "_"+mergedInstance+ext) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// readDetailCSV 读取 writeDetailCSV 生成的明细（按表头取列，兼容缺少新增列的旧报告）
+//
+// 缺少 instance 列时以文件名（detail_<时间>）作为实例名，合并后仍能区分来源。
+func readDetailCSV(path string) ([]RequestMetrics, error) {
+	f, err := reports.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	fallback := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), reports.GzipExt), ".csv")
+	fallback = strings.TrimPrefix(fallback, "detail_")
+
+	var metrics []RequestMetrics
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return metrics, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		m, err := parseDetailRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		if m.Instance == "" {
+			m.Instance = fallback
+		}
+		metrics = append(metrics, m)
+	}
+}
+
+// parseDetailRecord 解析一行明细
+func parseDetailRecord(record []string, columns map[string]int) (RequestMetrics, error) {
+	var firstErr error
+	str := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	num := func(name string) int64 {
+		v := str(name)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("column %s: %w", name, err)
+		}
+		return n
+	}
+
+	m := RequestMetrics{
+		VoiceID:     str("voice_id"),
+		WorkerID:    int(num("worker_id")),
+		RequestID:   int(num("request_id")),
+		Instance:    str("instance"),
+		TextLen:     int(num("text_length")),
+		ConnectMs:   num("connect_ms"),
+		SynthesisMs: num("synthesis_ms"),
+		TTFBMs:      num("ttfb_ms"),
+		TotalMs:     num("total_ms"),
+		ChunkCount:  int(num("chunks")),
+		TotalBytes:  num("bytes"),
+		AudioMs:     num("audio_ms"),
+		Success:     str("success") == "true",
+		Error:       str("error"),
+		AudioFile:   str("audio_file"),
+		Affinity:    str("affinity"),
+
+		ConnectAttempts: int(num("attempts")),
+		RetryMs:         num("retry_ms"),
+	}
+	if attempts, err := parseAttemptMs(str("attempt_ms")); err != nil {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("column attempt_ms: %w", err)
+		}
+	} else {
+		m.AttemptMs = attempts
+	}
+	if v := str("rtf"); v != "" {
+		rtf, err := strconv.ParseFloat(v, 64)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("column rtf: %w", err)
+		}
+		m.RTF = rtf
+	}
+	// 明细只保留问题代码，足以重新写出 quality 列；质量汇总取自各实例的 summary
+	if q := str("quality"); q != "" {
+		m.Quality = &AudioQuality{File: m.AudioFile}
+		if q != "ok" {
+			m.Quality.Issues = strings.Split(q, ",")
+		}
+	}
+	return m, firstErr
+}
+
+// mergeQuality 合并两份质量汇总（任一为 nil 时返回另一份）
+func mergeQuality(a, b *QualitySummary) *QualitySummary {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	merged := &QualitySummary{
+		Checked:     a.Checked + b.Checked,
+		SampleLevel: a.SampleLevel + b.SampleLevel,
+		Passed:      a.Passed + b.Passed,
+		Issues:      make(map[string]int),
+		Failed:      append(append([]*AudioQuality(nil), a.Failed...), b.Failed...),
+	}
+	for _, issues := range []map[string]int{a.Issues, b.Issues} {
+		for issue, n := range issues {
+			merged.Issues[issue] += n
+		}
+	}
+	return merged
+}
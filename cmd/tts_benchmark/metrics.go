@@ -12,6 +12,7 @@ type RequestMetrics struct {
 	VoiceID   string // 音色ID
 	WorkerID  int    // Worker编号
 	RequestID int    // 请求编号
+	Instance  string // 压测实例名（BenchmarkConfig.Instance）
 	Text      string // 测试文本
 	TextLen   int    // 文本长度
 
@@ -30,15 +31,27 @@ type RequestMetrics struct {
 	TotalMs     int64 // 总耗时
 
 	// 数据统计
-	ChunkCount int   // 音频块数量
-	TotalBytes int64 // 音频总字节
+	ChunkCount int     // 音频块数量
+	TotalBytes int64   // 音频总字节
+	AudioMs    int64   // 音频时长（stream.AudioDuration，非 PCM 格式为 0）
+	RTF        float64 // 实时率（stream.RealTimeFactor，非 PCM 格式为 0）
 
 	// 状态
 	Success bool   // 是否成功
 	Error   string // 错误信息（如有）
 
+	// 建连重试（-retries）：ConnectMs 只计最后一次尝试
+	ConnectAttempts int     // 建连尝试次数（1 为首次即成功；建连失败的请求为 0）
+	AttemptMs       []int64 // 各次尝试耗时
+	RetryMs         int64   // 首次尝试到最后一次尝试开始的耗时（失败尝试 + 退避等待）
+
+	// 会话亲和（仅 -affinity）
+	AffinityToken string // gateway 返回的亲和令牌
+	Affinity      string // cold / warm / miss（见 classifyAffinity）
+
 	// 音频文件路径（如果保存了）
 	AudioFile string
+	Quality   *AudioQuality // 保存音频的质量检查结果（未保存时为 nil）
 }
 
 // AggregatedMetrics 聚合指标
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+)
+
+const (
+	qaSilenceDBFS  = -50.0  // 峰值低于此电平视为全静音
+	qaMaxClipRatio = 0.001  // 满幅采样占比超过 0.1% 视为削波
+	qaFloorDBFS    = -120.0 // 报告中电平的下限（全零音频）
+)
+
+// 质量问题（AudioQuality.Issues，具体数值见对应字段）
+const (
+	issueUnreadable = "unreadable"  // 文件无法读取或格式无效（见 Error）
+	issueEmpty      = "empty"       // 时长为 0
+	issueSilent     = "silent"      // 峰值低于 qaSilenceDBFS
+	issueClipping   = "clipping"    // 满幅采样占比超过 qaMaxClipRatio
+	issueSampleRate = "sample_rate" // 采样率与请求不符
+)
+
+// AudioQuality 保存音频的质量检查结果
+//
+// WAV/PCM 做样本级检查（时长、静音、削波、采样率）；MP3 不解码，只解析帧头检查时长和采样率。
+type AudioQuality struct {
+	File         string   `json:"file"`
+	Format       string   `json:"format"`
+	SampleRate   int      `json:"sample_rate"`
+	ExpectedRate int      `json:"expected_sample_rate,omitempty"` // 请求的采样率（0 不检查）
+	DurationMs   int64    `json:"duration_ms"`
+	PeakDBFS     float64  `json:"peak_dbfs,omitempty"`
+	RMSDBFS      float64  `json:"rms_dbfs,omitempty"`
+	ClippedRatio float64  `json:"clipped_ratio,omitempty"`
+	SampleLevel  bool     `json:"sample_level"` // 是否做了样本级检查（MP3 为 false）
+	Issues       []string `json:"issues,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// OK 是否通过全部检查
+func (q *AudioQuality) OK() bool {
+	return len(q.Issues) == 0
+}
+
+// fail 记录无法检查的原因
+func (q *AudioQuality) fail(err error) *AudioQuality {
+	q.Issues = append(q.Issues, issueUnreadable)
+	q.Error = err.Error()
+	return q
+}
+
+// checkAudioFile 读取保存的音频文件并检查质量；expectRate 为 0 时不检查采样率
+func checkAudioFile(path string, expectRate int) *AudioQuality {
+	q := &AudioQuality{
+		File:         path,
+		Format:       strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+		ExpectedRate: expectRate,
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return q.fail(err)
+	}
+
+	switch q.Format {
+	case "wav":
+		pcm, header, err := audio.WAVToPCM(data)
+		if err != nil {
+			return q.fail(fmt.Errorf("invalid wav: %w", err))
+		}
+		if header.NumChannels != 1 || header.BitsPerSample != 16 {
+			return q.fail(fmt.Errorf("unexpected format %d ch / %d bit", header.NumChannels, header.BitsPerSample))
+		}
+		q.analysePCM(pcm, int(header.SampleRate))
+	case "pcm":
+		q.analysePCM(data, expectRate)
+	case "mp3":
+		q.analyseMP3(data)
+	default:
+		return q.fail(fmt.Errorf("unknown format %q", q.Format))
+	}
+
+	if q.DurationMs == 0 {
+		q.Issues = append(q.Issues, issueEmpty)
+	}
+	if expectRate > 0 && q.SampleRate != 0 && q.SampleRate != expectRate {
+		q.Issues = append(q.Issues, issueSampleRate)
+	}
+	return q
+}
+
+// summary 返回问题的可读描述
+func (q *AudioQuality) summary() string {
+	parts := make([]string, 0, len(q.Issues))
+	for _, issue := range q.Issues {
+		switch issue {
+		case issueUnreadable:
+			parts = append(parts, q.Error)
+		case issueSilent:
+			parts = append(parts, fmt.Sprintf("silent (peak %.1f dBFS)", q.PeakDBFS))
+		case issueClipping:
+			parts = append(parts, fmt.Sprintf("clipping (%.2f%% samples at full scale)", q.ClippedRatio*100))
+		case issueSampleRate:
+			parts = append(parts, fmt.Sprintf("sample rate %d Hz, want %d", q.SampleRate, q.ExpectedRate))
+		default:
+			parts = append(parts, issue)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// analysePCM 样本级检查 16-bit 小端单声道 PCM
+func (q *AudioQuality) analysePCM(pcm []byte, sampleRate int) {
+	q.SampleLevel = true
+	q.SampleRate = sampleRate
+	samples := len(pcm) / 2
+	if sampleRate > 0 {
+		q.DurationMs = int64(samples) * 1000 / int64(sampleRate)
+	}
+	if samples == 0 {
+		return
+	}
+
+	var peak, clipped int
+	for i := 0; i < samples; i++ {
+		v := int(int16(binary.LittleEndian.Uint16(pcm[2*i:])))
+		if v == math.MaxInt16 || v == math.MinInt16 {
+			clipped++
+		}
+		if v < 0 {
+			v = -v
+		}
+		peak = max(peak, v)
+	}
+	q.PeakDBFS = math.Round(math.Max(20*math.Log10(float64(peak)/32768), qaFloorDBFS)*10) / 10
+	q.RMSDBFS = math.Round(math.Max(audio.RMSDBFS(pcm), qaFloorDBFS)*10) / 10
+	q.ClippedRatio = float64(clipped) / float64(samples)
+
+	if q.PeakDBFS < qaSilenceDBFS {
+		q.Issues = append(q.Issues, issueSilent)
+	}
+	if q.ClippedRatio > qaMaxClipRatio {
+		q.Issues = append(q.Issues, issueClipping)
+	}
+}
+
+// analyseMP3 解析 MPEG Layer III 帧头，统计时长与采样率（不解码，无法检查静音/削波）
+func (q *AudioQuality) analyseMP3(data []byte) {
+	pos := 0
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		// ID3v2 标签：10 字节头 + syncsafe 长度
+		pos = 10 + (int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9]))
+	}
+
+	var samples int64
+	for pos+4 <= len(data) {
+		rate, frameLen, frameSamples := mp3Frame(data[pos:])
+		if frameLen == 0 {
+			pos++ // 重新同步
+			continue
+		}
+		if q.SampleRate == 0 {
+			q.SampleRate = rate
+		}
+		samples += int64(frameSamples)
+		pos += frameLen
+	}
+	if q.SampleRate > 0 {
+		q.DurationMs = samples * 1000 / int64(q.SampleRate)
+	}
+}
+
+// mp3 Layer III 码率表（kbps，索引 1-14）与采样率表
+var (
+	mp3Bitrates = [2][15]int{
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}, // MPEG-1
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},     // MPEG-2 / 2.5
+	}
+	mp3Rates = [3]int{44100, 48000, 32000}
+)
+
+// mp3Frame 解析 Layer III 帧头，返回采样率、帧长和帧内采样数；不是有效帧头时帧长为 0
+func mp3Frame(h []byte) (rate, frameLen, samples int) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return 0, 0, 0
+	}
+	version := (h[1] >> 3) & 3 // 3: MPEG-1, 2: MPEG-2, 0: MPEG-2.5
+	layer := (h[1] >> 1) & 3   // 1: Layer III
+	bitrateIdx := int(h[2] >> 4)
+	rateIdx := int(h[2]>>2) & 3
+	padding := int(h[2]>>1) & 1
+	if version == 1 || layer != 1 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return 0, 0, 0
+	}
+
+	rate = mp3Rates[rateIdx]
+	table, coeff := 0, 144
+	samples = 1152
+	switch version {
+	case 2:
+		rate /= 2
+		table, coeff, samples = 1, 72, 576
+	case 0:
+		rate /= 4
+		table, coeff, samples = 1, 72, 576
+	}
+	frameLen = coeff*mp3Bitrates[table][bitrateIdx]*1000/rate + padding
+	return rate, frameLen, samples
+}
+
+// QualitySummary 保存音频质量检查汇总（summary JSON 的 quality 字段）
+type QualitySummary struct {
+	Checked     int             `json:"checked"`      // 检查的文件数
+	SampleLevel int             `json:"sample_level"` // 其中做了样本级检查的文件数
+	Passed      int             `json:"passed"`
+	Issues      map[string]int  `json:"issues,omitempty"` // 问题类型 → 文件数
+	Failed      []*AudioQuality `json:"failed,omitempty"`
+}
+
+// summarizeQuality 汇总各请求的质量检查结果；没有检查任何文件时返回 nil
+func summarizeQuality(metrics []RequestMetrics) *QualitySummary {
+	var s *QualitySummary
+	for _, m := range metrics {
+		if m.Quality == nil {
+			continue
+		}
+		if s == nil {
+			s = &QualitySummary{Issues: make(map[string]int)}
+		}
+		s.Checked++
+		if m.Quality.SampleLevel {
+			s.SampleLevel++
+		}
+		if m.Quality.OK() {
+			s.Passed++
+			continue
+		}
+		for _, issue := range m.Quality.Issues {
+			s.Issues[issue]++
+		}
+		s.Failed = append(s.Failed, m.Quality)
+	}
+	return s
+}
@@ -2,6 +2,8 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -10,20 +12,47 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/reports"
 )
 
 // Reporter 报告生成器
 type Reporter struct {
-	outputDir string
-	timestamp string
+	outputDir     string
+	timestamp     string
+	instance      string // 实例名，非空时作为报告文件名后缀（多实例共享输出目录）
+	gzipThreshold int    // 报告超过此字节数时 gzip 压缩（见 reports.WriteFile）
 }
 
-// NewReporter 创建报告生成器
-func NewReporter(outputDir string) *Reporter {
+// reportLockName 输出目录中的报告锁文件（写入报告与合并报告互斥，见 reports.Lock）
+const reportLockName = ".report.lock"
+
+// reportLockTimeout 等待报告锁的最长时间
+const reportLockTimeout = 30 * time.Second
+
+// NewReporter 创建报告生成器；instance 非空时报告命名为 detail_<时间>_<instance>.csv 等
+func NewReporter(outputDir, instance string) *Reporter {
 	return &Reporter{
 		outputDir: outputDir,
 		timestamp: time.Now().Format("20060102_150405"),
+		instance:  instance,
+	}
+}
+
+// reportPath 返回报告文件路径（prefix_<时间>[_<实例>].ext）
+func (r *Reporter) reportPath(prefix, ext string) string {
+	name := prefix + "_" + r.timestamp
+	if r.instance != "" {
+		name += "_" + r.instance
 	}
+	return filepath.Join(r.outputDir, name+ext)
+}
+
+// lock 获取输出目录的报告锁
+func (r *Reporter) lock() (func() error, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reportLockTimeout)
+	defer cancel()
+	return reports.Lock(ctx, filepath.Join(r.outputDir, reportLockName))
 }
 
 // GenerateReport 生成完整报告
@@ -33,11 +62,32 @@ func (r *Reporter) GenerateReport(collector *MetricsCollector, config *Benchmark
 		return fmt.Errorf("create output dir: %w", err)
 	}
 
+	r.gzipThreshold = config.GzipThreshold
 	metrics := collector.GetAll()
 	aggregated := collector.Aggregate()
 
+	quality := summarizeQuality(metrics)
+	affinity := summarizeAffinity(metrics)
+	retries := summarizeRetries(metrics)
+
 	// 1. 生成摘要报告（控制台输出）
 	r.printSummary(aggregated, config, collector.Duration())
+	if quality != nil {
+		r.printQuality(quality)
+	}
+	if affinity != nil {
+		r.printAffinity(affinity)
+	}
+	if retries != nil {
+		r.printRetries(retries)
+	}
+
+	// 持锁写入，合并方（-merge）不会读到写了一半的报告
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// 2. 生成详细 CSV
 	if err := r.writeDetailCSV(metrics); err != nil {
@@ -45,7 +95,10 @@ func (r *Reporter) GenerateReport(collector *MetricsCollector, config *Benchmark
 	}
 
 	// 3. 生成聚合 JSON
-	if err := r.writeAggregatedJSON(aggregated, config, collector.Duration()); err != nil {
+	report := buildSummaryReport(aggregated, quality, config, collector.Duration())
+	report.Affinity = affinity
+	report.Retries = retries
+	if err := r.writeSummaryJSON(report); err != nil {
 		return fmt.Errorf("write aggregated json: %w", err)
 	}
 
@@ -85,6 +138,31 @@ func (r *Reporter) printSummary(aggregated map[string]*AggregatedMetrics, config
 	fmt.Println(strings.Repeat("=", 80))
 }
 
+// printQuality 打印保存音频的质量检查结果
+func (r *Reporter) printQuality(q *QualitySummary) {
+	fmt.Printf("\nAudio Quality:\n")
+	fmt.Printf("  Checked: %d files (%d sample-level, %d mp3 header-only)\n", q.Checked, q.SampleLevel, q.Checked-q.SampleLevel)
+	fmt.Printf("  Passed:  %d\n", q.Passed)
+	if len(q.Failed) == 0 {
+		return
+	}
+	fmt.Printf("  Failed:  %d\n", len(q.Failed))
+	for _, issue := range []string{issueUnreadable, issueEmpty, issueSilent, issueClipping, issueSampleRate} {
+		if n := q.Issues[issue]; n > 0 {
+			fmt.Printf("    %-12s %d\n", issue+":", n)
+		}
+	}
+	const maxListed = 10
+	for i, f := range q.Failed {
+		if i == maxListed {
+			fmt.Printf("    ... and %d more (see summary JSON)\n", len(q.Failed)-maxListed)
+			break
+		}
+		fmt.Printf("    %s: %s\n", f.File, f.summary())
+	}
+	fmt.Println(strings.Repeat("=", 80))
+}
+
 // printVoiceMetrics 打印单个音色的指标
 func (r *Reporter) printVoiceMetrics(m *AggregatedMetrics) {
 	fmt.Printf("  Requests:     %d (Success: %d, Failed: %d)\n",
@@ -139,23 +217,17 @@ func (r *Reporter) printVoiceMetrics(m *AggregatedMetrics) {
 
 // writeDetailCSV 写入详细 CSV 文件
 func (r *Reporter) writeDetailCSV(metrics []RequestMetrics) error {
-	filename := fmt.Sprintf("detail_%s.csv", r.timestamp)
-	filepath := filepath.Join(r.outputDir, filename)
-
-	file, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	filepath := r.reportPath("detail", ".csv")
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
 
 	// 写入表头
 	headers := []string{
 		"voice_id", "worker_id", "request_id", "text_length",
 		"connect_ms", "synthesis_ms", "ttfb_ms", "total_ms",
-		"chunks", "bytes", "success", "error", "audio_file",
+		"chunks", "bytes", "audio_ms", "rtf", "success", "error", "audio_file", "quality", "instance", "affinity",
+		"attempts", "attempt_ms", "retry_ms",
 	}
 	if err := writer.Write(headers); err != nil {
 		return err
@@ -163,6 +235,13 @@ func (r *Reporter) writeDetailCSV(metrics []RequestMetrics) error {
 
 	// 写入数据
 	for _, m := range metrics {
+		quality := ""
+		if m.Quality != nil {
+			quality = "ok"
+			if !m.Quality.OK() {
+				quality = strings.Join(m.Quality.Issues, ",")
+			}
+		}
 		record := []string{
 			m.VoiceID,
 			strconv.Itoa(m.WorkerID),
@@ -174,16 +253,32 @@ func (r *Reporter) writeDetailCSV(metrics []RequestMetrics) error {
 			strconv.FormatInt(m.TotalMs, 10),
 			strconv.Itoa(m.ChunkCount),
 			strconv.FormatInt(m.TotalBytes, 10),
+			strconv.FormatInt(m.AudioMs, 10),
+			strconv.FormatFloat(m.RTF, 'f', 3, 64),
 			strconv.FormatBool(m.Success),
 			m.Error,
 			m.AudioFile,
+			quality,
+			m.Instance,
+			m.Affinity,
+			strconv.Itoa(m.ConnectAttempts),
+			formatAttemptMs(m.AttemptMs),
+			strconv.FormatInt(m.RetryMs, 10),
 		}
 		if err := writer.Write(record); err != nil {
 			return err
 		}
 	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
 
-	fmt.Printf("Detail CSV: %s\n", filepath)
+	path, err := reports.WriteFile(filepath, buf.Bytes(), r.gzipThreshold)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Detail CSV: %s\n", path)
 	return nil
 }
 
@@ -193,6 +288,9 @@ type SummaryReport struct {
 	DurationSec float64                       `json:"duration_sec"`
 	Voices      map[string]*VoiceMetricsSummary `json:"voices"`
 	Overall     *VoiceMetricsSummary          `json:"overall"`
+	Quality     *QualitySummary               `json:"quality,omitempty"` // 仅 -save-audio 时
+	Affinity    *AffinitySummary              `json:"affinity,omitempty"` // 仅 -affinity 时
+	Retries     *RetrySummary                 `json:"retries,omitempty"`  // 有请求经过建连重试时
 }
 
 // ConfigSummary 配置摘要
@@ -202,6 +300,7 @@ type ConfigSummary struct {
 	Voices     []string `json:"voices"`
 	RequestsPerWorker int `json:"requests_per_worker"`
 	SaveAudio  bool     `json:"save_audio"`
+	Instances  []string `json:"instances,omitempty"` // 生成报告的实例（合并报告为全部实例）
 }
 
 // VoiceMetricsSummary 音色指标摘要
@@ -232,11 +331,8 @@ type LatencyStats struct {
 	P99 int64 `json:"p99"`
 }
 
-// writeAggregatedJSON 写入聚合 JSON 文件
-func (r *Reporter) writeAggregatedJSON(aggregated map[string]*AggregatedMetrics, config *BenchmarkConfig, duration time.Duration) error {
-	filename := fmt.Sprintf("summary_%s.json", r.timestamp)
-	filepath := filepath.Join(r.outputDir, filename)
-
+// buildSummaryReport 构建 JSON 报告结构
+func buildSummaryReport(aggregated map[string]*AggregatedMetrics, quality *QualitySummary, config *BenchmarkConfig, duration time.Duration) *SummaryReport {
 	// 构建配置摘要
 	var voiceStrs []string
 	for _, v := range config.Voices {
@@ -253,6 +349,10 @@ func (r *Reporter) writeAggregatedJSON(aggregated map[string]*AggregatedMetrics,
 		},
 		DurationSec: duration.Seconds(),
 		Voices:      make(map[string]*VoiceMetricsSummary),
+		Quality:     quality,
+	}
+	if config.Instance != "" {
+		report.Config.Instances = []string{config.Instance}
 	}
 
 	// 转换指标
@@ -309,16 +409,22 @@ func (r *Reporter) writeAggregatedJSON(aggregated map[string]*AggregatedMetrics,
 		}
 	}
 
-	// 写入文件
+	return report
+}
+
+// writeSummaryJSON 写入 summary JSON 文件
+func (r *Reporter) writeSummaryJSON(report *SummaryReport) error {
+	filepath := r.reportPath("summary", ".json")
 	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
+	path, err := reports.WriteFile(filepath, data, r.gzipThreshold)
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Summary JSON: %s\n", filepath)
+	fmt.Printf("Summary JSON: %s\n", path)
 	return nil
 }
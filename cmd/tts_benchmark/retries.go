@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// 建连重试分类（RetrySummary.Classes 的键）
+const (
+	retryFirst   = "first_attempt" // 首次建连即成功
+	retryRetried = "retried"       // 经退避重试后成功
+)
+
+// recordAttempts 记录建连尝试次数与各次耗时
+func recordAttempts(m *RequestMetrics, attempts []transport.ConnectAttempt) {
+	m.ConnectAttempts = len(attempts)
+	m.AttemptMs = make([]int64, 0, len(attempts))
+	for _, a := range attempts {
+		m.AttemptMs = append(m.AttemptMs, a.Duration.Milliseconds())
+	}
+	if len(attempts) > 1 {
+		m.RetryMs = attempts[len(attempts)-1].StartedAt.Sub(attempts[0].StartedAt).Milliseconds()
+	}
+}
+
+// formatAttemptMs 明细 CSV 的 attempt_ms 列（各次尝试耗时，分号分隔）
+func formatAttemptMs(values []int64) string {
+	fields := make([]string, len(values))
+	for i, v := range values {
+		fields[i] = strconv.FormatInt(v, 10)
+	}
+	return strings.Join(fields, ";")
+}
+
+// parseAttemptMs 解析 attempt_ms 列
+func parseAttemptMs(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var values []int64
+	for _, field := range strings.Split(s, ";") {
+		v, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// RetryStats 一类请求的耗时统计（仅成功请求）
+type RetryStats struct {
+	Requests int           `json:"requests"`
+	RetryMs  *LatencyStats `json:"retry_ms,omitempty"` // 首次尝试到最后一次尝试开始（失败尝试 + 退避等待）
+	TTFBMs   *LatencyStats `json:"ttfb_ms,omitempty"`
+	TotalMs  *LatencyStats `json:"total_ms,omitempty"`
+}
+
+// RetrySummary 建连重试结果（summary JSON 的 retries 字段；没有请求经过重试时为 nil）
+//
+// connect_ms 只计最后一次尝试：重试后的请求若 TTFB 明显低于首次成功的请求，说明是重试落到了更空闲的节点，
+// 而不是服务本身更快。
+type RetrySummary struct {
+	Classes  map[string]*RetryStats `json:"classes"`
+	Attempts int                    `json:"attempts"` // 全部建连尝试次数
+}
+
+// summarizeRetries 按是否经过重试汇总成功请求的耗时
+func summarizeRetries(metrics []RequestMetrics) *RetrySummary {
+	type values struct{ retry, ttfb, total []int64 }
+	classes := map[string]*values{retryFirst: {}, retryRetried: {}}
+	s := &RetrySummary{Classes: make(map[string]*RetryStats)}
+	retried := false
+	for _, m := range metrics {
+		s.Attempts += m.ConnectAttempts
+		if !m.Success || m.ConnectAttempts == 0 {
+			continue
+		}
+		class := retryFirst
+		if m.ConnectAttempts > 1 {
+			class, retried = retryRetried, true
+		}
+		v := classes[class]
+		v.retry = append(v.retry, m.RetryMs)
+		v.ttfb = append(v.ttfb, m.TTFBMs)
+		v.total = append(v.total, m.TotalMs)
+	}
+	if !retried {
+		return nil
+	}
+	for class, v := range classes {
+		stats := &RetryStats{Requests: len(v.ttfb), TTFBMs: latencyStats(v.ttfb), TotalMs: latencyStats(v.total)}
+		if class == retryRetried {
+			stats.RetryMs = latencyStats(v.retry)
+		}
+		s.Classes[class] = stats
+	}
+	return s
+}
+
+// printRetries 打印首次成功与经重试请求的耗时对比
+func (r *Reporter) printRetries(s *RetrySummary) {
+	fmt.Printf("\nConnect Retries (successful requests, %d connect attempts):\n", s.Attempts)
+	for _, class := range []string{retryFirst, retryRetried} {
+		c := s.Classes[class]
+		if c.TTFBMs == nil {
+			fmt.Printf("  %-13s %5d requests\n", class, c.Requests)
+			continue
+		}
+		fmt.Printf("  %-13s %5d requests  TTFB P50 %5d ms  P95 %5d ms  Total P50 %5d ms\n",
+			class, c.Requests, c.TTFBMs.P50, c.TTFBMs.P95, c.TotalMs.P50)
+	}
+	if c := s.Classes[retryRetried]; c.RetryMs != nil {
+		fmt.Printf("  Time lost to retries: P50 %v  P95 %v\n",
+			time.Duration(c.RetryMs.P50)*time.Millisecond, time.Duration(c.RetryMs.P95)*time.Millisecond)
+	}
+	fmt.Println(strings.Repeat("=", 80))
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// phase 导出的时延阶段
+type phase struct {
+	name string
+	ms   func(r *RequestResult) int64
+}
+
+// phases 每轮的阶段拆分：connect + config + synthesis 为 TTFB，streaming 为首包到合成完成
+var phases = []phase{
+	{"connect", func(r *RequestResult) int64 { return r.ConnectMs }},
+	{"config", configMs},
+	{"synthesis", func(r *RequestResult) int64 { return r.SynthesisMs }},
+	{"streaming", streamingMs},
+	{"ttfb", func(r *RequestResult) int64 { return r.TTFBMs }},
+	{"total", func(r *RequestResult) int64 { return r.TotalMs }},
+}
+
+// configMs 建连后到 commit 发送的耗时（session.config + text.append；warm 轮次为 0）
+func configMs(r *RequestResult) int64 {
+	if r.ConnectedAt.IsZero() || r.CommitSentAt.IsZero() {
+		return 0
+	}
+	return max(r.CommitSentAt.Sub(r.ConnectedAt).Milliseconds(), 0)
+}
+
+// streamingMs 首包到合成完成的耗时
+func streamingMs(r *RequestResult) int64 {
+	if r.FirstByteAt.IsZero() || r.CompleteAt.IsZero() {
+		return 0
+	}
+	return r.CompleteAt.Sub(r.FirstByteAt).Milliseconds()
+}
+
+// phaseSummary 一个阶段在所有轮次上的统计
+type phaseSummary struct {
+	name        string
+	avg         int64
+	min, max    int64
+	percentiles []int64 // 与 -percentiles 一一对应
+}
+
+// summarize 按阶段汇总各轮耗时（分位数为最近秩法）
+func summarize(results []*RequestResult, percentiles []float64) []phaseSummary {
+	summaries := make([]phaseSummary, 0, len(phases))
+	for _, p := range phases {
+		values := make([]int64, 0, len(results))
+		var sum int64
+		for _, r := range results {
+			v := p.ms(r)
+			values = append(values, v)
+			sum += v
+		}
+		s := phaseSummary{name: p.name}
+		if len(values) > 0 {
+			sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+			s.avg = sum / int64(len(values))
+			s.min, s.max = values[0], values[len(values)-1]
+			for _, pct := range percentiles {
+				rank := int(math.Ceil(pct / 100 * float64(len(values))))
+				s.percentiles = append(s.percentiles, values[max(rank, 1)-1])
+			}
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+// parsePercentiles 解析 -percentiles（逗号分隔，取值 (0, 100]）
+func parsePercentiles(list string) ([]float64, error) {
+	var percentiles []float64
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(field, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %q must be in (0, 100]", field)
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
+
+// percentileLabel 分位数列名，如 p95、p99.9
+func percentileLabel(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// writeCSV 写出每轮的阶段耗时，之后每个统计量（avg / min / max / pNN）一行，iteration 列为统计量名称
+func writeCSV(path string, results []*RequestResult, percentiles []float64) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := []string{"iteration", "label", "session_id"}
+	for _, p := range phases {
+		header = append(header, p.name+"_ms")
+	}
+	header = append(header, "bytes", "chunks", "audio_ms", "rtf")
+	w.Write(header)
+
+	for _, r := range results {
+		row := []string{strconv.Itoa(r.Iteration), r.Label, r.SessionID}
+		for _, p := range phases {
+			row = append(row, strconv.FormatInt(p.ms(r), 10))
+		}
+		row = append(row, strconv.FormatInt(r.TotalBytes, 10), strconv.Itoa(r.ChunkCount),
+			strconv.FormatInt(r.AudioMs, 10), strconv.FormatFloat(r.RTF, 'f', 3, 64))
+		w.Write(row)
+	}
+
+	summaries := summarize(results, percentiles)
+	stat := func(name string, value func(s phaseSummary) int64) {
+		row := []string{name, "", ""}
+		for _, s := range summaries {
+			row = append(row, strconv.FormatInt(value(s), 10))
+		}
+		w.Write(append(row, "", "", "", ""))
+	}
+	stat("avg", func(s phaseSummary) int64 { return s.avg })
+	stat("min", func(s phaseSummary) int64 { return s.min })
+	stat("max", func(s phaseSummary) int64 { return s.max })
+	for i, p := range percentiles {
+		stat(percentileLabel(p), func(s phaseSummary) int64 { return s.percentiles[i] })
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// runInfo 报告头部的运行参数
+type runInfo struct {
+	gateway, provider, voice, text string
+	iterations                     int
+	startedAt                      time.Time
+}
+
+// writeMarkdown 写出 Markdown 报告：运行参数、每轮阶段耗时、各阶段统计，可直接贴到性能工单
+func writeMarkdown(path string, info runInfo, results []*RequestResult, percentiles []float64) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# TTS Detailed Timing\n\n")
+	fmt.Fprintf(&buf, "| Gateway | Provider | Voice | Iterations | Successful | Started |\n")
+	fmt.Fprintf(&buf, "|---|---|---|---|---|---|\n")
+	fmt.Fprintf(&buf, "| %s | %s | %s | %d | %d | %s |\n\n", info.gateway, info.provider, info.voice,
+		info.iterations, len(results), info.startedAt.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Text: %s\n\n", markdownEscape(info.text))
+
+	fmt.Fprintf(&buf, "## Iterations (ms)\n\n| # | Label |")
+	for _, p := range phases {
+		fmt.Fprintf(&buf, " %s |", p.name)
+	}
+	fmt.Fprintf(&buf, " RTF |\n|---|---|%s---|\n", strings.Repeat("---:|", len(phases)))
+	for _, r := range results {
+		fmt.Fprintf(&buf, "| %d | %s |", r.Iteration, r.Label)
+		for _, p := range phases {
+			fmt.Fprintf(&buf, " %d |", p.ms(r))
+		}
+		fmt.Fprintf(&buf, " %.3f |\n", r.RTF)
+	}
+
+	fmt.Fprintf(&buf, "\n## Summary (ms)\n\n| Phase | avg | min | max |")
+	for _, p := range percentiles {
+		fmt.Fprintf(&buf, " %s |", percentileLabel(p))
+	}
+	fmt.Fprintf(&buf, "\n|---|%s\n", strings.Repeat("---:|", 3+len(percentiles)))
+	for _, s := range summarize(results, percentiles) {
+		fmt.Fprintf(&buf, "| %s | %d | %d | %d |", s.name, s.avg, s.min, s.max)
+		for _, v := range s.percentiles {
+			fmt.Fprintf(&buf, " %d |", v)
+		}
+		fmt.Fprintln(&buf)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// markdownEscape 转义表格与强调语法中的特殊字符
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`").Replace(s)
+}
@@ -8,8 +8,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/internal/gatewaydebug"
 	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
 	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
 )
@@ -32,6 +34,12 @@ func main() {
 		voice      string
 		text       string
 		iterations int
+		alternate  bool
+		debugStats bool
+		debugPath  string
+		csvPath    string
+		mdPath     string
+		pctList    string
 	)
 
 	flag.StringVar(&gateway, "gateway", "ws://localhost:7861", "Gateway WebSocket URL")
@@ -41,6 +49,12 @@ func main() {
 	// 默认使用尼日利亚英语(en-NG)文本
 	flag.StringVar(&text, "text", "The development of artificial intelligence has transformed the way we interact with technology in our daily lives.", "Text to synthesize")
 	flag.IntVar(&iterations, "iterations", 1, "Number of iterations to run")
+	flag.BoolVar(&alternate, "alternate", false, "Alternate cold (fresh connection) and warm (reused session) iterations")
+	flag.BoolVar(&debugStats, "gateway-debug", false, "Fetch server-side timings from the gateway debug endpoint after each request")
+	flag.StringVar(&debugPath, "debug-endpoint", gatewaydebug.DefaultEndpoint, "Gateway debug endpoint path template ({session_id} is substituted)")
+	flag.StringVar(&csvPath, "csv", "", "Write per-iteration phase timings and summary statistics to this CSV file")
+	flag.StringVar(&mdPath, "markdown", "", "Write per-iteration phase timings and summary statistics to this Markdown file")
+	flag.StringVar(&pctList, "percentiles", "50,90,95,99", "Comma-separated percentiles in the exported summary")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "TTS Detailed Timing - TTS request latency analysis tool\n\n")
@@ -51,6 +65,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -provider qwen -voice en-NG-OkunNeutral -text \"Hello world\" -iterations 3\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  # Test Azure TTS\n")
 		fmt.Fprintf(os.Stderr, "  %s -provider azure -voice en-NG-EzinneNeural -iterations 5\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Cold vs warm: alternate fresh-connection and reused-session iterations\n")
+		fmt.Fprintf(os.Stderr, "  %s -provider qwen -iterations 6 -alternate\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Export phase breakdown for a performance ticket\n")
+		fmt.Fprintf(os.Stderr, "  %s -provider qwen -iterations 20 -markdown timing.md -csv timing.csv\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 	}
@@ -63,6 +81,12 @@ func main() {
 		logging.Error("Text cannot be empty")
 		os.Exit(1)
 	}
+	percentiles, err := parsePercentiles(pctList)
+	if err != nil {
+		logging.Error("Invalid percentiles", "error", err)
+		os.Exit(1)
+	}
+	startedAt := time.Now()
 
 	fmt.Printf("\n%s========================================%s\n", colorCyan, colorReset)
 	fmt.Printf("%s  TTS Detailed Timing Analysis%s\n", colorCyan, colorReset)
@@ -72,23 +96,69 @@ func main() {
 	fmt.Printf("Voice:      %s\n", voice)
 	fmt.Printf("Text:       %s\n", truncateText(text, 60))
 	fmt.Printf("Iterations: %d\n", iterations)
+	fmt.Printf("Alternate:  %v\n", alternate)
 	fmt.Printf("%s========================================%s\n\n", colorCyan, colorReset)
 
+	config := newTTSConfig(gateway, provider, apiKey, voice)
+
+	// 服务端统计拉取（可选）
+	var fetcher *gatewaydebug.Fetcher
+	if debugStats {
+		fetcher, err = gatewaydebug.NewFetcher(gateway, debugPath, apiKey)
+		if err != nil {
+			logging.Error("Invalid gateway debug settings", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// 交替模式：预先建立一个复用 session，warm 轮次在其上合成（建连不计入 warm 轮耗时）
+	var warmSession *tts.Session
+	if alternate {
+		client, err := tts.NewClient(config)
+		if err != nil {
+			logging.Error("Failed to create client", "error", err)
+			os.Exit(1)
+		}
+		warmSession, err = client.CreateSession(context.Background(), nil)
+		if err != nil {
+			logging.Error("Failed to create warm session", "error", err)
+			os.Exit(1)
+		}
+		defer warmSession.Close()
+	}
+
 	// 运行测试
 	var totalTTFB, totalConnect, totalSynthesis, totalTime int64
 	successCount := 0
+	stats := map[string]*labelStats{labelCold: {}, labelWarm: {}}
+	var results []*RequestResult
 
 	for i := 0; i < iterations; i++ {
-		fmt.Printf("%s[Iteration %d/%d]%s\n", colorYellow, i+1, iterations, colorReset)
-
-		result, err := runSingleRequest(gateway, provider, apiKey, voice, text)
+		label := labelCold
+		if alternate && i%2 == 1 {
+			label = labelWarm
+		}
+		fmt.Printf("%s[Iteration %d/%d] %s%s\n", colorYellow, i+1, iterations, strings.ToUpper(label), colorReset)
+
+		var result *RequestResult
+		var err error
+		if label == labelWarm {
+			result, err = runWarmRequest(warmSession, text)
+		} else {
+			result, err = runSingleRequest(config, text)
+		}
 		if err != nil {
 			fmt.Printf("%s✗ Error: %v%s\n\n", colorRed, err, colorReset)
 			continue
 		}
+		result.Iteration = i + 1
+		results = append(results, result)
 
 		// 打印详细时间戳
 		printDetailedTimings(result)
+		if fetcher != nil {
+			printServerStats(fetcher, result.SessionID)
+		}
 
 		// 累计统计
 		totalTTFB += result.TTFBMs
@@ -96,6 +166,7 @@ func main() {
 		totalSynthesis += result.SynthesisMs
 		totalTime += result.TotalMs
 		successCount++
+		stats[label].add(result)
 
 		if i < iterations-1 {
 			fmt.Println()
@@ -114,10 +185,90 @@ func main() {
 		fmt.Printf("Average Total:      %s%6d ms%s\n", colorCyan, totalTime/int64(successCount), colorReset)
 		fmt.Printf("%s========================================%s\n\n", colorCyan, colorReset)
 	}
+
+	if alternate {
+		printWarmColdDelta(stats[labelCold], stats[labelWarm])
+	}
+
+	// 导出（失败的轮次不计入）
+	if csvPath != "" {
+		if err := writeCSV(csvPath, results, percentiles); err != nil {
+			logging.Error("Write CSV failed", "path", csvPath, "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("CSV report:      %s\n", csvPath)
+	}
+	if mdPath != "" {
+		info := runInfo{gateway: gateway, provider: provider, voice: voice, text: text, iterations: iterations, startedAt: startedAt}
+		if err := writeMarkdown(mdPath, info, results, percentiles); err != nil {
+			logging.Error("Write Markdown failed", "path", mdPath, "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Markdown report: %s\n", mdPath)
+	}
+}
+
+// 轮次标签
+const (
+	labelCold = "cold" // 新建连接（TCP+TLS+WS 握手 + session.config）
+	labelWarm = "warm" // 复用已建立的 session
+)
+
+// labelStats 按标签累计的耗时统计
+type labelStats struct {
+	count     int64
+	ttfb      int64
+	connect   int64
+	synthesis int64
+	total     int64
+}
+
+func (s *labelStats) add(r *RequestResult) {
+	s.count++
+	s.ttfb += r.TTFBMs
+	s.connect += r.ConnectMs
+	s.synthesis += r.SynthesisMs
+	s.total += r.TotalMs
+}
+
+func (s *labelStats) avg(v int64) int64 {
+	if s.count == 0 {
+		return 0
+	}
+	return v / s.count
+}
+
+// printWarmColdDelta 打印 cold/warm 对比，拆分建连开销与 provider 合成耗时差异
+func printWarmColdDelta(cold, warm *labelStats) {
+	if cold.count == 0 || warm.count == 0 {
+		fmt.Printf("%sCold/warm comparison skipped (cold=%d, warm=%d successful)%s\n\n",
+			colorYellow, cold.count, warm.count, colorReset)
+		return
+	}
+
+	ttfbDelta := cold.avg(cold.ttfb) - warm.avg(warm.ttfb)
+	synthDelta := cold.avg(cold.synthesis) - warm.avg(warm.synthesis)
+
+	fmt.Printf("%s========================================%s\n", colorCyan, colorReset)
+	fmt.Printf("%s  Cold vs Warm (cold=%d, warm=%d)%s\n", colorCyan, cold.count, warm.count, colorReset)
+	fmt.Printf("%s========================================%s\n", colorCyan, colorReset)
+	fmt.Printf("%-20s %10s %10s %10s\n", "", "Cold", "Warm", "Delta")
+	fmt.Printf("%-20s %7d ms %7d ms %7d ms\n", "TTFB (start->first)", cold.avg(cold.ttfb), warm.avg(warm.ttfb), ttfbDelta)
+	fmt.Printf("%-20s %7d ms %7d ms %7d ms\n", "Connect", cold.avg(cold.connect), warm.avg(warm.connect), cold.avg(cold.connect)-warm.avg(warm.connect))
+	fmt.Printf("%-20s %7d ms %7d ms %7d ms\n", "Synthesis", cold.avg(cold.synthesis), warm.avg(warm.synthesis), synthDelta)
+	fmt.Printf("%-20s %7d ms %7d ms %7d ms\n", "Total", cold.avg(cold.total), warm.avg(warm.total), cold.avg(cold.total)-warm.avg(warm.total))
+	fmt.Printf("\n  Attribution of TTFB delta (%d ms):\n", ttfbDelta)
+	fmt.Printf("    • Connection establishment + config: %s%6d ms%s\n", colorBlue, ttfbDelta-synthDelta, colorReset)
+	fmt.Printf("    • Provider synthesis (commit->first): %s%6d ms%s\n", colorYellow, synthDelta, colorReset)
+	fmt.Printf("%s========================================%s\n\n", colorCyan, colorReset)
 }
 
 // RequestResult 请求结果
 type RequestResult struct {
+	Iteration int    // 轮次序号（从 1 开始）
+	Label     string // cold / warm
+	SessionID string // gateway 会话ID（用于关联服务端统计）
+
 	StartTime    time.Time
 	ConnectedAt  time.Time
 	CommitSentAt time.Time
@@ -130,17 +281,13 @@ type RequestResult struct {
 	TotalMs     int64
 	TotalBytes  int64
 	ChunkCount  int
+	AudioMs     int64   // 音频时长（stream.AudioDuration）
+	RTF         float64 // 实时率（stream.RealTimeFactor）
 }
 
-// runSingleRequest 执行单次请求
-func runSingleRequest(gateway, provider, apiKey, voice, text string) (*RequestResult, error) {
-	ctx := context.Background()
-	result := &RequestResult{
-		StartTime: time.Now(),
-	}
-
-	// 创建客户端
-	config := &tts.Config{
+// newTTSConfig 创建测试用的 TTS 配置
+func newTTSConfig(gateway, provider, apiKey, voice string) *tts.Config {
+	return &tts.Config{
 		GatewayURL:     gateway,
 		Provider:       provider,
 		APIKey:         apiKey,
@@ -149,8 +296,19 @@ func runSingleRequest(gateway, provider, apiKey, voice, text string) (*RequestRe
 		ConnectTimeout: 30 * time.Second,
 		ReadTimeout:    120 * time.Second,
 		WriteTimeout:   10 * time.Second,
+		MaxReconnects:  -1, // 不重连：重试会把建连失败计入建连耗时
 	}
+}
 
+// runSingleRequest 执行单次请求（cold：新建连接）
+func runSingleRequest(config *tts.Config, text string) (*RequestResult, error) {
+	ctx := context.Background()
+	result := &RequestResult{
+		Label:     labelCold,
+		StartTime: time.Now(),
+	}
+
+	// 创建客户端
 	client, err := tts.NewClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("create client: %w", err)
@@ -166,9 +324,37 @@ func runSingleRequest(gateway, provider, apiKey, voice, text string) (*RequestRe
 
 	// 获取时间戳
 	result.ConnectedAt = stream.ConnectedAt()
-	result.CommitSentAt = stream.CommitSentAt()
 	result.ConnectMs = stream.ConnectDuration().Milliseconds()
 
+	if err := readStream(result, stream); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// runWarmRequest 在已建立的 session 上执行单次请求（warm：无建连开销）
+func runWarmRequest(session *tts.Session, text string) (*RequestResult, error) {
+	result := &RequestResult{
+		Label:     labelWarm,
+		StartTime: time.Now(),
+	}
+
+	stream, err := session.SynthesizeStream(context.Background(), text)
+	if err != nil {
+		return nil, fmt.Errorf("synthesize: %w", err)
+	}
+
+	if err := readStream(result, stream); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readStream 读取音频并填充首包/完成时间
+func readStream(result *RequestResult, stream *tts.AudioStream) error {
+	result.SessionID = stream.SessionID()
+	result.CommitSentAt = stream.CommitSentAt()
+
 	// 接收音频数据
 	buf := make([]byte, 4096)
 	firstChunk := true
@@ -179,7 +365,7 @@ func runSingleRequest(gateway, provider, apiKey, voice, text string) (*RequestRe
 			if err.Error() == "EOF" {
 				break
 			}
-			return nil, fmt.Errorf("read: %w", err)
+			return fmt.Errorf("read: %w", err)
 		}
 
 		if n > 0 {
@@ -208,13 +394,25 @@ func runSingleRequest(gateway, provider, apiKey, voice, text string) (*RequestRe
 
 	result.CompleteAt = time.Now()
 	result.TotalMs = result.CompleteAt.Sub(result.StartTime).Milliseconds()
+	result.AudioMs = stream.AudioDuration().Milliseconds()
+	result.RTF = stream.RealTimeFactor()
 
 	// 检查流错误
-	if streamErr := stream.Error(); streamErr != nil {
-		return nil, streamErr
-	}
+	return stream.Error()
+}
 
-	return result, nil
+// printServerStats 拉取并打印服务端统计（调试接口不可用时打印原因，不影响客户端结果）
+func printServerStats(fetcher *gatewaydebug.Fetcher, sessionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := fetcher.Fetch(ctx, sessionID)
+	if err != nil {
+		fmt.Printf("\n  %sServer stats unavailable: %v%s\n", colorYellow, err, colorReset)
+		return
+	}
+	fmt.Println()
+	gatewaydebug.WriteText(os.Stdout, stats, "  ")
 }
 
 // printDetailedTimings 打印详细时间戳
@@ -258,9 +456,17 @@ func printDetailedTimings(result *RequestResult) {
 		colorCyan, result.TotalMs, colorReset)
 	fmt.Printf("    • Total Bytes:     %d bytes (%d chunks)\n",
 		result.TotalBytes, result.ChunkCount)
+	if result.AudioMs > 0 {
+		fmt.Printf("    • Audio Duration:  %6d ms (RTF %.3f)\n", result.AudioMs, result.RTF)
+	}
 
 	// Time breakdown
 	fmt.Printf("\n  %sTime Breakdown:%s\n", colorCyan, colorReset)
+	if result.ConnectedAt.IsZero() {
+		// warm 轮次：无建连/配置阶段，TTFB 仅由合成耗时构成
+		fmt.Printf("    • Synthesis:       %6d ms (reused session, no connect/config)\n", result.SynthesisMs)
+		return
+	}
 	configMs := result.CommitSentAt.Sub(result.ConnectedAt).Milliseconds()
 	if configMs > 0 {
 		fmt.Printf("    • Connect:         %6d ms (%.1f%%)\n",
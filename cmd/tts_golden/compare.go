@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+)
+
+// 退出码（供 CI 判定）
+const (
+	exitSetupError = 1 // 参数/环境错误
+	exitDrift      = 2 // 有用例偏离黄金音频、缺少黄金音频或合成失败
+)
+
+// 用例状态
+const (
+	statusOK      = "ok"
+	statusDrift   = "drift"
+	statusMissing = "missing" // 没有黄金音频（用 -update 生成）
+	statusError   = "error"   // 合成失败
+	statusUpdated = "updated" // -update 模式下已写入黄金音频
+)
+
+// limits 漂移阈值
+type limits struct {
+	MaxDistance      float64 // 频谱形状距离上限（dB）
+	MaxLevelDrift    float64 // 电平变化上限（dB）
+	MaxDurationDrift float64 // 非静音时长相对变化上限（0.2 = 20%）
+}
+
+// testCase 矩阵中的一个用例（音色 × 文本）
+type testCase struct {
+	Voice string
+	Name  string // 文本名称（黄金音频文件名）
+	Text  string
+}
+
+// goldenPath 返回用例的黄金音频路径：<dir>/<voice>/<name>.wav
+func (c testCase) goldenPath(dir string) string {
+	return filepath.Join(dir, c.Voice, c.Name+".wav")
+}
+
+// caseResult 单个用例的比对结果（-output 输出的 JSON 结构）
+type caseResult struct {
+	Voice         string   `json:"voice"`
+	Name          string   `json:"name"`
+	Status        string   `json:"status"`
+	Distance      float64  `json:"distance_db,omitempty"`
+	LevelDrift    float64  `json:"level_drift_db,omitempty"`
+	DurationDrift float64  `json:"duration_drift,omitempty"`
+	Reasons       []string `json:"reasons,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// compare 比较合成结果与黄金音频的频谱轮廓，超过任一阈值时标记为 drift
+func compare(c testCase, golden, got *audio.Profile, l limits) caseResult {
+	r := caseResult{Voice: c.Voice, Name: c.Name, Status: statusOK}
+	switch {
+	case len(golden.Bands) == 0:
+		r.Reasons = append(r.Reasons, "golden audio is silent")
+	case len(got.Bands) == 0:
+		r.Reasons = append(r.Reasons, "synthesized audio is silent")
+	}
+	if len(r.Reasons) > 0 {
+		r.Status = statusDrift
+		return r
+	}
+
+	r.Distance = round2(golden.Distance(got))
+	r.LevelDrift = round2(got.LevelDBFS - golden.LevelDBFS)
+	if golden.Voiced > 0 {
+		r.DurationDrift = round2(float64(got.Voiced-golden.Voiced) / float64(golden.Voiced))
+	}
+
+	if r.Distance > l.MaxDistance {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("spectral distance %.2f dB > %.2f", r.Distance, l.MaxDistance))
+	}
+	if math.Abs(r.LevelDrift) > l.MaxLevelDrift {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("level drift %+.2f dB exceeds ±%.2f", r.LevelDrift, l.MaxLevelDrift))
+	}
+	if math.Abs(r.DurationDrift) > l.MaxDurationDrift {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("voiced duration %v vs golden %v (%+.0f%%)",
+			got.Voiced.Round(10*time.Millisecond), golden.Voiced.Round(10*time.Millisecond), r.DurationDrift*100))
+	}
+	if len(r.Reasons) > 0 {
+		r.Status = statusDrift
+	}
+	return r
+}
+
+// round2 保留两位小数（报告可读性）
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
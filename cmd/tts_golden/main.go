@@ -0,0 +1,292 @@
+// Package main TTS 黄金音频回归比对工具
+//
+// 按固定的 音色 × 文本 矩阵合成音频，计算长时平均频谱轮廓（audio.Profile），与保存的黄金音频比较
+// 频谱形状距离、电平和非静音时长；任一项超过阈值即报告漂移，用于发现提供商侧静默更换音色模型。
+//
+// 用法:
+//
+//	./tts_golden -voices en-NG-RoseSerious,en-NG-OkunSerious -update     # 生成/刷新黄金音频
+//	./tts_golden -voices en-NG-RoseSerious,en-NG-OkunSerious             # 比对（漂移时退出码 2）
+//	./tts_golden -texts texts.json -golden ./golden -output drift.json   # 自定义文本矩阵并输出 JSON 报告
+//
+// 黄金音频保存为 <golden>/<voice>/<name>.wav；-texts 为 {"name": "text"} 形式的 JSON 文件。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
+)
+
+// defaultTexts 默认文本矩阵（覆盖问候、数字、疑问语调和长句）
+var defaultTexts = map[string]string{
+	"greeting": "Good morning, thank you for calling. How can I help you today?",
+	"numbers":  "Your account balance is four thousand, two hundred and fifteen naira.",
+	"question": "Would you like me to send the confirmation code to your phone?",
+	"long":     "The development of artificial intelligence has transformed the way we interact with technology in our daily lives.",
+}
+
+func main() {
+	var (
+		gateway    string
+		provider   string
+		apiKey     string
+		voiceList  string
+		textsPath  string
+		goldenDir  string
+		update     bool
+		sampleRate int
+		outputPath string
+		lim        limits
+	)
+
+	flag.StringVar(&gateway, "gateway", "ws://localhost:7861", "Gateway WebSocket URL")
+	flag.StringVar(&provider, "provider", "tengen", "TTS provider (tengen, qwen, azure)")
+	flag.StringVar(&apiKey, "api-key", os.Getenv("GATEWAY_API_KEY"), "API Key for authentication")
+	flag.StringVar(&voiceList, "voices", "en-NG-RoseSerious,en-NG-OkunSerious", "Comma-separated voice IDs")
+	flag.StringVar(&textsPath, "texts", "", "JSON file of {\"name\": \"text\"} to synthesize (default: built-in matrix)")
+	flag.StringVar(&goldenDir, "golden", "./golden", "Golden audio directory (<golden>/<voice>/<name>.wav)")
+	flag.BoolVar(&update, "update", false, "Write synthesized audio as the new golden audio instead of comparing")
+	flag.IntVar(&sampleRate, "sample-rate", 8000, "Requested sample rate in Hz")
+	flag.StringVar(&outputPath, "output", "", "Write per-case results as JSON to this file")
+	flag.Float64Var(&lim.MaxDistance, "max-distance", 3.0, "Maximum spectral-shape distance from golden audio (dB)")
+	flag.Float64Var(&lim.MaxLevelDrift, "max-level-drift", 3.0, "Maximum loudness change from golden audio (dB)")
+	flag.Float64Var(&lim.MaxDurationDrift, "max-duration-drift", 0.2, "Maximum relative change in voiced duration (0.2 = 20%)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "TTS Golden - Golden-audio regression check for TTS voices\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  # Record golden audio\n")
+		fmt.Fprintf(os.Stderr, "  %s -voices en-NG-RoseSerious -update\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Compare against golden audio (exit code 2 on drift)\n")
+		fmt.Fprintf(os.Stderr, "  %s -voices en-NG-RoseSerious -output drift.json\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	logging.Setup(logging.LevelInfo)
+
+	cases, err := buildMatrix(voiceList, textsPath)
+	if err != nil {
+		logging.Error("Invalid test matrix", "error", err)
+		os.Exit(exitSetupError)
+	}
+	if apiKey == "" {
+		logging.Warn("No API key provided. Set -api-key or GATEWAY_API_KEY environment variable.")
+	}
+
+	mode := "compare"
+	if update {
+		mode = "update"
+	}
+	fmt.Println("========================================")
+	fmt.Println("  TTS Golden Audio Regression")
+	fmt.Println("========================================")
+	fmt.Printf("Gateway:  %s\n", gateway)
+	fmt.Printf("Provider: %s\n", provider)
+	fmt.Printf("Golden:   %s\n", goldenDir)
+	fmt.Printf("Cases:    %d\n", len(cases))
+	fmt.Printf("Mode:     %s\n", mode)
+	if !update {
+		fmt.Printf("Limits:   distance %.1f dB, level ±%.1f dB, duration ±%.0f%%\n",
+			lim.MaxDistance, lim.MaxLevelDrift, lim.MaxDurationDrift*100)
+	}
+	fmt.Println("========================================")
+	fmt.Println()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var results []caseResult
+	for _, c := range cases {
+		if ctx.Err() != nil {
+			break
+		}
+		r := runCase(ctx, c, &tts.Config{
+			GatewayURL:     gateway,
+			Provider:       provider,
+			APIKey:         apiKey,
+			VoiceID:        c.Voice,
+			SampleRate:     sampleRate,
+			AudioFormat:    "pcm",
+			ConnectTimeout: 30 * time.Second,
+			ReadTimeout:    120 * time.Second,
+			WriteTimeout:   10 * time.Second,
+		}, goldenDir, update, lim)
+		printResult(r)
+		results = append(results, r)
+	}
+
+	failed := printSummary(results, len(cases))
+	if outputPath != "" {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		if err := os.WriteFile(outputPath, data, 0644); err != nil {
+			logging.Error("Failed to write report", "path", outputPath, "error", err)
+			os.Exit(exitSetupError)
+		}
+		fmt.Printf("Report saved to: %s\n", outputPath)
+	}
+	if failed || ctx.Err() != nil {
+		os.Exit(exitDrift)
+	}
+}
+
+// buildMatrix 生成 音色 × 文本 用例（按音色、文本名称排序）
+func buildMatrix(voiceList, textsPath string) ([]testCase, error) {
+	texts := defaultTexts
+	if textsPath != "" {
+		data, err := os.ReadFile(textsPath)
+		if err != nil {
+			return nil, fmt.Errorf("read texts: %w", err)
+		}
+		texts = nil
+		if err := json.Unmarshal(data, &texts); err != nil {
+			return nil, fmt.Errorf("parse texts %s: %w", textsPath, err)
+		}
+	}
+	names := make([]string, 0, len(texts))
+	for name, text := range texts {
+		if name == "" || name != filepath.Base(name) || strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("text %q: name must be a plain file name and text non-empty", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cases []testCase
+	for _, voice := range strings.Split(voiceList, ",") {
+		voice = strings.TrimSpace(voice)
+		if voice == "" {
+			continue
+		}
+		if voice != filepath.Base(voice) {
+			return nil, fmt.Errorf("voice %q: invalid voice ID", voice)
+		}
+		for _, name := range names {
+			cases = append(cases, testCase{Voice: voice, Name: name, Text: texts[name]})
+		}
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no voices or texts")
+	}
+	return cases, nil
+}
+
+// runCase 合成一个用例，并写入黄金音频（update）或与黄金音频比较
+func runCase(ctx context.Context, c testCase, config *tts.Config, goldenDir string, update bool, lim limits) caseResult {
+	fail := func(status string, err error) caseResult {
+		return caseResult{Voice: c.Voice, Name: c.Name, Status: status, Error: err.Error()}
+	}
+
+	pcm, rate, err := synthesize(ctx, config, c.Text)
+	if err != nil {
+		return fail(statusError, err)
+	}
+
+	path := c.goldenPath(goldenDir)
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fail(statusError, err)
+		}
+		if err := audio.WriteWAVFile(path, pcm, rate, 1, 16); err != nil {
+			return fail(statusError, fmt.Errorf("write golden: %w", err))
+		}
+		return caseResult{Voice: c.Voice, Name: c.Name, Status: statusUpdated}
+	}
+
+	goldenPCM, header, err := audio.ReadWAVFile(path)
+	if os.IsNotExist(err) {
+		return fail(statusMissing, fmt.Errorf("no golden audio at %s (run with -update)", path))
+	}
+	if err != nil {
+		return fail(statusError, fmt.Errorf("read golden: %w", err))
+	}
+	goldenRate := int(header.SampleRate)
+	if rate != goldenRate {
+		r := audio.NewResampler(rate, goldenRate)
+		pcm = append(r.Process(pcm), r.Flush()...)
+	}
+	return compare(c, audio.NewProfile(goldenPCM, goldenRate), audio.NewProfile(pcm, goldenRate), lim)
+}
+
+// synthesize 合成文本，返回 PCM 与采样率
+func synthesize(ctx context.Context, config *tts.Config, text string) ([]byte, int, error) {
+	client, err := tts.NewClient(config)
+	if err != nil {
+		return nil, 0, fmt.Errorf("create client: %w", err)
+	}
+	defer client.Close()
+
+	stream, err := client.SynthesizeStream(ctx, text)
+	if err != nil {
+		return nil, 0, fmt.Errorf("synthesize: %w", err)
+	}
+	defer stream.Close()
+
+	pcm, err := stream.ReadAll()
+	if err == nil {
+		err = stream.Error()
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("read audio: %w", err)
+	}
+	if len(pcm) == 0 {
+		return nil, 0, fmt.Errorf("empty audio")
+	}
+	return pcm, stream.SampleRate(), nil
+}
+
+// printResult 打印单个用例结果
+func printResult(r caseResult) {
+	label := fmt.Sprintf("%s/%s", r.Voice, r.Name)
+	switch r.Status {
+	case statusOK:
+		fmt.Printf("[OK]      %-40s distance %.2f dB, level %+.2f dB, duration %+.0f%%\n",
+			label, r.Distance, r.LevelDrift, r.DurationDrift*100)
+	case statusDrift:
+		fmt.Printf("[DRIFT]   %-40s %s\n", label, strings.Join(r.Reasons, "; "))
+	case statusUpdated:
+		fmt.Printf("[UPDATED] %s\n", label)
+	default:
+		fmt.Printf("[%s] %-40s %s\n", strings.ToUpper(r.Status), label, r.Error)
+	}
+}
+
+// printSummary 打印汇总，返回是否有失败用例（drift / missing / error 或未跑完）
+func printSummary(results []caseResult, total int) bool {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	fmt.Println()
+	fmt.Println("========================================")
+	fmt.Printf("Cases:   %d/%d\n", len(results), total)
+	for _, status := range []string{statusOK, statusUpdated, statusDrift, statusMissing, statusError} {
+		if counts[status] > 0 {
+			fmt.Printf("%-8s %d\n", status+":", counts[status])
+		}
+	}
+	failed := len(results) < total || counts[statusDrift]+counts[statusMissing]+counts[statusError] > 0
+	if failed {
+		fmt.Println("Result:  FAILED")
+	} else {
+		fmt.Println("Result:  PASSED")
+	}
+	fmt.Println("========================================")
+	return failed
+}
@@ -0,0 +1,88 @@
+// Package main 麦克风实时识别演示程序
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio/capture"
+	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+	"github.com/jinbozhan/tengen-speech-sdk-go/stt"
+)
+
+func main() {
+	var (
+		gatewayURL string
+		provider   string
+		apiKey     string
+		language   string
+		sampleRate int
+		device     string
+		recorder   string
+		clientVAD  bool
+	)
+	flag.StringVar(&gatewayURL, "gateway", "ws://localhost:8080", "Gateway URL")
+	flag.StringVar(&provider, "provider", "azure", "STT provider (azure, qwen)")
+	flag.StringVar(&apiKey, "apikey", "", "API Key for authentication")
+	flag.StringVar(&language, "language", "zh-CN", "Recognition language")
+	flag.IntVar(&sampleRate, "sample-rate", 16000, "Capture sample rate")
+	flag.StringVar(&device, "device", "", "Input device (default: system default)")
+	flag.StringVar(&recorder, "recorder", "", "Recorder program (arecord, parec, rec, ffmpeg); auto-detected if empty")
+	flag.BoolVar(&clientVAD, "client-vad", false, "Drop long silence locally before upload (client-side VAD)")
+	flag.Parse()
+	logging.Setup(logging.LevelInfo)
+
+	// Ctrl+C 停止采集；已说出内容的最终结果仍会输出
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	config := &stt.Config{
+		GatewayURL:    gatewayURL,
+		Provider:      provider,
+		APIKey:        apiKey,
+		Language:      language,
+		SampleRate:    sampleRate,
+		AudioFormat:   "pcm",
+		Channels:      1,
+		BitsPerSample: 16,
+
+		EnableClientVAD: clientVAD,
+	}
+	client, err := stt.NewClient(config)
+	if err != nil {
+		logging.Error("Failed to create client", "error", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	session, err := client.RecognizeMicrophone(ctx, &stt.MicrophoneOptions{Device: device, Recorder: recorder})
+	if errors.Is(err, capture.ErrNoRecorder) {
+		logging.Error("No recorder available", "error", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		logging.Error("Failed to start microphone recognition", "error", err)
+		os.Exit(1)
+	}
+	defer session.Close()
+	fmt.Println("Listening... press Ctrl+C to stop")
+
+	err = session.Handle(context.Background(), stt.Handlers{
+		OnPartial: func(text string) { fmt.Printf("\r[Partial] %s", text) },
+		OnFinal: func(seg stt.Segment) {
+			fmt.Printf("\r[Final] [%.3fs-%.3fs] %s\n", seg.StartTime.Seconds(), seg.EndTime.Seconds(), seg.Text)
+		},
+	})
+	if err != nil {
+		logging.Error("Recognition failed", "error", err)
+		os.Exit(1)
+	}
+
+	l := session.Latencies()
+	fmt.Printf("First partial: %dms  Finalization: %dms\n", l.FirstPartial.Milliseconds(), l.Finalization.Milliseconds())
+}
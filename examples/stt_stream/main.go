@@ -2,6 +2,7 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"flag"
 	"fmt"
@@ -12,19 +13,28 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
 	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
 	"github.com/jinbozhan/tengen-speech-sdk-go/stt"
 )
 
-const wavHeaderSize = 44 // WAV 文件头字节数
-
 var (
 	gatewayURL string
 	provider   string
 	apiKey     string
 	language   string
 	sampleRate int
-	interval   int
+	speed      float64
+	format     string
+	channels   int
+	bits       int
+	bigEndian  bool
+	clientVAD  bool
+	recordPath string
+	rawOutput  bool
+	profanity  string
+	endpoint   time.Duration
 )
 
 func init() {
@@ -33,7 +43,17 @@ func init() {
 	flag.StringVar(&apiKey, "apikey", "", "API Key for authentication")
 	flag.StringVar(&language, "language", "zh-CN", "Recognition language")
 	flag.IntVar(&sampleRate, "sample-rate", 8000, "Audio sample rate")
-	flag.IntVar(&interval, "interval", 100, "Send interval in milliseconds")
+	flag.IntVar(&sampleRate, "rate", 8000, "Audio sample rate (alias of -sample-rate)")
+	flag.StringVar(&format, "format", "", "Input format: wav or raw (headerless PCM); default by file extension")
+	flag.IntVar(&channels, "channels", 1, "Channels of raw PCM input")
+	flag.IntVar(&bits, "bits", 16, "Bits per sample of raw PCM input")
+	flag.BoolVar(&bigEndian, "big-endian", false, "Raw PCM input is big-endian (converted to little-endian before sending)")
+	flag.BoolVar(&clientVAD, "client-vad", false, "Drop long silence locally before upload (client-side VAD)")
+	flag.Float64Var(&speed, "speed", 1, "Upload speed relative to real time (2: twice as fast)")
+	flag.StringVar(&recordPath, "record", "", "Record recognition events to a JSONL file, gzip-compressed when the path ends in .gz (replay with stt_replay)")
+	flag.BoolVar(&rawOutput, "raw", false, "Raw lowercase tokens: disable punctuation and inverse text normalization")
+	flag.DurationVar(&endpoint, "endpoint-silence", 0, "Emit utterance.end after this much silence at a semantically complete point (0: disabled)")
+	flag.StringVar(&profanity, "profanity", "", "Profanity filtering: raw, masked or removed (default: provider default)")
 }
 
 func main() {
@@ -51,12 +71,52 @@ func main() {
 		fmt.Println("Examples:")
 		fmt.Println("  stt_demo audio.wav")
 		fmt.Println("  stt_demo -provider qwen -language en-US recording.wav")
+		fmt.Println("  stt_demo -format raw -rate 16000 -channels 1 -bits 16 capture.pcm")
+		fmt.Println("  stt_demo -format raw -big-endian legacy_capture.pcm")
+		fmt.Println("  stt_demo -record events.jsonl audio.wav")
+		fmt.Println("  stt_demo -raw -language en-US audio.wav")
+		fmt.Println("  stt_demo -profanity masked audio.wav")
 		os.Exit(1)
 	}
 
 	// 检查文件是否存在
-	if _, err := os.Stat(audioFile); os.IsNotExist(err) {
-		logging.Error("Audio file not found", "file", audioFile)
+	info, err := os.Stat(audioFile)
+	if err != nil {
+		logging.Error("Audio file not found", "file", audioFile, "error", err)
+		os.Exit(1)
+	}
+
+	// 确定输入格式：未指定时按扩展名判断
+	if format == "" {
+		format = "raw"
+		if strings.HasSuffix(strings.ToLower(audioFile), ".wav") {
+			format = "wav"
+		}
+	}
+	switch format {
+	case "wav":
+		// 格式以 WAV 头为准（头部可能含 LIST/fact 等块，不是固定 44 字节）
+		header, err := audio.GetWAVInfo(audioFile)
+		if err == nil {
+			err = audio.CheckWAVFormat(header)
+		}
+		if err == nil && header.AudioFormat != audio.WAVFormatPCM {
+			err = fmt.Errorf("float WAV is not supported here, convert to 16-bit PCM or use RecognizeFile")
+		}
+		if err != nil {
+			logging.Error("Invalid WAV file", "file", audioFile, "error", err)
+			os.Exit(1)
+		}
+		sampleRate, channels, bits = int(header.SampleRate), int(header.NumChannels), int(header.BitsPerSample)
+		logging.Info("WAV format", "sample_rate", sampleRate, "channels", channels, "bits", bits)
+	case "raw", "pcm":
+		// 无头 PCM 没有格式信息，字节数必须与声明的声道数/位深对齐
+		if err := audio.ValidatePCMSize(int(info.Size()), channels, bits); err != nil {
+			logging.Error("Raw PCM does not match declared format", "file", audioFile, "error", err)
+			os.Exit(1)
+		}
+	default:
+		logging.Error("Unsupported format (use wav or raw)", "format", format)
 		os.Exit(1)
 	}
 
@@ -75,12 +135,17 @@ func main() {
 
 	// 创建STT配置
 	config := &stt.Config{
-		GatewayURL:  gatewayURL,
-		Provider:    provider,
-		APIKey:      apiKey,
-		Language:    language,
-		SampleRate:  sampleRate,
-		AudioFormat: "pcm",
+		GatewayURL:    gatewayURL,
+		Provider:      provider,
+		APIKey:        apiKey,
+		Language:      language,
+		SampleRate:    sampleRate,
+		AudioFormat:   "pcm",
+		Channels:      channels,
+		BitsPerSample: bits,
+		BigEndian:     bigEndian,
+
+		EnableClientVAD: clientVAD,
 	}
 
 	// 创建客户端
@@ -93,9 +158,18 @@ func main() {
 
 	// 创建流式会话
 	opts := &stt.StreamOptions{
-		Language:    language,
-		SampleRate:  sampleRate,
-		AudioFormat: "pcm",
+		Language:      language,
+		SampleRate:    sampleRate,
+		AudioFormat:   "pcm",
+		Channels:      channels,
+		BitsPerSample: bits,
+		BigEndian:     bigEndian,
+		RealtimeSpeed: speed,
+
+		EnablePunctuation: !rawOutput,
+		EnableITN:         !rawOutput,
+		ProfanityMode:     protocol.ProfanityMode(profanity),
+		EndpointSilence:   endpoint,
 	}
 	session, err := client.CreateSession(ctx, opts)
 	if err != nil {
@@ -113,9 +187,17 @@ func main() {
 	}
 	defer reader.Close()
 
-	// 跳过WAV头
-	if strings.HasSuffix(strings.ToLower(audioFile), ".wav") {
-		reader.Seek(wavHeaderSize, io.SeekStart)
+	// 跳过WAV头，只发送 data 块
+	var src io.Reader = reader
+	if format == "wav" {
+		header, err := audio.ReadWAVHeader(reader)
+		if err != nil {
+			logging.Error("Failed to read WAV header", "error", err)
+			os.Exit(1)
+		}
+		if header.Subchunk2Size != 0 {
+			src = io.LimitReader(reader, int64(header.Subchunk2Size))
+		}
 	}
 
 	start := time.Now()
@@ -123,28 +205,11 @@ func main() {
 	// 发送音频流: 从文件发送音频，数据结束发送EndInput 
 	sendErrCh := make(chan error, 1)
 	go func() {
-		chunkSize := sampleRate * 2 / 10 // 100ms
-		buf := make([]byte, chunkSize)
-		for {
-			n, err := reader.Read(buf)
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				logging.Error("Failed to read audio", "error", err)
-				sendErrCh <- err
-				return
-			}
-			// 发送数据分块
-			if n > 0 {
-				if err := session.Send(buf[:n]); err != nil {
-					logging.Error("Failed to send audio", "error", err)
-					sendErrCh <- err
-					return
-				}
-				// 模拟实时速度
-				time.Sleep(time.Duration(interval) * time.Millisecond)
-			}
+		// 按音频时长节奏发送（模拟实时采集）
+		if err := session.SendRealtime(ctx, src); err != nil {
+			logging.Error("Failed to send audio", "error", err)
+			sendErrCh <- err
+			return
 		}
 		// 数据结束：EndInput发送session.end到服务端
 		if err := session.EndInput(); err != nil {
@@ -156,7 +221,23 @@ func main() {
 	}()
 
     // 流式识别: 接受事件并返回最终识别文本
-	finalTexts, err := recognizeStreaming(session.Events())
+	var eventLog *stt.EventLog
+	if recordPath != "" {
+		f, err := os.Create(recordPath)
+		if err != nil {
+			logging.Error("Failed to create event log", "path", recordPath, "error", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		var w io.Writer = f
+		if strings.HasSuffix(recordPath, ".gz") {
+			gz := gzip.NewWriter(f)
+			defer gz.Close()
+			w = gz
+		}
+		eventLog = stt.NewEventLog(w, nil)
+	}
+	finalTexts, err := recognizeStreaming(session.Events(), eventLog)
 	if err != nil {
 		logging.Error("Recognition failed", "error", err)
 		os.Exit(1)
@@ -171,19 +252,30 @@ func main() {
 
 	// 显示完整结果
 	if len(finalTexts) > 0 {
-		logging.Info("TTFB", "ms", session.TTFB().Milliseconds())
+		stats := session.Stats()
+		logging.Info("Session stats", "ttfb_ms", stats.TTFB.Milliseconds(), "first_partial_ms", stats.FirstPartial.Milliseconds(),
+			"finalization_ms", stats.Finalization.Milliseconds(), "bytes_sent", stats.BytesSent, "chunks_sent", stats.ChunksSent,
+			"partials", stats.Partials, "finals", stats.Finals, "dropped_events", stats.DroppedEvents)
+		if clientVAD {
+			logging.Info("Client VAD", "dropped_ms", stats.DroppedAudio.Milliseconds())
+		}
 		logging.Info("Final result", "text", strings.Join(finalTexts, ""))
 	}
 
 	logging.Info("Recognition complete", "duration_ms", elapsed.Milliseconds())
 }
 
-// recognizeStreaming 流式识别，接收事件并返回最终识别文本
-func recognizeStreaming(events <-chan *stt.RecognitionEvent) ([]string, error) {
+// recognizeStreaming 流式识别，接收事件并返回最终识别文本（eventLog 非 nil 时记录每个事件）
+func recognizeStreaming(events <-chan *stt.RecognitionEvent, eventLog *stt.EventLog) ([]string, error) {
 	var finalTexts []string
 
 loop:
 	for event := range events {
+		if eventLog != nil {
+			if err := eventLog.Record(event); err != nil {
+				return finalTexts, fmt.Errorf("record event: %w", err)
+			}
+		}
 		switch event.Type {
 		case stt.EventTranscriptPartial:
 			logging.Info("[Partial]", "text", event.Text)
@@ -196,6 +288,9 @@ loop:
 		case stt.EventSpeechStarted:
 			logging.Info("[Speech] speech.started")
 
+		case stt.EventEndOfUtterance:
+			logging.Info("[Utterance] utterance.end", "end", event.EndTime.Seconds(), "reason", event.Reason, "text", event.Text)
+
 		case stt.EventSessionEnded:
 			break loop
 
@@ -11,6 +11,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
@@ -22,6 +23,7 @@ import (
 	"time"
 
 	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio/playback"
 	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
 	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
 )
@@ -38,6 +40,7 @@ var (
 	channels      int
 	bitsPerSample int
 	mode          string // "pipeline" 或 "serial"
+	play          bool
 )
 
 func init() {
@@ -52,6 +55,7 @@ func init() {
 	flag.IntVar(&channels, "channels", 1, "Audio channels")
 	flag.IntVar(&bitsPerSample, "bits", 16, "Bits per sample")
 	flag.StringVar(&mode, "mode", "pipeline", "Mode: pipeline (fast) or serial (baseline)")
+	flag.BoolVar(&play, "play", false, "Play the merged audio after synthesis (requires aplay, paplay, sox or ffplay)")
 }
 
 type roundResult struct {
@@ -150,6 +154,18 @@ func main() {
 
 	// 打印结果
 	printResults(results, totalElapsed)
+
+	// 合成结束后播放（不计入耗时统计）
+	if play && len(allPCM) > 0 {
+		err := playback.Play(ctx, bytes.NewReader(allPCM), playback.Options{
+			SampleRate:    sampleRate,
+			Channels:      channels,
+			BitsPerSample: bitsPerSample,
+		})
+		if err != nil {
+			logging.Error("Playback failed", "error", err)
+		}
+	}
 }
 
 // runPipeline 管道化模式：先快速提交所有 round，再按序读取
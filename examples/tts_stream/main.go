@@ -6,6 +6,7 @@
 //
 //	./tts_stream "第一句" "第二句" "第三句"
 //	./tts_stream -voice en-NG-RoseNeutral "Hello" "World"
+//	./tts_stream -play "边合成边播放"
 package main
 
 import (
@@ -19,6 +20,7 @@ import (
 	"time"
 
 	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio/playback"
 	"github.com/jinbozhan/tengen-speech-sdk-go/logging"
 	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
 )
@@ -36,6 +38,8 @@ var (
 	sampleRate    int
 	channels      int
 	bitsPerSample int
+	play          bool
+	playDevice    string
 )
 
 func init() {
@@ -51,6 +55,8 @@ func init() {
 	flag.IntVar(&sampleRate, "sample-rate", 8000, "Audio sample rate")
 	flag.IntVar(&channels, "channels", 1, "Audio channels")
 	flag.IntVar(&bitsPerSample, "bits", 16, "Bits per sample")
+	flag.BoolVar(&play, "play", false, "Play audio while receiving (requires aplay, paplay, sox or ffplay)")
+	flag.StringVar(&playDevice, "play-device", "", "Playback device (default: system default)")
 }
 
 func main() {
@@ -110,12 +116,28 @@ func main() {
 	defer session.Close()
 	logging.Info("Session created", "id", session.ID, "connect_duration_ms", session.ConnectDuration().Milliseconds())
 
+	// 边收边播：所有轮次写入同一个播放器，轮次间无缝衔接
+	var player *playback.Player
+	if play {
+		player, err = playback.Open(ctx, playback.Options{
+			Device:        playDevice,
+			SampleRate:    sampleRate,
+			Channels:      channels,
+			BitsPerSample: bitsPerSample,
+		})
+		if err != nil {
+			logging.Error("Failed to open player", "error", err)
+			os.Exit(1)
+		}
+		logging.Info("Playback enabled", "player", player.Name())
+	}
+
 	// 多轮合成，复用同一个 Session
 	var allPCMData []byte
 	var results []RoundResult
 
 	for i, text := range texts {
-		result, pcmData, err := synthesizeStream(ctx, session, i+1, text)
+		result, pcmData, err := synthesizeStream(ctx, session, i+1, text, player)
 		if err != nil {
 			logging.Error("Synthesis failed", "round", i+1, "error", err)
 			os.Exit(1)
@@ -131,6 +153,13 @@ func main() {
 	}
 	logging.Info("Audio saved", "file", output, "bytes", len(allPCMData))
 
+	// 等待播放完毕
+	if player != nil {
+		if err := player.Close(); err != nil {
+			logging.Error("Playback failed", "error", err)
+		}
+	}
+
 	// 打印统计
 	printSummary(results)
 }
@@ -145,7 +174,8 @@ type RoundResult struct {
 }
 
 // synthesizeStream 使用 Session 合成单段文本，返回结果和 PCM 数据
-func synthesizeStream(ctx context.Context, session *tts.Session, round int, text string) (RoundResult, []byte, error) {
+// player 非空时同时写入播放器
+func synthesizeStream(ctx context.Context, session *tts.Session, round int, text string, player io.Writer) (RoundResult, []byte, error) {
 	logging.Info("Synthesis round", "round", round, "text", truncate(text, 30))
 	start := time.Now()
 
@@ -167,6 +197,11 @@ func synthesizeStream(ctx context.Context, session *tts.Session, round int, text
 				firstChunkTime = time.Now()
 			}
 			pcmData = append(pcmData, buf[:n]...)
+			if player != nil {
+				if _, werr := player.Write(buf[:n]); werr != nil {
+					return RoundResult{}, nil, fmt.Errorf("playback: %w", werr)
+				}
+			}
 		}
 		if err == io.EOF {
 			break
@@ -0,0 +1,225 @@
+// Package audiosave 按文件名模板保存测试工具合成的音频，并生成 manifest 清单
+//
+// 模板中的占位符会被替换为对应字段，路径中的 / 会创建子目录：
+//
+//	{voice}/{date}/{worker}-{req}.{ext}  →  en-NG-RoseSerious/20240105/3-17.mp3
+//
+// 目标文件已存在时自动追加 _1、_2 … 后缀，不会覆盖之前的结果。
+package audiosave
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTemplate 默认文件名模板
+const DefaultTemplate = "{voice}/{date}/{worker}-{req}.{ext}"
+
+// ManifestFile manifest 清单文件名（位于保存根目录）
+const ManifestFile = "manifest.json"
+
+// placeholders 支持的占位符
+var placeholders = map[string]bool{
+	"voice": true, "provider": true, "date": true, "time": true,
+	"worker": true, "req": true, "seq": true, "ext": true,
+}
+
+var placeholderRe = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// unsafeChars 路径分量中需要替换的字符（音色名等字段可能包含）
+var unsafeChars = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+
+// Fields 单个音频文件的模板字段
+type Fields struct {
+	Voice    string
+	Provider string
+	Worker   int
+	Req      int
+	Ext      string // 文件扩展名（不含点），空为 bin
+	Text     string // 合成文本（仅写入 manifest）
+}
+
+// Entry manifest 条目
+type Entry struct {
+	File     string    `json:"file"` // 相对保存根目录的路径
+	Voice    string    `json:"voice,omitempty"`
+	Provider string    `json:"provider,omitempty"`
+	Worker   int       `json:"worker"`
+	Req      int       `json:"req"`
+	Bytes    int       `json:"bytes"`
+	Text     string    `json:"text,omitempty"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+// Saver 音频保存器（并发安全）
+type Saver struct {
+	root     string
+	template string
+	start    time.Time // 模板中 {date}/{time} 取运行开始时间，同一次运行的文件落在同一目录
+
+	mu      sync.Mutex
+	seq     int
+	entries []Entry
+}
+
+// New 创建保存器；template 为空时使用 DefaultTemplate
+func New(root, template string) (*Saver, error) {
+	if template == "" {
+		template = DefaultTemplate
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(template, -1) {
+		if !placeholders[m[1]] {
+			return nil, fmt.Errorf("unknown placeholder {%s} in template %q", m[1], template)
+		}
+	}
+	if filepath.IsAbs(template) || hasParentRef(template) {
+		return nil, fmt.Errorf("template %q must be a relative path without '..'", template)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("create audio dir: %w", err)
+	}
+	return &Saver{root: root, template: template, start: time.Now()}, nil
+}
+
+// Root 返回保存根目录
+func (s *Saver) Root() string {
+	return s.root
+}
+
+// Save 按模板保存音频，返回实际写入的文件路径
+func (s *Saver) Save(f Fields, data []byte) (string, error) {
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	s.mu.Unlock()
+
+	rel := s.render(f, seq)
+	path := filepath.Join(s.root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create dir: %w", err)
+	}
+
+	file, path, err := createUnique(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("close %s: %w", path, err)
+	}
+
+	rel, _ = filepath.Rel(s.root, path)
+	s.mu.Lock()
+	s.entries = append(s.entries, Entry{
+		File:     filepath.ToSlash(rel),
+		Voice:    f.Voice,
+		Provider: f.Provider,
+		Worker:   f.Worker,
+		Req:      f.Req,
+		Bytes:    len(data),
+		Text:     f.Text,
+		SavedAt:  time.Now(),
+	})
+	s.mu.Unlock()
+	return path, nil
+}
+
+// WriteManifest 将已保存文件的清单写入根目录下的 manifest.json（按 worker、req 排序）
+func (s *Saver) WriteManifest() (string, error) {
+	s.mu.Lock()
+	entries := append([]Entry(nil), s.entries...)
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Worker != entries[j].Worker {
+			return entries[i].Worker < entries[j].Worker
+		}
+		return entries[i].Req < entries[j].Req
+	})
+
+	manifest := struct {
+		Template string  `json:"template"`
+		Created  string  `json:"created"`
+		Files    []Entry `json:"files"`
+	}{s.template, s.start.Format(time.RFC3339), entries}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.root, ManifestFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// render 替换模板占位符，生成相对路径
+func (s *Saver) render(f Fields, seq int) string {
+	ext := f.Ext
+	if ext == "" {
+		ext = "bin"
+	}
+	values := map[string]string{
+		"voice":    sanitize(f.Voice),
+		"provider": sanitize(f.Provider),
+		"date":     s.start.Format("20060102"),
+		"time":     s.start.Format("150405"),
+		"worker":   strconv.Itoa(f.Worker),
+		"req":      strconv.Itoa(f.Req),
+		"seq":      strconv.Itoa(seq),
+		"ext":      sanitize(ext),
+	}
+	rel := placeholderRe.ReplaceAllStringFunc(s.template, func(m string) string {
+		return values[m[1:len(m)-1]]
+	})
+	return filepath.FromSlash(rel)
+}
+
+// sanitize 将字段值转换为安全的路径分量
+func sanitize(v string) string {
+	v = strings.Trim(unsafeChars.ReplaceAllString(v, "_"), ".")
+	if v == "" {
+		return "_"
+	}
+	return v
+}
+
+// hasParentRef 模板是否包含 .. 路径分量
+func hasParentRef(template string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(template), "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// createUnique 独占创建文件；已存在时在扩展名前追加 _1、_2 …
+func createUnique(path string) (*os.File, string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 1; ; i++ {
+		file, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return file, candidate, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, "", fmt.Errorf("create %s: %w", candidate, err)
+		}
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
@@ -0,0 +1,62 @@
+package audiosave
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSaveTemplateCollisionAndManifest 验证：模板展开为子目录、音色名中的不安全字符被替换、
+// 同名文件追加后缀而不覆盖，manifest 记录全部文件。
+// WHY：原先平铺的 {voice}_w{n}_r{n}.mp3 在重复运行时互相覆盖，多次压测的音频无法对比。
+func TestSaveTemplateCollisionAndManifest(t *testing.T) {
+	root := t.TempDir()
+	s, err := New(root, "{voice}/{worker}-{req}.{ext}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := Fields{Voice: "en/NG Rose", Worker: 3, Req: 17, Ext: "mp3", Text: "hello"}
+	first, err := s.Save(f, []byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Save(f, []byte("bb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(root, "en_NG_Rose", "3-17.mp3"); first != want {
+		t.Fatalf("first = %s, want %s", first, want)
+	}
+	if want := filepath.Join(root, "en_NG_Rose", "3-17_1.mp3"); second != want {
+		t.Fatalf("second = %s, want %s", second, want)
+	}
+	if data, _ := os.ReadFile(first); string(data) != "a" {
+		t.Fatalf("first file overwritten: %q", data)
+	}
+
+	path, err := s.WriteManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest struct {
+		Files []Entry `json:"files"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 2 || manifest.Files[1].File != "en_NG_Rose/3-17_1.mp3" || manifest.Files[1].Bytes != 2 {
+		t.Fatalf("unexpected manifest: %+v", manifest.Files)
+	}
+
+	if _, err := New(root, "{voice}/../{req}"); err == nil || !strings.Contains(err.Error(), "..") {
+		t.Fatalf("expected error for template escaping root, got %v", err)
+	}
+}
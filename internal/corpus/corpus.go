@@ -0,0 +1,230 @@
+// Package corpus 按清单从对象存储下载测试语料（WAV 样本集）并校验 SHA-256
+//
+// 清单格式（JSON）：
+//
+//	{
+//	  "base_url": "https://bucket.example.com/speech-samples/",
+//	  "files": [
+//	    {"name": "greeting_01.wav", "sha256": "9f86d0…", "size": 128044}
+//	  ]
+//	}
+//
+// base_url 为空时相对清单所在位置解析；单个文件可用 url 字段指定完整地址。
+// 本地已存在且校验通过的文件不会重复下载，下载先写临时文件、校验通过后再改名，
+// 中断或校验失败不会留下不完整的样本。
+package corpus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Manifest 语料清单
+type Manifest struct {
+	BaseURL string `json:"base_url,omitempty"`
+	Files   []File `json:"files"`
+}
+
+// File 清单中的单个文件
+type File struct {
+	Name   string `json:"name"`           // 本地文件名（不含目录）
+	SHA256 string `json:"sha256"`         // 十六进制 SHA-256
+	Size   int64  `json:"size,omitempty"` // 字节数（可选，用于提前发现截断）
+	URL    string `json:"url,omitempty"`  // 完整下载地址（可选，默认 base_url + name）
+}
+
+// Result 同步结果
+type Result struct {
+	Downloaded []string // 新下载的文件
+	Verified   []string // 本地已存在且校验通过的文件
+}
+
+// Syncer 语料同步器
+type Syncer struct {
+	client *http.Client
+}
+
+// NewSyncer 创建同步器（单个文件下载超时 timeout，0 使用 5 分钟）
+func NewSyncer(timeout time.Duration) *Syncer {
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &Syncer{client: &http.Client{Timeout: timeout}}
+}
+
+// LoadManifest 读取清单（location 为 http(s) URL 或本地路径）
+func (s *Syncer) LoadManifest(ctx context.Context, location string) (*Manifest, error) {
+	var data []byte
+	if isURL(location) {
+		body, err := s.get(ctx, location)
+		if err != nil {
+			return nil, fmt.Errorf("fetch manifest: %w", err)
+		}
+		defer body.Close()
+		if data, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("fetch manifest: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(location); err != nil {
+			return nil, fmt.Errorf("read manifest: %w", err)
+		}
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if len(m.Files) == 0 {
+		return nil, fmt.Errorf("manifest %s has no files", location)
+	}
+	for _, f := range m.Files {
+		if f.Name == "" || f.Name != filepath.Base(f.Name) || f.Name == ".." {
+			return nil, fmt.Errorf("manifest entry %q: name must be a plain file name", f.Name)
+		}
+		if len(f.SHA256) != sha256.Size*2 {
+			return nil, fmt.Errorf("manifest entry %s: invalid sha256 %q", f.Name, f.SHA256)
+		}
+	}
+	if m.BaseURL == "" && isURL(location) {
+		m.BaseURL = location[:strings.LastIndex(location, "/")+1]
+	}
+	return &m, nil
+}
+
+// Sync 将清单中的文件同步到 dir：已存在且校验通过的跳过，缺失或校验不符的重新下载
+func (s *Syncer) Sync(ctx context.Context, m *Manifest, dir string) (*Result, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create sample dir: %w", err)
+	}
+	res := &Result{}
+	for _, f := range m.Files {
+		path := filepath.Join(dir, f.Name)
+		if err := verifyFile(path, f); err == nil {
+			res.Verified = append(res.Verified, f.Name)
+			continue
+		}
+
+		src, err := m.fileURL(f)
+		if err != nil {
+			return res, err
+		}
+		if err := s.download(ctx, src, path, f); err != nil {
+			return res, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		res.Downloaded = append(res.Downloaded, f.Name)
+	}
+	return res, nil
+}
+
+// Verify 只校验 dir 中的文件，返回缺失或校验失败的文件及原因
+func Verify(m *Manifest, dir string) map[string]error {
+	bad := make(map[string]error)
+	for _, f := range m.Files {
+		if err := verifyFile(filepath.Join(dir, f.Name), f); err != nil {
+			bad[f.Name] = err
+		}
+	}
+	return bad
+}
+
+// fileURL 返回文件下载地址
+func (m *Manifest) fileURL(f File) (string, error) {
+	if f.URL != "" {
+		return f.URL, nil
+	}
+	if m.BaseURL == "" {
+		return "", fmt.Errorf("%s: no url and manifest has no base_url", f.Name)
+	}
+	base, err := url.Parse(m.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base_url: %w", err)
+	}
+	return base.ResolveReference(&url.URL{Path: f.Name}).String(), nil
+}
+
+// download 下载到临时文件，校验通过后改名为 path
+func (s *Syncer) download(ctx context.Context, src, path string, f File) error {
+	body, err := s.get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+f.Name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("download %s: %w", src, err)
+	}
+	if err := check(f, n, h.Sum(nil)); err != nil {
+		return fmt.Errorf("download %s: %w", src, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// get 发起 GET 请求，非 2xx 返回错误
+func (s *Syncer) get(ctx context.Context, src string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", src, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// verifyFile 校验本地文件的大小与 SHA-256
+func verifyFile(path string, f File) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return err
+	}
+	return check(f, n, h.Sum(nil))
+}
+
+// check 比较大小与摘要
+func check(f File, size int64, sum []byte) error {
+	if f.Size > 0 && size != f.Size {
+		return fmt.Errorf("size %d, want %d", size, f.Size)
+	}
+	if got := hex.EncodeToString(sum); !strings.EqualFold(got, f.SHA256) {
+		return fmt.Errorf("sha256 mismatch: got %s, want %s", got, f.SHA256)
+	}
+	return nil
+}
+
+// isURL 判断是否为 http(s) 地址
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
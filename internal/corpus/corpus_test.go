@@ -0,0 +1,65 @@
+package corpus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSyncDownloadsAndVerifies 验证：缺失文件按 base_url 下载并校验，已存在且校验通过的文件不重复下载，
+// 摘要不符的下载报错且不留下样本文件。
+// WHY：新机器上靠清单拉取语料，损坏或被替换的样本必须被发现，而不是悄悄进入测试结果。
+func TestSyncDownloadsAndVerifies(t *testing.T) {
+	good := []byte("RIFF....WAVEfmt good sample")
+	sum := sha256.Sum256(good)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/samples/manifest.json":
+			json.NewEncoder(w).Encode(Manifest{Files: []File{
+				{Name: "a.wav", SHA256: hex.EncodeToString(sum[:]), Size: int64(len(good))},
+			}})
+		case "/samples/a.wav", "/samples/bad.wav":
+			w.Write(good)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := NewSyncer(0)
+	m, err := s.LoadManifest(ctx, srv.URL+"/samples/manifest.json")
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+
+	res, err := s.Sync(ctx, m, dir)
+	if err != nil || len(res.Downloaded) != 1 {
+		t.Fatalf("first sync = %+v (%v), want a.wav downloaded", res, err)
+	}
+	before := requests
+	res, err = s.Sync(ctx, m, dir)
+	if err != nil || len(res.Verified) != 1 || requests != before {
+		t.Fatalf("second sync = %+v (%v, %d new requests), want a.wav verified locally", res, err, requests-before)
+	}
+
+	m.Files = append(m.Files, File{Name: "bad.wav", SHA256: hex.EncodeToString(make([]byte, sha256.Size))})
+	if _, err := s.Sync(ctx, m, dir); err == nil {
+		t.Fatal("sync with wrong checksum succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad.wav")); !os.IsNotExist(err) {
+		t.Fatalf("corrupt sample left on disk: %v", err)
+	}
+	if bad := Verify(m, dir); len(bad) != 1 || bad["bad.wav"] == nil {
+		t.Fatalf("Verify = %v, want only bad.wav", bad)
+	}
+}
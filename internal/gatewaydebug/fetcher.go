@@ -0,0 +1,139 @@
+// Package gatewaydebug 从 gateway 调试接口拉取服务端会话统计（时延、VAD），
+// 供测试工具按 session ID 合并到客户端报告，补齐客户端/服务端耗时归因
+package gatewaydebug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint 默认调试接口路径模板（{session_id} 会被替换为会话ID）
+const DefaultEndpoint = "/debug/sessions/{session_id}"
+
+// ErrNotAvailable gateway 未开启调试接口或该会话无记录（404/501）
+var ErrNotAvailable = errors.New("gateway debug endpoint not available")
+
+// SessionStats 服务端会话统计
+type SessionStats struct {
+	SessionID string             `json:"session_id"`
+	Provider  string             `json:"provider,omitempty"`
+	Timings   map[string]float64 `json:"timings_ms,omitempty"` // 服务端阶段耗时（毫秒），如 provider_connect、provider_ttfb
+	VAD       map[string]float64 `json:"vad,omitempty"`        // VAD 统计，如 speech_pct、speech_ms、clipped_ms
+	Events    []Event            `json:"events,omitempty"`     // 服务端事件日志
+}
+
+// Event 服务端事件
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Fetcher 调试接口拉取器
+type Fetcher struct {
+	baseURL  string // http(s)://host:port
+	endpoint string // 路径模板
+	apiKey   string
+	client   *http.Client
+}
+
+// NewFetcher 创建拉取器
+// gatewayURL 可以是 ws:// 或 wss:// 地址（自动转换为 http:// / https://），
+// endpoint 为空时使用 DefaultEndpoint
+func NewFetcher(gatewayURL, endpoint, apiKey string) (*Fetcher, error) {
+	u, err := url.Parse(gatewayURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse gateway url: %w", err)
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported gateway url scheme: %s", u.Scheme)
+	}
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
+	return &Fetcher{
+		baseURL:  u.Scheme + "://" + u.Host,
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// URL 返回指定会话的调试接口地址
+func (f *Fetcher) URL(sessionID string) string {
+	path := strings.ReplaceAll(f.endpoint, "{session_id}", url.PathEscape(sessionID))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	full := f.baseURL + path
+	if f.apiKey != "" {
+		sep := "?"
+		if strings.Contains(full, "?") {
+			sep = "&"
+		}
+		full += sep + "api_key=" + url.QueryEscape(f.apiKey)
+	}
+	return full
+}
+
+// Fetch 拉取指定会话的服务端统计
+// 调试接口不存在时返回 ErrNotAvailable，调用方可据此静默跳过
+func (f *Fetcher) Fetch(ctx context.Context, sessionID string) (*SessionStats, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("fetch gateway stats: empty session id")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL(sessionID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch gateway stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusNotImplemented:
+		return nil, ErrNotAvailable
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("fetch gateway stats: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	stats := &SessionStats{}
+	if err := json.NewDecoder(resp.Body).Decode(stats); err != nil {
+		return nil, fmt.Errorf("decode gateway stats: %w", err)
+	}
+	if stats.SessionID == "" {
+		stats.SessionID = sessionID
+	}
+	return stats, nil
+}
+
+// SortedKeys 返回 map 的有序键（用于稳定输出 Timings / VAD）
+func SortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,41 @@
+package gatewaydebug
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestFetchMapsSessionAndMissingEndpoint 验证：ws 地址转换为 http、{session_id} 被替换，
+// 调试接口 404 时返回 ErrNotAvailable。
+// WHY：测试工具对生产 gateway（未开启调试接口）运行时必须能静默跳过，而不是把整轮判为失败。
+func TestFetchMapsSessionAndMissingEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/debug/sessions/sess-1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"timings_ms":{"provider_ttfb":123.5},"vad":{"speech_pct":42}}`))
+	}))
+	defer srv.Close()
+
+	f, err := NewFetcher(strings.Replace(srv.URL, "http://", "ws://", 1), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := f.Fetch(context.Background(), "sess-1")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if stats.SessionID != "sess-1" || stats.Timings["provider_ttfb"] != 123.5 || stats.VAD["speech_pct"] != 42 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	if _, err := f.Fetch(context.Background(), "unknown"); !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("expected ErrNotAvailable, got %v", err)
+	}
+}
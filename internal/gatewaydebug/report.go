@@ -0,0 +1,31 @@
+package gatewaydebug
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteText 以缩进文本形式输出服务端统计（用于合并到测试工具的终端报告）
+func WriteText(w io.Writer, stats *SessionStats, indent string) {
+	if stats == nil {
+		return
+	}
+	if len(stats.Timings) > 0 {
+		fmt.Fprintf(w, "%sServer Timings (session %s):\n", indent, stats.SessionID)
+		for _, k := range SortedKeys(stats.Timings) {
+			fmt.Fprintf(w, "%s  • %-22s %8.1f ms\n", indent, k+":", stats.Timings[k])
+		}
+	}
+	if len(stats.VAD) > 0 {
+		fmt.Fprintf(w, "%sServer VAD:\n", indent)
+		for _, k := range SortedKeys(stats.VAD) {
+			fmt.Fprintf(w, "%s  • %-22s %8.1f\n", indent, k+":", stats.VAD[k])
+		}
+	}
+	if len(stats.Events) > 0 {
+		fmt.Fprintf(w, "%sServer Events (%d):\n", indent, len(stats.Events))
+		for _, e := range stats.Events {
+			fmt.Fprintf(w, "%s  %s %-20s %s\n", indent, e.Time.Format("15:04:05.000"), e.Type, e.Message)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// LockStaleAfter 锁文件超过此时间未释放视为持有进程已退出（崩溃或被 kill），可被接管
+const LockStaleAfter = 2 * time.Minute
+
+// lockRetryInterval 等待锁时的重试间隔
+const lockRetryInterval = 50 * time.Millisecond
+
+// Lock 获取跨进程排他锁，返回释放函数
+//
+// 锁以 path 文件存在与否表示：O_CREATE|O_EXCL 创建在 Linux / macOS / Windows 及
+// 常见网络文件系统上都是原子的，多台压测机共享输出目录时同样有效。锁文件记录持有者
+// （主机名、PID、时间）便于排查；修改时间超过 LockStaleAfter 的锁被删除后重新竞争。
+// 拿不到锁时按 lockRetryInterval 重试，直到 ctx 结束。
+func Lock(ctx context.Context, path string) (func() error, error) {
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s pid=%d at=%s\n", host, os.Getpid(), time.Now().Format(time.RFC3339))
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, werr := f.WriteString(owner)
+			if err := errors.Join(werr, f.Close()); err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("write lock %s: %w", path, err)
+			}
+			return func() error { return os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create lock %s: %w", path, err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > LockStaleAfter {
+			os.Remove(path) // 失败说明已被其他进程接管，继续竞争即可
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			holder, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("lock %s held by %q: %w", path, strings.TrimSpace(string(holder)), ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
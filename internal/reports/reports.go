@@ -0,0 +1,96 @@
+// Package reports 测试工具报告文件（Markdown / CSV / JSON）的读写
+//
+// 大样本集的报告可能达到数十 MB：超过阈值时自动 gzip 压缩并以 .gz 结尾保存，
+// 读取时 Open / ReadFile 同时支持原始文件和 .gz 文件，调用方无需关心实际保存形式。
+package reports
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DefaultGzipThreshold 默认压缩阈值（字节）
+const DefaultGzipThreshold = 1 << 20
+
+// GzipExt 压缩文件后缀
+const GzipExt = ".gz"
+
+// WriteFile 写入报告；数据超过 threshold 字节时 gzip 压缩并保存为 path+".gz"
+// threshold 为 0 使用 DefaultGzipThreshold，<0 不压缩。返回实际写入的路径，
+// 并删除另一种形式的同名旧文件，避免读取到过期结果。
+func WriteFile(path string, data []byte, threshold int) (string, error) {
+	if threshold == 0 {
+		threshold = DefaultGzipThreshold
+	}
+	path = strings.TrimSuffix(path, GzipExt)
+
+	target, stale := path, path+GzipExt
+	if threshold > 0 && len(data) > threshold {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return "", fmt.Errorf("gzip %s: %w", path, err)
+		}
+		if err := zw.Close(); err != nil {
+			return "", fmt.Errorf("gzip %s: %w", path, err)
+		}
+		data = buf.Bytes()
+		target, stale = stale, target
+	}
+
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Remove(stale); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return target, fmt.Errorf("remove stale %s: %w", stale, err)
+	}
+	return target, nil
+}
+
+// Open 打开报告：path 存在时直接读取（.gz 结尾则解压），否则尝试 path+".gz"
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && !strings.HasSuffix(path, GzipExt) {
+		path += GzipExt
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, GzipExt) {
+		return f, nil
+	}
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("gunzip %s: %w", path, err)
+	}
+	return &gzipFile{Reader: zr, file: f}, nil
+}
+
+// ReadFile 读取完整报告（自动处理 .gz）
+func ReadFile(path string) ([]byte, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// gzipFile 关闭时同时关闭解压器和底层文件
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close 关闭解压器和文件
+func (g *gzipFile) Close() error {
+	return errors.Join(g.Reader.Close(), g.file.Close())
+}
@@ -0,0 +1,81 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestWriteFileGzipOverThreshold 验证：超过阈值的报告保存为 .gz，旧的未压缩文件被删除；
+// ReadFile 按原路径读取时透明解压，未超过阈值时保存原文件。
+// WHY：大样本集的 Markdown/JSONL 报告达到数十 MB，压缩后读取方不应感知保存形式。
+func TestWriteFileGzipOverThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.md")
+	small := []byte("# report\n")
+	large := bytes.Repeat([]byte("| 1 | a.wav | ok |\n"), 100)
+
+	got, err := WriteFile(path, small, len(large)-1)
+	if err != nil || got != path {
+		t.Fatalf("small report written to %s (%v), want %s", got, err, path)
+	}
+
+	got, err = WriteFile(path, large, len(large)-1)
+	if err != nil || got != path+GzipExt {
+		t.Fatalf("large report written to %s (%v), want %s.gz", got, err, path)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("stale uncompressed report still present: %v", err)
+	}
+	if info, _ := os.Stat(got); info.Size() >= int64(len(large)) {
+		t.Fatalf("gzip file %d bytes, not smaller than %d", info.Size(), len(large))
+	}
+
+	data, err := ReadFile(path)
+	if err != nil || !bytes.Equal(data, large) {
+		t.Fatalf("ReadFile = %d bytes (%v), want original %d bytes", len(data), err, len(large))
+	}
+}
+
+// TestLockExclusiveAndStale 验证：锁被持有时第二次 Lock 等到 ctx 超时失败，释放后可再次获取；
+// 修改时间超过 LockStaleAfter 的锁文件（持有进程已崩溃）被接管。
+// WHY：多个压测实例共享输出目录时，锁保证报告写入与合并互斥，崩溃实例留下的锁不能永久阻塞。
+func TestLockExclusiveAndStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+	unlock, err := Lock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if _, err := Lock(ctx, path); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Lock = %v, want deadline exceeded", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	unlock, err = Lock(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Lock after unlock: %v", err)
+	}
+
+	// 模拟崩溃：不释放，把锁文件改旧
+	old := time.Now().Add(-2 * LockStaleAfter)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err = Lock(ctx, path)
+	if err != nil {
+		t.Fatalf("Lock over stale lock: %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package preset 命名的提供商预设（如 "fast-tts"、"accurate-stt"）
+//
+// 预设将逻辑名称映射到 provider + 音色/语言 + 合成/识别参数，业务代码只引用名称，
+// 切换提供商时修改配置文件即可：
+//
+//	{
+//	  "fast-tts":     {"kind": "tts", "provider": "qwen", "voice": "Cherry", "sample_rate": 8000},
+//	  "accurate-stt": {"kind": "stt", "provider": "azure", "language": "en-NG"}
+//	}
+//
+// 通过 tts.NewClientFromPreset / stt.NewClientFromPreset 按名称创建客户端。
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Kind 预设适用的服务类型
+type Kind string
+
+const (
+	KindTTS Kind = "tts"
+	KindSTT Kind = "stt"
+)
+
+// ErrNotFound 预设不存在
+var ErrNotFound = errors.New("preset not found")
+
+// Preset 提供商预设（零值字段表示沿用基础配置）
+type Preset struct {
+	Kind        Kind    `json:"kind,omitempty"` // tts / stt（空表示两者通用）
+	Provider    string  `json:"provider"`
+	Voice       string  `json:"voice,omitempty"`    // TTS 音色ID
+	Language    string  `json:"language,omitempty"` // 语言代码
+	Speed       float64 `json:"speed,omitempty"`    // TTS 语速
+	Pitch       float64 `json:"pitch,omitempty"`    // TTS 音调
+	Volume      float64 `json:"volume,omitempty"`   // TTS 音量
+	SampleRate  int     `json:"sample_rate,omitempty"`
+	AudioFormat string  `json:"audio_format,omitempty"`
+}
+
+// Registry 预设注册表（并发安全）
+type Registry struct {
+	mu      sync.RWMutex
+	presets map[string]Preset
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{presets: make(map[string]Preset)}
+}
+
+// Register 注册或覆盖预设
+func (r *Registry) Register(name string, p Preset) error {
+	if name == "" {
+		return fmt.Errorf("preset name is required")
+	}
+	if p.Provider == "" {
+		return fmt.Errorf("preset %q: provider is required", name)
+	}
+	if p.Kind != "" && p.Kind != KindTTS && p.Kind != KindSTT {
+		return fmt.Errorf("preset %q: unknown kind %q", name, p.Kind)
+	}
+	r.mu.Lock()
+	r.presets[name] = p
+	r.mu.Unlock()
+	return nil
+}
+
+// Get 按名称查找预设；kind 非空时校验预设适用于该服务类型
+func (r *Registry) Get(name string, kind Kind) (Preset, error) {
+	r.mu.RLock()
+	p, ok := r.presets[name]
+	r.mu.RUnlock()
+	if !ok {
+		return Preset{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	if kind != "" && p.Kind != "" && p.Kind != kind {
+		return Preset{}, fmt.Errorf("preset %q is for %s, not %s", name, p.Kind, kind)
+	}
+	return p, nil
+}
+
+// Names 返回已注册的预设名称（按字母序）
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.presets))
+	for name := range r.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load 从 JSON（名称 → 预设）加载预设，已存在的同名预设被覆盖
+func (r *Registry) Load(reader io.Reader) error {
+	var presets map[string]Preset
+	if err := json.NewDecoder(reader).Decode(&presets); err != nil {
+		return fmt.Errorf("parse presets: %w", err)
+	}
+	// 先全部校验再注册，避免配置错误时只加载一半
+	check := NewRegistry()
+	for name, p := range presets {
+		if err := check.Register(name, p); err != nil {
+			return err
+		}
+	}
+	for name, p := range presets {
+		r.Register(name, p)
+	}
+	return nil
+}
+
+// LoadFile 从 JSON 文件加载预设
+func (r *Registry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open presets: %w", err)
+	}
+	defer f.Close()
+	if err := r.Load(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Default 默认注册表（tts.NewClientFromPreset / stt.NewClientFromPreset 使用）
+var Default = NewRegistry()
+
+// Register 在默认注册表中注册预设
+func Register(name string, p Preset) error {
+	return Default.Register(name, p)
+}
+
+// Get 在默认注册表中查找预设
+func Get(name string, kind Kind) (Preset, error) {
+	return Default.Get(name, kind)
+}
+
+// LoadFile 从 JSON 文件加载预设到默认注册表
+func LoadFile(path string) error {
+	return Default.LoadFile(path)
+}
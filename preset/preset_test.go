@@ -0,0 +1,40 @@
+package preset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestLoadAndGet 验证：从 JSON 加载预设后按名称查找，kind 不匹配或名称不存在时报错；
+// 配置中有非法预设时整体不加载。
+// WHY：产品代码中散落的 provider 字符串难以统一切换，命名预设需要在启动时就暴露配置错误。
+func TestLoadAndGet(t *testing.T) {
+	r := NewRegistry()
+	err := r.Load(strings.NewReader(`{
+		"fast-tts": {"kind": "tts", "provider": "qwen", "voice": "Cherry", "sample_rate": 8000},
+		"accurate-stt": {"kind": "stt", "provider": "azure", "language": "en-NG"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := r.Get("fast-tts", KindTTS)
+	if err != nil || p.Provider != "qwen" || p.Voice != "Cherry" || p.SampleRate != 8000 {
+		t.Fatalf("Get(fast-tts) = %+v, %v", p, err)
+	}
+	if _, err := r.Get("accurate-stt", KindTTS); err == nil {
+		t.Fatal("expected error using stt preset for tts")
+	}
+	if _, err := r.Get("missing", ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) = %v, want ErrNotFound", err)
+	}
+
+	err = r.Load(strings.NewReader(`{"ok": {"provider": "qwen"}, "bad": {"voice": "x"}}`))
+	if err == nil || !strings.Contains(err.Error(), "provider is required") {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	if names := r.Names(); len(names) != 2 {
+		t.Fatalf("Names() = %v, want only the first two presets", names)
+	}
+}
@@ -0,0 +1,173 @@
+// Package prompt IVR 提示语编排：文本、静音、音频文件、提示音按序组合为一个连续的音频流
+//
+// 文本由 TTS 会话合成，其余元素在本地生成或读取，统一转换为会话的输出格式后拼接
+// （基于 tts.Session.SynthesizeScript，输出须为 PCM）：
+//
+//	p := prompt.New().
+//		Text("您好，欢迎致电。").
+//		Silence(300 * time.Millisecond).
+//		File("menu.wav").
+//		Tone(1000, 200*time.Millisecond)
+//	err := p.WriteFile(ctx, session, "welcome.wav")
+//
+// Prompt 构建后可重复使用；音频文件在 File 时读入内存。
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
+)
+
+// Prompt 按序组合的提示语元素（构建方法不是并发安全的）
+type Prompt struct {
+	lines []tts.Line
+	err   error // 构建过程中的第一个错误，Stream / WriteFile 时返回
+}
+
+// New 创建空的提示语
+func New() *Prompt {
+	return &Prompt{}
+}
+
+// Text 追加一段文本，使用会话的默认音色合成（空文本忽略）
+func (p *Prompt) Text(text string) *Prompt {
+	return p.Say("", text)
+}
+
+// Say 追加一段文本，使用指定音色合成
+func (p *Prompt) Say(voice, text string) *Prompt {
+	if text != "" {
+		p.lines = append(p.lines, tts.Line{Voice: voice, Text: text})
+	}
+	return p
+}
+
+// Silence 追加 d 时长的静音
+func (p *Prompt) Silence(d time.Duration) *Prompt {
+	if d <= 0 {
+		return p.fail(fmt.Errorf("element %d: silence duration must be positive", len(p.lines)+1))
+	}
+	p.lines = append(p.lines, tts.Line{Duration: d})
+	return p
+}
+
+// Tone 追加 d 时长、频率 freq Hz 的提示音
+func (p *Prompt) Tone(freq float64, d time.Duration) *Prompt {
+	if freq <= 0 || d <= 0 {
+		return p.fail(fmt.Errorf("element %d: tone frequency and duration must be positive", len(p.lines)+1))
+	}
+	p.lines = append(p.lines, tts.Line{Duration: d, Tone: freq})
+	return p
+}
+
+// File 追加预录音频文件：单声道 WAV（16-bit 或 32-bit 浮点，任意采样率），或与会话输出格式相同的裸 PCM（.pcm / .raw）
+func (p *Prompt) File(path string) *Prompt {
+	pcm, sampleRate, err := readFile(path)
+	if err != nil {
+		return p.fail(fmt.Errorf("element %d: %w", len(p.lines)+1, err))
+	}
+	return p.Audio(pcm, sampleRate)
+}
+
+// Audio 追加内存中的预录音频（16-bit 单声道 PCM，sampleRate 为 0 表示与会话输出相同）
+func (p *Prompt) Audio(pcm []byte, sampleRate int) *Prompt {
+	if len(pcm) < 2 {
+		return p.fail(fmt.Errorf("element %d: audio is empty", len(p.lines)+1))
+	}
+	p.lines = append(p.lines, tts.Line{Audio: pcm, AudioSampleRate: sampleRate})
+	return p
+}
+
+// fail 记录第一个构建错误
+func (p *Prompt) fail(err error) *Prompt {
+	if p.err == nil {
+		p.err = err
+	}
+	return p
+}
+
+// Err 返回构建过程中的第一个错误
+func (p *Prompt) Err() error {
+	return p.err
+}
+
+// Lines 返回对应的剧本（可直接传给 tts.Session.SynthesizeScript）
+func (p *Prompt) Lines() []tts.Line {
+	return append([]tts.Line(nil), p.lines...)
+}
+
+// Stream 合成提示语，返回拼接后的音频流；Offsets 的 Line 对应元素下标
+func (p *Prompt) Stream(ctx context.Context, session *tts.Session) (*tts.ScriptStream, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(p.lines) == 0 {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+	stream, err := session.SynthesizeScript(ctx, p.Lines())
+	if err != nil {
+		return nil, fmt.Errorf("synthesize prompt: %w", err)
+	}
+	return stream, nil
+}
+
+// Bytes 合成提示语并读取全部音频（会话输出格式的 PCM）
+func (p *Prompt) Bytes(ctx context.Context, session *tts.Session) ([]byte, int, error) {
+	stream, err := p.Stream(ctx, session)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stream.Close()
+	pcm, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read prompt audio: %w", err)
+	}
+	return pcm, stream.SampleRate(), nil
+}
+
+// WriteFile 合成提示语并写入文件（按扩展名写 WAV 或裸 PCM）
+func (p *Prompt) WriteFile(ctx context.Context, session *tts.Session, path string) error {
+	pcm, sampleRate, err := p.Bytes(ctx, session)
+	if err != nil {
+		return err
+	}
+	return audio.WriteAudioFile(path, pcm, sampleRate, 1, 16)
+}
+
+// readFile 读取音频文件为 16-bit 单声道 PCM，返回其采样率（裸 PCM 为 0）
+func readFile(path string) ([]byte, int, error) {
+	switch audio.DetectFormat(path) {
+	case audio.FormatWAV:
+		pcm, header, err := audio.ReadWAVFile(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("read %s: %w", path, err)
+		}
+		if err := audio.CheckWAVFormat(header); err != nil {
+			return nil, 0, fmt.Errorf("read %s: %w", path, err)
+		}
+		if header.NumChannels != 1 {
+			return nil, 0, fmt.Errorf("read %s: %d channels, mono required", path, header.NumChannels)
+		}
+		switch {
+		case header.AudioFormat == audio.WAVFormatFloat:
+			pcm = audio.FloatPCMToPCM16(pcm)
+		case header.BitsPerSample != 16:
+			return nil, 0, fmt.Errorf("read %s: %d-bit PCM, 16-bit required", path, header.BitsPerSample)
+		}
+		return pcm, int(header.SampleRate), nil
+	case audio.FormatPCM:
+		pcm, err := os.ReadFile(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("read %s: %w", path, err)
+		}
+		return pcm, 0, nil
+	default:
+		return nil, 0, fmt.Errorf("read %s: unsupported format", path)
+	}
+}
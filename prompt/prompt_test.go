@@ -0,0 +1,95 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
+)
+
+// TestWriteFile 验证：文本、静音、音频文件（不同采样率）、提示音按序合成为一个 WAV 文件，
+// 文件按会话输出格式重采样，各元素时长与位置正确。
+// WHY：各 IVR 团队在 SDK 之上反复实现同样的拼接与格式转换，采样率不一致时拼出的音频会变调。
+func TestWriteFile(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			roundID, _ := msg["round_id"].(string)
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeSessionConfig:
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+			case protocol.MessageTypeInputCommit:
+				ws.WriteJSON(protocol.AudioDelta{Type: protocol.MessageTypeAudioDelta, RoundID: roundID,
+					Audio: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 320))})
+				ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	menu := filepath.Join(dir, "menu.wav")
+	// 16kHz 100ms，会话输出为 8kHz
+	if err := audio.WriteWAVFile(menu, audio.Tone(16000, 440, 100*time.Millisecond, 0.5), 16000, 1, 16); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := tts.NewClient(&tts.Config{GatewayURL: "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Provider: "tengen", VoiceID: "A", AudioFormat: "pcm", SampleRate: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	p := New().Text("欢迎致电").Silence(50*time.Millisecond).File(menu).Tone(1000, 50*time.Millisecond)
+	out := filepath.Join(dir, "welcome.wav")
+	if err := p.WriteFile(ctx, session, out); err != nil {
+		t.Fatal(err)
+	}
+
+	pcm, header, err := audio.ReadWAVFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 8kHz 16-bit：合成 320 + 静音 800 + 文件 1600 + 提示音 800 字节
+	if header.SampleRate != 8000 || len(pcm) != 320+800+1600+800 {
+		t.Fatalf("output = %d Hz, %d bytes; want 8000 Hz, %d bytes", header.SampleRate, len(pcm), 320+800+1600+800)
+	}
+	if !bytes.Equal(pcm[320:1120], make([]byte, 800)) {
+		t.Fatal("silence element is not silent")
+	}
+
+	if err := New().Text("你好").File(filepath.Join(dir, "missing.wav")).Err(); err == nil || !strings.Contains(err.Error(), "element 2") {
+		t.Fatalf("missing file error = %v, want element 2", err)
+	}
+}
@@ -13,11 +13,14 @@ const (
 	MessageTypeSessionReady      MessageType = "session.ready"
 	MessageTypeSessionConfig     MessageType = "session.config"
 	MessageTypeSessionConfigDone MessageType = "session.config_done"
+	MessageTypeSessionUpdate     MessageType = "session.update" // TTS: 轮次间更新合成参数（无需重连）
+	MessageTypeSessionResume     MessageType = "session.resume" // 重连后凭 resume_token 接回 gateway 侧的会话（替代 session.config）
 
 	// 阶段 2: 客户端输入
 	MessageTypeAudioAppend MessageType = "audio.append"
 	MessageTypeTextAppend  MessageType = "text.append"
-	MessageTypeInputCommit MessageType = "input.commit" // TTS 触发合成
+	MessageTypeInputCommit MessageType = "input.commit" // TTS 触发合成；STT 立即结束当前语句（输出 transcript.final，会话继续）
+	MessageTypeInputCancel MessageType = "input.cancel" // TTS 取消轮次（服务端停止合成并回复该轮 audio.done）
 
 	// 阶段 3: 服务端处理与输出
 	MessageTypeSpeechStarted     MessageType = "speech.started"
@@ -25,6 +28,7 @@ const (
 	MessageTypeTranscriptFinal   MessageType = "transcript.final"
 	MessageTypeAudioDelta        MessageType = "audio.delta"
 	MessageTypeAudioDone         MessageType = "audio.done"
+	MessageTypeEndOfUtterance    MessageType = "utterance.end" // STT: 检测到一句话说完（早于该句 transcript.final）
 
 	// 阶段 4: 会话结束
 	MessageTypeSessionEnd   MessageType = "session.end"   // TTS: 关闭会话；STT: 音频发完，请完成识别
@@ -40,11 +44,68 @@ type SessionParams struct {
 	Language    string `json:"language,omitempty"`     // zh-CN, en-US
 	SampleRate  int    `json:"sample_rate,omitempty"`  // 默认 8000
 	AudioFormat string `json:"audio_format,omitempty"` // pcm, wav, mp3
+	// 原始 PCM 格式（STT 输入；未设置时 gateway 按单声道 16-bit 处理）
+	Channels      int `json:"channels,omitempty"`
+	BitsPerSample int `json:"bits_per_sample,omitempty"`
+	// STT 短语提示（自定义词汇）：领域术语、产品名、人名等，提高识别准确率
+	PhraseHints []PhraseHint `json:"phrase_hints,omitempty"`
+	// STT 输出格式：标点、逆文本规范化（ITN，日期/金额等转写为数字形式）；nil 使用提供商默认值
+	EnablePunctuation *bool `json:"enable_punctuation,omitempty"`
+	EnableITN         *bool `json:"enable_itn,omitempty"`
+	// STT N-best：transcript.final 最多返回的候选数（0 / 1 只返回最佳结果）
+	MaxAlternatives int `json:"max_alternatives,omitempty"`
+	// STT 不雅词过滤：raw / masked / removed，空使用提供商默认值
+	ProfanityMode ProfanityMode `json:"profanity_mode,omitempty"`
+	// STT 语句结束检测（utterance.end）；nil 不发送该事件
+	Endpointing *Endpointing `json:"endpointing,omitempty"`
 	// TTS 特有参数
 	VoiceID string  `json:"voice_id,omitempty"`
 	Speed   float64 `json:"speed,omitempty"`
 	Pitch   float64 `json:"pitch,omitempty"`
 	Volume  float64 `json:"volume,omitempty"`
+	// 调度优先级提示：realtime（交互式语音）/ bulk（批量任务），空由 gateway 决定
+	Priority Priority `json:"priority,omitempty"`
+	// 会话亲和令牌：回传之前 config_done 返回的 affinity_token，gateway 尽量路由到同一后端（复用热缓存）
+	AffinityToken string `json:"affinity_token,omitempty"`
+}
+
+// PhraseHint STT 短语提示
+type PhraseHint struct {
+	Phrase string  `json:"phrase"`
+	Boost  float64 `json:"boost,omitempty"` // 增强权重（0 使用提供商默认值）
+}
+
+// Priority 会话调度优先级（软实时提示，gateway 可优先调度 realtime 会话）
+type Priority string
+
+const (
+	PriorityRealtime Priority = "realtime"
+	PriorityBulk     Priority = "bulk"
+)
+
+// Valid 判断优先级取值是否合法（空值合法）
+func (p Priority) Valid() bool {
+	return p == "" || p == PriorityRealtime || p == PriorityBulk
+}
+
+// Endpointing STT 语句结束检测阈值
+type Endpointing struct {
+	SilenceMs    int `json:"silence_ms,omitempty"`     // 语义完整时，静音达到此时长即判定一句话说完（0 使用提供商默认值）
+	MaxSilenceMs int `json:"max_silence_ms,omitempty"` // 不论语义是否完整，静音达到此时长即判定说完（0 使用提供商默认值）
+}
+
+// ProfanityMode STT 不雅词处理方式
+type ProfanityMode string
+
+const (
+	ProfanityRaw     ProfanityMode = "raw"     // 原样输出
+	ProfanityMasked  ProfanityMode = "masked"  // 替换为星号（保留首字母，如 "f***"）
+	ProfanityRemoved ProfanityMode = "removed" // 从文本中删除
+)
+
+// Valid 判断不雅词处理方式取值是否合法（空值合法）
+func (m ProfanityMode) Valid() bool {
+	return m == "" || m == ProfanityRaw || m == ProfanityMasked || m == ProfanityRemoved
 }
 
 // ──────────────────────────────────────────────
@@ -64,17 +125,70 @@ type Message struct {
 type SessionReady struct {
 	Type      MessageType `json:"type"`
 	SessionID string      `json:"session_id"`
+	// 会话恢复令牌：连接中断后在新连接上以 session.resume 携带，接回本会话在 gateway 侧的状态（不支持恢复的 gateway 不返回）
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// 功能标志：客户端在 client_info.features 中声明，服务端在 session.config_done.features 中确认
+const (
+	FeatureBinaryAudio    = "binary_audio"    // 音频以 WebSocket 二进制帧传输（替代 base64）
+	FeatureWordTimestamps = "word_timestamps" // 逐词时间戳
+)
+
+// Features 功能标志集合（名称 → 是否启用）
+type Features map[string]bool
+
+// Enabled 判断功能是否启用
+func (f Features) Enabled(name string) bool {
+	return f[name]
+}
+
+// ClientInfo 客户端 SDK 信息（随 session.config 上报，供服务端统计版本分布、按版本开放功能）
+type ClientInfo struct {
+	SDK       string   `json:"sdk"`
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit,omitempty"`
+	BuildDate string   `json:"build_date,omitempty"`
+	GoVersion string   `json:"go_version,omitempty"`
+	Platform  string   `json:"platform,omitempty"` // GOOS/GOARCH
+	Features  Features `json:"features,omitempty"` // 客户端声明支持的功能
+	Codecs    []string `json:"codecs,omitempty"`   // 客户端支持的 JSON 以外的消息编码（按偏好排序，如 "msgpack"）
 }
 
 // SessionConfig 会话配置消息（C→S）
 type SessionConfig struct {
+	Type       MessageType   `json:"type"`
+	Session    SessionParams `json:"session"`
+	ClientInfo *ClientInfo   `json:"client_info,omitempty"`
+}
+
+// SessionUpdate 会话参数更新消息（C→S，TTS）
+// 仅携带需要变更的字段，作用于其后提交的轮次；已排队的轮次不受影响
+type SessionUpdate struct {
 	Type    MessageType   `json:"type"`
 	Session SessionParams `json:"session"`
 }
 
 // SessionConfigDone 会话配置完成消息（S→C）
 type SessionConfigDone struct {
-	Type MessageType `json:"type"`
+	Type     MessageType `json:"type"`
+	Features Features    `json:"features,omitempty"` // 服务端确认启用的功能（旧版 gateway 不返回）
+	// 会话亲和令牌：标识处理本会话的后端，后续会话携带它以路由到同一后端（不支持亲和的 gateway 不返回）
+	AffinityToken string `json:"affinity_token,omitempty"`
+	// 此后消息使用的编码（从 client_info.codecs 中选定；空为 JSON，旧版 gateway 不返回）
+	Codec string `json:"codec,omitempty"`
+	// 回复 session.resume 时为 true：会话状态已接回，配置沿用中断前的值
+	Resumed bool `json:"resumed,omitempty"`
+	// STT 恢复时 gateway 已收到的音频时长（自该 gateway 会话开始），客户端从此处继续上传
+	ReceivedMs int64 `json:"received_ms,omitempty"`
+}
+
+// SessionResume 会话恢复消息（C→S，session.ready 之后代替 session.config 发送）
+// gateway 接回令牌对应的会话时回复 resumed 的 session.config_done；令牌未知或已过期时回复
+// RESUME_FAILED 错误，连接保持等待 session.config 的状态
+type SessionResume struct {
+	Type        MessageType `json:"type"`
+	ResumeToken string      `json:"resume_token"`
 }
 
 // ──────────────────────────────────────────────
@@ -89,13 +203,25 @@ type AudioAppend struct {
 
 // TextAppend 文本数据消息（C→S，TTS）
 type TextAppend struct {
-	Type MessageType `json:"type"`
-	Text string      `json:"text"`
+	Type    MessageType `json:"type"`
+	Text    string      `json:"text"`
+	RoundID string      `json:"round_id,omitempty"` // 合成轮次ID，服务端在 audio.delta/audio.done 中回显
+	// ResumeOffsetMs 断线续传提示：客户端已收到本轮前 N 毫秒音频，服务端可从该位置继续输出
+	ResumeOffsetMs int64 `json:"resume_offset_ms,omitempty"`
 }
 
-// InputCommit 输入提交消息（C→S，TTS 专用）
+// InputCommit 输入提交消息（C→S；TTS 触发合成，STT 立即结束当前语句）
 type InputCommit struct {
-	Type MessageType `json:"type"`
+	Type      MessageType `json:"type"`
+	RoundID   string      `json:"round_id,omitempty"`
+	RequestID string      `json:"request_id,omitempty"` // 幂等键：同一键的重试不重复计费、不重复缓存
+}
+
+// InputCancel 取消轮次消息（C→S，TTS 专用）
+// 服务端停止该轮合成并回复该轮 audio.done；不支持取消的旧版服务端会照常发完剩余音频
+type InputCancel struct {
+	Type    MessageType `json:"type"`
+	RoundID string      `json:"round_id,omitempty"`
 }
 
 // ──────────────────────────────────────────────
@@ -119,17 +245,45 @@ type TranscriptFinal struct {
 	Text      string      `json:"text"`
 	StartTime int64       `json:"start_time,omitempty"` // 毫秒
 	EndTime   int64       `json:"end_time,omitempty"`
+	// N-best 候选（请求 max_alternatives 且提供商支持时返回，按置信度从高到低）
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+}
+
+// Alternative 识别候选
+type Alternative struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"` // 0-1
+}
+
+// EndOfUtterance 检测到一句话说完（S→C，STT）：语义完整且静音达到 silence_ms，或静音达到 max_silence_ms
+type EndOfUtterance struct {
+	Type    MessageType `json:"type"`
+	Text    string      `json:"text,omitempty"`     // 该句目前的识别文本（之后的 transcript.final 可能修正）
+	EndTime int64       `json:"end_time,omitempty"` // 语音结束位置（毫秒）
+	Reason  string      `json:"reason,omitempty"`   // semantic（语义完整）/ silence（静音达到上限）
 }
 
 // AudioDelta 音频数据块（S→C，TTS）
 type AudioDelta struct {
-	Type  MessageType `json:"type"`
-	Audio string      `json:"audio"` // base64 编码的音频数据
+	Type    MessageType `json:"type"`
+	Audio   string      `json:"audio"`              // base64 编码的音频数据
+	RoundID string      `json:"round_id,omitempty"` // 回显的合成轮次ID（旧版服务端不带，按 FIFO 归属）
+	// OffsetMs 本块音频在本轮中的起始位置（毫秒）；服务端采纳 resume_offset_ms 时回显，未回显视为 0
+	OffsetMs int64 `json:"offset_ms,omitempty"`
 }
 
 // AudioDone 音频完成消息（S→C，TTS）
 type AudioDone struct {
-	Type MessageType `json:"type"`
+	Type    MessageType `json:"type"`
+	RoundID string      `json:"round_id,omitempty"`
+	Usage   *Usage      `json:"usage,omitempty"` // 本轮用量（gateway 支持时返回）
+}
+
+// Usage 合成用量与计费元数据
+type Usage struct {
+	Characters int    `json:"characters,omitempty"` // 计费字符数
+	RequestID  string `json:"request_id,omitempty"` // 提供商请求ID（用于与提供商账单对账）
+	Model      string `json:"model,omitempty"`      // 提供商模型
 }
 
 // ──────────────────────────────────────────────
@@ -155,6 +309,7 @@ type ErrorMessage struct {
 	Type    MessageType `json:"type"`
 	Code    string      `json:"code"`
 	Message string      `json:"message"`
+	RoundID string      `json:"round_id,omitempty"` // TTS: 出错的合成轮次（为空表示队列头部轮次）
 }
 
 // 错误代码
@@ -168,6 +323,7 @@ const (
 	ErrorCodeUnsupported        = "UNSUPPORTED"
 	ErrorCodeServiceUnavailable = "SERVICE_UNAVAILABLE" // 服务不可用（内部配置问题）
 	ErrorCodeVoiceNotFound      = "VOICE_NOT_FOUND"     // 音色不存在
+	ErrorCodeResumeFailed       = "RESUME_FAILED"       // session.resume 的令牌未知或已过期（客户端改发 session.config）
 )
 
 // ──────────────────────────────────────────────
@@ -0,0 +1,111 @@
+// Package speech 语音机器人最小循环：STT 的定稿文本经变换后送入 TTS 会话合成
+//
+//	replies := speech.Pipe(ctx, sttSession.Events(), ttsSession, func(ctx context.Context, text string) (string, error) {
+//		return bot.Answer(ctx, text)
+//	})
+//	for reply := range replies {
+//		if reply.Err != nil {
+//			log.Print(reply.Err)
+//			continue
+//		}
+//		player.Play(reply.Stream) // 按提交顺序播放每轮回复
+//	}
+//
+// 每个 transcript.final 提交为 TTS 会话的一轮合成，回复按识别顺序送出；播放跟不上时管道暂停读取识别事件，
+// 积压在 STT 会话的事件缓冲区中。
+package speech
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/stt"
+	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
+)
+
+// replyBuffer Pipe 返回通道的容量（已提交合成、等待调用方取走的回复数）
+const replyBuffer = 8
+
+// Transform 把一句识别结果变为要合成的回复文本（如调用对话机器人）；返回空文本时跳过该句
+type Transform func(ctx context.Context, text string) (string, error)
+
+// Reply 一轮回复
+type Reply struct {
+	Input  string           // 触发本轮的 transcript.final 文本
+	Text   string           // Transform 后提交合成的文本
+	Stream *tts.AudioStream // 本轮音频（读完或 Close 后释放）
+	Err    error            // STT 错误事件、Transform 或提交合成失败；此时 Stream 为 nil
+}
+
+// Pipe 在后台读取 events：每个 transcript.final 经 transform（nil 为原样回声）后在 session 上提交一轮合成，
+// 按识别顺序从返回的通道送出；events 关闭或 ctx 取消后关闭通道（不关闭 session）
+//
+// STT 错误事件、Transform 与提交合成的错误以 Reply.Err 送出，管道继续处理后续语句。
+func Pipe(ctx context.Context, events <-chan *stt.RecognitionEvent, session *tts.Session, transform Transform) <-chan Reply {
+	replies := make(chan Reply, replyBuffer)
+	go func() {
+		defer close(replies)
+		for {
+			var event *stt.RecognitionEvent
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				event = e
+			}
+
+			reply, ok := respond(ctx, event, session, transform)
+			if !ok {
+				continue
+			}
+			select {
+			case replies <- reply:
+			case <-ctx.Done():
+				if reply.Stream != nil {
+					reply.Stream.Close()
+				}
+				return
+			}
+		}
+	}()
+	return replies
+}
+
+// Echo 原样回声：把识别出的每句话合成回去（验证 STT → TTS 链路、听写回读）
+func Echo(ctx context.Context, events <-chan *stt.RecognitionEvent, session *tts.Session) <-chan Reply {
+	return Pipe(ctx, events, session, nil)
+}
+
+// respond 处理一个识别事件；不产生回复（非定稿事件、空文本）时返回 false
+func respond(ctx context.Context, event *stt.RecognitionEvent, session *tts.Session, transform Transform) (Reply, bool) {
+	switch {
+	case event.IsError():
+		return Reply{Err: fmt.Errorf("stt: %w", event.Error)}, true
+	case !event.IsTranscriptFinal() || event.Text == "":
+		return Reply{}, false
+	}
+
+	reply := Reply{Input: event.Text, Text: event.Text}
+	if transform != nil {
+		text, err := transform(ctx, event.Text)
+		if err != nil {
+			reply.Err = fmt.Errorf("transform %q: %w", event.Text, err)
+			return reply, true
+		}
+		reply.Text = text
+	}
+	if reply.Text == "" {
+		return Reply{}, false
+	}
+
+	stream, err := session.SynthesizeStream(ctx, reply.Text)
+	if err != nil {
+		reply.Err = fmt.Errorf("synthesize: %w", err)
+		return reply, true
+	}
+	reply.Stream = stream
+	return reply, true
+}
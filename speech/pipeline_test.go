@@ -0,0 +1,102 @@
+package speech
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/stt"
+	"github.com/jinbozhan/tengen-speech-sdk-go/tts"
+)
+
+// TestPipe 验证：transcript.final 经 Transform 后按识别顺序提交合成，partial 与空回复被跳过，Transform 错误以 Reply.Err 送出。
+// WHY：语音机器人把回复与触发它的那句话对应起来播放，顺序错乱或一次错误中断整个循环都会让对话失控。
+func TestPipe(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		texts := map[string]string{}
+		for {
+			var msg protocol.TextAppend
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch msg.Type {
+			case protocol.MessageTypeSessionConfig:
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+			case protocol.MessageTypeTextAppend:
+				texts[msg.RoundID] += msg.Text
+			case protocol.MessageTypeInputCommit:
+				// 音频内容为合成的文本本身，便于核对每轮对应哪句回复
+				ws.WriteJSON(protocol.AudioDelta{Type: protocol.MessageTypeAudioDelta, RoundID: msg.RoundID,
+					Audio: base64Text(texts[msg.RoundID])})
+				ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: msg.RoundID})
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client, err := tts.NewClient(&tts.Config{GatewayURL: "ws" + strings.TrimPrefix(srv.URL, "http"), Provider: "tengen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	events := make(chan *stt.RecognitionEvent, 8)
+	events <- stt.NewTranscriptPartialEvent("hel")
+	events <- stt.NewTranscriptFinalEvent("hello", 0, 0)
+	events <- stt.NewTranscriptFinalEvent("skip me", 0, 0)
+	events <- stt.NewTranscriptFinalEvent("fail", 0, 0)
+	events <- stt.NewTranscriptFinalEvent("bye", 0, 0)
+	close(events)
+
+	replies := Pipe(ctx, events, session, func(_ context.Context, text string) (string, error) {
+		switch text {
+		case "skip me":
+			return "", nil
+		case "fail":
+			return "", context.DeadlineExceeded
+		}
+		return "re: " + text, nil
+	})
+	var got []string
+	for reply := range replies {
+		if reply.Err != nil {
+			got = append(got, "error "+reply.Input)
+			continue
+		}
+		audio, err := reply.Stream.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, reply.Input+" -> "+string(audio))
+	}
+	want := "hello -> re: hello|error fail|bye -> re: bye"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("replies = %q, want %q", strings.Join(got, "|"), want)
+	}
+}
+
+// base64Text 把文本编码为 audio.delta 的音频字段
+func base64Text(text string) string {
+	return base64.StdEncoding.EncodeToString([]byte(text))
+}
@@ -0,0 +1,189 @@
+// Package stt 录音归档：可插拔音频编码与保留策略
+package stt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+)
+
+// AudioEncoder 归档音频编码器：把会话格式的 PCM 编码后写入 w
+type AudioEncoder interface {
+	Encode(ctx context.Context, w io.Writer, pcm []byte, opts *StreamOptions) error
+	Ext() string // 归档文件扩展名（含点，如 ".wav"）
+}
+
+// WAVEncoder 按会话格式写为 WAV（默认编码器，见 PCMToWAV）
+type WAVEncoder struct{}
+
+// Encode 实现 AudioEncoder 接口
+func (WAVEncoder) Encode(_ context.Context, w io.Writer, pcm []byte, opts *StreamOptions) error {
+	wav, err := PCMToWAV(pcm, opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(wav)
+	return err
+}
+
+// Ext 实现 AudioEncoder 接口
+func (WAVEncoder) Ext() string { return ".wav" }
+
+// encoderStderrLimit 保留的编码程序错误输出长度
+const encoderStderrLimit = 4096
+
+// CommandEncoder 通过外部程序编码：标准输入写入 WAV（见 PCMToWAV），标准输出即归档内容，不引入 cgo 依赖
+type CommandEncoder struct {
+	Path      string   // 程序名或路径（按 PATH 查找）
+	Args      []string // 命令行参数
+	Extension string   // 归档文件扩展名（含点）
+}
+
+// OpusEncoder 用 ffmpeg（libopus）编码为 Ogg Opus；bitrate 为比特率（bit/s，<= 0 时为 24000，语音归档足够清晰）
+//
+// 16kHz 单声道语音在 24kbps 下约为 WAV 的 1/10，适合长期保存的合规录音。
+func OpusEncoder(bitrate int) *CommandEncoder {
+	if bitrate <= 0 {
+		bitrate = 24000
+	}
+	return &CommandEncoder{
+		Path: "ffmpeg",
+		Args: []string{"-hide_banner", "-loglevel", "error", "-f", "wav", "-i", "pipe:0",
+			"-c:a", "libopus", "-b:a", strconv.Itoa(bitrate), "-application", "voip", "-f", "ogg", "pipe:1"},
+		Extension: ".opus",
+	}
+}
+
+// Encode 实现 AudioEncoder 接口；程序不存在或异常退出时返回带错误输出的错误
+func (e *CommandEncoder) Encode(ctx context.Context, w io.Writer, pcm []byte, opts *StreamOptions) error {
+	wav, err := PCMToWAV(pcm, opts)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, e.Path, e.Args...)
+	cmd.Stdin = bytes.NewReader(wav)
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > encoderStderrLimit {
+			msg = msg[:encoderStderrLimit]
+		}
+		if msg != "" {
+			return fmt.Errorf("encode audio with %s: %w: %s", e.Path, err, msg)
+		}
+		return fmt.Errorf("encode audio with %s: %w", e.Path, err)
+	}
+	return nil
+}
+
+// Ext 实现 AudioEncoder 接口
+func (e *CommandEncoder) Ext() string { return e.Extension }
+
+// RetentionPolicy 归档保留策略（Archive.Sweep 时生效）
+type RetentionPolicy struct {
+	TTL       time.Duration           // 保留期限，按文件修改时间计（0 永久保留）
+	LegalHold func(name string) bool  // 返回 true 的归档处于法律保全，到期也不删除（name 为文件名）
+	OnExpire  func(name string) error // 删除前调用（如同步清理外部索引）；返回错误时保留该文件
+}
+
+// Archive 录音归档目录：按 Encoder 写入会话音频，按 Retention 清理过期归档
+//
+//	archive := &stt.Archive{Dir: "/data/calls", Encoder: stt.OpusEncoder(0), Retention: stt.RetentionPolicy{TTL: 90 * 24 * time.Hour}}
+//	path, err := archive.SaveAudio(ctx, session.ID, pcm, &opts)
+//
+// 事件日志可用 EventLog 写入同一目录（gzip 压缩见 Replay）；Sweep 对目录中的全部归档文件统一应用保留策略。
+type Archive struct {
+	Dir       string
+	Encoder   AudioEncoder // nil 使用 WAVEncoder
+	Retention RetentionPolicy
+	Clock     clock.Clock // nil 使用系统时钟（测试可注入 clock.Fake）
+}
+
+// SaveAudio 把 PCM 编码后写为 Dir 下的 name+扩展名，返回文件路径
+//
+// 先写入临时文件再重命名，编码失败或中途取消不会留下残缺的归档。
+func (a *Archive) SaveAudio(ctx context.Context, name string, pcm []byte, opts *StreamOptions) (string, error) {
+	if name == "" || name != filepath.Base(name) {
+		return "", ErrInvalidConfig(fmt.Sprintf("invalid archive name %q", name))
+	}
+	enc := a.Encoder
+	if enc == nil {
+		enc = WAVEncoder{}
+	}
+	tmp, err := os.CreateTemp(a.Dir, "."+name+"-*")
+	if err != nil {
+		return "", fmt.Errorf("create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := enc.Encode(ctx, tmp, pcm, opts); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("write archive file: %w", err)
+	}
+	path := filepath.Join(a.Dir, name+enc.Ext())
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("write archive file: %w", err)
+	}
+	return path, nil
+}
+
+// Sweep 删除超过 TTL 的归档文件（跳过法律保全与 OnExpire 拒绝的文件、以 . 开头的临时文件），返回删除的文件名
+//
+// 单个文件删除失败不中断清理，错误合并返回。
+func (a *Archive) Sweep() ([]string, error) {
+	ttl := a.Retention.TTL
+	if ttl <= 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(a.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("read archive dir: %w", err)
+	}
+	cutoff := clock.Or(a.Clock).Now().Add(-ttl)
+	var removed []string
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if hold := a.Retention.LegalHold; hold != nil && hold(name) {
+			slog.Debug("Archive on legal hold kept", "component", "stt", "name", name)
+			continue
+		}
+		if onExpire := a.Retention.OnExpire; onExpire != nil {
+			if err := onExpire(name); err != nil {
+				errs = append(errs, fmt.Errorf("expire %s: %w", name, err))
+				continue
+			}
+		}
+		if err := os.Remove(filepath.Join(a.Dir, name)); err != nil {
+			errs = append(errs, fmt.Errorf("remove %s: %w", name, err))
+			continue
+		}
+		removed = append(removed, name)
+	}
+	if len(removed) > 0 {
+		slog.Info("Expired archives removed", "component", "stt", "dir", a.Dir, "count", len(removed))
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+)
+
+// rawEncoder 测试用编码器：原样写出 PCM
+type rawEncoder struct{}
+
+func (rawEncoder) Encode(_ context.Context, w io.Writer, pcm []byte, _ *StreamOptions) error {
+	_, err := w.Write(pcm)
+	return err
+}
+
+func (rawEncoder) Ext() string { return ".raw" }
+
+// TestArchiveRetention 验证：Archive 按配置的 Encoder 写出归档（默认 WAV）；Sweep 只删除超过 TTL 的文件，
+// 跳过法律保全与 OnExpire 拒绝的文件，编码失败不留下残缺文件。
+// WHY：合规录音要按期限清理以控制存储成本，但处于法律保全的录音一旦被误删无法恢复。
+func TestArchiveRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	opts := &StreamOptions{SampleRate: 16000}
+	pcm := make([]byte, 3200)
+
+	wav := &Archive{Dir: dir}
+	for _, name := range []string{"old", "held"} {
+		if _, err := wav.SaveAudio(context.Background(), name, pcm, opts); err != nil {
+			t.Fatalf("SaveAudio(%s): %v", name, err)
+		}
+	}
+	raw := &Archive{Dir: dir, Encoder: rawEncoder{}}
+	path, err := raw.SaveAudio(context.Background(), "veto", pcm, opts)
+	if err != nil || filepath.Ext(path) != ".raw" {
+		t.Fatalf("SaveAudio with custom encoder = %q, %v", path, err)
+	}
+	if _, err := wav.SaveAudio(context.Background(), "fresh", pcm, opts); err != nil {
+		t.Fatalf("SaveAudio(fresh): %v", err)
+	}
+	if _, err := wav.SaveAudio(context.Background(), "bad", pcm[:3], opts); err == nil {
+		t.Fatal("SaveAudio with odd-length PCM succeeded")
+	}
+	if _, err := wav.SaveAudio(context.Background(), "../escape", pcm, opts); err == nil {
+		t.Fatal("SaveAudio accepted a name outside Dir")
+	}
+	for _, name := range []string{"old.wav", "held.wav", "veto.raw"} {
+		old := now.Add(-48 * time.Hour)
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var expired []string
+	archive := &Archive{
+		Dir:   dir,
+		Clock: clock.NewFake(now),
+		Retention: RetentionPolicy{
+			TTL:       24 * time.Hour,
+			LegalHold: func(name string) bool { return name == "held.wav" },
+			OnExpire: func(name string) error {
+				expired = append(expired, name)
+				if name == "veto.raw" {
+					return errors.New("index unavailable")
+				}
+				return nil
+			},
+		},
+	}
+	removed, err := archive.Sweep()
+	if err == nil {
+		t.Fatal("Sweep ignored the OnExpire error")
+	}
+	if !slices.Equal(removed, []string{"old.wav"}) {
+		t.Fatalf("Sweep removed %v, want [old.wav]", removed)
+	}
+	slices.Sort(expired)
+	if !slices.Equal(expired, []string{"old.wav", "veto.raw"}) {
+		t.Fatalf("OnExpire called for %v, want [old.wav veto.raw]", expired)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	if !slices.Equal(left, []string{"fresh.wav", "held.wav", "veto.raw"}) {
+		t.Fatalf("archive dir = %v, want [fresh.wav held.wav veto.raw] (no temp files)", left)
+	}
+}
@@ -2,7 +2,9 @@
 package stt
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,6 +13,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/preset"
 	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
 )
 
@@ -22,6 +27,7 @@ const (
 // Client STT客户端
 type Client struct {
 	config *Config
+	warm   *transport.WarmPool // Warmup 预建的空闲连接
 }
 
 // NewClient 创建STT客户端
@@ -30,17 +36,37 @@ func NewClient(config *Config) (*Client, error) {
 		config = DefaultConfig()
 	}
 
+	config.Normalize()
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
+	for _, w := range config.Warnings() {
+		slog.Warn("Suspicious config", "component", "stt", "warning", w)
+	}
 
-	return &Client{config: config}, nil
+	return &Client{config: config, warm: &transport.WarmPool{}}, nil
+}
+
+// NewClientFromPreset 按预设名称创建STT客户端（预设见 preset 包，从 preset.Default 查找）
+// config 提供 GatewayURL、APIKey 等基础配置（nil 使用 DefaultConfig），预设中的非零字段覆盖其识别参数；
+// 传入的 config 不会被修改
+func NewClientFromPreset(config *Config, name string) (*Client, error) {
+	p, err := preset.Get(name, preset.KindSTT)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = DefaultConfig()
+	}
+	c := *config
+	return NewClient(c.ApplyPreset(p))
 }
 
 // RecognizeFile 识别音频文件（简化API）
-// 自动处理连接、会话、文件读取和关闭
+// 自动处理连接、会话、文件读取和关闭；ctx 带 deadline 时以其为整体时限（替代 ConnectTimeout），
+// 超时返回已识别的部分结果与 context.DeadlineExceeded
 func (c *Client) RecognizeFile(ctx context.Context, audioPath string) (*RecognitionResult, error) {
-	start := time.Now()
+	start := c.clock().Now()
 
 	// 检查文件是否存在
 	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
@@ -54,46 +80,129 @@ func (c *Client) RecognizeFile(ctx context.Context, audioPath string) (*Recognit
 	}
 	defer file.Close()
 
+	// WAV 按块结构解析头部并校验/转换为会话格式；无头 PCM 校验字节数与声明格式对齐
+	var src io.Reader = file
+	isWAV := c.config.AudioFormat == "wav" || strings.HasSuffix(strings.ToLower(audioPath), ".wav")
+	if isWAV {
+		header, err := audio.ReadWAVHeader(file)
+		if err != nil {
+			return nil, fmt.Errorf("wav %s: %w", audioPath, err)
+		}
+		src, err = wavSource(file, header, c.config.SampleRate, c.config.Channels, c.config.BitsPerSample)
+		if err != nil {
+			return nil, fmt.Errorf("wav %s: %w", audioPath, err)
+		}
+	} else {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("stat audio file: %w", err)
+		}
+		if err := audio.ValidatePCMSize(int(info.Size()), c.config.Channels, c.config.BitsPerSample); err != nil {
+			return nil, fmt.Errorf("raw audio %s: %w", audioPath, err)
+		}
+	}
+
 	// 创建流式会话
-	opts := &StreamOptions{
-		Language:    c.config.Language,
-		SampleRate:  c.config.SampleRate,
-		AudioFormat: c.config.AudioFormat,
+	session, err := c.within(ctx).createSession(ctx, c.streamOptions())
+	if err != nil {
+		return nil, err
 	}
+	defer session.Close()
 
-	session, err := c.createSession(ctx, opts)
+	result, err := c.recognize(ctx, session, start, func() error {
+		return session.sendChunks(ctx, src, 0)
+	})
+	if result != nil {
+		slog.Info("RecognizeFile completed", "component", "stt", "file", audioPath, "text", truncateText(result.Text, 50), "duration_ms", result.Duration.Milliseconds(), "ttfb_ms", result.TTFB.Milliseconds(),
+			"first_partial_ms", result.Latencies.FirstPartial.Milliseconds(), "finalization_ms", result.Latencies.Finalization.Milliseconds())
+	}
+	return result, err
+}
+
+// RecognizeReader 从任意 io.Reader 流式识别（stdin 管道、HTTP body、ffmpeg 输出等）
+// 按 100ms 分块发送，读到 EOF 后自动 EndInput 并等待识别完成。opts 为 nil 时使用客户端配置；
+// AudioFormat 为 "wav" 时先读取 WAV 头，声道/位深以头部为准，采样率未声明时以头部为准、声明了且与头部不同时
+// 重采样（仅 16-bit 单声道，其它格式返回错误）；
+// opts.Realtime 为 true 时按音频时长节奏发送（模拟实时采集，同 Session.SendRealtime），否则尽快发送
+func (c *Client) RecognizeReader(ctx context.Context, r io.Reader, opts *StreamOptions) (*RecognitionResult, error) {
+	start := c.clock().Now()
+	if opts == nil {
+		opts = c.streamOptions()
+	}
+	if opts.AudioFormat == "wav" {
+		header, err := audio.ReadWAVHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		filled := *opts
+		// 未声明采样率时以 WAV 头为准；声明的采样率与 WAV 头不同时重采样到声明的采样率
+		if filled.SampleRate == 0 {
+			filled.SampleRate = int(header.SampleRate)
+		}
+		filled.Channels = int(header.NumChannels)
+		filled.BitsPerSample = int(header.BitsPerSample)
+		if header.AudioFormat == audio.WAVFormatFloat {
+			filled.BitsPerSample = 16 // 浮点转换为 16-bit 上传
+		}
+		opts = &filled
+		if r, err = wavSource(r, header, opts.SampleRate, opts.Channels, opts.BitsPerSample); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SampleRate == 0 {
+		filled := *opts
+		filled.SampleRate = c.config.SampleRate
+		opts = &filled
+	}
+
+	session, err := c.within(ctx).createSession(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer session.Close()
 
-	// 跳过WAV头（如果是WAV文件）
-	if strings.HasSuffix(strings.ToLower(audioPath), ".wav") {
-		skipWavHeader(file)
+	return c.recognize(ctx, session, start, func() error {
+		if opts.Realtime {
+			return session.SendRealtime(ctx, r)
+		}
+		return session.sendChunks(ctx, r, 0)
+	})
+}
+
+// within 简化 API 使用：ctx 带 deadline 时以其剩余时间替代 ConnectTimeout（建连与等待 session.ready），
+// 整个请求只受调用方 deadline 约束，而不是 deadline 与配置超时谁先到算谁
+func (c *Client) within(ctx context.Context) *Client {
+	if _, ok := ctx.Deadline(); !ok {
+		return c
 	}
+	config := *c.config
+	config.ConnectTimeout = transport.ContextTimeout(ctx, config.ConnectTimeout)
+	return &Client{config: &config, warm: c.warm}
+}
 
-	// 发送音频数据
+// recognize 在 goroutine 中执行 send 后 EndInput，同时收集识别结果直到识别完成
+// EndInput 后启动空闲计时器，每收到事件重置；recognizeIdleTimeout 内无新事件到达即认为识别完成
+func (c *Client) recognize(ctx context.Context, session *Session, start time.Time, send func() error) (*RecognitionResult, error) {
 	result := &RecognitionResult{
-		Segments: make([]Segment, 0),
+		SessionID: session.ID,
+		Segments:  make([]Segment, 0),
 	}
 	var texts []string
 
 	// goroutine: 发送音频 + commit
 	sendDoneCh := make(chan error, 1)
 	go func() {
-		if err := c.sendAudioFromReader(session, file); err != nil {
+		if err := send(); err != nil {
 			sendDoneCh <- err
 			return
 		}
 		sendDoneCh <- session.EndInput()
 	}()
 
-	// 事件循环：EndInput 后启动空闲计时器，每收到事件重置
-	// 如果 idleTimeout 内无新事件到达，认为识别完成
 	committed := false
-	idleTimer := time.NewTimer(0)
+	idleTimer := c.clock().NewTimer(0)
 	if !idleTimer.Stop() {
-		<-idleTimer.C
+		<-idleTimer.C()
 	}
 	defer idleTimer.Stop()
 
@@ -101,6 +210,10 @@ loop:
 	for {
 		select {
 		case <-ctx.Done():
+			// 超出调用方的时间预算：返回已识别的部分结果并报错（主动取消仍视为正常结束）
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && result.Error == nil {
+				result.Error = fmt.Errorf("recognize: %w", ctx.Err())
+			}
 			break loop
 
 		case err := <-sendDoneCh:
@@ -128,6 +241,8 @@ loop:
 					IsFinal:   true,
 					StartTime: event.StartTime,
 					EndTime:   event.EndTime,
+
+					Alternatives: event.Alternatives,
 				})
 			case EventError:
 				result.Error = event.Error
@@ -135,7 +250,7 @@ loop:
 				break loop
 			}
 
-		case <-idleTimer.C:
+		case <-idleTimer.C():
 			slog.Warn("Idle timeout after last event, closing", "component", "stt", "timeout", recognizeIdleTimeout)
 			break loop
 		}
@@ -143,193 +258,235 @@ loop:
 
 	// 合并文本
 	result.Text = strings.Join(texts, "")
-	result.Duration = time.Since(start)
+	result.Duration = clock.Since(c.clock(), start)
 	result.TTFB = session.TTFB()
-
-	slog.Info("RecognizeFile completed", "component", "stt", "file", audioPath, "text", truncateText(result.Text, 50), "duration_ms", result.Duration.Milliseconds(), "ttfb_ms", result.TTFB.Milliseconds())
+	result.Latencies = session.Latencies()
 
 	return result, result.Error
 }
 
-// sendAudioFromReader 从Reader发送音频
-func (c *Client) sendAudioFromReader(session *Session, reader io.Reader) error {
-	// 100ms音频块 @ 16kHz, 16-bit = 3200字节
-	chunkSize := c.config.SampleRate * 2 / 10 // 100ms
-	buf := make([]byte, chunkSize)
-
-	for {
-		n, err := reader.Read(buf)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		if n > 0 {
-			if err := session.Send(buf[:n]); err != nil {
-				return err
-			}
-			// 模拟实时发送（可选）
-			// time.Sleep(100 * time.Millisecond)
-		}
-	}
-	return nil
-}
-
 // CreateSession 创建 STT 流式会话。
 // 返回 Session 对象，调用方通过 Send() 发送音频、EndInput() 标记结束、Events() 接收识别结果。
 func (c *Client) CreateSession(ctx context.Context, opts *StreamOptions) (*Session, error) {
 	if opts == nil {
-		opts = &StreamOptions{
-			Language:    c.config.Language,
-			SampleRate:  c.config.SampleRate,
-			AudioFormat: c.config.AudioFormat,
-		}
+		opts = c.streamOptions()
 	}
 	return c.createSession(ctx, opts)
 }
 
-// createSession 内部创建会话
-func (c *Client) createSession(ctx context.Context, opts *StreamOptions) (*Session, error) {
-	if opts == nil {
-		opts = DefaultStreamOptions()
+// clock 返回客户端配置的时钟
+func (c *Client) clock() clock.Clock {
+	return clock.Or(c.config.Clock)
+}
+
+// streamOptions 从客户端配置构建流式选项
+func (c *Client) streamOptions() *StreamOptions {
+	return &StreamOptions{
+		Language:      c.config.Language,
+		SampleRate:    c.config.SampleRate,
+		AudioFormat:   c.config.AudioFormat,
+		Channels:      c.config.Channels,
+		BitsPerSample: c.config.BitsPerSample,
+		BigEndian:     c.config.BigEndian,
+		PhraseHints:   c.config.PhraseHints,
+		PhraseBoosts:  c.config.PhraseBoosts,
+
+		EnablePunctuation: true,
+		EnableITN:         true,
 	}
+}
 
+// Warmup 预先建立 n 个到 gateway 的空闲连接并保活，之后的会话直接取用，省去首个请求的 TCP / TLS / WebSocket 握手
+//
+// 连接按 Config.Provider 建立（与不指定提供商的会话相同的 URL），取用后不补充；
+// 返回成功建立的数量，一个都没有建立时返回错误。Client.Close 关闭未被取用的连接。
+func (c *Client) Warmup(ctx context.Context, n int) (int, error) {
+	if n <= 0 {
+		return 0, ErrInvalidConfig("warmup count must be positive")
+	}
+	return c.warm.Warm(ctx, c.connConfig(c.config.Provider), n)
+}
+
+// connConfig 按提供商构建连接配置（createSession 与 Warmup 共用，URL 相同的连接可互换）
+func (c *Client) connConfig(provider string) *transport.Config {
 	// 构建WebSocket URL
-	wsURL := fmt.Sprintf("%s/ws/stt?provider=%s", c.config.GatewayURL, c.config.Provider)
+	wsURL := fmt.Sprintf("%s/ws/stt?provider=%s", c.config.gatewayURL(provider), provider)
 	if c.config.APIKey != "" {
 		wsURL += "&api_key=" + url.QueryEscape(c.config.APIKey)
 	}
 
-	// 创建连接
-	connConfig := &transport.Config{
+	return &transport.Config{
 		URL:              wsURL,
 		ConnectTimeout:   c.config.ConnectTimeout,
 		ReadTimeout:      c.config.ReadTimeout,
 		WriteTimeout:     c.config.WriteTimeout,
 		ReconnectBackoff: c.config.ReconnectBackoff,
 		MaxReconnects:    c.config.MaxReconnects,
+		IdleTimeout:      c.config.IdleTimeout,
+		MaxConnDuration:  c.config.MaxConnDuration,
+		PongTimeout:      c.config.PongTimeout,
+		Clock:            c.config.Clock,
+		EventLoop:        c.config.EventLoop,
+		Auth:             c.config.Auth,
+		Header:           c.config.Header,
+		BearerAuth:       c.config.BearerAuth,
+		Proxy:            c.config.proxy(),
+		NetDialContext:   c.config.NetDialContext,
+		Metrics:          c.config.Metrics,
+		Interceptors:     c.config.Interceptors,
+		ReceiveBuffer:    c.config.ReceiveBuffer,
+		ReceiveOverflow:  c.config.ReceiveOverflow,
+		SendQueueBytes:   c.config.SendQueueBytes,
+		SendQueuePolicy:  c.config.SendQueuePolicy,
+	}
+}
+
+// createSession 内部创建会话
+func (c *Client) createSession(ctx context.Context, opts *StreamOptions) (*Session, error) {
+	opts, err := resolveStreamOptions(c.config, opts)
+	if err != nil {
+		return nil, err
+	}
+	connConfig := c.connConfig(opts.Provider)
+	connConfig.SendRate = c.config.sendRate(opts)
+
+	// 时延预算：记录建连与握手耗时，失败时附在错误中
+	var setup []transport.PhaseLatency
+	phaseStart := c.clock().Now()
+	endPhase := func(phase transport.LatencyPhase) {
+		now := c.clock().Now()
+		setup = append(setup, transport.PhaseLatency{Phase: phase, Duration: now.Sub(phaseStart)})
+		phaseStart = now
+	}
+	withLatency := func(err error) error {
+		if c.config.LatencyBudget <= 0 {
+			return err
+		}
+		return &transport.LatencyError{Err: err, Breakdown: transport.LatencyBreakdown{Budget: c.config.LatencyBudget, Phases: setup}}
 	}
 
-	conn := transport.NewConn(connConfig)
-	if err := conn.ConnectWithRetry(ctx); err != nil {
-		return nil, fmt.Errorf("connect to gateway: %w", err)
+	// 优先取用 Warmup 预建的连接（预建时尚不知道本会话的音频格式，限速在取用时设置）
+	conn := c.warm.Take(connConfig.URL)
+	if conn != nil {
+		conn.SetSendRate(connConfig.SendRate)
+	} else {
+		conn = transport.NewConn(connConfig)
+		if err := conn.ConnectWithRetry(ctx); err != nil {
+			endPhase(transport.PhaseConnect)
+			return nil, withLatency(fmt.Errorf("connect to gateway: %w", err))
+		}
 	}
+	endPhase(transport.PhaseConnect)
 
 	// 创建会话
 	session := newSession(conn, c.config, opts)
+	// 断线重连时按相同配置建立新连接
+	session.dial = func(ctx context.Context) (*transport.Conn, error) {
+		conn := transport.NewConn(connConfig)
+		if err := conn.Connect(ctx); err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
 
 	// 启动会话
 	if err := session.start(ctx); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("start session: %w", err)
+		endPhase(transport.PhaseConfig)
+		return nil, withLatency(fmt.Errorf("start session: %w", err))
 	}
+	endPhase(transport.PhaseConfig)
+	session.mu.Lock()
+	session.setup = setup
+	session.mu.Unlock()
 
 	return session, nil
 }
 
-// RecognizeBytes 识别音频字节（简化API）
-func (c *Client) RecognizeBytes(ctx context.Context, audio []byte) (*RecognitionResult, error) {
-	start := time.Now()
-
-	// 创建流式会话
-	opts := &StreamOptions{
-		Language:    c.config.Language,
-		SampleRate:  c.config.SampleRate,
-		AudioFormat: c.config.AudioFormat,
+// resolveStreamOptions 补全并校验流式选项：未声明的字段沿用客户端配置（createSession 与 Reconfigure 共用）
+func resolveStreamOptions(config *Config, opts *StreamOptions) (*StreamOptions, error) {
+	if opts == nil {
+		opts = DefaultStreamOptions()
 	}
-
-	session, err := c.createSession(ctx, opts)
-	if err != nil {
+	if opts.Provider == "" {
+		filled := *opts
+		filled.Provider = config.Provider
+		opts = &filled
+	}
+	// 未声明的 PCM 格式参数沿用客户端配置
+	if opts.Channels == 0 || opts.BitsPerSample == 0 {
+		filled := *opts
+		if filled.Channels == 0 {
+			filled.Channels = config.Channels
+		}
+		if filled.BitsPerSample == 0 {
+			filled.BitsPerSample = config.BitsPerSample
+		}
+		opts = &filled
+	}
+	if opts.AudioFormat == "raw" {
+		filled := *opts
+		filled.AudioFormat = "pcm"
+		opts = &filled
+	}
+	if config.BigEndian && !opts.BigEndian {
+		filled := *opts
+		filled.BigEndian = true
+		opts = &filled
+	}
+	if len(opts.PhraseHints) == 0 && len(config.PhraseHints) > 0 {
+		filled := *opts
+		filled.PhraseHints, filled.PhraseBoosts = config.PhraseHints, config.PhraseBoosts
+		opts = &filled
+	}
+	if err := validatePhraseHints(opts.PhraseHints, opts.PhraseBoosts); err != nil {
 		return nil, err
 	}
-	defer session.Close()
-
-	// 分块发送
-	result := &RecognitionResult{
-		Segments: make([]Segment, 0),
+	if opts.MaxAlternatives < 0 {
+		return nil, ErrInvalidConfig("MaxAlternatives must not be negative")
 	}
-	var texts []string
-
-	// goroutine: 发送音频 + commit
-	sendDoneCh := make(chan error, 1)
-	go func() {
-		chunkSize := c.config.SampleRate * 2 / 10 // 100ms
-		for i := 0; i < len(audio); i += chunkSize {
-			end := i + chunkSize
-			if end > len(audio) {
-				end = len(audio)
-			}
-			if err := session.Send(audio[i:end]); err != nil {
-				sendDoneCh <- err
-				return
-			}
-		}
-		sendDoneCh <- session.EndInput()
-	}()
-
-	// 事件循环：EndInput 后启动空闲计时器
-	committed := false
-	idleTimer := time.NewTimer(0)
-	if !idleTimer.Stop() {
-		<-idleTimer.C
+	if !opts.ProfanityMode.Valid() {
+		return nil, ErrInvalidConfig("ProfanityMode must be raw, masked or removed")
 	}
-	defer idleTimer.Stop()
-
-loop:
-	for {
-		select {
-		case <-ctx.Done():
-			break loop
-
-		case err := <-sendDoneCh:
-			if err != nil {
-				return nil, fmt.Errorf("send audio: %w", err)
-			}
-			committed = true
-			sendDoneCh = nil
-			idleTimer.Reset(recognizeIdleTimeout)
+	if err := validateEndpointing(opts.EndpointSilence, opts.MaxEndpointSilence); err != nil {
+		return nil, err
+	}
+	if opts.MaxUtteranceDuration < 0 {
+		return nil, ErrInvalidConfig("MaxUtteranceDuration must not be negative")
+	}
+	if opts.RealtimeSpeed < 0 {
+		return nil, ErrInvalidConfig("RealtimeSpeed must not be negative")
+	}
+	if err := validateKeepalive(opts); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
 
-		case event, ok := <-session.Events():
-			if !ok {
-				break loop
-			}
-			if committed {
-				idleTimer.Reset(recognizeIdleTimeout)
-			}
-			switch event.Type {
-			case EventTranscriptFinal:
-				texts = append(texts, event.Text)
-				result.Segments = append(result.Segments, Segment{
-					Text:      event.Text,
-					IsFinal:   true,
-					StartTime: event.StartTime,
-					EndTime:   event.EndTime,
-				})
-			case EventError:
-				result.Error = event.Error
-			case EventSessionEnded:
-				break loop
-			}
+// RecognizeBytes 识别音频字节（简化API）
+func (c *Client) RecognizeBytes(ctx context.Context, data []byte) (*RecognitionResult, error) {
+	start := c.clock().Now()
 
-		case <-idleTimer.C:
-			slog.Warn("Idle timeout after last event, closing", "component", "stt", "timeout", recognizeIdleTimeout)
-			break loop
-		}
+	if err := audio.ValidatePCMSize(len(data), c.config.Channels, c.config.BitsPerSample); err != nil {
+		return nil, fmt.Errorf("raw audio: %w", err)
 	}
 
-	// 合并文本
-	result.Text = strings.Join(texts, "")
-	result.Duration = time.Since(start)
-	result.TTFB = session.TTFB()
+	// 创建流式会话
+	session, err := c.within(ctx).createSession(ctx, c.streamOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
 
-	return result, result.Error
+	return c.recognize(ctx, session, start, func() error {
+		return session.sendChunks(ctx, bytes.NewReader(data), 0)
+	})
 }
 
 // Close 关闭客户端
 func (c *Client) Close() error {
-	// 客户端本身无需关闭资源，每次识别创建独立会话
-	return nil
+	// 每次识别创建独立会话，只需关闭 Warmup 预建但未被取用的连接
+	return c.warm.Close()
 }
 
 // Config 返回当前配置
@@ -337,11 +494,6 @@ func (c *Client) Config() *Config {
 	return c.config
 }
 
-// skipWavHeader 跳过WAV文件头（44字节）
-func skipWavHeader(file *os.File) {
-	file.Seek(44, io.SeekStart)
-}
-
 // truncateText 截断文本用于日志
 func truncateText(text string, maxLen int) string {
 	if len(text) <= maxLen {
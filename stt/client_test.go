@@ -0,0 +1,829 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// countingGateway 启动模拟 gateway：记录每个 audio.append 的字节数，session.end 时以
+// "<总字节数> bytes" 作为最终结果返回；chunks 返回已收到的分块大小
+func countingGateway(t *testing.T) (url string, chunks func() []int) {
+	var (
+		mu       sync.Mutex
+		received []int
+	)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		total := 0
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeAudioAppend:
+				data, _ := base64.StdEncoding.DecodeString(msg["audio"].(string))
+				mu.Lock()
+				received = append(received, len(data))
+				mu.Unlock()
+				total += len(data)
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: fmt.Sprintf("%d bytes", total)})
+				ws.WriteJSON(protocol.SessionEnded{Type: protocol.MessageTypeSessionEnded})
+				// 等客户端收到 session.ended 后主动关闭连接
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), received...)
+	}
+}
+
+// configGateway 启动模拟 gateway：收到的每个 session.config 依次放入 configs；reply 非 nil 时在收到配置后调用
+// （写出 gateway 的回复消息），之后等客户端关闭连接
+func configGateway(t *testing.T, reply func(ws *websocket.Conn)) (url string, configs <-chan protocol.SessionConfig) {
+	ch := make(chan protocol.SessionConfig, 4)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		var msg protocol.SessionConfig
+		if ws.ReadJSON(&msg) != nil {
+			return
+		}
+		ch <- msg
+		if reply != nil {
+			reply(ws)
+		}
+		ws.ReadMessage() // 等客户端关闭
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), ch
+}
+
+// TestRecognizeReader 验证：从短读的 Reader（模拟管道）读取 WAV 时按头部采样率凑满 100ms 块发送，
+// 读到 EOF 后自动 session.end，返回 gateway 的最终结果。
+// WHY：stdin、HTTP body、ffmpeg 输出不是文件，短读若直接发送会产生大量碎块且未对齐采样帧。
+func TestRecognizeReader(t *testing.T) {
+	url, received := countingGateway(t)
+
+	pcm := make([]byte, 16000*2/2+100) // 0.5 秒 + 不足一块的尾部
+	wav, err := audio.PCMToWAV(pcm, 16000, 1, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig().WithSampleRate(8000) // WAV 头（16kHz）优先于客户端配置
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.RecognizeReader(context.Background(), iotest.HalfReader(strings.NewReader(string(wav))),
+		&StreamOptions{Language: "en-NG", AudioFormat: "wav"})
+	if err != nil {
+		t.Fatalf("RecognizeReader: %v", err)
+	}
+
+	chunks := received()
+	if want := fmt.Sprintf("%d bytes", len(pcm)); result.Text != want {
+		t.Fatalf("text = %q, want %q", result.Text, want)
+	}
+	for i, n := range chunks[:len(chunks)-1] {
+		if n != 3200 { // 16kHz 100ms
+			t.Fatalf("chunk %d is %d bytes, want 3200 (chunks %v)", i, n, chunks)
+		}
+	}
+	if last := chunks[len(chunks)-1]; last != 100 {
+		t.Fatalf("last chunk %d bytes, want 100", last)
+	}
+}
+
+// TestRecognizeReaderResamplesWAV 验证：RecognizeReader 声明的采样率与 WAV 头不同时重采样到声明的采样率上传；
+// 无法重采样的格式（多声道）在建连前返回说明两个采样率的错误。
+// WHY：原先 WAV 头静默覆盖声明的采样率，44.1kHz 录音按 8kHz 会话识别只会得到乱码。
+func TestRecognizeReaderResamplesWAV(t *testing.T) {
+	url, _ := countingGateway(t)
+	config := DefaultConfig()
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wav, err := audio.PCMToWAV(audio.Tone(44100, 440, 500*time.Millisecond, 0.5), 44100, 1, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.RecognizeReader(context.Background(), bytes.NewReader(wav),
+		&StreamOptions{Language: "en-NG", SampleRate: 8000, AudioFormat: "wav"})
+	if err != nil {
+		t.Fatalf("RecognizeReader: %v", err)
+	}
+	var sent int
+	fmt.Sscanf(result.Text, "%d bytes", &sent)
+	if want := 8000; sent < want-8 || sent > want+8 { // 0.5s @ 8kHz 16-bit
+		t.Fatalf("uploaded %d bytes, want ~%d", sent, want)
+	}
+
+	stereo, err := audio.PCMToWAV(make([]byte, 44100*4/10), 44100, 2, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.RecognizeReader(context.Background(), bytes.NewReader(stereo),
+		&StreamOptions{Language: "en-NG", SampleRate: 8000, AudioFormat: "wav"})
+	if err == nil || !strings.Contains(err.Error(), "44100 Hz") || !strings.Contains(err.Error(), "8000 Hz") {
+		t.Fatalf("stereo WAV error = %v, want sample rate mismatch", err)
+	}
+}
+
+// TestRecognizeFileConvertsWAV 验证：RecognizeFile 按块解析 WAV 头，8kHz 32-bit 浮点 WAV 在 16kHz 16-bit
+// 会话中被转换并重采样后上传（只上传 data 块）；声道数与会话不符时在连接前明确报错。
+// WHY：固定跳过 44 字节会把扩展头当作音频，且浮点/采样率不符的音频被原样上传只会得到乱码结果。
+func TestRecognizeFileConvertsWAV(t *testing.T) {
+	url, _ := countingGateway(t)
+	dir := t.TempDir()
+	writeFloatWAV := func(name string, channels, samples int) string {
+		data := make([]byte, samples*channels*4)
+		for i := 0; i < samples*channels; i++ {
+			binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(0.25))
+		}
+		var wav bytes.Buffer
+		wav.WriteString("RIFF")
+		binary.Write(&wav, binary.LittleEndian, uint32(4+26+8+len(data)))
+		wav.WriteString("WAVEfmt ")
+		for _, v := range []any{
+			uint32(18), uint16(audio.WAVFormatFloat), uint16(channels), uint32(8000),
+			uint32(8000 * 4 * channels), uint16(4 * channels), uint16(32), uint16(0), // fmt 块 18 字节（含 cbSize）
+		} {
+			binary.Write(&wav, binary.LittleEndian, v)
+		}
+		wav.WriteString("data")
+		binary.Write(&wav, binary.LittleEndian, uint32(len(data)))
+		wav.Write(data)
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, wav.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	config := DefaultConfig().WithSampleRate(16000)
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.RecognizeFile(context.Background(), writeFloatWAV("mono.wav", 1, 4000))
+	if err != nil {
+		t.Fatalf("RecognizeFile: %v", err)
+	}
+	var sent int
+	fmt.Sscanf(result.Text, "%d bytes", &sent)
+	if want := 16000 / 2 * 2; sent < want-8 || sent > want+8 { // 0.5s @ 16kHz 16-bit
+		t.Fatalf("uploaded %d bytes, want ~%d", sent, want)
+	}
+
+	_, err = client.RecognizeFile(context.Background(), writeFloatWAV("stereo.wav", 2, 800))
+	if err == nil || !strings.Contains(err.Error(), "2 channels") {
+		t.Fatalf("stereo WAV error = %v, want channel mismatch", err)
+	}
+}
+
+// TestRecognizeMicrophone 验证：RecognizeMicrophone 以会话采样率启动系统录音程序，把其输出的 PCM 持续上传；
+// 采集结束后自动 EndInput，最终结果和 session.ended 照常送达。
+// WHY：麦克风实时识别要求 SDK 自己管理采集进程与会话的生命周期，调用方只处理事件。
+func TestRecognizeMicrophone(t *testing.T) {
+	url, _ := countingGateway(t)
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\nhead -c 8000 /dev/zero\n"
+	if err := os.WriteFile(filepath.Join(dir, "arecord"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	config := DefaultConfig().WithSampleRate(16000)
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := client.RecognizeMicrophone(context.Background(), &MicrophoneOptions{Recorder: "arecord"})
+	if err != nil {
+		t.Fatalf("RecognizeMicrophone: %v", err)
+	}
+	defer session.Close()
+
+	var text string
+	if err := session.Handle(context.Background(), Handlers{OnFinal: func(seg Segment) { text += seg.Text }}); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if text != "8000 bytes" {
+		t.Fatalf("text = %q, want %q", text, "8000 bytes")
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "-f S16_LE -r 16000 -c 1") {
+		t.Fatalf("recorder args = %q, want 16-bit mono 16kHz", args)
+	}
+}
+
+// TestPhraseHints 验证：客户端配置的短语提示（含可选权重）随 session.config 发送，重复短语只发送一次；
+// 权重对应的短语不在提示列表中时在建连前报错。
+// WHY：领域术语、产品名、尼日利亚人名依赖短语提示才能稳定识别，写错的权重被静默忽略会难以排查。
+func TestPhraseHints(t *testing.T) {
+	url, configs := configGateway(t, nil)
+
+	config := DefaultConfig().WithPhraseHints("Tengen", "Tengen").WithPhraseBoost("Chukwuemeka", 5)
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := client.CreateSession(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	got := (<-configs).Session.PhraseHints
+	want := []protocol.PhraseHint{{Phrase: "Tengen"}, {Phrase: "Chukwuemeka", Boost: 5}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("phrase_hints = %v, want %v", got, want)
+	}
+
+	_, err = client.CreateSession(context.Background(), &StreamOptions{
+		SampleRate:   16000,
+		PhraseHints:  []string{"Lagos"},
+		PhraseBoosts: map[string]float64{"Abuja": 2},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Abuja") {
+		t.Fatalf("CreateSession with unmatched boost = %v, want error naming the phrase", err)
+	}
+}
+
+// TestPunctuationAndITN 验证：默认选项在 session.config 中显式开启标点与 ITN；关闭时发送 false 而不是省略字段。
+// WHY：省略字段时 gateway 使用提供商默认值（通常为格式化输出），调用方要求的原始 token 会被静默忽略。
+func TestPunctuationAndITN(t *testing.T) {
+	url, configs := configGateway(t, nil)
+
+	config := DefaultConfig()
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := DefaultStreamOptions()
+	raw.EnablePunctuation, raw.EnableITN = false, false
+	for _, tc := range []struct {
+		opts *StreamOptions
+		want bool
+	}{{nil, true}, {raw, false}} {
+		session, err := client.CreateSession(context.Background(), tc.opts)
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		params := (<-configs).Session
+		session.Close()
+		if params.EnablePunctuation == nil || *params.EnablePunctuation != tc.want ||
+			params.EnableITN == nil || *params.EnableITN != tc.want {
+			t.Fatalf("enable_punctuation / enable_itn = %v / %v, want both %v",
+				params.EnablePunctuation, params.EnableITN, tc.want)
+		}
+	}
+}
+
+// TestProfanityMode 验证：ProfanityMode 随 session.config 发送，未设置时省略字段；非法取值在建连前被拒绝。
+// WHY：拼错的取值（如 "mask"）会被 gateway 忽略并回退到提供商默认值，面向用户的字幕里可能出现未过滤的不雅词。
+func TestProfanityMode(t *testing.T) {
+	url, configs := configGateway(t, nil)
+
+	config := DefaultConfig()
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	masked := DefaultStreamOptions()
+	masked.ProfanityMode = protocol.ProfanityMasked
+	for _, tc := range []struct {
+		opts *StreamOptions
+		want protocol.ProfanityMode
+	}{{nil, ""}, {masked, protocol.ProfanityMasked}} {
+		session, err := client.CreateSession(context.Background(), tc.opts)
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		params := (<-configs).Session
+		session.Close()
+		if params.ProfanityMode != tc.want {
+			t.Fatalf("profanity_mode = %q, want %q", params.ProfanityMode, tc.want)
+		}
+	}
+
+	invalid := DefaultStreamOptions()
+	invalid.ProfanityMode = "mask"
+	if _, err := client.CreateSession(context.Background(), invalid); err == nil {
+		t.Fatal("CreateSession accepted invalid ProfanityMode")
+	}
+}
+
+// TestAlternatives 验证：MaxAlternatives 随 session.config 发送；transcript.final 的 N-best 候选按原顺序出现在
+// 最终事件上，gateway 多返回的候选被截断。
+// WHY：下游 NLU 依赖候选列表重排序，顺序错乱或数量超出请求都会改变重排结果。
+func TestAlternatives(t *testing.T) {
+	url, configs := configGateway(t, func(ws *websocket.Conn) {
+		ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "recognize speech",
+			Alternatives: []protocol.Alternative{
+				{Text: "recognize speech", Confidence: 0.82},
+				{Text: "wreck a nice beach", Confidence: 0.11},
+				{Text: "recognise peach", Confidence: 0.04},
+			}})
+	})
+
+	config := DefaultConfig()
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultStreamOptions()
+	opts.MaxAlternatives = 2
+	session, err := client.CreateSession(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	if got := (<-configs).Session.MaxAlternatives; got != 2 {
+		t.Fatalf("max_alternatives = %d, want 2", got)
+	}
+	for event := range session.Events() {
+		if event.Type != EventTranscriptFinal {
+			continue
+		}
+		want := []Alternative{{"recognize speech", 0.82}, {"wreck a nice beach", 0.11}}
+		if fmt.Sprint(event.Alternatives) != fmt.Sprint(want) {
+			t.Fatalf("alternatives = %v, want %v", event.Alternatives, want)
+		}
+		return
+	}
+	t.Fatal("no transcript.final event")
+}
+
+// TestEndOfUtterance 验证：端点阈值以毫秒随 session.config 发送；utterance.end 转为 EventEndOfUtterance，
+// 在该句 transcript.final 之前经 Handlers.OnEndOfUtterance 送达；上限小于语义阈值的配置在建连前被拒绝。
+// WHY：语音机器人靠该事件提前开始回复，晚于 final 送达或阈值单位错误（秒当毫秒）都会让"抢答"失效。
+func TestEndOfUtterance(t *testing.T) {
+	url, configs := configGateway(t, func(ws *websocket.Conn) {
+		ws.WriteJSON(protocol.EndOfUtterance{Type: protocol.MessageTypeEndOfUtterance, Text: "book a flight", EndTime: 1800, Reason: "semantic"})
+		ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "Book a flight.", EndTime: 1800})
+		ws.WriteJSON(protocol.SessionEnded{Type: protocol.MessageTypeSessionEnded})
+	})
+
+	config := DefaultConfig()
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultStreamOptions()
+	opts.EndpointSilence, opts.MaxEndpointSilence = 400*time.Millisecond, 1500*time.Millisecond
+	session, err := client.CreateSession(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	if got := (<-configs).Session.Endpointing; got == nil || *got != (protocol.Endpointing{SilenceMs: 400, MaxSilenceMs: 1500}) {
+		t.Fatalf("endpointing = %+v, want 400 / 1500 ms", got)
+	}
+	var order []string
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = session.Handle(ctx, Handlers{
+		OnEvent: func(e *RecognitionEvent) {
+			if e.Type == EventEndOfUtterance && (e.EndTime != 1800*time.Millisecond || e.Reason != "semantic") {
+				t.Errorf("utterance.end event = %+v", e)
+			}
+		},
+		OnEndOfUtterance: func(text string) { order = append(order, "eou:"+text) },
+		OnFinal:          func(seg Segment) { order = append(order, "final:"+seg.Text) },
+	})
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if want := "[eou:book a flight final:Book a flight.]"; fmt.Sprint(order) != want {
+		t.Fatalf("callbacks = %v, want %s", order, want)
+	}
+
+	invalid := DefaultStreamOptions()
+	invalid.EndpointSilence, invalid.MaxEndpointSilence = time.Second, 500*time.Millisecond
+	if _, err := client.CreateSession(context.Background(), invalid); err == nil {
+		t.Fatal("CreateSession accepted MaxEndpointSilence < EndpointSilence")
+	}
+}
+
+// TestReconfigure 验证：Reconfigure 在同一连接上重新发送完整的 session.config，收到 config_done 后新参数生效；
+// gateway 回复 error 时返回该错误并保持原参数；变更音频格式在发送前被拒绝。
+// WHY：首句识别出语言后需要切换，原先只能断开重连，丢掉连接预热并让用户在切换期间说的话无处可发。
+func TestReconfigure(t *testing.T) {
+	configs := make(chan protocol.SessionParams, 3)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg protocol.SessionConfig
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			if msg.Type != protocol.MessageTypeSessionConfig {
+				continue
+			}
+			configs <- msg.Session
+			if msg.Session.Provider == "qwen" {
+				ws.WriteJSON(protocol.ErrorMessage{Type: protocol.MessageTypeError, Code: "provider_unsupported", Message: "cannot switch provider"})
+				continue
+			}
+			ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.GatewayURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+	<-configs
+
+	opts := DefaultStreamOptions()
+	opts.Language = "en-US"
+	if err := session.Reconfigure(ctx, opts); err != nil {
+		t.Fatalf("Reconfigure(en-US): %v", err)
+	}
+	if got := <-configs; got.Language != "en-US" || got.Provider != config.Provider || got.SampleRate != opts.SampleRate {
+		t.Fatalf("reconfigured session.config = %+v, want full config with language en-US", got)
+	}
+
+	opts.Provider = "qwen"
+	if err := session.Reconfigure(ctx, opts); err == nil || !strings.Contains(err.Error(), "provider_unsupported") {
+		t.Fatalf("Reconfigure(qwen) = %v, want gateway error", err)
+	}
+	<-configs
+	if got := session.Options(); got.Language != "en-US" || got.Provider != config.Provider || session.Provider != config.Provider {
+		t.Fatalf("after rejected Reconfigure: options %s/%s, Provider %s; want en-US/%s", got.Language, got.Provider, session.Provider, config.Provider)
+	}
+
+	opts.Provider, opts.SampleRate = "", 8000
+	if err := session.Reconfigure(ctx, opts); err == nil {
+		t.Fatal("Reconfigure accepted a sample rate change")
+	}
+	if len(configs) != 0 {
+		t.Fatal("session.config sent for rejected sample rate change")
+	}
+}
+
+// TestReconnectReplay 验证：开启 ReplayBuffer 后连接异常断开时自动重连，从最后一个 transcript.final
+// 之后的位置重放音频（断线后 Send 的音频一并送达），新连接上的时间戳换算回原会话时间轴。
+// WHY：移动网络切换时连接常在句中断开，原先会话直接报错，断线前未出结果的语音和断线期间说的话都会丢失。
+func TestReconnectReplay(t *testing.T) {
+	const chunk = 3200 // 100ms 16kHz 16-bit 单声道
+	drop := make(chan struct{})
+	replayed := make(chan int, 1)
+	var attempt int
+	var mu sync.Mutex
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		mu.Lock()
+		attempt++
+		first := attempt == 1
+		mu.Unlock()
+
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: fmt.Sprintf("s%d", attempt)})
+		total := 0
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeSessionConfig:
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+			case protocol.MessageTypeAudioAppend:
+				data, _ := base64.StdEncoding.DecodeString(msg["audio"].(string))
+				total += len(data)
+				if first && total == 2*chunk {
+					// 只确认前 100ms，然后异常断开（不发 Close 帧）
+					ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "一", StartTime: 0, EndTime: 100})
+					<-drop
+					ws.UnderlyingConn().Close()
+					return
+				}
+			case protocol.MessageTypeSessionEnd:
+				replayed <- total
+				ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "二三", StartTime: 0, EndTime: 200})
+				ws.WriteJSON(protocol.SessionEnded{Type: protocol.MessageTypeSessionEnded})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig().WithReplayBuffer(5 * time.Second)
+	config.GatewayURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	config.ReconnectBackoff = 10 * time.Millisecond
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := session.Send(bytes.Repeat([]byte{byte(i + 1)}, chunk)); err != nil {
+			t.Fatalf("Send #%d: %v", i, err)
+		}
+	}
+	var finals []*RecognitionEvent
+	nextFinal := func() *RecognitionEvent {
+		for {
+			select {
+			case ev, ok := <-session.Events():
+				if !ok {
+					t.Fatalf("events closed, finals so far: %d", len(finals))
+				}
+				if ev.Type == EventError {
+					t.Fatalf("unexpected error event: %v", ev.Error)
+				}
+				if ev.Type == EventTranscriptFinal {
+					finals = append(finals, ev)
+					return ev
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for transcript.final")
+			}
+		}
+	}
+	nextFinal()
+	close(drop)
+
+	// 断线后说的话：无论落在重连期间还是之后，都应送达新连接
+	if err := session.Send(bytes.Repeat([]byte{3}, chunk)); err != nil {
+		t.Fatalf("Send during outage: %v", err)
+	}
+	if err := session.EndInput(); err != nil {
+		t.Fatalf("EndInput: %v", err)
+	}
+	if got := <-replayed; got != 2*chunk {
+		t.Fatalf("new connection received %d bytes, want %d (unacknowledged 100ms + audio sent after drop)", got, 2*chunk)
+	}
+	ev := nextFinal()
+	if ev.StartTime != 100*time.Millisecond || ev.EndTime != 300*time.Millisecond {
+		t.Fatalf("final after reconnect at %v-%v, want 100ms-300ms on the original timeline", ev.StartTime, ev.EndTime)
+	}
+	if n := session.Resumes(); n != 1 {
+		t.Fatalf("Resumes() = %d, want 1", n)
+	}
+}
+
+// TestReconnectResume 验证：session.ready 返回了恢复令牌时，重连后以 session.resume 接回原会话而不是重发
+// session.config，只补发 gateway 未收到的音频，时间戳沿用原会话时间轴。
+// WHY：重建会话要重放整段未确认的音频并丢失识别器上下文，gateway 能保留会话状态时没有必要从头开始。
+func TestReconnectResume(t *testing.T) {
+	const chunk = 3200 // 100ms 16kHz 16-bit 单声道
+	drop := make(chan struct{})
+	resumed := make(chan string, 1)
+	replayed := make(chan int, 1)
+	var attempt int
+	var mu sync.Mutex
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		mu.Lock()
+		attempt++
+		n := attempt
+		mu.Unlock()
+
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: fmt.Sprintf("s%d", n), ResumeToken: fmt.Sprintf("tok-%d", n)})
+		total := 0
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeSessionConfig:
+				if n > 1 {
+					resumed <- "session.config"
+				}
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+			case protocol.MessageTypeSessionResume:
+				resumed <- msg["resume_token"].(string)
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone, Resumed: true, ReceivedMs: 200})
+			case protocol.MessageTypeAudioAppend:
+				data, _ := base64.StdEncoding.DecodeString(msg["audio"].(string))
+				total += len(data)
+				if n == 1 && total == 2*chunk {
+					// 收到 200ms、只确认前 100ms，然后异常断开
+					ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "一", StartTime: 0, EndTime: 100})
+					<-drop
+					ws.UnderlyingConn().Close()
+					return
+				}
+			case protocol.MessageTypeSessionEnd:
+				replayed <- total
+				ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "二三", StartTime: 100, EndTime: 300})
+				ws.WriteJSON(protocol.SessionEnded{Type: protocol.MessageTypeSessionEnded})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig().WithReplayBuffer(5 * time.Second)
+	config.GatewayURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	config.ReconnectBackoff = 10 * time.Millisecond
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := session.Send(bytes.Repeat([]byte{byte(i + 1)}, chunk)); err != nil {
+			t.Fatalf("Send #%d: %v", i, err)
+		}
+	}
+	nextFinal := func() *RecognitionEvent {
+		for {
+			select {
+			case ev, ok := <-session.Events():
+				if !ok {
+					t.Fatal("events closed")
+				}
+				if ev.Type == EventError {
+					t.Fatalf("unexpected error event: %v", ev.Error)
+				}
+				if ev.Type == EventTranscriptFinal {
+					return ev
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for transcript.final")
+			}
+		}
+	}
+	nextFinal()
+	close(drop)
+
+	if err := session.Send(bytes.Repeat([]byte{3}, chunk)); err != nil {
+		t.Fatalf("Send during outage: %v", err)
+	}
+	if err := session.EndInput(); err != nil {
+		t.Fatalf("EndInput: %v", err)
+	}
+	if got := <-resumed; got != "tok-1" {
+		t.Fatalf("second connection got %s, want session.resume with tok-1", got)
+	}
+	if got := <-replayed; got != chunk {
+		t.Fatalf("resumed connection received %d bytes, want %d (only audio the gateway had not received)", got, chunk)
+	}
+	ev := nextFinal()
+	if ev.StartTime != 100*time.Millisecond || ev.EndTime != 300*time.Millisecond {
+		t.Fatalf("final after resume at %v-%v, want 100ms-300ms", ev.StartTime, ev.EndTime)
+	}
+}
+
+// TestBlockOnFullBuffer 验证：BlockOnFullBuffer 时事件缓冲区满后等待消费，消费方延迟读取也能按顺序收到全部
+// transcript.final，且不计入 DroppedEvents。
+// WHY：默认模式缓冲区满即丢弃事件，字幕落盘、计费等场景丢一条 final 就是错误结果，需要用背压换取不丢失。
+func TestBlockOnFullBuffer(t *testing.T) {
+	const total = 20
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeSessionConfig:
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+			case protocol.MessageTypeSessionEnd:
+				for i := 0; i < total; i++ {
+					ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: fmt.Sprint(i)})
+				}
+				ws.WriteJSON(protocol.SessionEnded{Type: protocol.MessageTypeSessionEnded})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig().WithEventBuffer(2, true)
+	config.GatewayURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+	if err := session.EndInput(); err != nil {
+		t.Fatalf("EndInput: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond) // 消费方迟到：此时缓冲区早已写满
+
+	var texts []string
+	for ev := range session.Events() {
+		if ev.Type == EventTranscriptFinal {
+			texts = append(texts, ev.Text)
+		}
+		if ev.Type == EventSessionEnded {
+			break
+		}
+	}
+	if len(texts) != total {
+		t.Fatalf("received %d finals, want %d", len(texts), total)
+	}
+	for i, text := range texts {
+		if text != fmt.Sprint(i) {
+			t.Fatalf("final %d = %q, want in-order delivery", i, text)
+		}
+	}
+	if n := session.Stats().DroppedEvents; n != 0 {
+		t.Fatalf("DroppedEvents = %d, want 0", n)
+	}
+}
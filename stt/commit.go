@@ -0,0 +1,31 @@
+// Package stt 长语句自动提交
+package stt
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// autoCommitDueLocked 当前语句（自上个 transcript.final 或上次自动提交起）上传的音频是否达到
+// StreamOptions.MaxUtteranceDuration（调用方持有 mu）
+//
+// 重连期间不提交：音频只进入重放缓冲区，重连后的下一次 Send 再判断。
+func (s *Session) autoCommitDueLocked() bool {
+	limit := s.opts.MaxUtteranceDuration
+	return limit > 0 && !s.reconnecting && s.opts.durationOf(s.uploadEnd-s.utteranceStart) >= limit
+}
+
+// autoCommit 发送 input.commit，gateway 据此立即输出该段的 transcript.final（调用方持有 sendMu、不持有 mu）
+func (s *Session) autoCommit(ctx context.Context, conn *transport.Conn) {
+	if err := conn.SendContext(ctx, transport.NewInputCommit()); err != nil {
+		slog.Warn("Auto commit failed", "component", "stt", "id", s.ID, "error", err)
+		return
+	}
+	s.mu.Lock()
+	s.utteranceStart = s.uploadEnd
+	s.autoCommits++
+	s.mu.Unlock()
+	slog.Debug("Max utterance duration reached, committed", "component", "stt", "id", s.ID, "max_utterance", s.opts.MaxUtteranceDuration)
+}
@@ -0,0 +1,93 @@
+package stt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestMaxUtteranceDuration 验证：一句话上传的音频达到 MaxUtteranceDuration 时自动发送 input.commit，
+// 计时从上个 transcript.final 的结束位置（或上次提交处）算起。
+// WHY：连续不断的长音频流（会议、直播）可能长时间没有自然断句，结果迟迟不出且 gateway 需缓存整段音频。
+func TestMaxUtteranceDuration(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		commits []int // 每次 input.commit 时已收到的音频块数
+	)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		chunks := 0
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeAudioAppend:
+				if chunks++; chunks == 2 { // 自然断句：前 200ms 为一句
+					ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "a", EndTime: 200})
+				}
+			case protocol.MessageTypeInputCommit:
+				mu.Lock()
+				commits = append(commits, chunks)
+				mu.Unlock()
+			}
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig().WithSampleRate(8000)
+	config.GatewayURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultStreamOptions()
+	opts.SampleRate = 8000
+	opts.MaxUtteranceDuration = 300 * time.Millisecond
+	session, err := client.CreateSession(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	chunk := make([]byte, 1600) // 100ms
+	for i := 1; i <= 10; i++ {
+		if err := session.Send(chunk); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			for event := range session.Events() { // 等自然断句的 final 到达
+				if event.Type == EventTranscriptFinal {
+					break
+				}
+			}
+		}
+	}
+
+	// 自然断句在 200ms，之后每 300ms 提交一次：第 5、8 块之后
+	deadline := time.Now().Add(time.Second)
+	for session.Stats().AutoCommits < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(commits) != 2 || commits[0] != 5 || commits[1] != 8 {
+		t.Fatalf("input.commit after chunks %v, want [5 8]", commits)
+	}
+}
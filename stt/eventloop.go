@@ -0,0 +1,55 @@
+// Package stt 共享事件循环模式
+package stt
+
+import (
+	"context"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// handleConn 共享事件循环模式下替代 messageLoop：在连接回调中处理消息，
+// ctx 取消、读取失败或 Close 时结束事件流（关闭 Events 通道）
+func (s *Session) handleConn(ctx context.Context) error {
+	s.stopWatch = context.AfterFunc(ctx, func() { s.currentConn().Post(s.finishEvents) })
+	return s.attach(ctx, s.conn)
+}
+
+// attach 在 conn 上注册回调；断线重连换到新连接后重新注册
+func (s *Session) attach(ctx context.Context, conn *transport.Conn) error {
+	fail := func(err error) {
+		if !s.eventsClosed {
+			s.sendEvent(NewErrorEvent(err))
+			s.finishEvents()
+		}
+	}
+	return conn.Handle(transport.Handler{
+		OnMessage: func(data []byte) {
+			if !s.eventsClosed {
+				s.handleMessage(data)
+			}
+		},
+		OnError: func(err error) {
+			if s.eventsClosed || s.replay == nil {
+				fail(err)
+				return
+			}
+			// 重连要重新建连和握手，不占用共享 worker；旧连接之后不再有回调，处理仍是串行的
+			go func() {
+				if next := s.reconnect(ctx, err); next != nil && s.attach(ctx, next) == nil {
+					return
+				}
+				conn.Post(func() { fail(err) })
+			}()
+		},
+	})
+}
+
+// finishEvents 关闭事件通道（在连接回调序列中调用，与 handleMessage 串行，避免向已关闭的通道发送）
+func (s *Session) finishEvents() {
+	if s.eventsClosed {
+		return
+	}
+	s.eventsClosed = true
+	s.stopWatch()
+	close(s.eventsCh)
+}
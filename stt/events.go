@@ -1,7 +1,11 @@
 // Package stt 识别事件定义
 package stt
 
-import "time"
+import (
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
 
 // EventType 事件类型
 type EventType string
@@ -19,6 +23,10 @@ const (
 	EventSessionEnded EventType = "session.ended"
 	// EventSpeechStarted 用户开始说话（对应 MessageType "speech.started"）
 	EventSpeechStarted EventType = "speech.started"
+	// EventEndOfUtterance 一句话说完（对应 MessageType "utterance.end"，需设置 StreamOptions.EndpointSilence / MaxEndpointSilence）
+	EventEndOfUtterance EventType = "utterance.end"
+	// EventBudgetExceeded 超出时延预算（客户端事件，需设置 Config.LatencyBudget；Error 为 *transport.BudgetExceededError）
+	EventBudgetExceeded EventType = "budget.exceeded"
 )
 
 // RecognitionEvent 识别事件
@@ -29,7 +37,26 @@ type RecognitionEvent struct {
 	IsFinal   bool          // 是否最终结果
 	StartTime time.Duration // 开始时间
 	EndTime   time.Duration // 结束时间
-	Error     error         // 错误（仅EventError时有效）
+	Error     error         // 错误（EventError；EventBudgetExceeded 时为 *transport.BudgetExceededError）
+
+	// 语句结束原因（仅 EventEndOfUtterance）：semantic（语义完整）/ silence（静音达到上限）
+	Reason string
+
+	// 相对上一个送达的 partial 新增的文本（仅 transcript.partial）：上一个 partial 是本次 Text 的前缀时为追加部分，
+	// 否则（提供商修改了前文）等于 Text。StreamOptions.StablePartialsOnly 时始终为追加部分
+	DeltaText string
+
+	// 语句序号（仅 transcript.final，从 0 起）：Client.Restore 恢复的会话从 Snapshot.Utterances 继续编号
+	Utterance int
+
+	// N-best 候选（仅 transcript.final，且 StreamOptions.MaxAlternatives > 1、提供商支持时），按置信度从高到低
+	Alternatives []Alternative
+}
+
+// Alternative 识别候选（供下游 NLU 重排序）
+type Alternative struct {
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"` // 0-1
 }
 
 // IsSessionReady 是否为就绪事件
@@ -102,13 +129,33 @@ func NewSpeechStartedEvent() *RecognitionEvent {
 	}
 }
 
+// NewEndOfUtteranceEvent 创建语句结束事件
+func NewEndOfUtteranceEvent(text string, endTime time.Duration, reason string) *RecognitionEvent {
+	return &RecognitionEvent{
+		Type:    EventEndOfUtterance,
+		Text:    text,
+		EndTime: endTime,
+		Reason:  reason,
+	}
+}
+
+// NewBudgetExceededEvent 创建超出时延预算事件
+func NewBudgetExceededEvent(err *transport.BudgetExceededError) *RecognitionEvent {
+	return &RecognitionEvent{
+		Type:  EventBudgetExceeded,
+		Error: err,
+	}
+}
+
 // RecognitionResult 完整识别结果
 type RecognitionResult struct {
-	Text     string         // 完整文本
-	Segments []Segment      // 分段结果
-	Duration time.Duration  //
-	TTFB     time.Duration  // 首个识别结果延迟
-	Error    error
+	SessionID string        // 会话ID（gateway 分配，可用于关联服务端日志）
+	Text      string        // 完整文本
+	Segments  []Segment     // 分段结果
+	Duration  time.Duration //
+	TTFB      time.Duration // 首个识别结果延迟
+	Latencies Latencies     // 首个 partial 延迟、定稿延迟（见 Session.Latencies）
+	Error     error
 }
 
 // Segment 识别分段
@@ -117,6 +164,8 @@ type Segment struct {
 	IsFinal   bool
 	StartTime time.Duration
 	EndTime   time.Duration
+
+	Alternatives []Alternative // N-best 候选（见 RecognitionEvent.Alternatives）
 }
 
 // EventHandler 事件处理回调
@@ -0,0 +1,100 @@
+// Package stt 基于回调的事件处理
+package stt
+
+import (
+	"context"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// Handlers 识别事件回调（nil 回调忽略对应事件）
+//
+// 作为 Session.Events() 的替代：只需回调的集成方不必再各自实现同样的 select 循环。
+// 回调在调用 Handle 的 goroutine 中按事件顺序同步执行，回调阻塞会延后后续事件的处理。
+type Handlers struct {
+	OnEvent          EventHandler      // 每个事件（在具体回调之前调用）
+	OnSpeechStarted  func()            // 用户开始说话
+	OnPartial        func(text string) // 部分识别结果
+	OnFinal          func(seg Segment) // 最终识别结果
+	OnEndOfUtterance func(text string) // 一句话说完（可开始准备回复，定稿文本随后由 OnFinal 给出）
+	OnError          func(err error)   // 错误
+	OnEnded          func()            // 识别完成（session.ended）
+
+	OnBudgetExceeded func(err *transport.BudgetExceededError) // 超出时延预算（Config.LatencyBudget）
+}
+
+// Handle 持续读取会话事件并分发到回调，直到收到 session.ended、事件通道关闭或 ctx 取消
+//
+// 返回会话期间的第一个错误（同时已传给 OnError）；ctx 取消时返回 ctx.Err()。
+// 与 Events() 互斥：同一会话只能选择其中一种方式消费事件。
+//
+//	go func() {
+//		if err := session.Handle(ctx, stt.Handlers{
+//			OnPartial: func(text string) { fmt.Print("\r", text) },
+//			OnFinal:   func(seg stt.Segment) { fmt.Println("\r", seg.Text) },
+//		}); err != nil {
+//			log.Println("recognition failed:", err)
+//		}
+//	}()
+func (s *Session) Handle(ctx context.Context, h Handlers) error {
+	return handleEvents(ctx, s.eventsCh, h)
+}
+
+// handleEvents 从 events 读取事件并分发到回调（Session 与 Replayer 共用）
+func handleEvents(ctx context.Context, events <-chan *RecognitionEvent, h Handlers) error {
+	var firstErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return firstErr
+			}
+			if event.Type == EventError && firstErr == nil {
+				firstErr = event.Error
+			}
+			h.dispatch(event)
+			if event.Type == EventSessionEnded {
+				return firstErr
+			}
+		}
+	}
+}
+
+// dispatch 调用事件对应的回调
+func (h *Handlers) dispatch(event *RecognitionEvent) {
+	if h.OnEvent != nil {
+		h.OnEvent(event)
+	}
+	switch event.Type {
+	case EventSpeechStarted:
+		if h.OnSpeechStarted != nil {
+			h.OnSpeechStarted()
+		}
+	case EventTranscriptPartial:
+		if h.OnPartial != nil {
+			h.OnPartial(event.Text)
+		}
+	case EventTranscriptFinal:
+		if h.OnFinal != nil {
+			h.OnFinal(Segment{Text: event.Text, IsFinal: true, StartTime: event.StartTime, EndTime: event.EndTime})
+		}
+	case EventEndOfUtterance:
+		if h.OnEndOfUtterance != nil {
+			h.OnEndOfUtterance(event.Text)
+		}
+	case EventBudgetExceeded:
+		if err, ok := event.Error.(*transport.BudgetExceededError); ok && h.OnBudgetExceeded != nil {
+			h.OnBudgetExceeded(err)
+		}
+	case EventError:
+		if h.OnError != nil {
+			h.OnError(event.Error)
+		}
+	case EventSessionEnded:
+		if h.OnEnded != nil {
+			h.OnEnded()
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package stt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestHandleDispatchesCallbacks 验证：Handle 按顺序把事件分发到对应回调，收到 session.ended 后返回
+// 会话中的第一个错误；未设置的回调被忽略。
+// WHY：大多数集成只需要回调，Handle 要与手写 select 循环行为一致（顺序、结束条件、错误）。
+func TestHandleDispatchesCallbacks(t *testing.T) {
+	s := &Session{eventsCh: make(chan *RecognitionEvent, 10), closeCh: make(chan struct{})}
+	upstream := errors.New("[provider_error] upstream reset")
+	s.eventsCh <- NewSpeechStartedEvent()
+	s.eventsCh <- NewTranscriptPartialEvent("hel")
+	s.eventsCh <- NewErrorEvent(upstream)
+	s.eventsCh <- NewTranscriptFinalEvent("hello", 100*time.Millisecond, 900*time.Millisecond)
+	s.eventsCh <- NewSessionEndedEvent()
+	s.eventsCh <- NewTranscriptPartialEvent("after end")
+
+	var got []string
+	err := s.Handle(context.Background(), Handlers{
+		OnEvent:   func(e *RecognitionEvent) { got = append(got, string(e.Type)) },
+		OnPartial: func(text string) { got = append(got, "partial:"+text) },
+		OnFinal: func(seg Segment) {
+			if seg.EndTime != 900*time.Millisecond {
+				t.Errorf("final EndTime = %v, want 900ms", seg.EndTime)
+			}
+			got = append(got, "final:"+seg.Text)
+		},
+		OnEnded: func() { got = append(got, "ended") },
+	})
+
+	if !errors.Is(err, upstream) {
+		t.Fatalf("Handle = %v, want %v", err, upstream)
+	}
+	want := []string{
+		"speech.started",
+		"transcript.partial", "partial:hel",
+		"error",
+		"transcript.final", "final:hello",
+		"session.ended", "ended",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("callbacks = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("callbacks = %v, want %v", got, want)
+		}
+	}
+}
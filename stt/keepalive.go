@@ -0,0 +1,149 @@
+// Package stt 稀疏音频保活
+package stt
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// KeepaliveMode 稀疏音频保活方式（见 StreamOptions.KeepaliveInterval）
+type KeepaliveMode string
+
+const (
+	// KeepaliveSilence 补发一帧全零 PCM（默认）
+	KeepaliveSilence KeepaliveMode = "silence"
+	// KeepaliveNoise 补发一帧舒适噪声（约 -70 dBFS），用于把全零音频视为无输入的提供商；仅 16-bit PCM
+	KeepaliveNoise KeepaliveMode = "noise"
+	// KeepalivePing 只发送 WebSocket Ping，不注入音频（gateway 空闲超时；提供商无输入超时不受影响）
+	KeepalivePing KeepaliveMode = "ping"
+)
+
+// keepaliveFrame 每次保活注入的音频时长
+const keepaliveFrame = 100 * time.Millisecond
+
+// comfortNoiseAmplitude 舒适噪声的最大振幅（16-bit，约 -70 dBFS）
+const comfortNoiseAmplitude = 10
+
+// valid 是否为已知的保活方式（空为 KeepaliveSilence）
+func (m KeepaliveMode) valid() bool {
+	switch m {
+	case "", KeepaliveSilence, KeepaliveNoise, KeepalivePing:
+		return true
+	}
+	return false
+}
+
+// validateKeepalive 校验保活配置：注入音频只支持 PCM 输入，舒适噪声只支持 16-bit
+func validateKeepalive(opts *StreamOptions) error {
+	if opts.KeepaliveInterval < 0 {
+		return ErrInvalidConfig("KeepaliveInterval must not be negative")
+	}
+	if !opts.Keepalive.valid() {
+		return ErrInvalidConfig("Keepalive must be silence, noise or ping")
+	}
+	if opts.KeepaliveInterval == 0 || opts.Keepalive == KeepalivePing {
+		return nil
+	}
+	if opts.AudioFormat != "pcm" {
+		return ErrInvalidConfig("Keepalive silence and noise require PCM input, use KeepalivePing")
+	}
+	if opts.Keepalive == KeepaliveNoise && opts.BitsPerSample != 16 {
+		return ErrInvalidConfig("Keepalive noise requires 16-bit PCM")
+	}
+	return nil
+}
+
+// startKeepalive 按 StreamOptions.KeepaliveInterval 启动保活检查（会话就绪后调用）
+//
+// 每 1/4 间隔检查一次，距上次上传达到间隔时保活，因此两次上传之间最长约 1.25 倍间隔。
+func (s *Session) startKeepalive() {
+	interval := s.opts.KeepaliveInterval
+	if interval <= 0 {
+		return
+	}
+	check := max(interval/4, time.Millisecond)
+	if s.config.EventLoop != nil {
+		s.config.EventLoop.Every(check, s.keepalive)
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-s.closeCh:
+				return
+			case <-s.clock().After(check):
+			}
+			if !s.keepalive() {
+				return
+			}
+		}
+	}()
+}
+
+// keepalive 距上次上传达到 KeepaliveInterval 时补发一帧音频或 Ping；会话已结束或已 EndInput 后返回 false
+//
+// 写网络时不持有 mu；Send 正在上传（持有 sendMu）时本次跳过，不阻塞共享事件循环
+func (s *Session) keepalive() bool {
+	if !s.sendMu.TryLock() {
+		return true
+	}
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	if s.closed || !s.endInputAt.IsZero() || !s.sessionEndedAt.IsZero() {
+		s.mu.Unlock()
+		return false
+	}
+	opts := s.opts
+	if opts.KeepaliveInterval <= 0 || s.reconnecting || s.clock().Now().Sub(s.lastUploadAt) < opts.KeepaliveInterval {
+		s.mu.Unlock()
+		return true
+	}
+	conn := s.conn
+	s.mu.Unlock()
+
+	var data []byte
+	if opts.Keepalive == KeepalivePing {
+		if err := conn.Ping(); err != nil {
+			slog.Warn("Keepalive ping failed", "component", "stt", "id", s.ID, "error", err)
+			return true
+		}
+	} else {
+		data = keepaliveAudio(opts)
+		if err := conn.SendAudio(context.Background(), data); err != nil {
+			slog.Warn("Keepalive audio failed", "component", "stt", "id", s.ID, "error", err)
+			return true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data != nil {
+		// 计入上传时间轴：之后的识别结果时间戳包含注入的音频
+		s.uploadEnd += int64(len(data))
+		s.uploadedBytes += int64(len(data))
+		s.chunksSent++
+		if s.replay != nil {
+			s.replay.add(data)
+		}
+	}
+	s.lastUploadAt = s.clock().Now()
+	s.keepalives++
+	slog.Debug("Sparse audio keepalive", "component", "stt", "id", s.ID, "mode", opts.Keepalive)
+	return true
+}
+
+// keepaliveAudio 一帧保活音频：全零，或 16-bit 舒适噪声
+func keepaliveAudio(opts *StreamOptions) []byte {
+	frame := make([]byte, opts.bytesAt(keepaliveFrame))
+	if opts.Keepalive != KeepaliveNoise {
+		return frame
+	}
+	for i := 0; i+1 < len(frame); i += 2 {
+		binary.LittleEndian.PutUint16(frame[i:], uint16(int16(rand.IntN(2*comfortNoiseAmplitude+1)-comfortNoiseAmplitude)))
+	}
+	return frame
+}
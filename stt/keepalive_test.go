@@ -0,0 +1,43 @@
+package stt
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestKeepaliveInjectsSilence 验证：长时间不 Send 时按 KeepaliveInterval 补发静音帧，EndInput 后停止。
+// WHY：push-to-talk 松开按键期间没有音频，提供商的无输入超时会提前结束识别会话。
+func TestKeepaliveInjectsSilence(t *testing.T) {
+	url, received := countingGateway(t)
+	config := DefaultConfig().WithSampleRate(16000)
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultStreamOptions()
+	opts.KeepaliveInterval = 40 * time.Millisecond
+	session, err := client.CreateSession(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	time.Sleep(300 * time.Millisecond)
+	if err := session.EndInput(); err != nil {
+		t.Fatal(err)
+	}
+	injected := session.Stats().Keepalives
+	time.Sleep(100 * time.Millisecond)
+
+	chunks := received()
+	if injected < 3 || len(chunks) != injected || session.Stats().Keepalives != injected {
+		t.Fatalf("keepalives = %d, gateway received %v, want >= 3 frames and none after EndInput", injected, chunks)
+	}
+	for _, n := range chunks {
+		if n != 3200 { // 100ms 16kHz 16-bit
+			t.Fatalf("keepalive frame = %d bytes, want 3200", n)
+		}
+	}
+}
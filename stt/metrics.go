@@ -0,0 +1,196 @@
+// Package stt 响应性 KPI（首个 partial 延迟、定稿延迟）及分位数统计
+package stt
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// DefaultPercentiles 默认统计的分位数
+var DefaultPercentiles = []float64{50, 90, 95, 99}
+
+// Latencies 单个会话的响应性 KPI，对应实时字幕的感知延迟
+//
+// 与 TTFB（首个任意结果）不同，两者分别衡量"开始出字"与"说完到定稿"，是实时字幕场景的主要指标。
+type Latencies struct {
+	FirstPartial time.Duration // 首个音频块发送 → 首个 transcript.partial（未收到 partial 为 0）
+	Finalization time.Duration // EndInput（session.end）→ 最后一个 transcript.final（EndInput 之后无 final 为 0）
+}
+
+// Latencies 返回会话的响应性 KPI（在收到 session.ended 后读取结果完整）
+func (s *Session) Latencies() Latencies {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var l Latencies
+	l.FirstPartial = between(s.firstSendTime, s.firstPartialAt)
+	if n := len(s.finalAts); n > 0 {
+		l.Finalization = between(s.endInputAt, s.finalAts[n-1])
+	}
+	return l
+}
+
+// FirstPartialLatency 返回首次 Send → 首个 transcript.partial 的时间（未收到 partial 为 0，同 Latencies.FirstPartial）
+func (s *Session) FirstPartialLatency() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return between(s.firstSendTime, s.firstPartialAt)
+}
+
+// FirstFinalLatency 返回首次 Send → 首个 transcript.final 的时间（未收到 final 为 0）
+func (s *Session) FirstFinalLatency() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.finalAts) == 0 {
+		return 0
+	}
+	return between(s.firstSendTime, s.finalAts[0])
+}
+
+// CommitToFinal 返回 EndInput（session.end）→ 最后一个 transcript.final 的时间（同 Latencies.Finalization）
+func (s *Session) CommitToFinal() time.Duration {
+	return s.Latencies().Finalization
+}
+
+// CommitToEnded 返回 EndInput（session.end）→ session.ended 的时间，即 gateway 处理完全部输入的尾延迟（未结束为 0）
+func (s *Session) CommitToEnded() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return between(s.endInputAt, s.sessionEndedAt)
+}
+
+// Stats 单个会话的统计（在收到 session.ended 后读取结果完整）
+type Stats struct {
+	BytesInput    int64         // 送入 Send 的音频字节数
+	BytesSent     int64         // 实际上传的音频字节数（客户端 VAD 过滤后，含断线重连的重放）
+	ChunksSent    int           // 上传的 audio.append 消息数
+	Partials      int           // 收到的 transcript.partial 数
+	Finals        int           // 收到的 transcript.final 数
+	TTFB          time.Duration // 首次 Send → 首个识别结果（partial 或 final）
+	FirstPartial  time.Duration // 同 Latencies.FirstPartial
+	Finalization  time.Duration // EndInput → 最后一个 transcript.final，同 Latencies.Finalization
+	DroppedEvents int           // 事件缓冲区满、未送达 Events 的事件数
+	DroppedAudio  time.Duration // 客户端 VAD 丢弃的静音时长
+	Resumes       int           // 断线重连次数
+	AutoCommits   int           // StreamOptions.MaxUtteranceDuration 触发的自动提交次数
+	Keepalives    int           // StreamOptions.KeepaliveInterval 触发的保活次数（注入的音频计入 BytesSent）
+}
+
+// Stats 返回会话的统计快照
+func (s *Session) Stats() Stats {
+	l := s.Latencies()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := Stats{
+		BytesInput:    s.sentBytes,
+		BytesSent:     s.uploadedBytes,
+		ChunksSent:    s.chunksSent,
+		Partials:      s.partials,
+		Finals:        len(s.finalAts),
+		TTFB:          s.ttfb,
+		FirstPartial:  l.FirstPartial,
+		Finalization:  l.Finalization,
+		DroppedEvents: s.droppedEvents,
+		Resumes:       s.resumes,
+		AutoCommits:   s.autoCommits,
+		Keepalives:    s.keepalives,
+	}
+	if s.vad != nil {
+		stats.DroppedAudio = s.vad.Dropped()
+	}
+	return stats
+}
+
+// LatencyBreakdown 返回建连、握手、首个识别结果各阶段的耗时（首个结果未到时 TTFB 计到当前时刻）
+func (s *Session) LatencyBreakdown() transport.LatencyBreakdown {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.breakdownLocked()
+}
+
+// breakdownLocked 计算阶段明细（调用方持有 mu）
+func (s *Session) breakdownLocked() transport.LatencyBreakdown {
+	b := transport.LatencyBreakdown{Budget: s.config.LatencyBudget, Phases: append([]transport.PhaseLatency(nil), s.setup...)}
+	if !s.firstSendTime.IsZero() {
+		ttfb := s.ttfb
+		if !s.ttfbDone {
+			ttfb = clock.Since(s.clock(), s.firstSendTime)
+		}
+		b.Phases = append(b.Phases, transport.PhaseLatency{Phase: transport.PhaseTTFB, Duration: ttfb})
+	}
+	return b
+}
+
+// between 返回 from → to 的时长；任一时间为零或顺序倒置时返回 0
+func between(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+// LatencyStats 一组延迟样本的统计（分位数按最近秩法计算）
+type LatencyStats struct {
+	Count       int                       // 有效样本数（0 值样本表示未测得，不计入）
+	Min         time.Duration             //
+	Max         time.Duration             //
+	Mean        time.Duration             //
+	Percentiles map[float64]time.Duration // 分位数（如 95 → P95）
+}
+
+// Percentile 返回分位数 p 的值（未统计该分位数或无样本时为 0）
+func (s LatencyStats) Percentile(p float64) time.Duration {
+	return s.Percentiles[p]
+}
+
+// NewLatencyStats 统计 samples；percentiles 为空时使用 DefaultPercentiles
+func NewLatencyStats(samples []time.Duration, percentiles ...float64) LatencyStats {
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+	var sorted []time.Duration
+	for _, v := range samples {
+		if v > 0 {
+			sorted = append(sorted, v)
+		}
+	}
+	stats := LatencyStats{Count: len(sorted), Percentiles: make(map[float64]time.Duration, len(percentiles))}
+	if len(sorted) == 0 {
+		return stats
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, v := range sorted {
+		sum += v
+	}
+	stats.Min, stats.Max = sorted[0], sorted[len(sorted)-1]
+	stats.Mean = sum / time.Duration(len(sorted))
+	for _, p := range percentiles {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		stats.Percentiles[p] = sorted[min(max(rank, 1), len(sorted))-1]
+	}
+	return stats
+}
+
+// LatencySummary 多个会话的 KPI 统计
+type LatencySummary struct {
+	FirstPartial LatencyStats
+	Finalization LatencyStats
+}
+
+// SummarizeLatencies 按 percentiles（为空时使用 DefaultPercentiles）统计多个会话的 KPI
+func SummarizeLatencies(latencies []Latencies, percentiles ...float64) LatencySummary {
+	firstPartial := make([]time.Duration, len(latencies))
+	finalization := make([]time.Duration, len(latencies))
+	for i, l := range latencies {
+		firstPartial[i], finalization[i] = l.FirstPartial, l.Finalization
+	}
+	return LatencySummary{
+		FirstPartial: NewLatencyStats(firstPartial, percentiles...),
+		Finalization: NewLatencyStats(finalization, percentiles...),
+	}
+}
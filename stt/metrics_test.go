@@ -0,0 +1,167 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestLatenciesAndSummary 验证：Session.Latencies 以首包发送 → 首个 partial、EndInput → 最后一个 final 计算 KPI，
+// 缺失的时间戳记为 0；SummarizeLatencies 按最近秩法计算分位数且不计入 0 值样本。
+// WHY：首个 partial 与定稿延迟是实时字幕的主要 KPI，未测得的会话若按 0 计入会拉低分位数、掩盖问题。
+func TestLatenciesAndSummary(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	s := &Session{
+		firstSendTime:  base,
+		firstPartialAt: base.Add(300 * time.Millisecond),
+		endInputAt:     base.Add(2 * time.Second),
+		finalAts:       []time.Time{base.Add(1500 * time.Millisecond), base.Add(2400 * time.Millisecond)},
+	}
+	got := s.Latencies()
+	if got.FirstPartial != 300*time.Millisecond || got.Finalization != 400*time.Millisecond {
+		t.Fatalf("Latencies = %+v, want 300ms / 400ms", got)
+	}
+	if l := (&Session{firstSendTime: base}).Latencies(); l != (Latencies{}) {
+		t.Fatalf("Latencies without events = %+v, want zero", l)
+	}
+
+	var all []Latencies
+	for i := 1; i <= 10; i++ {
+		all = append(all, Latencies{FirstPartial: time.Duration(i) * 100 * time.Millisecond})
+	}
+	all = append(all, Latencies{}) // 未收到 partial 的会话
+	sum := SummarizeLatencies(all, 50, 95)
+	fp := sum.FirstPartial
+	if fp.Count != 10 || fp.Min != 100*time.Millisecond || fp.Max != time.Second || fp.Mean != 550*time.Millisecond {
+		t.Fatalf("FirstPartial = %+v", fp)
+	}
+	if fp.Percentile(50) != 500*time.Millisecond || fp.Percentile(95) != time.Second {
+		t.Fatalf("P50/P95 = %v/%v, want 500ms/1s", fp.Percentile(50), fp.Percentile(95))
+	}
+	if sum.Finalization.Count != 0 || sum.Finalization.Percentile(95) != 0 {
+		t.Fatalf("Finalization = %+v, want no samples", sum.Finalization)
+	}
+}
+
+// TestSessionStats 验证：Session.Stats 统计实际上传的字节数与 audio.append 条数、partial / final 条数，
+// 以及与 TTFB()、Latencies() 一致的时延。
+// WHY：此前 STT 只暴露零散的 TTFB / DroppedAudio，排查"字幕慢"时无法区分是音频没发出去还是结果没回来。
+func TestSessionStats(t *testing.T) {
+	const chunk = 3200
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeSessionConfig:
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+			case protocol.MessageTypeAudioAppend:
+				ws.WriteJSON(protocol.TranscriptPartial{Type: protocol.MessageTypeTranscriptPartial, Text: "你"})
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "你好"})
+				ws.WriteJSON(protocol.SessionEnded{Type: protocol.MessageTypeSessionEnded})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.GatewayURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := session.Send(bytes.Repeat([]byte{1}, chunk)); err != nil {
+			t.Fatalf("Send #%d: %v", i, err)
+		}
+	}
+	// 等 3 个 partial 都到达后再结束，保证计数确定
+	for seen := 0; seen < 3; {
+		select {
+		case ev := <-session.Events():
+			if ev.Type == EventTranscriptPartial {
+				seen++
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for partials")
+		}
+	}
+	if err := session.EndInput(); err != nil {
+		t.Fatalf("EndInput: %v", err)
+	}
+	for ev := range session.Events() {
+		if ev.Type == EventSessionEnded {
+			break
+		}
+	}
+
+	stats := session.Stats()
+	if stats.BytesInput != 3*chunk || stats.BytesSent != 3*chunk || stats.ChunksSent != 3 {
+		t.Fatalf("Stats bytes/chunks = %d/%d/%d, want %d/%d/3", stats.BytesInput, stats.BytesSent, stats.ChunksSent, 3*chunk, 3*chunk)
+	}
+	if stats.Partials != 3 || stats.Finals != 1 || stats.DroppedEvents != 0 {
+		t.Fatalf("Stats partials/finals/dropped = %d/%d/%d, want 3/1/0", stats.Partials, stats.Finals, stats.DroppedEvents)
+	}
+	l := session.Latencies()
+	if stats.TTFB != session.TTFB() || stats.TTFB <= 0 || stats.FirstPartial != l.FirstPartial || stats.Finalization != l.Finalization {
+		t.Fatalf("Stats timing = %v/%v/%v, want TTFB %v and %+v", stats.TTFB, stats.FirstPartial, stats.Finalization, session.TTFB(), l)
+	}
+}
+
+// TestLatencyGetters 验证：首个 partial / 首个 final 从首次 Send 起算，CommitToFinal / CommitToEnded 从 EndInput 起算；
+// 对应事件未发生时为 0。
+// WHY：STT 压测原先要在应用层自己打点，口径与 TTS 的 TTFB() 不一致，不同工具的结果无法对比。
+func TestLatencyGetters(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	s := &Session{
+		config:         &Config{},
+		firstSendTime:  base,
+		firstPartialAt: base.Add(200 * time.Millisecond),
+		finalAts:       []time.Time{base.Add(900 * time.Millisecond), base.Add(2300 * time.Millisecond)},
+		endInputAt:     base.Add(2 * time.Second),
+		sessionEndedAt: base.Add(2500 * time.Millisecond),
+	}
+	if got := s.FirstPartialLatency(); got != 200*time.Millisecond {
+		t.Fatalf("FirstPartialLatency = %v, want 200ms", got)
+	}
+	if got := s.FirstFinalLatency(); got != 900*time.Millisecond {
+		t.Fatalf("FirstFinalLatency = %v, want 900ms", got)
+	}
+	if got := s.CommitToFinal(); got != 300*time.Millisecond {
+		t.Fatalf("CommitToFinal = %v, want 300ms", got)
+	}
+	if got := s.CommitToEnded(); got != 500*time.Millisecond {
+		t.Fatalf("CommitToEnded = %v, want 500ms", got)
+	}
+
+	empty := &Session{config: &Config{}, firstSendTime: base}
+	if empty.FirstFinalLatency() != 0 || empty.CommitToFinal() != 0 || empty.CommitToEnded() != 0 {
+		t.Fatal("latency getters without events should return 0")
+	}
+}
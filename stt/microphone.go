@@ -0,0 +1,72 @@
+// Package stt 麦克风实时识别
+package stt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio/capture"
+)
+
+// MicrophoneOptions 麦克风识别选项
+type MicrophoneOptions struct {
+	Language   string // 识别语言（空使用客户端配置）
+	SampleRate int    // 采集采样率（0 使用客户端配置）
+	Device     string // 输入设备（空为系统默认）
+	Recorder   string // 指定系统录音程序（arecord/parec/rec/ffmpeg），空则自动探测
+}
+
+// RecognizeMicrophone 从默认（或指定）输入设备采集 16-bit 单声道 PCM 并实时识别
+//
+// 返回的会话持续发送麦克风音频，调用方通过 Events() 或 Handle 接收识别结果。ctx 取消时停止采集并
+// EndInput，已上传音频的最终结果仍会送达，随后收到 session.ended；调用方负责 Close 会话。
+// 未安装系统录音程序时返回 capture.ErrNoRecorder。
+func (c *Client) RecognizeMicrophone(ctx context.Context, opts *MicrophoneOptions) (*Session, error) {
+	if opts == nil {
+		opts = &MicrophoneOptions{}
+	}
+	stream := c.streamOptions()
+	stream.AudioFormat = "pcm"
+	stream.Channels = 1
+	stream.BitsPerSample = 16
+	stream.BigEndian = false
+	if opts.Language != "" {
+		stream.Language = opts.Language
+	}
+	if opts.SampleRate > 0 {
+		stream.SampleRate = opts.SampleRate
+	}
+
+	// 先启动采集：没有录音程序或设备时尽早失败，不必建连
+	recorder, err := capture.Open(ctx, capture.Options{
+		Device:     opts.Device,
+		SampleRate: stream.SampleRate,
+		Channels:   1,
+		Recorder:   opts.Recorder,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open microphone: %w", err)
+	}
+
+	// 会话不随 ctx 取消：停止说话后仍需接收已上传音频的最终结果
+	session, err := c.createSession(context.WithoutCancel(ctx), stream)
+	if err != nil {
+		recorder.Close()
+		return nil, err
+	}
+	slog.Info("Microphone recognition started", "component", "stt", "recorder", recorder.Name(),
+		"device", opts.Device, "sample_rate", stream.SampleRate, "session_id", session.ID)
+
+	go func() {
+		defer recorder.Close()
+		// 会话被 Close 时 Send 失败即停止采集；录音程序异常退出（如设备不可用）时结束输入
+		if err := session.sendChunks(ctx, recorder, 0); err != nil && ctx.Err() == nil && !session.IsClosed() {
+			slog.Warn("Microphone capture stopped", "component", "stt", "error", err)
+		}
+		if err := session.EndInput(); err != nil {
+			slog.Debug("EndInput after microphone capture failed", "component", "stt", "error", err)
+		}
+	}()
+	return session, nil
+}
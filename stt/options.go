@@ -1,7 +1,21 @@
 // Package stt 提供STT客户端
 package stt
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/preset"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
 
 // Config STT客户端配置
 type Config struct {
@@ -10,18 +24,128 @@ type Config struct {
 	Provider   string // 提供商: tengen (默认), azure, qwen, voxnexus
 	APIKey     string // API Key 认证（可选，通过URL参数传递）
 
+	// 按提供商指定 gateway URL（不同提供商由不同 gateway 集群服务时），未列出的提供商使用 GatewayURL；
+	// 同一个 Client 按每次会话的提供商选择 gateway
+	Gateways map[string]string
+
+	// 认证凭据提供方（可选，如短期令牌）：设置后每次建连获取凭据并覆盖 APIKey；
+	// gateway 返回 401/403 时刷新重试一次，仍被拒绝时返回 *transport.AuthError（含 gateway 的错误说明）
+	Auth transport.AuthProvider
+
+	// 握手附加的 HTTP 头（如链路追踪头）；BearerAuth 时 APIKey / Auth 的凭据改用 Authorization: Bearer 头发送，
+	// 不再出现在 URL 中（URL 会记入代理与访问日志）。见 transport.Config
+	Header     http.Header
+	BearerAuth bool
+
+	// 代理（企业网络出口）：ProxyURL 为固定代理（http:// 或 socks5://，可带用户名密码），
+	// Proxy 为自定义选择函数（如 http.ProxyFromEnvironment），两者都设置时以 Proxy 为准；均为空时直连
+	ProxyURL string
+	Proxy    func(*http.Request) (*url.URL, error)
+
+	// 自定义 TCP 拨号（固定 IP、自定义 DNS、socket 选项、VPC 端点等），见 transport.Config.NetDialContext
+	NetDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// 异步发送队列（0 关闭）：>0 时发送只入队、由专用 goroutine 写出，相邻音频帧合并，排队达到此字节数时
+	// 按 SendQueuePolicy 阻塞或返回 transport.ErrBufferFull。见 transport.Config.SendQueueBytes
+	SendQueueBytes  int
+	SendQueuePolicy transport.SendQueuePolicy
+
+	// 接收缓冲区（消息数，0 使用 transport.DefaultReceiveBuffer）与满时的处理方式（默认暂停读取），
+	// 见 transport.Config.ReceiveBuffer
+	ReceiveBuffer   int
+	ReceiveOverflow transport.OverflowPolicy
+
+	// 音频上传限速（0 不限速），批量识别文件时避免以远超实时的速度触发 gateway / 提供商的速率限制：
+	// MaxSendRate 为字节/秒；MaxSendSpeed 为相对实时的倍数（如 2 即最快 2 倍速，按会话的采样率、声道与位深换算，
+	// 仅 PCM / WAV 输入生效）。两者都设置时取较慢者，见 transport.Config.SendRate
+	MaxSendRate  int
+	MaxSendSpeed float64
+
+	// 连接拦截器链（日志、加密、消息改写等），作用于本客户端的所有连接，见 transport.Interceptor
+	Interceptors []transport.Interceptor
+
+	// 连接指标回调（可选）：本客户端所有连接（含断线重连建立的新连接）的消息、字节、Ping RTT、重连与错误，
+	// 见 transport.MetricsHook
+	Metrics transport.MetricsHook
+
 	// 识别参数
-	Language     string // 识别语言: zh-CN, en-US
-	SampleRate   int    // 采样率: 16000, 8000
-	AudioFormat  string // 音频格式: pcm, wav
-	// 连接配置
+	Language      string // 识别语言: zh-CN, en-US
+	SampleRate    int    // 采样率: 16000, 8000
+	AudioFormat   string // 音频格式: pcm, wav, raw（raw 为无头 PCM，等同 pcm）
+	Channels      int    // 声道数（默认 1）
+	BitsPerSample int    // 位深（默认 16）
+	BigEndian     bool   // 输入 PCM 为大端字节序（部分传统电话录音），发送前转换为小端
+
+	// 短语提示（自定义词汇）：领域术语、产品名、人名等，随 session.config 发送；
+	// PhraseBoosts 可选地为其中的短语指定增强权重（未指定的短语使用提供商默认值）
+	PhraseHints  []string
+	PhraseBoosts map[string]float64
+
+	// 客户端 VAD：Send 前用本地能量 VAD 丢弃长静音（保留语音前后的短停顿），节省上行带宽和提供商费用；
+	// 识别结果的时间戳自动换算回原始音频时间轴。仅支持 16-bit PCM
+	EnableClientVAD  bool
+	VADThresholdDBFS float64 // 语音判定电平（0 使用 audio.DefaultVADThresholdDBFS）
+
+	// 调度优先级提示（protocol.PriorityRealtime / PriorityBulk），随 session.config 发送，空由 gateway 决定
+	Priority protocol.Priority
+
+	// 会话亲和令牌（之前会话 Session.AffinityToken 的返回值），随 session.config 发送，
+	// gateway 尽量路由到同一后端；空不发送
+	AffinityToken string
+
+	// 功能标志：在 client_info.features 中声明的功能（如 protocol.FeatureWordTimestamps），
+	// gateway 在 config_done 中确认后生效，见 Session.Features
+	Features []string
+
+	// 消息编码："" / "json"（默认）或 "msgpack"。非 JSON 编码在 client_info.codecs 中声明，
+	// gateway 在 config_done 中选定后用于此后的消息；旧版 gateway 不选定时保持 JSON
+	Codec string
+
+	// 连接配置（0 使用 transport.Default*）
 	ConnectTimeout   time.Duration // 连接超时
-	ReadTimeout      time.Duration // 读超时
-	WriteTimeout     time.Duration // 写超时
+	ReadTimeout      time.Duration // 读超时（<0 不设置读 deadline）
+	WriteTimeout     time.Duration // 写超时（<0 不设置写 deadline）
 	ReconnectBackoff time.Duration // 重连退避基数
-	MaxReconnects    int           // 最大重连次数
+	MaxReconnects    int           // 最大重连次数（<0 不重连）
+
+	// 空闲超时（0 不启用）：>0 时替代 ReadTimeout，收发任一方向有消息或 Ping/Pong 即顺延，
+	// 等待用户开口期间 gateway 不发消息、只要仍在 Send 音频连接就不会超时。
+	// MaxConnDuration 为连接最长存活时间（0 不限制），从建连起计算、不随活动顺延。见 transport.Config
+	IdleTimeout     time.Duration
+	MaxConnDuration time.Duration
+
+	// Pong 超时（0 不检测）：保活 Ping 之后此时间内未收到 Pong 即判定连接失效并按断线处理（续传 / 重连），
+	// 不必等到 ReadTimeout。见 transport.Config.PongTimeout
+	PongTimeout time.Duration
+
+	// 断线重连（默认关闭）：ReplayBuffer >0 时保留最近上传的这段音频，识别中连接异常断开后自动重连（最多 MaxResumes 次），
+	// 从最后一个 transcript.final 之后的位置重发音频继续识别；重连期间 Send 的音频暂存并一并重发。
+	// 断开期间超出 ReplayBuffer 的音频丢失。仅支持 PCM 输入
+	ReplayBuffer time.Duration
+	MaxResumes   int // 重连次数（0 使用 DefaultMaxResumes，<0 不重连）
+
+	// 端到端时延预算（0 关闭）：记录建连、握手、首个识别结果各阶段耗时（Session.LatencyBreakdown），
+	// 首个识别结果到达时合计超出预算则发送 EventBudgetExceeded（Error 为 *transport.BudgetExceededError，指出耗时最长的阶段）；
+	// 建连失败时错误包装为 *transport.LatencyError，附带失败时的阶段明细
+	LatencyBudget time.Duration
+
+	// 事件缓冲区：Events() 的容量（0 使用 DefaultEventBufferSize）。缓冲区满时默认丢弃新事件并计入 Stats.DroppedEvents；
+	// BlockOnFullBuffer 时改为等待消费：消息循环暂停处理，未读消息积压在连接上（TCP 背压，gateway 随之放慢发送），
+	// 不丢失任何事件。阻塞模式下必须持续读取 Events()（或使用 Handle），否则会话停滞直到 Close
+	EventBufferSize   int
+	BlockOnFullBuffer bool
+
+	// 共享事件循环（transport.NewEventLoop）：高密度部署下多个会话共用分发 worker，
+	// 替代每个会话常驻的消息循环 goroutine；nil 为每会话独立 goroutine。公开 API 不变
+	EventLoop *transport.EventLoop
+
+	// 时钟：时延计算、识别空闲超时使用（nil 使用系统时钟，测试可注入 clock.Fake）
+	Clock clock.Clock
 }
 
+// DefaultEventBufferSize Events() 的默认缓冲区大小
+const DefaultEventBufferSize = 100
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -30,43 +154,143 @@ func DefaultConfig() *Config {
 		Language:         "zh-CN",
 		SampleRate:       16000,
 		AudioFormat:      "pcm",
-		ConnectTimeout:   10 * time.Second,
-		ReadTimeout:      60 * time.Second,
-		WriteTimeout:     10 * time.Second,
-		ReconnectBackoff: 1 * time.Second,
-		MaxReconnects:    3,
+		Channels:         1,
+		BitsPerSample:    16,
+		ConnectTimeout:   transport.DefaultConnectTimeout,
+		ReadTimeout:      transport.DefaultReadTimeout,
+		WriteTimeout:     transport.DefaultWriteTimeout,
+		ReconnectBackoff: transport.DefaultReconnectBackoff,
+		MaxReconnects:    transport.DefaultMaxReconnects,
 	}
 }
 
-// Validate 验证配置
+// Normalize 把零值字段替换为默认值（NewClient 在 Validate 之前调用）
+//
+// 零值一律表示"使用默认值"，与 DefaultConfig 一致；需要关闭的功能用负值表示（见各字段说明）。
+func (c *Config) Normalize() {
+	if c.SampleRate <= 0 {
+		c.SampleRate = 16000
+	}
+	if c.Language == "" {
+		c.Language = "zh-CN"
+	}
+	if c.AudioFormat == "" || c.AudioFormat == "raw" {
+		c.AudioFormat = "pcm"
+	}
+	if c.Channels <= 0 {
+		c.Channels = 1
+	}
+	if c.BitsPerSample <= 0 {
+		c.BitsPerSample = 16
+	}
+	if c.MaxResumes == 0 {
+		c.MaxResumes = DefaultMaxResumes
+	}
+	if c.EventBufferSize == 0 {
+		c.EventBufferSize = DefaultEventBufferSize
+	}
+	conn := transport.Config{
+		ConnectTimeout:   c.ConnectTimeout,
+		ReadTimeout:      c.ReadTimeout,
+		WriteTimeout:     c.WriteTimeout,
+		ReconnectBackoff: c.ReconnectBackoff,
+		MaxReconnects:    c.MaxReconnects,
+	}
+	conn.Normalize()
+	c.ConnectTimeout, c.ReadTimeout, c.WriteTimeout = conn.ConnectTimeout, conn.ReadTimeout, conn.WriteTimeout
+	c.ReconnectBackoff, c.MaxReconnects = conn.ReconnectBackoff, conn.MaxReconnects
+}
+
+// Warnings 返回可疑但合法的取值说明（配置仍然生效，NewClient 逐条记录 Warn 日志）
+func (c *Config) Warnings() []string {
+	return transport.TimeoutWarnings(c.ConnectTimeout, c.ReadTimeout, c.WriteTimeout)
+}
+
+// Validate 验证配置（不修改配置；零值字段的默认值由 Normalize 填充）
 func (c *Config) Validate() error {
-	if c.GatewayURL == "" {
+	if c.GatewayURL == "" && c.Gateways[c.Provider] == "" {
 		return ErrInvalidConfig("GatewayURL is required")
 	}
+	if c.GatewayURL != "" {
+		if err := transport.ValidateGatewayURL(c.GatewayURL); err != nil {
+			return ErrInvalidConfig(err.Error())
+		}
+	}
+	for provider, gatewayURL := range c.Gateways {
+		if gatewayURL == "" {
+			return ErrInvalidConfig(fmt.Sprintf("empty gateway URL for provider %q", provider))
+		}
+		if err := transport.ValidateGatewayURL(gatewayURL); err != nil {
+			return ErrInvalidConfig(err.Error())
+		}
+	}
 	if c.Provider == "" {
 		return ErrInvalidConfig("Provider is required")
 	}
-	if c.SampleRate <= 0 {
-		c.SampleRate = 16000
+	if !c.Priority.Valid() {
+		return ErrInvalidConfig("Priority must be realtime or bulk")
 	}
-	if c.Language == "" {
-		c.Language = "zh-CN"
+	if _, err := transport.CodecByName(c.Codec); err != nil {
+		return ErrInvalidConfig(err.Error())
 	}
-	if c.AudioFormat == "" {
-		c.AudioFormat = "pcm"
+	if c.BitsPerSample%8 != 0 || c.BitsPerSample > 32 {
+		return ErrInvalidConfig(fmt.Sprintf("unsupported BitsPerSample: %d", c.BitsPerSample))
+	}
+	if c.EnableClientVAD && c.BitsPerSample != 16 {
+		return ErrInvalidConfig("EnableClientVAD requires 16-bit PCM")
 	}
-	if c.ConnectTimeout <= 0 {
-		c.ConnectTimeout = 10 * time.Second
+	if err := validatePhraseHints(c.PhraseHints, c.PhraseBoosts); err != nil {
+		return err
 	}
-	if c.ReadTimeout <= 0 {
-		c.ReadTimeout = 60 * time.Second
+	if c.ReplayBuffer < 0 {
+		return ErrInvalidConfig("ReplayBuffer must not be negative")
 	}
-	if c.WriteTimeout <= 0 {
-		c.WriteTimeout = 10 * time.Second
+	if c.LatencyBudget < 0 {
+		return ErrInvalidConfig("LatencyBudget must not be negative")
+	}
+	if c.ProxyURL != "" {
+		if _, err := transport.ParseProxyURL(c.ProxyURL); err != nil {
+			return ErrInvalidConfig(err.Error())
+		}
+	}
+	if c.SendQueueBytes < 0 {
+		return ErrInvalidConfig("SendQueueBytes must not be negative")
+	}
+	if !c.SendQueuePolicy.Valid() {
+		return ErrInvalidConfig("SendQueuePolicy must be block or reject")
+	}
+	if c.ReceiveBuffer < 0 {
+		return ErrInvalidConfig("ReceiveBuffer must not be negative")
+	}
+	if !c.ReceiveOverflow.Valid() {
+		return ErrInvalidConfig("ReceiveOverflow must be block, drop-oldest or error")
+	}
+	if c.EventBufferSize < 0 {
+		return ErrInvalidConfig("EventBufferSize must not be negative")
+	}
+	if c.MaxSendRate < 0 || c.MaxSendSpeed < 0 {
+		return ErrInvalidConfig("MaxSendRate and MaxSendSpeed must not be negative")
 	}
 	return nil
 }
 
+// WithGateway 指定 provider 使用的 gateway URL
+func (c *Config) WithGateway(provider, gatewayURL string) *Config {
+	if c.Gateways == nil {
+		c.Gateways = make(map[string]string)
+	}
+	c.Gateways[provider] = gatewayURL
+	return c
+}
+
+// gatewayURL 返回 provider 对应的 gateway URL（未在 Gateways 中指定时为 GatewayURL）
+func (c *Config) gatewayURL(provider string) string {
+	if u, ok := c.Gateways[provider]; ok {
+		return u
+	}
+	return c.GatewayURL
+}
+
 // WithProvider 设置提供商
 func (c *Config) WithProvider(provider string) *Config {
 	c.Provider = provider
@@ -85,28 +309,339 @@ func (c *Config) WithSampleRate(sampleRate int) *Config {
 	return c
 }
 
+// WithAudioFormat 设置音频格式（pcm/raw 为无头 PCM，wav 自动跳过文件头）
+func (c *Config) WithAudioFormat(audioFormat string) *Config {
+	c.AudioFormat = audioFormat
+	return c
+}
+
+// WithChannels 设置声道数
+func (c *Config) WithChannels(channels int) *Config {
+	c.Channels = channels
+	return c
+}
+
+// WithBitsPerSample 设置位深
+func (c *Config) WithBitsPerSample(bits int) *Config {
+	c.BitsPerSample = bits
+	return c
+}
+
+// WithBigEndian 声明输入 PCM 为大端字节序
+func (c *Config) WithBigEndian(bigEndian bool) *Config {
+	c.BigEndian = bigEndian
+	return c
+}
+
+// WithReplayBuffer 开启断线重连，保留最近 d 的上传音频用于重放
+func (c *Config) WithReplayBuffer(d time.Duration) *Config {
+	c.ReplayBuffer = d
+	return c
+}
+
+// WithLatencyBudget 设置端到端时延预算（0 关闭）
+func (c *Config) WithLatencyBudget(d time.Duration) *Config {
+	c.LatencyBudget = d
+	return c
+}
+
+// WithEventBuffer 设置事件缓冲区大小；block 为 true 时缓冲区满后等待消费而不是丢弃事件
+func (c *Config) WithEventBuffer(size int, block bool) *Config {
+	c.EventBufferSize = size
+	c.BlockOnFullBuffer = block
+	return c
+}
+
+// WithAuth 设置认证凭据提供方
+func (c *Config) WithAuth(auth transport.AuthProvider) *Config {
+	c.Auth = auth
+	return c
+}
+
 // WithAPIKey 设置API Key
 func (c *Config) WithAPIKey(apiKey string) *Config {
 	c.APIKey = apiKey
 	return c
 }
 
+// WithHeader 添加握手时发送的 HTTP 头
+func (c *Config) WithHeader(key, value string) *Config {
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+	c.Header.Add(key, value)
+	return c
+}
+
+// WithBearerAuth 凭据改用 Authorization: Bearer 头发送，不放在 URL 中
+func (c *Config) WithBearerAuth() *Config {
+	c.BearerAuth = true
+	return c
+}
+
+// WithProxy 经 HTTP 或 SOCKS5 代理连接 gateway（格式见 transport.ParseProxyURL）
+func (c *Config) WithProxy(proxyURL string) *Config {
+	c.ProxyURL = proxyURL
+	return c
+}
+
+// WithNetDialContext 使用自定义 TCP 拨号函数建立连接
+func (c *Config) WithNetDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) *Config {
+	c.NetDialContext = dial
+	return c
+}
+
+// WithSendQueue 开启异步发送队列（limit 为排队字节上限，policy 为队列满时的处理方式）
+func (c *Config) WithSendQueue(limit int, policy transport.SendQueuePolicy) *Config {
+	c.SendQueueBytes, c.SendQueuePolicy = limit, policy
+	return c
+}
+
+// WithReceiveBuffer 设置接收缓冲区容量（消息数）与满时的处理方式
+func (c *Config) WithReceiveBuffer(size int, policy transport.OverflowPolicy) *Config {
+	c.ReceiveBuffer, c.ReceiveOverflow = size, policy
+	return c
+}
+
+// WithMaxSendRate 限制音频上传速度（字节/秒）
+func (c *Config) WithMaxSendRate(bytesPerSec int) *Config {
+	c.MaxSendRate = bytesPerSec
+	return c
+}
+
+// WithMaxSendSpeed 限制音频上传速度为实时的 speed 倍
+func (c *Config) WithMaxSendSpeed(speed float64) *Config {
+	c.MaxSendSpeed = speed
+	return c
+}
+
+// sendRate 会话的音频上传限速（字节/秒，0 不限速）：MaxSendRate 与按 MaxSendSpeed 换算的速度中较慢者
+func (c *Config) sendRate(opts *StreamOptions) int {
+	rate := c.MaxSendRate
+	if c.MaxSendSpeed > 0 && (opts.AudioFormat == "pcm" || opts.AudioFormat == "wav") {
+		if r := int(c.MaxSendSpeed * float64(opts.byteRate())); r > 0 && (rate == 0 || r < rate) {
+			rate = r
+		}
+	}
+	return rate
+}
+
+// WithInterceptor 在拦截器链末尾追加一个拦截器
+func (c *Config) WithInterceptor(i transport.Interceptor) *Config {
+	c.Interceptors = append(c.Interceptors, i)
+	return c
+}
+
+// WithMetrics 设置连接指标回调
+func (c *Config) WithMetrics(hook transport.MetricsHook) *Config {
+	c.Metrics = hook
+	return c
+}
+
+// proxy 连接使用的代理选择函数（nil 直连）
+func (c *Config) proxy() func(*http.Request) (*url.URL, error) {
+	if c.Proxy != nil || c.ProxyURL == "" {
+		return c.Proxy
+	}
+	proxy, err := transport.ProxyURL(c.ProxyURL)
+	if err != nil {
+		return nil // Validate 已拒绝无效的 ProxyURL
+	}
+	return proxy
+}
+
+// ApplyPreset 应用提供商预设：Provider 总是覆盖，Language / SampleRate / AudioFormat 非零时覆盖
+// （音色、语速等 TTS 字段忽略）
+func (c *Config) ApplyPreset(p preset.Preset) *Config {
+	c.Provider = p.Provider
+	if p.Language != "" {
+		c.Language = p.Language
+	}
+	if p.SampleRate != 0 {
+		c.SampleRate = p.SampleRate
+	}
+	if p.AudioFormat != "" {
+		c.AudioFormat = p.AudioFormat
+	}
+	return c
+}
+
+// WithPriority 设置调度优先级提示
+func (c *Config) WithPriority(p protocol.Priority) *Config {
+	c.Priority = p
+	return c
+}
+
+// WithAffinityToken 设置会话亲和令牌（复用之前会话的后端实例）
+func (c *Config) WithAffinityToken(token string) *Config {
+	c.AffinityToken = token
+	return c
+}
+
+// WithClientVAD 开启客户端静音过滤（thresholdDBFS 为 0 使用默认电平）
+func (c *Config) WithClientVAD(thresholdDBFS float64) *Config {
+	c.EnableClientVAD = true
+	c.VADThresholdDBFS = thresholdDBFS
+	return c
+}
+
+// WithPhraseHints 追加短语提示
+func (c *Config) WithPhraseHints(phrases ...string) *Config {
+	c.PhraseHints = append(c.PhraseHints, phrases...)
+	return c
+}
+
+// WithPhraseBoost 追加带增强权重的短语提示（已存在的短语只更新权重）
+func (c *Config) WithPhraseBoost(phrase string, boost float64) *Config {
+	if !slices.Contains(c.PhraseHints, phrase) {
+		c.PhraseHints = append(c.PhraseHints, phrase)
+	}
+	if c.PhraseBoosts == nil {
+		c.PhraseBoosts = make(map[string]float64)
+	}
+	c.PhraseBoosts[phrase] = boost
+	return c
+}
+
+// WithCodec 设置期望的消息编码（"json" 或 "msgpack"，gateway 确认后生效）
+func (c *Config) WithCodec(name string) *Config {
+	c.Codec = name
+	return c
+}
+
+// WithEventLoop 设置共享事件循环（多个 Client 可共用同一个）
+func (c *Config) WithEventLoop(loop *transport.EventLoop) *Config {
+	c.EventLoop = loop
+	return c
+}
+
+// codec 返回期望的消息编码（Validate 已校验名称；未知名称按 JSON 处理）
+func (c *Config) codec() transport.Codec {
+	codec, err := transport.CodecByName(c.Codec)
+	if err != nil {
+		return transport.JSON
+	}
+	return codec
+}
+
+// WithFeatures 声明客户端功能标志（gateway 确认后生效）
+func (c *Config) WithFeatures(names ...string) *Config {
+	c.Features = append(c.Features, names...)
+	return c
+}
+
+// WithIdleTimeout 设置空闲超时（>0 时替代 ReadTimeout，收发任一方向有活动即顺延；0 不启用）
+func (c *Config) WithIdleTimeout(d time.Duration) *Config {
+	c.IdleTimeout = d
+	return c
+}
+
+// WithMaxConnDuration 设置连接最长存活时间（不随活动顺延；0 不限制）
+func (c *Config) WithMaxConnDuration(d time.Duration) *Config {
+	c.MaxConnDuration = d
+	return c
+}
+
+// WithPongTimeout 设置 Pong 超时（Ping 后未按时收到 Pong 即判定连接失效；0 不检测）
+func (c *Config) WithPongTimeout(d time.Duration) *Config {
+	c.PongTimeout = d
+	return c
+}
+
 // StreamOptions 流式识别选项
 type StreamOptions struct {
-	Language    string // 识别语言
-	SampleRate  int    // 采样率
-	AudioFormat string // 音频格式
+	Provider      string  // 识别提供商（空使用 Config.Provider）
+	Language      string  // 识别语言
+	SampleRate    int     // 采样率
+	AudioFormat   string  // 音频格式
+	Channels      int     // 声道数（0 使用客户端配置）
+	BitsPerSample int     // 位深（0 使用客户端配置）
+	BigEndian     bool    // 输入 PCM 为大端字节序（客户端配置为大端时同样生效）
+	Realtime      bool    // RecognizeReader 按音频时长节奏发送（模拟实时采集；默认尽快发送）
+	RealtimeSpeed float64 // 按实时节奏发送时的速度倍率（0 为 1 倍实时，2 为两倍速；见 Session.SendRealtime）
+
+	PhraseHints  []string           // 短语提示（为空时使用客户端配置）
+	PhraseBoosts map[string]float64 // 短语增强权重（键须在 PhraseHints 中）
+
+	// 输出格式（DefaultStreamOptions 均开启；直接构造 StreamOptions 时零值为原始输出）
+	EnablePunctuation bool // 添加标点、句首大写（false 输出小写的原始 token）
+	EnableITN         bool // 逆文本规范化："二零二四年三月" → "2024年3月"、"fifty dollars" → "$50"
+
+	MaxAlternatives int // transcript.final 最多返回的 N-best 候选数（0 / 1 只返回最佳结果）
+
+	// 只送达稳定的部分结果：partial 的 Text 为连续两个 partial 的公共前缀（以空格分词的语言断在词边界），
+	// 仅在该前缀增长时送达，已显示的文字不再闪烁修改；定稿文本仍以 transcript.final 为准
+	StablePartialsOnly bool
+
+	// 不雅词过滤（protocol.ProfanityRaw / ProfanityMasked / ProfanityRemoved），作用于 partial、final 与 N-best 候选；
+	// 空使用提供商默认值
+	ProfanityMode protocol.ProfanityMode
+
+	// 语句结束检测（EventEndOfUtterance）：语义完整时静音达到 EndpointSilence、或不论语义静音达到
+	// MaxEndpointSilence 即判定一句话说完，语音机器人可据此提前开始回复；均为 0 时不请求该事件
+	EndpointSilence    time.Duration
+	MaxEndpointSilence time.Duration
+
+	// 最长语句：一句话上传的音频（客户端 VAD 过滤后）达到该时长仍无 transcript.final 时自动发送 input.commit，
+	// gateway 立即输出该段结果，超长连续流按有界分段转写；0 不自动提交
+	MaxUtteranceDuration time.Duration
+
+	// 稀疏音频保活（push-to-talk 等长时间不 Send 的音源）：距上次上传达到 KeepaliveInterval 时按 Keepalive 方式
+	// 补发一帧静音 / 舒适噪声或 Ping，避免提供商的无输入超时提前结束识别；0 关闭，EndInput 后停止。
+	// 注入的音频计入上传时间轴，之后的识别结果时间戳包含这段时长
+	KeepaliveInterval time.Duration
+	Keepalive         KeepaliveMode // 空为 KeepaliveSilence
 }
 
 // DefaultStreamOptions 返回默认流式选项
 func DefaultStreamOptions() *StreamOptions {
 	return &StreamOptions{
-		Language:    "zh-CN",
-		SampleRate:  16000,
-		AudioFormat: "pcm",
+		Language:      "zh-CN",
+		SampleRate:    16000,
+		AudioFormat:   "pcm",
+		Channels:      1,
+		BitsPerSample: 16,
+
+		EnablePunctuation: true,
+		EnableITN:         true,
 	}
 }
 
+// validatePhraseHints 校验短语提示：短语非空，权重为正且对应的短语在提示列表中
+func validatePhraseHints(hints []string, boosts map[string]float64) error {
+	for _, phrase := range hints {
+		if strings.TrimSpace(phrase) == "" {
+			return ErrInvalidConfig("PhraseHints must not contain empty phrases")
+		}
+	}
+	for phrase, boost := range boosts {
+		if boost <= 0 {
+			return ErrInvalidConfig(fmt.Sprintf("PhraseBoosts[%q] must be positive, got %v", phrase, boost))
+		}
+		if !slices.Contains(hints, phrase) {
+			return ErrInvalidConfig(fmt.Sprintf("PhraseBoosts[%q] has no matching entry in PhraseHints", phrase))
+		}
+	}
+	return nil
+}
+
+// phraseHints 构建 session.config 中的短语提示（重复的短语只发送一次）
+func phraseHints(hints []string, boosts map[string]float64) []protocol.PhraseHint {
+	if len(hints) == 0 {
+		return nil
+	}
+	out := make([]protocol.PhraseHint, 0, len(hints))
+	seen := make(map[string]bool, len(hints))
+	for _, phrase := range hints {
+		if seen[phrase] {
+			continue
+		}
+		seen[phrase] = true
+		out = append(out, protocol.PhraseHint{Phrase: phrase, Boost: boosts[phrase]})
+	}
+	return out
+}
+
 // ConfigError 配置错误
 type configError struct {
 	message string
@@ -120,3 +655,42 @@ func (e *configError) Error() string {
 func ErrInvalidConfig(message string) error {
 	return &configError{message: message}
 }
+
+// boolPtr 返回 b 的指针（session.config 中显式发送 false）
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// alternatives 转换 N-best 候选（gateway 返回多于请求的数量时截断）
+func alternatives(alts []protocol.Alternative, limit int) []Alternative {
+	if len(alts) == 0 {
+		return nil
+	}
+	if limit > 0 && len(alts) > limit {
+		alts = alts[:limit]
+	}
+	out := make([]Alternative, len(alts))
+	for i, a := range alts {
+		out[i] = Alternative{Text: a.Text, Confidence: a.Confidence}
+	}
+	return out
+}
+
+// validateEndpointing 校验语句结束检测阈值：非负，且上限不小于语义完整时的阈值
+func validateEndpointing(silence, maxSilence time.Duration) error {
+	if silence < 0 || maxSilence < 0 {
+		return ErrInvalidConfig("EndpointSilence and MaxEndpointSilence must not be negative")
+	}
+	if maxSilence > 0 && maxSilence < silence {
+		return ErrInvalidConfig(fmt.Sprintf("MaxEndpointSilence (%v) must not be less than EndpointSilence (%v)", maxSilence, silence))
+	}
+	return nil
+}
+
+// endpointing 构建 session.config 中的语句结束检测参数（均为 0 时不请求）
+func endpointing(silence, maxSilence time.Duration) *protocol.Endpointing {
+	if silence == 0 && maxSilence == 0 {
+		return nil
+	}
+	return &protocol.Endpointing{SilenceMs: int(silence.Milliseconds()), MaxSilenceMs: int(maxSilence.Milliseconds())}
+}
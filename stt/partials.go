@@ -0,0 +1,65 @@
+// Package stt 部分结果稳定化
+package stt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// partialState 当前语句的 partial 状态（transcript.final 后重置）
+type partialState struct {
+	last    string // 上一个收到的 partial
+	emitted string // 上一个送达 Events 的 partial 文本
+}
+
+// next 处理收到的 partial，返回要送达的文本与增量；stableOnly 时只在稳定前缀增长时送达（ok 为 false 表示抑制）
+//
+// 稳定前缀为连续两个 partial 的公共前缀：提供商重复给出的部分视为不再修改。
+// 以空格分词的语言退回到词边界，避免 "hello wor" 这种半个词先显示出来。
+func (p *partialState) next(text string, stableOnly bool) (shown, delta string, ok bool) {
+	last := p.last
+	p.last = text
+	if !stableOnly {
+		shown = text
+	} else {
+		shown = stablePrefix(last, text)
+		if len(shown) <= len(p.emitted) || !strings.HasPrefix(shown, p.emitted) {
+			return "", "", false
+		}
+	}
+	delta = shown
+	if strings.HasPrefix(shown, p.emitted) {
+		delta = shown[len(p.emitted):]
+	}
+	p.emitted = shown
+	return shown, delta, true
+}
+
+// reset 一句话定稿，下一个 partial 属于新的语句
+func (p *partialState) reset() {
+	*p = partialState{}
+}
+
+// stablePrefix a 与 b 的公共前缀（按字符），断在词中间时退回到上一个词边界
+func stablePrefix(a, b string) string {
+	ar, br := []rune(a), []rune(b)
+	n := 0
+	for n < len(ar) && n < len(br) && ar[n] == br[n] {
+		n++
+	}
+	midWord := (n < len(br) && isWordRune(br[n])) || (n < len(ar) && isWordRune(ar[n]))
+	if n > 0 && isWordRune(br[n-1]) && midWord {
+		for n > 0 && isWordRune(br[n-1]) {
+			n--
+		}
+	}
+	return string(br[:n])
+}
+
+// isWordRune 是否为以空格分词的文字（拉丁字母、数字等）；汉字、假名逐字断开
+func isWordRune(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,42 @@
+package stt
+
+import "testing"
+
+// TestStablePartials 验证：StablePartialsOnly 时只送达连续两个 partial 的公共前缀，断在词中间时退回词边界，
+// 前缀增长才送达且 DeltaText 为追加部分；前文被修改时抑制，关闭时原样送达并在修改前文时 DeltaText 为整句。
+// WHY：实时字幕逐个渲染 partial，提供商反复修改末尾的词会让已显示的文字不停闪烁。
+func TestStablePartials(t *testing.T) {
+	type step struct{ text, shown, delta string }
+	cases := []struct {
+		name       string
+		stableOnly bool
+		steps      []step
+	}{
+		{"words", true, []step{
+			{"hel", "", ""},
+			{"hello wor", "", ""},
+			{"hello world how", "hello ", "hello "},
+			{"hello world how are", "hello world how", "world how"},
+			{"hello world who are", "", ""},
+		}},
+		{"han", true, []step{
+			{"今天", "", ""},
+			{"今天天", "今天", "今天"},
+			{"今天天气", "今天天", "天"},
+		}},
+		{"raw", false, []step{
+			{"ab", "ab", "ab"},
+			{"abc", "abc", "c"},
+			{"abd", "abd", "abd"},
+		}},
+	}
+	for _, c := range cases {
+		var p partialState
+		for _, s := range c.steps {
+			shown, delta, ok := p.next(s.text, c.stableOnly)
+			if ok != (s.shown != "") || shown != s.shown || delta != s.delta {
+				t.Fatalf("%s: next(%q) = %q, %q, %v; want %q, %q", c.name, s.text, shown, delta, ok, s.shown, s.delta)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package stt 按实时节奏发送音频
+package stt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+)
+
+// realtimeChunk 从 Reader 发送时每块的音频时长
+const realtimeChunk = 100 * time.Millisecond
+
+// SendRealtime 从 r 按 100ms 音频块读取并按音频时长节奏发送直到 EOF（模拟实时采集，
+// 倍率见 StreamOptions.RealtimeSpeed），不发送 session.end，读完后由调用方 EndInput
+//
+// 节奏按累计的音频时长计算，不因读取、发送耗时而漂移；ctx 取消时返回 ctx.Err()。
+func (s *Session) SendRealtime(ctx context.Context, r io.Reader) error {
+	opts := s.Options()
+	return s.sendChunks(ctx, r, opts.realtimeSpeed())
+}
+
+// realtimeSpeed SendRealtime 的发送速度倍率（未设置时为 1 倍实时）
+func (o *StreamOptions) realtimeSpeed() float64 {
+	if o.RealtimeSpeed > 0 {
+		return o.RealtimeSpeed
+	}
+	return 1
+}
+
+// sendChunks 从 Reader 按 100ms 音频块读取并发送直到 EOF（按会话声明的采样率/声道/位深计算块大小）
+// 短读（管道、网络流）会凑满整块再发送；speed > 0 时每块按其音频时长的 1/speed 间隔发送，为 0 时尽快发送
+func (s *Session) sendChunks(ctx context.Context, reader io.Reader, speed float64) error {
+	o := s.Options()
+	buf := make([]byte, audio.CalculateChunkSize(int(realtimeChunk/time.Millisecond), o.SampleRate, o.Channels, o.BitsPerSample))
+	if len(buf) == 0 {
+		return fmt.Errorf("invalid audio format: %d Hz, %d ch, %d bit", o.SampleRate, o.Channels, o.BitsPerSample)
+	}
+	bytesPerSecond := float64(o.SampleRate*audio.FrameSize(o.Channels, o.BitsPerSample)) * speed
+	clk := s.clock()
+	next := clk.Now()
+
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			if bytesPerSecond > 0 {
+				if wait := next.Sub(clk.Now()); wait > 0 {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-clk.After(wait):
+					}
+				}
+				next = next.Add(time.Duration(float64(n) * float64(time.Second) / bytesPerSecond))
+			}
+			if err := s.SendContext(ctx, buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
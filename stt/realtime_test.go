@@ -0,0 +1,123 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestSendRealtime 验证：SendRealtime 按 100ms 分块、按音频时长除以 RealtimeSpeed 的节奏发送，读完后不结束输入。
+// WHY：示例程序各自用 time.Sleep(100ms) 控速，发送耗时累积导致节奏漂移，且无法加速回放长录音。
+func TestSendRealtime(t *testing.T) {
+	url, received := countingGateway(t)
+	config := DefaultConfig().WithSampleRate(8000)
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := DefaultStreamOptions()
+	opts.SampleRate = 8000
+	opts.RealtimeSpeed = 4
+	session, err := client.CreateSession(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	// 8kHz 16-bit 400ms：4 块，4 倍速下依次在 0 / 25 / 50 / 75ms 发送
+	start := time.Now()
+	if err := session.SendRealtime(ctx, bytes.NewReader(make([]byte, 6400))); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond || elapsed > 250*time.Millisecond {
+		t.Fatalf("SendRealtime took %v, want ~75ms at 4x", elapsed)
+	}
+	if !session.EndInputAt().IsZero() {
+		t.Fatal("SendRealtime must not end the input")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(received()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if chunks := received(); len(chunks) != 4 || chunks[0] != 1600 {
+		t.Fatalf("chunks = %v, want 4 x 1600 bytes", chunks)
+	}
+}
+
+// TestThrottledSendDoesNotBlockEvents 验证：受 MaxSendRate 限速而阻塞的 Send 不持有会话锁，期间 transcript.final
+// 照常送达、Stats 立即返回；SendContext 的 ctx 取消时放弃等待配额并返回错误。
+// WHY：限速等待原先持有会话锁，批量上传时识别结果、Close 与统计全部卡到整段音频按限速发完。
+func TestThrottledSendDoesNotBlockEvents(t *testing.T) {
+	url, _ := configGateway(t, func(ws *websocket.Conn) {
+		final := false
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			if msg["type"] == string(protocol.MessageTypeAudioAppend) && !final {
+				final = true
+				ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "first chunk"})
+			}
+		}
+	})
+	config := DefaultConfig().WithMaxSendRate(3200) // 突发 800 字节，之后每 3200 字节等 1 秒
+	config.GatewayURL = url
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := client.CreateSession(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sent := make(chan error, 1)
+	go func() {
+		if err := session.Send(make([]byte, 800)); err != nil {
+			sent <- err
+			return
+		}
+		sent <- session.SendContext(ctx, make([]byte, 3200))
+	}()
+
+	timeout := time.After(time.Second)
+	for got := false; !got; {
+		select {
+		case event := <-session.Events():
+			got = event.Type == EventTranscriptFinal
+		case err := <-sent:
+			t.Fatalf("throttled Send returned before the transcript arrived: %v", err)
+		case <-timeout:
+			t.Fatal("transcript.final not delivered while Send was throttled")
+		}
+	}
+	start := time.Now()
+	session.Stats()
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("Stats blocked %v behind a throttled Send", d)
+	}
+
+	cancel()
+	select {
+	case err := <-sent:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("SendContext after cancel = %v, want context.Canceled", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("SendContext kept waiting for quota after ctx was cancelled")
+	}
+}
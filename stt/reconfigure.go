@@ -0,0 +1,118 @@
+// Package stt 会话内重新配置
+package stt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// reconfigWaiter 等待 gateway 确认第 seq 次 session.config
+type reconfigWaiter struct {
+	seq int
+	ch  chan error
+}
+
+// Reconfigure 在两句话之间更新识别参数（语言、提供商、短语提示、输出格式等），不重建连接
+//
+// 以 opts 重新发送完整的 session.config，gateway 以 session.config_done 确认后返回，之后发送的音频按新参数识别；
+// 典型用法是首句识别出说话人的语言后切换 Language。应在上一句 transcript.final 之后、下一句音频之前调用。
+// opts.Provider 为空时保持当前提供商，切换提供商需 gateway 支持（Config.Gateways 中指向其它 gateway 的提供商不可切换）。音频格式（采样率、编码、声道、位深、字节序）
+// 在会话内不可变更。gateway 拒绝（回复 error）、ctx 取消或超时（ctx 无 deadline 时为 ConnectTimeout）时
+// 返回错误，会话保持原参数（gateway 的 error 同时作为 EventError 送达事件流）。
+func (s *Session) Reconfigure(ctx context.Context, opts *StreamOptions) error {
+	if opts == nil {
+		return ErrInvalidConfig("opts is required")
+	}
+	s.reconfigMu.Lock()
+	defer s.reconfigMu.Unlock()
+
+	s.mu.Lock()
+	prev, provider := s.opts, s.Provider
+	s.mu.Unlock()
+	if opts.Provider == "" {
+		filled := *opts
+		filled.Provider = provider
+		opts = &filled
+	}
+	opts, err := resolveStreamOptions(s.config, opts)
+	if err != nil {
+		return err
+	}
+	if opts.SampleRate != prev.SampleRate || opts.AudioFormat != prev.AudioFormat || opts.Channels != prev.Channels ||
+		opts.BitsPerSample != prev.BitsPerSample || opts.BigEndian != prev.BigEndian {
+		return ErrInvalidConfig("audio format cannot be changed within a session")
+	}
+	if s.config.gatewayURL(opts.Provider) != s.config.gatewayURL(provider) {
+		return ErrInvalidConfig(fmt.Sprintf("provider %s is served by a different gateway, create a new session", opts.Provider))
+	}
+
+	waiter := &reconfigWaiter{ch: make(chan error, 1)}
+	s.mu.Lock()
+	switch {
+	case s.closed:
+		s.mu.Unlock()
+		return fmt.Errorf("session closed")
+	case !s.endInputAt.IsZero():
+		s.mu.Unlock()
+		return fmt.Errorf("input already ended")
+	}
+	s.opts = opts
+	waiter.seq = s.configsSent + 1
+	s.reconfigWait = waiter
+	s.mu.Unlock()
+
+	if err := s.sendConfig(); err != nil {
+		s.restoreOptions(prev, provider)
+		return fmt.Errorf("send session.config: %w", err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.ConnectTimeout)
+		defer cancel()
+	}
+	select {
+	case err = <-waiter.ch:
+	case <-ctx.Done():
+		err = fmt.Errorf("wait session.config_done: %w", ctx.Err())
+	case <-s.closeCh:
+		err = fmt.Errorf("session closed")
+	}
+	if err != nil {
+		s.restoreOptions(prev, provider)
+		return fmt.Errorf("reconfigure session: %w", err)
+	}
+
+	s.mu.Lock()
+	s.Provider = opts.Provider
+	s.mu.Unlock()
+	slog.Info("Session reconfigured", "component", "stt", "id", s.ID, "provider", opts.Provider, "language", opts.Language)
+	return nil
+}
+
+// restoreOptions 重新配置失败时恢复原参数
+func (s *Session) restoreOptions(prev *StreamOptions, provider string) {
+	s.mu.Lock()
+	s.opts, s.Provider = prev, provider
+	s.reconfigWait = nil
+	s.mu.Unlock()
+}
+
+// ackReconfigLocked 通知等待中的 Reconfigure：err 为 nil 表示收到 config_done（调用方持有 mu）
+// 首次 session.config 的 config_done 按次数区分，不会被误认为重新配置的确认
+func (s *Session) ackReconfigLocked(err error) {
+	w := s.reconfigWait
+	if w == nil || (err == nil && s.configsDone < w.seq) {
+		return
+	}
+	s.reconfigWait = nil
+	w.ch <- err
+}
+
+// Options 返回会话当前的识别参数（副本）
+func (s *Session) Options() StreamOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.opts
+}
@@ -0,0 +1,345 @@
+// Package stt 断线重连与音频重放
+package stt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// DefaultMaxResumes 开启 ReplayBuffer 时连接异常断开后默认的重连次数
+const DefaultMaxResumes = 2
+
+// replayChunk 一次上传的音频，pos 为其在上传时间轴上的字节位置
+type replayChunk struct {
+	pos  int64
+	data []byte
+}
+
+// replayBuffer 最近上传的音频（上传时间轴：客户端 VAD 过滤后实际发给 gateway 的音频，跨重连连续）
+//
+// 保留最近 limit 字节，已被 transcript.final 确认的部分随时丢弃；断线重连后从未确认的位置重发。
+type replayBuffer struct {
+	chunks []replayChunk
+	end    int64 // 已上传（含断线期间暂存）的总字节数
+	limit  int64 // 保留上限
+}
+
+// add 追加一块音频（复制：Send 的调用方可能复用缓冲区），超出保留上限的旧音频丢弃
+func (b *replayBuffer) add(data []byte) {
+	b.chunks = append(b.chunks, replayChunk{pos: b.end, data: append([]byte(nil), data...)})
+	b.end += int64(len(data))
+	b.trim(b.end - b.limit)
+}
+
+// trim 丢弃在 pos 之前结束的音频
+func (b *replayBuffer) trim(pos int64) {
+	n := 0
+	for n < len(b.chunks) && b.chunks[n].pos+int64(len(b.chunks[n].data)) <= pos {
+		n++
+	}
+	if n > 0 {
+		b.chunks = append(b.chunks[:0], b.chunks[n:]...)
+	}
+}
+
+// start 缓冲区中最早音频的位置（为空时等于 end）
+func (b *replayBuffer) start() int64 {
+	if len(b.chunks) == 0 {
+		return b.end
+	}
+	return b.chunks[0].pos
+}
+
+// from 返回从 pos 起的全部音频（首块按 pos 截断）
+func (b *replayBuffer) from(pos int64) [][]byte {
+	var out [][]byte
+	for _, c := range b.chunks {
+		if off := pos - c.pos; off > 0 {
+			if off >= int64(len(c.data)) {
+				continue
+			}
+			out = append(out, c.data[off:])
+			continue
+		}
+		out = append(out, c.data)
+	}
+	return out
+}
+
+// byteRate 上传音频每秒字节数
+func (o *StreamOptions) byteRate() int64 {
+	return int64(o.SampleRate * o.Channels * o.BitsPerSample / 8)
+}
+
+// bytesAt 上传时间轴上 d 对应的字节位置（对齐到采样帧）
+func (o *StreamOptions) bytesAt(d time.Duration) int64 {
+	frame := int64(o.Channels * o.BitsPerSample / 8)
+	if frame <= 0 {
+		return 0
+	}
+	return int64(d) * o.byteRate() / int64(time.Second) / frame * frame
+}
+
+// durationOf n 字节上传音频的时长
+func (o *StreamOptions) durationOf(n int64) time.Duration {
+	rate := o.byteRate()
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(n * int64(time.Second) / rate)
+}
+
+// newReplayBuffer 按配置创建重放缓冲区；未开启或格式不支持时返回 nil
+func newReplayBuffer(config *Config, opts *StreamOptions) *replayBuffer {
+	if config.ReplayBuffer <= 0 || config.MaxResumes < 0 {
+		return nil
+	}
+	if opts.AudioFormat != "pcm" {
+		slog.Warn("Replay buffer requires PCM input, reconnect disabled", "component", "stt", "audio_format", opts.AudioFormat)
+		return nil
+	}
+	return &replayBuffer{limit: opts.bytesAt(config.ReplayBuffer)}
+}
+
+// ackLocked 记录 gateway 已确认（已出 transcript.final）的上传位置，之前的音频无需重放（调用方持有 mu）
+func (s *Session) ackLocked(uploadedEnd time.Duration) {
+	if s.replay == nil {
+		return
+	}
+	if pos := min(s.opts.bytesAt(uploadedEnd), s.replay.end); pos > s.acked {
+		s.acked = pos
+		s.replay.trim(pos)
+	}
+}
+
+// reconnect 连接异常断开后重连并重放未确认的音频，返回新连接；未开启、会话已结束或重连失败时返回 nil
+//
+// 新连接上重新完成 session.ready / session.config 握手，从最后一个 transcript.final 的结束位置重发缓冲区中的音频
+// （已 EndInput 时随后重发 session.end）。新 gateway 会话的时间戳从重放起点开始，handleFinal 按 timeBase 换算回
+// 原会话时间轴。重连期间 Send 的音频只进入缓冲区，随重放一并发送。
+//
+// gateway 在 session.ready 中返回了恢复令牌时先以 session.resume 接回原会话：识别状态与时间轴都保留，
+// 只补发 gateway 尚未收到的音频；恢复被拒绝时回落到上面的新建会话与重放。
+func (s *Session) reconnect(ctx context.Context, cause error) *transport.Conn {
+	s.mu.Lock()
+	if s.replay == nil || s.dial == nil || s.closed || !s.sessionEndedAt.IsZero() {
+		s.mu.Unlock()
+		return nil
+	}
+	s.reconnecting = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.reconnecting = false
+		s.mu.Unlock()
+	}()
+
+	slog.Warn("Connection lost, reconnecting", "component", "stt", "id", s.ID, "error", cause)
+
+	backoff := s.config.ReconnectBackoff
+	for attempt := 1; attempt <= s.config.MaxResumes; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-s.closeCh:
+				return nil
+			case <-s.clock().After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if s.config.Metrics != nil {
+			s.config.Metrics.Reconnect(attempt, cause)
+		}
+		conn, done, err := s.reestablish(ctx)
+		if err != nil {
+			slog.Error("Reconnect attempt failed", "component", "stt", "id", s.ID, "attempt", attempt, "max", s.config.MaxResumes, "error", err)
+			continue
+		}
+
+		// 持 sendMu 重放并替换连接：与 Send 串行，新音频排在重放的音频之后；写网络时不持有 mu
+		s.sendMu.Lock()
+		replayed, err := s.replayUnacked(conn, done)
+		if err != nil {
+			s.sendMu.Unlock()
+			conn.Close()
+			if errors.Is(err, errSessionClosed) {
+				return nil
+			}
+			slog.Error("Replay audio failed", "component", "stt", "id", s.ID, "attempt", attempt, "error", err)
+			continue
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			s.sendMu.Unlock()
+			conn.Close()
+			return nil
+		}
+		old := s.conn
+		s.conn = conn
+		s.resumes++
+		s.reconnecting = false // 与替换连接同时清除：之后的 Send 直接发往新连接
+		// 新连接的 session.config 已包含进行中的 Reconfigure 参数，视为已确认
+		s.configsDone = s.configsSent
+		s.ackReconfigLocked(nil)
+		s.mu.Unlock()
+		s.sendMu.Unlock()
+		old.Close()
+
+		slog.Info("Session reconnected", "component", "stt", "id", s.ID, "attempt", attempt, "resumed", done.Resumed,
+			"replayed_ms", replayed.Milliseconds())
+		return conn
+	}
+	slog.Error("Reconnect failed", "component", "stt", "id", s.ID, "attempts", s.config.MaxResumes)
+	return nil
+}
+
+// reestablish 建立新连接并完成握手，返回 gateway 的 config_done（Resumed 表示已接回原会话）
+//
+// 持有恢复令牌时先发 session.resume；无令牌、恢复被拒绝或有未确认的 Reconfigure（其参数须随 session.config 重发）时
+// 走 session.ready / session.config / config_done 新建会话，并改用新会话的恢复令牌。
+func (s *Session) reestablish(ctx context.Context) (*transport.Conn, *protocol.SessionConfigDone, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.ConnectTimeout)
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reconnect: %w", err)
+	}
+	ready, err := readReady(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	token := s.resumeToken
+	if s.configsSent > s.configsDone {
+		token = ""
+	}
+	s.mu.Unlock()
+	if token != "" {
+		data, err := transport.ResumeSession(ctx, conn, token)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		if data != nil {
+			done := s.finishHandshake(conn, data)
+			slog.Info("Gateway session reattached", "component", "stt", "id", s.ID, "received_ms", done.ReceivedMs)
+			return conn, done, nil
+		}
+	}
+
+	if err := conn.Send(s.configMessage()); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("send session.config: %w", err)
+	}
+
+	// 消息循环尚未切换到新连接，此处直接读取 config_done
+	for {
+		data, err := conn.Receive(ctx)
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("wait session.config_done: %w", err)
+		}
+		msgType, err := transport.ParseMessageType(data)
+		if err != nil {
+			continue
+		}
+		switch msgType {
+		case protocol.MessageTypeSessionConfigDone:
+			done := s.finishHandshake(conn, data)
+			s.mu.Lock()
+			s.resumeToken = ready.ResumeToken
+			s.mu.Unlock()
+			slog.Info("Reconnected session ready", "component", "stt", "id", s.ID, "gateway_session", ready.SessionID)
+			return conn, done, nil
+		case protocol.MessageTypeError:
+			conn.Close()
+			msg, _ := transport.ParseMessage(data)
+			if errMsg, ok := msg.(*protocol.ErrorMessage); ok {
+				return nil, nil, fmt.Errorf("config rejected: [%s] %s", errMsg.Code, errMsg.Message)
+			}
+			return nil, nil, fmt.Errorf("config rejected")
+		}
+	}
+}
+
+// finishHandshake 处理新连接上的 config_done：重连后的 gateway 实例可能与之前不同，按新的确认结果更新功能标志与编码
+func (s *Session) finishHandshake(conn *transport.Conn, data []byte) *protocol.SessionConfigDone {
+	features := s.negotiate(conn, data)
+	s.mu.Lock()
+	s.features = features
+	s.mu.Unlock()
+	done := &protocol.SessionConfigDone{}
+	if msg, err := transport.ParseMessage(data); err == nil {
+		done = msg.(*protocol.SessionConfigDone)
+	}
+	return done
+}
+
+// errSessionClosed 重放前会话已关闭
+var errSessionClosed = errors.New("session closed")
+
+// replayUnacked 在新连接上重发未确认的音频，返回重放时长（调用方持有 sendMu、不持有 mu）
+//
+// 恢复的 gateway 会话（done.Resumed）保留了已收到的音频，只补发 received_ms 之后的部分，时间轴不变。
+// 待重放的音频在 mu 内取出，发送时不持有 mu（重放同样受上行限速），期间识别结果照常处理。
+func (s *Session) replayUnacked(conn *transport.Conn, done *protocol.SessionConfigDone) (time.Duration, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return 0, errSessionClosed
+	}
+	acked := s.acked
+	if done.Resumed {
+		received := s.opts.bytesAt(s.timeBase + time.Duration(done.ReceivedMs)*time.Millisecond)
+		acked = max(acked, min(received, s.replay.end))
+	}
+	from := max(acked, s.replay.start())
+	if lost := from - acked; lost > 0 {
+		slog.Warn("Outage exceeded replay buffer, audio lost", "component", "stt", "id", s.ID,
+			"lost_ms", s.opts.durationOf(lost).Milliseconds())
+	}
+	chunks := s.replay.from(from)
+	end := s.replay.end
+	ended := !s.endInputAt.IsZero()
+	s.mu.Unlock()
+
+	for _, data := range chunks {
+		if err := conn.SendAudio(context.Background(), data); err != nil {
+			return 0, fmt.Errorf("send audio: %w", err)
+		}
+		s.mu.Lock()
+		s.uploadedBytes += int64(len(data))
+		s.chunksSent++
+		s.mu.Unlock()
+	}
+	if ended {
+		if err := conn.Send(transport.NewSessionEnd()); err != nil {
+			return 0, fmt.Errorf("send session.end: %w", err)
+		}
+	}
+	if !done.Resumed {
+		s.mu.Lock()
+		s.timeBase = s.opts.durationOf(from)
+		s.mu.Unlock()
+	}
+	return s.opts.durationOf(end - from), nil
+}
+
+// Resumes 返回断线后成功重连的次数（见 Config.ReplayBuffer）
+func (s *Session) Resumes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resumes
+}
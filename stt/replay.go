@@ -0,0 +1,209 @@
+// Package stt 识别事件日志的记录与重放
+package stt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+)
+
+// 事件日志为 JSONL，每行一个 EventRecord，按 offset_ms 升序：
+//
+//	{"offset_ms":0,"type":"session.ready","session_id":"s1"}
+//	{"offset_ms":640,"type":"transcript.partial","text":"hello"}
+//	{"offset_ms":1210,"type":"transcript.final","text":"hello world","start_ms":120,"end_ms":1100}
+//	{"offset_ms":1500,"type":"session.ended"}
+//
+// EventLog 在真实会话中记录，Replayer 按原始节奏重放，前端无需 gateway 和音频即可对着真实的事件流开发字幕 UI。
+
+// EventRecord 事件日志中的一行
+type EventRecord struct {
+	OffsetMs  int64     `json:"offset_ms"` // 相对首个事件的时间
+	Type      EventType `json:"type"`
+	SessionID string    `json:"session_id,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	StartMs   int64     `json:"start_ms,omitempty"` // transcript.final 的音频起止时间
+	EndMs     int64     `json:"end_ms,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Reason    string    `json:"reason,omitempty"` // utterance.end 的结束原因
+
+	Alternatives []Alternative `json:"alternatives,omitempty"` // transcript.final 的 N-best 候选
+}
+
+// newEventRecord 由事件构建日志记录
+func newEventRecord(event *RecognitionEvent, offset time.Duration) EventRecord {
+	rec := EventRecord{
+		OffsetMs:  offset.Milliseconds(),
+		Type:      event.Type,
+		SessionID: event.SessionID,
+		Text:      event.Text,
+		StartMs:   event.StartTime.Milliseconds(),
+		EndMs:     event.EndTime.Milliseconds(),
+		Reason:    event.Reason,
+
+		Alternatives: event.Alternatives,
+	}
+	if event.Error != nil {
+		rec.Error = event.Error.Error()
+	}
+	return rec
+}
+
+// event 还原为识别事件
+func (r EventRecord) event() *RecognitionEvent {
+	e := &RecognitionEvent{
+		Type:      r.Type,
+		SessionID: r.SessionID,
+		Text:      r.Text,
+		IsFinal:   r.Type == EventTranscriptFinal,
+		StartTime: time.Duration(r.StartMs) * time.Millisecond,
+		EndTime:   time.Duration(r.EndMs) * time.Millisecond,
+		Reason:    r.Reason,
+
+		Alternatives: r.Alternatives,
+	}
+	if r.Type == EventError {
+		e.Error = errors.New(r.Error)
+	}
+	return e
+}
+
+// EventLog 把识别事件记录为 JSONL（并发安全）
+//
+//	log := stt.NewEventLog(f, nil)
+//	session.Handle(ctx, stt.Handlers{OnEvent: func(e *stt.RecognitionEvent) { log.Record(e) }})
+type EventLog struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	clock clock.Clock
+	start time.Time
+}
+
+// NewEventLog 创建事件日志；c 为 nil 时使用系统时钟
+func NewEventLog(w io.Writer, c clock.Clock) *EventLog {
+	return &EventLog{enc: json.NewEncoder(w), clock: clock.Or(c)}
+}
+
+// Record 记录一个事件（offset 从首个记录的事件起算）
+func (l *EventLog) Record(event *RecognitionEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	if l.start.IsZero() {
+		l.start = now
+	}
+	return l.enc.Encode(newEventRecord(event, now.Sub(l.start)))
+}
+
+// ReplayOptions 重放选项
+type ReplayOptions struct {
+	Speed float64     // 播放倍速（默认 1，即原始节奏；2 为两倍速）
+	Clock clock.Clock // nil 使用系统时钟（测试可注入 clock.Fake）
+}
+
+// Replayer 按原始时间重放事件日志，提供与 Session 相同的 Events() / Handle 接口
+type Replayer struct {
+	eventsCh chan *RecognitionEvent
+}
+
+// Replay 读取事件日志并在后台按记录的时间间隔发出事件；全部发出或 ctx 取消后关闭事件通道
+//
+// 日志在开始重放前完整解析，格式错误时返回带行号的错误。
+func Replay(ctx context.Context, r io.Reader, opts *ReplayOptions) (*Replayer, error) {
+	if opts == nil {
+		opts = &ReplayOptions{}
+	}
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	records, err := readEventLog(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &Replayer{eventsCh: make(chan *RecognitionEvent)}
+	c := clock.Or(opts.Clock)
+	go func() {
+		defer close(rp.eventsCh)
+		start := c.Now()
+		for _, rec := range records {
+			at := start.Add(time.Duration(float64(rec.OffsetMs) * float64(time.Millisecond) / speed))
+			if wait := at.Sub(c.Now()); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-c.After(wait):
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case rp.eventsCh <- rec.event():
+			}
+		}
+	}()
+	return rp, nil
+}
+
+// gzipMagic gzip 文件头
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readEventLog 解析 JSONL 事件日志（跳过空行；gzip 压缩的日志按文件头识别并自动解压）
+func readEventLog(r io.Reader) ([]EventRecord, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(gzipMagic)); bytes.Equal(head, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("event log: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var records []EventRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec EventRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("event log line %d: %w", line, err)
+		}
+		if rec.Type == "" {
+			return nil, fmt.Errorf("event log line %d: missing type", line)
+		}
+		if n := len(records); n > 0 && rec.OffsetMs < records[n-1].OffsetMs {
+			return nil, fmt.Errorf("event log line %d: offset_ms %d goes backwards", line, rec.OffsetMs)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read event log: %w", err)
+	}
+	return records, nil
+}
+
+// Events 返回重放的事件通道
+func (rp *Replayer) Events() <-chan *RecognitionEvent {
+	return rp.eventsCh
+}
+
+// Handle 读取重放事件并分发到回调，语义同 Session.Handle
+func (rp *Replayer) Handle(ctx context.Context, h Handlers) error {
+	return handleEvents(ctx, rp.eventsCh, h)
+}
@@ -0,0 +1,87 @@
+package stt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+)
+
+// TestEventLogReplay 验证：EventLog 记录的事件经 Replayer 按原始间隔（乘以倍速）通过 Events() 重放，
+// 文本、时间戳与错误保持不变；格式错误的日志在重放前报出行号。
+// WHY：前端依赖重放的真实节奏调试字幕 UI（partial 刷新、final 定稿），事件提前到达或内容变形都会误导开发。
+func TestEventLogReplay(t *testing.T) {
+	rec := clock.NewFake(time.Unix(1700000000, 0))
+	var buf bytes.Buffer
+	log := NewEventLog(&buf, rec)
+	log.Record(NewSessionReadyEvent("s1"))
+	rec.Advance(400 * time.Millisecond)
+	log.Record(NewTranscriptPartialEvent("hel"))
+	rec.Advance(600 * time.Millisecond)
+	log.Record(NewTranscriptFinalEvent("hello", 100*time.Millisecond, 900*time.Millisecond))
+	log.Record(NewErrorEvent(errors.New("[provider_error] upstream reset")))
+
+	play := clock.NewFake(time.Unix(1800000000, 0))
+	rp, err := Replay(context.Background(), strings.NewReader(buf.String()), &ReplayOptions{Speed: 2, Clock: play})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if e := <-rp.Events(); e.Type != EventSessionReady || e.SessionID != "s1" {
+		t.Fatalf("first event = %+v", e)
+	}
+
+	play.WaitForTimers(1)
+	play.Advance(199 * time.Millisecond) // 两倍速：400ms → 200ms
+	select {
+	case e := <-rp.Events():
+		t.Fatalf("event %s delivered early", e.Type)
+	case <-time.After(20 * time.Millisecond):
+	}
+	play.Advance(time.Millisecond)
+	if e := <-rp.Events(); e.Type != EventTranscriptPartial || e.Text != "hel" {
+		t.Fatalf("second event = %+v", e)
+	}
+
+	play.WaitForTimers(1)
+	play.Advance(300 * time.Millisecond)
+	e := <-rp.Events()
+	if e.Type != EventTranscriptFinal || e.Text != "hello" || !e.IsFinal || e.EndTime != 900*time.Millisecond {
+		t.Fatalf("final event = %+v", e)
+	}
+	if e := <-rp.Events(); e.Type != EventError || e.Error.Error() != "[provider_error] upstream reset" {
+		t.Fatalf("error event = %+v", e)
+	}
+	if _, ok := <-rp.Events(); ok {
+		t.Fatal("events channel not closed after the last record")
+	}
+
+	_, err = Replay(context.Background(), strings.NewReader("{\"offset_ms\":0,\"type\":\"session.ready\"}\nnot json\n"), nil)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("Replay(bad log) = %v, want line 2 error", err)
+	}
+}
+
+// TestReplayGzip 验证：gzip 压缩的事件日志按文件头识别，无需调用方解压即可重放。
+// WHY：合规要求长期保存的转写日志体积大，压缩后存档的文件应能直接交给 stt_replay。
+func TestReplayGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	log := NewEventLog(gz, clock.NewFake(time.Unix(1700000000, 0)))
+	log.Record(NewTranscriptFinalEvent("hello", 0, 500*time.Millisecond))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := Replay(context.Background(), &buf, nil)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if e := <-rp.Events(); e.Type != EventTranscriptFinal || e.Text != "hello" || e.EndTime != 500*time.Millisecond {
+		t.Fatalf("event = %+v", e)
+	}
+}
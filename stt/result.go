@@ -0,0 +1,140 @@
+// Package stt 识别结果的 JSON 序列化
+package stt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ResultSchemaVersion RecognitionResult JSON 的结构版本：只增加可选字段时不变，字段改名、删除或改变含义时递增
+//
+//	{
+//	  "schema_version": 1,
+//	  "session_id": "s1",
+//	  "text": "你好 世界",
+//	  "segments": [{"text": "你好", "is_final": true, "start_ms": 0, "end_ms": 800,
+//	                "alternatives": [{"text": "你好", "confidence": 0.93}]}],
+//	  "duration_ms": 1520,
+//	  "ttfb_ms": 310,
+//	  "latencies": {"first_partial_ms": 310, "finalization_ms": 240},
+//	  "error": "..."
+//	}
+//
+// 时间字段均为整数毫秒。
+const ResultSchemaVersion = 1
+
+// resultJSON RecognitionResult 的 JSON 结构（见 ResultSchemaVersion）
+type resultJSON struct {
+	SchemaVersion int           `json:"schema_version"`
+	SessionID     string        `json:"session_id,omitempty"`
+	Text          string        `json:"text"`
+	Segments      []Segment     `json:"segments"`
+	DurationMs    int64         `json:"duration_ms"`
+	TTFBMs        int64         `json:"ttfb_ms"`
+	Latencies     latenciesJSON `json:"latencies"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// latenciesJSON Latencies 的 JSON 结构
+type latenciesJSON struct {
+	FirstPartialMs int64 `json:"first_partial_ms"`
+	FinalizationMs int64 `json:"finalization_ms"`
+}
+
+// segmentJSON Segment 的 JSON 结构
+type segmentJSON struct {
+	Text         string        `json:"text"`
+	IsFinal      bool          `json:"is_final"`
+	StartMs      int64         `json:"start_ms"`
+	EndMs        int64         `json:"end_ms"`
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+}
+
+// MarshalJSON 按 ResultSchemaVersion 描述的结构编码（时间为毫秒，Error 为错误文本）
+func (r RecognitionResult) MarshalJSON() ([]byte, error) {
+	segments := r.Segments
+	if segments == nil {
+		segments = []Segment{}
+	}
+	out := resultJSON{
+		SchemaVersion: ResultSchemaVersion,
+		SessionID:     r.SessionID,
+		Text:          r.Text,
+		Segments:      segments,
+		DurationMs:    r.Duration.Milliseconds(),
+		TTFBMs:        r.TTFB.Milliseconds(),
+		Latencies: latenciesJSON{
+			FirstPartialMs: r.Latencies.FirstPartial.Milliseconds(),
+			FinalizationMs: r.Latencies.Finalization.Milliseconds(),
+		},
+	}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 解码 MarshalJSON 的输出（Error 还原为只含文本的 error）；schema_version 高于本版本时报错
+func (r *RecognitionResult) UnmarshalJSON(data []byte) error {
+	var in resultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.SchemaVersion > ResultSchemaVersion {
+		return fmt.Errorf("recognition result schema version %d is newer than supported %d", in.SchemaVersion, ResultSchemaVersion)
+	}
+	*r = RecognitionResult{
+		SessionID: in.SessionID,
+		Text:      in.Text,
+		Segments:  in.Segments,
+		Duration:  time.Duration(in.DurationMs) * time.Millisecond,
+		TTFB:      time.Duration(in.TTFBMs) * time.Millisecond,
+		Latencies: Latencies{
+			FirstPartial: time.Duration(in.Latencies.FirstPartialMs) * time.Millisecond,
+			Finalization: time.Duration(in.Latencies.FinalizationMs) * time.Millisecond,
+		},
+	}
+	if in.Error != "" {
+		r.Error = errors.New(in.Error)
+	}
+	return nil
+}
+
+// MarshalJSON 编码分段（起止时间为毫秒）
+func (s Segment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(segmentJSON{
+		Text:         s.Text,
+		IsFinal:      s.IsFinal,
+		StartMs:      s.StartTime.Milliseconds(),
+		EndMs:        s.EndTime.Milliseconds(),
+		Alternatives: s.Alternatives,
+	})
+}
+
+// UnmarshalJSON 解码 MarshalJSON 的输出
+func (s *Segment) UnmarshalJSON(data []byte) error {
+	var in segmentJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*s = Segment{
+		Text:         in.Text,
+		IsFinal:      in.IsFinal,
+		StartTime:    time.Duration(in.StartMs) * time.Millisecond,
+		EndTime:      time.Duration(in.EndMs) * time.Millisecond,
+		Alternatives: in.Alternatives,
+	}
+	return nil
+}
+
+// WriteJSON 把识别结果写为缩进的 JSON 文件（结构见 ResultSchemaVersion）
+func (r *RecognitionResult) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode result: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
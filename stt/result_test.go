@@ -0,0 +1,61 @@
+package stt
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecognitionResultJSON 验证：识别结果按带版本号的固定结构编码（时间为毫秒、错误为文本），WriteJSON 写出的文件可解码回原值。
+// WHY：转写结果要长期归档，调用方各自维护镜像结构体既重复又容易随字段增加而漂移。
+func TestRecognitionResultJSON(t *testing.T) {
+	result := &RecognitionResult{
+		SessionID: "s1",
+		Text:      "hello world",
+		Segments: []Segment{{Text: "hello world", IsFinal: true, StartTime: 120 * time.Millisecond, EndTime: 900 * time.Millisecond,
+			Alternatives: []Alternative{{Text: "hello world", Confidence: 0.9}}}},
+		Duration:  1500 * time.Millisecond,
+		TTFB:      300 * time.Millisecond,
+		Latencies: Latencies{FirstPartial: 300 * time.Millisecond, Finalization: 200 * time.Millisecond},
+		Error:     errors.New("[provider_error] upstream reset"),
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"schema_version":1`, `"start_ms":120`, `"end_ms":900`, `"duration_ms":1500`,
+		`"finalization_ms":200`, `"error":"[provider_error] upstream reset"`} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("JSON %s missing %s", data, want)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := result.WriteJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded RecognitionResult
+	if err := json.Unmarshal(file, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Error == nil || decoded.Error.Error() != result.Error.Error() {
+		t.Fatalf("decoded error = %v", decoded.Error)
+	}
+	decoded.Error = result.Error
+	if !reflect.DeepEqual(&decoded, result) {
+		t.Fatalf("decoded = %+v, want %+v", decoded, *result)
+	}
+
+	if err := json.Unmarshal([]byte(`{"schema_version":2}`), &decoded); err == nil {
+		t.Fatal("newer schema version accepted")
+	}
+}
@@ -3,12 +3,13 @@ package stt
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
 	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
 	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
 )
@@ -23,28 +24,114 @@ type Session struct {
 	eventsCh  chan *RecognitionEvent
 	closeCh   chan struct{}
 	closeOnce sync.Once
+	closeErr  error // 首次 Close 的结果
 	mu        sync.Mutex
+	sendMu    sync.Mutex // 串行化上行写入（Send、EndInput、保活、重连重放），保证音频顺序；写网络时不持有 mu
 	ready     bool
 	closed    bool
 
 	// TTFB 计时
 	firstSendTime time.Time     // 首次 Send 的时间
+	sentBytes     int64         // 已发送的音频字节数（EndInput 时校验帧对齐）
 	ttfb          time.Duration // 首个识别结果延迟
 	ttfbDone      bool          // TTFB 是否已计算
 
+	// 统计（见 Stats）
+	uploadedBytes int64 // 实际上传的音频字节数（客户端 VAD 过滤后，含重连重放）
+	chunksSent    int   // 上传的 audio.append 消息数
+	partials      int   // 收到的 transcript.partial 数
+	droppedEvents int   // 事件缓冲区满丢弃的事件数
+	autoCommits   int   // MaxUtteranceDuration 触发的 input.commit 数
+	keepalives    int   // StreamOptions.KeepaliveInterval 触发的保活次数
+
+	lastUploadAt time.Time // 最近一次上传音频（或保活）的时间，见 keepalive
+
+	partial partialState // 当前语句的 partial 去抖与增量（见 StreamOptions.StablePartialsOnly）
+
+	// 长语句自动提交（StreamOptions.MaxUtteranceDuration）：上传时间轴上的字节位置，不含重连重放
+	uploadEnd      int64 // 已上传音频的结束位置
+	utteranceStart int64 // 当前语句的起点（上个 transcript.final 的结束位置或上次自动提交处）
+
+	setup []transport.PhaseLatency // 建连与握手耗时（Config.LatencyBudget）
+
+	swapper *audio.ByteSwapper // 大端输入时转换为小端（跨块的半个采样点暂存到下一次 Send）
+	vad     *audio.VAD         // 客户端静音过滤（Config.EnableClientVAD）
+
 	connectedAt time.Time // set after session is ready and config is sent
+
+	// 阶段时间戳（用于时延分析，见 cmd/stt_detailed_timing）
+	readyAt        time.Time   // session.ready 收到时间
+	configSentAt   time.Time   // session.config 发送时间
+	firstPartialAt time.Time   // 首个 transcript.partial 收到时间
+	finalAts       []time.Time // 每个 transcript.final 收到时间
+	endInputAt     time.Time   // session.end（EndInput）发送时间
+	sessionEndedAt time.Time   // session.ended 收到时间
+
+	features protocol.Features // 协商后的功能标志（config_done 收到后设置）
+	affinity string            // gateway 在 config_done 中返回的亲和令牌
+
+	resumeToken string // session.ready 返回的会话恢复令牌（断线重连时以 session.resume 接回 gateway 侧的会话）
+
+	// 会话交接（见 Snapshot / Client.Restore）：恢复的会话从交接位置继续时间戳与语句序号
+	origin       time.Duration // 事件时间戳的起点（原始音频时间轴）
+	utterances   int           // 已定稿的语句数（含交接前）
+	lastFinalEnd time.Duration // 最后一个 transcript.final 的结束时间（含 origin）
+
+	// 会话内重新配置（见 Reconfigure）：已发送 / 已确认的 session.config 次数，等待确认的调用方
+	configsSent  int
+	configsDone  int
+	reconfigWait *reconfigWaiter
+	reconfigMu   sync.Mutex // 串行化 Reconfigure
+
+	// 断线重连（Config.ReplayBuffer）：dial 按相同配置建立新连接，replay 保存最近上传的音频
+	dial         func(ctx context.Context) (*transport.Conn, error)
+	replay       *replayBuffer
+	acked        int64         // 已被 transcript.final 确认的上传位置（字节）
+	timeBase     time.Duration // 当前 gateway 会话时间戳的起点（上传时间轴，重连后为重放起点）
+	reconnecting bool          // 重连中：Send 只写入 replay，随重放发送
+	resumes      int           // 成功重连次数
+
+	onRawMessage func(msgType protocol.MessageType, data []byte) // 原始消息回调（见 OnRawMessage）
+
+	// 共享事件循环模式（Config.EventLoop）：只在连接回调序列中访问
+	eventsClosed bool
+	stopWatch    func() bool
 }
 
 // newSession 创建会话
 func newSession(conn *transport.Conn, config *Config, opts *StreamOptions) *Session {
-	return &Session{
-		Provider: config.Provider,
+	s := &Session{
+		Provider: opts.Provider,
 		conn:     conn,
 		config:   config,
 		opts:     opts,
-		eventsCh: make(chan *RecognitionEvent, 100),
+		eventsCh: make(chan *RecognitionEvent, config.EventBufferSize),
 		closeCh:  make(chan struct{}),
 	}
+	if opts.BigEndian {
+		s.swapper = audio.NewByteSwapper(opts.BitsPerSample)
+	}
+	s.replay = newReplayBuffer(config, opts)
+	if config.EnableClientVAD {
+		if opts.BitsPerSample == 16 {
+			s.vad = audio.NewVAD(config.VADThresholdDBFS, opts.SampleRate, opts.Channels)
+		} else {
+			slog.Warn("Client VAD requires 16-bit PCM, disabled", "component", "stt", "bits_per_sample", opts.BitsPerSample)
+		}
+	}
+	return s
+}
+
+// currentConn 返回当前连接（断线重连后替换）
+func (s *Session) currentConn() *transport.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
+// clock 返回会话的时钟
+func (s *Session) clock() clock.Clock {
+	return clock.Or(s.config.Clock)
 }
 
 // start 启动会话
@@ -59,69 +146,114 @@ func (s *Session) start(ctx context.Context) error {
 		return err
 	}
 
-	// 启动消息处理循环
-	go s.messageLoop(ctx)
+	// 启动消息处理循环（共享事件循环模式下改为连接回调）
+	if s.config.EventLoop != nil {
+		if err := s.handleConn(ctx); err != nil {
+			return err
+		}
+	} else {
+		go s.messageLoop(ctx)
+	}
 
-	s.connectedAt = time.Now()
+	s.connectedAt = s.clock().Now()
+	s.mu.Lock()
+	s.lastUploadAt = s.connectedAt
+	s.mu.Unlock()
+	s.startKeepalive()
 
 	return nil
 }
 
 // waitReady 等待会话就绪
 func (s *Session) waitReady(ctx context.Context) error {
-	// 设置超时
-	timeout := s.config.ConnectTimeout
-	if timeout == 0 {
-		timeout = 10 * time.Second
-	}
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	// 设置超时（ConnectTimeout 已由 Config.Normalize 补全）
+	ctx, cancel := context.WithTimeout(ctx, s.config.ConnectTimeout)
 	defer cancel()
 
-	// 接收第一条消息，应该是session.ready
-	data, err := s.conn.Receive(ctx)
+	ready, err := readReady(ctx, s.conn)
 	if err != nil {
-		return fmt.Errorf("wait session.ready: %w", err)
+		return err
+	}
+	s.ID = ready.SessionID
+	s.ready = true
+	s.readyAt = s.clock().Now()
+	s.mu.Lock()
+	s.resumeToken = ready.ResumeToken
+	s.mu.Unlock()
+
+	slog.Info("Session ready", "component", "stt", "id", s.ID, "provider", s.Provider)
+
+	// 发送就绪事件
+	s.sendEvent(NewSessionReadyEvent(s.ID))
+
+	return nil
+}
+
+// readReady 接收 conn 上的第一条消息，应为 session.ready
+func readReady(ctx context.Context, conn *transport.Conn) (*protocol.SessionReady, error) {
+	data, err := conn.Receive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("wait session.ready: %w", err)
 	}
 
 	// 解析消息类型
 	msgType, err := transport.ParseMessageType(data)
 	if err != nil {
-		return fmt.Errorf("parse session.ready: %w", err)
+		return nil, fmt.Errorf("parse session.ready: %w", err)
 	}
 
 	if msgType != protocol.MessageTypeSessionReady {
-		return fmt.Errorf("expected session.ready, got %s", msgType)
+		return nil, fmt.Errorf("expected session.ready, got %s", msgType)
 	}
 
 	// 解析会话ID
 	msg, err := transport.ParseMessage(data)
 	if err != nil {
-		return fmt.Errorf("parse session.ready body: %w", err)
+		return nil, fmt.Errorf("parse session.ready body: %w", err)
 	}
-
-	ready := msg.(*protocol.SessionReady)
-	s.ID = ready.SessionID
-	s.ready = true
-
-	slog.Info("Session ready", "component", "stt", "id", s.ID, "provider", s.Provider)
-
-	// 发送就绪事件
-	s.sendEvent(NewSessionReadyEvent(s.ID))
-
-	return nil
+	return msg.(*protocol.SessionReady), nil
 }
 
 // sendConfig 发送会话配置
 func (s *Session) sendConfig() error {
+	if err := s.currentConn().Send(s.configMessage()); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.configsSent++
+	if s.configSentAt.IsZero() {
+		s.configSentAt = s.clock().Now()
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// configMessage 按当前识别参数构建 session.config
+func (s *Session) configMessage() *protocol.SessionConfig {
+	s.mu.Lock()
+	opts := s.opts
+	s.mu.Unlock()
 	params := protocol.SessionParams{
-		Provider:    s.Provider,
-		Language:    s.opts.Language,
-		SampleRate:  s.opts.SampleRate,
-		AudioFormat: s.opts.AudioFormat,
+		Provider:      opts.Provider,
+		Language:      opts.Language,
+		SampleRate:    opts.SampleRate,
+		AudioFormat:   opts.AudioFormat,
+		Channels:      opts.Channels,
+		BitsPerSample: opts.BitsPerSample,
+		PhraseHints:   phraseHints(opts.PhraseHints, opts.PhraseBoosts),
+		Priority:      s.config.Priority,
+		AffinityToken: s.AffinityToken(),
+
+		EnablePunctuation: boolPtr(opts.EnablePunctuation),
+		EnableITN:         boolPtr(opts.EnableITN),
+		MaxAlternatives:   opts.MaxAlternatives,
+		ProfanityMode:     opts.ProfanityMode,
+		Endpointing:       endpointing(opts.EndpointSilence, opts.MaxEndpointSilence),
 	}
 
-	msg := transport.NewSessionConfig(params)
-	return s.conn.SendJSON(msg)
+	msg := transport.NewSessionConfig(params, s.config.Features...)
+	transport.AdvertiseCodec(msg, s.config.codec())
+	return msg
 }
 
 // messageLoop 消息处理循环
@@ -130,21 +262,37 @@ func (s *Session) messageLoop(ctx context.Context) {
 		close(s.eventsCh)
 	}()
 
+	conn := s.currentConn()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-s.closeCh:
 			return
-		case err := <-s.conn.ErrorChan():
+		case err := <-conn.ErrorChan():
+			if next := s.reconnect(ctx, err); next != nil {
+				conn = next
+				continue
+			}
 			s.sendEvent(NewErrorEvent(err))
 			return
-		case data := <-s.conn.ReceiveChan():
+		case data := <-conn.ReceiveChan():
 			s.handleMessage(data)
 		}
 	}
 }
 
+// OnRawMessage 设置原始消息回调：此后每条 gateway 消息在 SDK 处理之前以其类型和原始内容回调（nil 取消）
+//
+// 用于记录、持久化或自行处理 SDK 尚未建模的消息类型（设置回调后这类消息不再记录 Warn 日志）。
+// 在消息处理 goroutine 中同步调用，不应阻塞；data 为线上编码（JSON，或协商后的 MessagePack），回调返回后不得保留，
+// 需要时复制。握手阶段（session.ready、创建会话时的 config_done）的消息在设置之前已处理，不会回调
+func (s *Session) OnRawMessage(fn func(msgType protocol.MessageType, data []byte)) {
+	s.mu.Lock()
+	s.onRawMessage = fn
+	s.mu.Unlock()
+}
+
 // handleMessage 处理消息
 func (s *Session) handleMessage(data []byte) {
 	msgType, err := transport.ParseMessageType(data)
@@ -153,25 +301,94 @@ func (s *Session) handleMessage(data []byte) {
 		return
 	}
 
+	s.mu.Lock()
+	onRaw := s.onRawMessage
+	s.mu.Unlock()
+	if onRaw != nil {
+		onRaw(msgType, data)
+	}
+
 	switch msgType {
+	case protocol.MessageTypeSessionConfigDone:
+		s.handleConfigDone(data)
 	case protocol.MessageTypeTranscriptPartial:
 		s.handlePartial(data)
 	case protocol.MessageTypeTranscriptFinal:
 		s.handleFinal(data)
 	case protocol.MessageTypeSessionEnded:
+		s.mu.Lock()
+		s.sessionEndedAt = s.clock().Now()
+		s.mu.Unlock()
 		s.sendEvent(NewSessionEndedEvent())
 	case protocol.MessageTypeSpeechStarted:
 		s.sendEvent(NewSpeechStartedEvent())
+	case protocol.MessageTypeEndOfUtterance:
+		s.handleEndOfUtterance(data)
 	case protocol.MessageTypeError:
 		s.handleError(data)
 	default:
-		slog.Warn("Unknown message type", "component", "stt", "type", msgType)
+		if onRaw == nil {
+			slog.Warn("Unknown message type", "component", "stt", "type", msgType)
+		}
+	}
+}
+
+// handleConfigDone 处理配置完成消息：记录服务端确认的功能标志，切换到 gateway 选定的消息编码
+func (s *Session) handleConfigDone(data []byte) {
+	features := s.negotiate(s.currentConn(), data)
+
+	s.mu.Lock()
+	s.features = features
+	s.configsDone++
+	s.ackReconfigLocked(nil)
+	s.mu.Unlock()
+
+	if len(s.config.Features) > 0 {
+		slog.Info("Features negotiated", "component", "stt", "requested", s.config.Features, "enabled", features, "id", s.ID)
+	}
+}
+
+// negotiate 按 config_done 的确认结果计算生效的功能标志，并把 conn 切换到 gateway 选定的消息编码
+func (s *Session) negotiate(conn *transport.Conn, data []byte) protocol.Features {
+	var confirmed protocol.Features
+	var codec string
+	if msg, err := transport.ParseMessage(data); err == nil {
+		done := msg.(*protocol.SessionConfigDone)
+		confirmed, codec = done.Features, done.Codec
+		if done.AffinityToken != "" {
+			s.mu.Lock()
+			s.affinity = done.AffinityToken
+			s.mu.Unlock()
+		}
+	}
+	if advertised := s.config.codec(); advertised != transport.JSON {
+		negotiated := transport.NegotiateCodec(advertised, codec)
+		conn.SetCodec(negotiated)
+		slog.Info("Codec negotiated", "component", "stt", "requested", advertised.Name(), "codec", negotiated.Name(), "id", s.ID)
+	}
+	return transport.NegotiateFeatures(s.config.Features, confirmed)
+}
+
+// Features 返回协商后的功能标志（客户端声明且 gateway 确认；config_done 之前为空）
+func (s *Session) Features() protocol.Features {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	features := make(protocol.Features, len(s.features))
+	for name, on := range s.features {
+		features[name] = on
 	}
+	return features
 }
 
 // handlePartial 处理部分识别结果
 func (s *Session) handlePartial(data []byte) {
 	s.recordTTFB()
+	s.mu.Lock()
+	if s.firstPartialAt.IsZero() {
+		s.firstPartialAt = s.clock().Now()
+	}
+	s.partials++
+	s.mu.Unlock()
 	msg, err := transport.ParseMessage(data)
 	if err != nil {
 		slog.Error("Parse partial error", "component", "stt", "error", err)
@@ -179,13 +396,23 @@ func (s *Session) handlePartial(data []byte) {
 	}
 
 	partial := msg.(*protocol.TranscriptPartial)
-	event := NewTranscriptPartialEvent(partial.Text)
+	s.mu.Lock()
+	text, delta, ok := s.partial.next(partial.Text, s.opts.StablePartialsOnly)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	event := NewTranscriptPartialEvent(text)
+	event.DeltaText = delta
 	s.sendEvent(event)
 }
 
 // handleFinal 处理最终识别结果
 func (s *Session) handleFinal(data []byte) {
 	s.recordTTFB()
+	s.mu.Lock()
+	s.finalAts = append(s.finalAts, s.clock().Now())
+	s.mu.Unlock()
 	msg, err := transport.ParseMessage(data)
 	if err != nil {
 		slog.Error("Parse final error", "component", "stt", "error", err)
@@ -193,14 +420,57 @@ func (s *Session) handleFinal(data []byte) {
 	}
 
 	final := msg.(*protocol.TranscriptFinal)
-	// Gateway 发送的时间戳单位为毫秒
-	startTime := time.Duration(final.StartTime) * time.Millisecond
-	endTime := time.Duration(final.EndTime) * time.Millisecond
+	// Gateway 发送的时间戳单位为毫秒，以当前 gateway 会话的起点（重连后为重放起点）为基准
+	s.mu.Lock()
+	startTime := s.timeBase + time.Duration(final.StartTime)*time.Millisecond
+	endTime := s.timeBase + time.Duration(final.EndTime)*time.Millisecond
+	s.ackLocked(endTime)
+	s.utteranceStart = max(s.utteranceStart, s.opts.bytesAt(endTime))
+	s.partial.reset()
+	s.mu.Unlock()
+	if s.vad != nil {
+		// 时间戳基于过滤后上传的音频，换算回原始音频时间轴
+		s.mu.Lock()
+		startTime, endTime = s.vad.OriginalTime(startTime), s.vad.OriginalTime(endTime)
+		s.mu.Unlock()
+	}
 
+	s.mu.Lock()
+	limit := s.opts.MaxAlternatives
+	startTime, endTime = s.origin+startTime, s.origin+endTime
+	s.lastFinalEnd = max(s.lastFinalEnd, endTime)
+	utterance := s.utterances
+	s.utterances++
+	s.mu.Unlock()
 	event := NewTranscriptFinalEvent(final.Text, startTime, endTime)
+	event.Utterance = utterance
+	event.Alternatives = alternatives(final.Alternatives, limit)
 	s.sendEvent(event)
 }
 
+// handleEndOfUtterance 处理语句结束消息
+func (s *Session) handleEndOfUtterance(data []byte) {
+	msg, err := transport.ParseMessage(data)
+	if err != nil {
+		slog.Error("Parse utterance.end error", "component", "stt", "error", err)
+		return
+	}
+
+	eou := msg.(*protocol.EndOfUtterance)
+	s.mu.Lock()
+	endTime := s.timeBase + time.Duration(eou.EndTime)*time.Millisecond
+	s.mu.Unlock()
+	if s.vad != nil {
+		s.mu.Lock()
+		endTime = s.vad.OriginalTime(endTime)
+		s.mu.Unlock()
+	}
+	s.mu.Lock()
+	endTime += s.origin
+	s.mu.Unlock()
+	s.sendEvent(NewEndOfUtteranceEvent(eou.Text, endTime, eou.Reason))
+}
+
 // handleError 处理错误消息
 func (s *Session) handleError(data []byte) {
 	msg, err := transport.ParseMessage(data)
@@ -211,67 +481,187 @@ func (s *Session) handleError(data []byte) {
 
 	errMsg := msg.(*protocol.ErrorMessage)
 	event := NewErrorEvent(fmt.Errorf("[%s] %s", errMsg.Code, errMsg.Message))
+	s.mu.Lock()
+	s.ackReconfigLocked(event.Error)
+	s.mu.Unlock()
 	s.sendEvent(event)
 }
 
-// sendEvent 发送事件到channel
+// sendEvent 发送事件到channel（BlockOnFullBuffer 时缓冲区满则等待消费或会话关闭）
 func (s *Session) sendEvent(event *RecognitionEvent) {
+	if s.config.BlockOnFullBuffer {
+		select {
+		case s.eventsCh <- event:
+		case <-s.closeCh:
+		}
+		return
+	}
 	select {
 	case s.eventsCh <- event:
 	case <-s.closeCh:
 	default:
 		// 缓冲区满，丢弃事件
-		slog.Error("Event buffer full, dropping event", "component", "stt", "event_type", event.Type)
+		s.mu.Lock()
+		s.droppedEvents++
+		s.mu.Unlock()
+		slog.Error("Event buffer full, dropping event", "component", "stt", "event_type", event.Type,
+			"hint", "raise EventBufferSize or set BlockOnFullBuffer")
 	}
 }
 
-// Send 发送音频数据
-func (s *Session) Send(audio []byte) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Send 发送音频数据（见 SendContext）
+func (s *Session) Send(data []byte) error {
+	return s.SendContext(context.Background(), data)
+}
+
+// SendContext 同 Send，ctx 取消时放弃等待上行限速配额（MaxSendRate / MaxSendSpeed）或发送队列空间并返回错误，
+// 该块未发送给 gateway（开启 ReplayBuffer 时仍在重放缓冲中，断线重连后随重放补发）
+//
+// 字节序转换、VAD 与重放登记在会话锁内完成，写网络时只持有 sendMu：限速或背压阻塞期间识别结果照常送达，
+// Close、Stats 等不受影响。
+func (s *Session) SendContext(ctx context.Context, data []byte) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
 
+	s.mu.Lock()
 	if s.closed {
+		s.mu.Unlock()
 		return fmt.Errorf("session closed")
 	}
 	if !s.ready {
+		s.mu.Unlock()
 		return fmt.Errorf("session not ready")
 	}
 
-	// Base64编码
-	encoded := base64.StdEncoding.EncodeToString(audio)
-	msg := transport.NewAudioAppend(encoded)
-	if err := s.conn.SendJSON(msg); err != nil {
+	// 大端输入：gateway 只接受小端 PCM，发送前翻转字节序
+	payload := data
+	if s.swapper != nil {
+		payload = s.swapper.Convert(data)
+	}
+	// 客户端 VAD：丢弃长静音（整块都是静音时不发送）
+	if s.vad != nil && len(payload) > 0 {
+		payload = s.vad.Process(payload)
+	}
+	if len(payload) == 0 {
+		s.sentBytes += int64(len(data))
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.uploadEnd += int64(len(payload))
+	s.lastUploadAt = s.clock().Now()
+	if s.replay != nil {
+		s.replay.add(payload)
+		if s.reconnecting {
+			// 断线重连中：随重放发送
+			s.sentBytes += int64(len(data))
+			s.mu.Unlock()
+			return nil
+		}
+	}
+	conn := s.conn
+	s.mu.Unlock()
+
+	err := conn.SendAudio(ctx, payload)
+
+	s.mu.Lock()
+	if err != nil && (s.replay == nil || ctx.Err() != nil) {
+		s.mu.Unlock()
 		return err
 	}
+	if err != nil {
+		// 连接已断：音频已在 replay 中，消息循环发现断线后重连并重放
+		slog.Warn("Send failed, audio kept for replay", "component", "stt", "id", s.ID, "error", err)
+	} else {
+		s.uploadedBytes += int64(len(payload))
+		s.chunksSent++
+	}
 	if s.firstSendTime.IsZero() {
-		s.firstSendTime = time.Now()
+		s.firstSendTime = s.clock().Now()
+	}
+	s.sentBytes += int64(len(data))
+	commit := err == nil && s.autoCommitDueLocked()
+	s.mu.Unlock()
+
+	if commit {
+		s.autoCommit(ctx, conn)
 	}
 	return nil
 }
 
 // EndInput 通知 Gateway 音频流已全部发送完毕（发送 session.end）
 func (s *Session) EndInput() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
 
+	s.mu.Lock()
 	if s.closed {
+		s.mu.Unlock()
 		return fmt.Errorf("session closed")
 	}
 
-	msg := transport.NewSessionEnd()
-	return s.conn.SendJSON(msg)
+	// 总字节数未对齐到采样帧：多半是声明的声道数/位深与实际数据不符
+	if frame := int64(s.opts.Channels * s.opts.BitsPerSample / 8); frame > 0 && s.sentBytes%frame != 0 {
+		slog.Warn("Sent audio is not frame-aligned, check declared PCM format", "component", "stt",
+			"id", s.ID, "sent_bytes", s.sentBytes, "frame_size", frame,
+			"channels", s.opts.Channels, "bits_per_sample", s.opts.BitsPerSample)
+	}
+	if s.vad != nil {
+		slog.Info("Client VAD dropped silence", "component", "stt", "id", s.ID, "dropped_ms", s.vad.Dropped().Milliseconds())
+	}
+
+	// 断线重连中或发送失败：重放音频后补发 session.end（见 reconnect）；先记录 endInputAt，
+	// 释放锁后开始的重连也会补发
+	send := s.replay == nil || !s.reconnecting
+	first := s.endInputAt.IsZero()
+	if first {
+		s.endInputAt = s.clock().Now()
+	}
+	conn := s.conn
+	s.mu.Unlock()
+
+	if !send {
+		return nil
+	}
+	if err := conn.Send(transport.NewSessionEnd()); err != nil && s.replay == nil {
+		if first {
+			s.mu.Lock()
+			s.endInputAt = time.Time{}
+			s.mu.Unlock()
+		}
+		return err
+	}
+	return nil
 }
 
-// recordTTFB 记录首个识别结果延迟（从首次 Send 起算）
-func (s *Session) recordTTFB() {
+// DroppedAudio 返回客户端 VAD 丢弃的静音时长（未开启 EnableClientVAD 时为 0）
+func (s *Session) DroppedAudio() time.Duration {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.vad == nil {
+		return 0
+	}
+	return s.vad.Dropped()
+}
+
+// recordTTFB 记录首个识别结果延迟（从首次 Send 起算），超出时延预算时发送 EventBudgetExceeded
+func (s *Session) recordTTFB() {
+	s.mu.Lock()
 	if s.ttfbDone || s.firstSendTime.IsZero() {
+		s.mu.Unlock()
 		return
 	}
-	s.ttfb = time.Since(s.firstSendTime)
+	s.ttfb = clock.Since(s.clock(), s.firstSendTime)
 	s.ttfbDone = true
+	b := s.breakdownLocked()
+	s.mu.Unlock()
 	slog.Info("TTFB", "component", "stt", "ttfb_ms", s.ttfb.Milliseconds())
+
+	if b.Exceeded() {
+		exceeded := transport.NewBudgetExceededError(b)
+		slog.Warn("Latency budget exceeded", "component", "stt", "id", s.ID, "phase", exceeded.Phase, "breakdown", b.String())
+		s.sendEvent(NewBudgetExceededEvent(exceeded))
+	}
 }
 
 // TTFB 返回首个识别结果的延迟时间
@@ -291,24 +681,119 @@ func (s *Session) ConnectDuration() time.Duration {
 	if s.connectedAt.IsZero() {
 		return 0
 	}
-	return time.Since(s.connectedAt)
+	return clock.Since(s.clock(), s.connectedAt)
+}
+
+// DialDuration 返回建连耗时（TCP+TLS+WS握手，从 Conn 获取）
+func (s *Session) DialDuration() time.Duration {
+	return s.currentConn().ConnectDuration()
+}
+
+// Warm 当前连接是否取自预热池（见 Client.Warmup；此时 DialDuration 为 0）
+func (s *Session) Warm() bool {
+	return s.currentConn().Warm()
+}
+
+// ConnectAttempts 返回建连的各次尝试（见 transport.Conn.ConnectAttempts；断线重连后为新连接的尝试）
+func (s *Session) ConnectAttempts() []transport.ConnectAttempt {
+	return s.currentConn().ConnectAttempts()
+}
+
+// ConnectedAt 返回 WebSocket 建连完成时间
+func (s *Session) ConnectedAt() time.Time {
+	return s.currentConn().ConnectedAt()
+}
+
+// ReadyAt 返回 session.ready 收到时间
+func (s *Session) ReadyAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readyAt
+}
+
+// ConfigSentAt 返回 session.config 发送时间
+func (s *Session) ConfigSentAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.configSentAt
+}
+
+// FirstSendAt 返回首个音频块发送时间
+func (s *Session) FirstSendAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.firstSendTime
+}
+
+// FirstPartialAt 返回首个部分识别结果收到时间
+func (s *Session) FirstPartialAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.firstPartialAt
+}
+
+// FirstFinalAt 返回首个最终识别结果收到时间
+func (s *Session) FirstFinalAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.finalAts) == 0 {
+		return time.Time{}
+	}
+	return s.finalAts[0]
+}
+
+// FinalAts 返回每个最终识别结果的收到时间（按到达顺序，副本）
+func (s *Session) FinalAts() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.finalAts...)
+}
+
+// EndInputAt 返回 EndInput（session.end）发送时间
+func (s *Session) EndInputAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endInputAt
+}
+
+// SessionEndedAt 返回 session.ended 收到时间
+func (s *Session) SessionEndedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessionEndedAt
 }
 
 // Close 关闭会话
+// 正常关闭（已 EndInput 且收到 session.ended）返回 nil，否则返回 *transport.CloseError；
+// 重复调用返回首次关闭的结果
 func (s *Session) Close() error {
 	s.closeOnce.Do(func() {
 		s.mu.Lock()
 		s.closed = true
+		res := &transport.CloseError{
+			SessionID: s.ID,
+			EndSent:   !s.endInputAt.IsZero(),
+			Handshake: !s.sessionEndedAt.IsZero(),
+		}
 		s.mu.Unlock()
 
 		// 关闭 session 自己的 closeCh（通知 messageLoop 退出）
 		// 客户端收到 session.ended 后主动关闭连接
 		close(s.closeCh)
-		s.conn.Close()
+		conn := s.currentConn()
+		if s.config.EventLoop != nil {
+			conn.Post(s.finishEvents)
+		}
+		res.CloseErr = conn.Close()
 
+		s.closeErr = res.Err()
+		if s.closeErr != nil {
+			slog.Warn("Session closed abnormally", "component", "stt", "id", s.ID, "error", s.closeErr)
+			return
+		}
 		slog.Info("Session closed", "component", "stt", "id", s.ID)
 	})
-	return nil
+	return s.closeErr
 }
 
 // IsReady 检查会话是否就绪
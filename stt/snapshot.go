@@ -0,0 +1,112 @@
+// Package stt 会话快照与跨进程恢复
+package stt
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Snapshot 会话交接所需的最小状态（可 JSON 序列化），用于在另一进程中重建等价的会话（蓝绿发布时迁移长时间转写任务）
+//
+// 快照只描述已定稿的进度：Offset 之后已上传但尚未出 transcript.final 的音频不在其中，
+// 恢复方从 Offset 对应的位置继续 Send 音频。
+type Snapshot struct {
+	SessionID     string        `json:"session_id"`               // 原 gateway 会话 ID（仅用于日志关联）
+	GatewayURL    string        `json:"gateway_url"`              // 原会话连接的 gateway
+	Provider      string        `json:"provider"`                 // 识别提供商
+	AffinityToken string        `json:"affinity_token,omitempty"` // gateway 返回的亲和令牌，恢复时尽量路由到同一后端
+	Features      []string      `json:"features,omitempty"`       // 协商启用的功能标志
+	Codec         string        `json:"codec,omitempty"`          // 协商的消息编码
+	Options       StreamOptions `json:"options"`                  // 当前识别参数（含 Reconfigure 的修改）
+	Utterances    int           `json:"utterances"`               // 已定稿的语句数，恢复后的语句序号从此继续
+	Offset        time.Duration `json:"offset"`                   // 最后一个 transcript.final 的结束时间（原始音频时间轴）
+	TakenAt       time.Time     `json:"taken_at"`
+}
+
+// Snapshot 返回当前会话的交接快照（可在会话进行中调用；调用方通常先停止 Send 并等待在途的 transcript.final）
+func (s *Session) Snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	features := make([]string, 0, len(s.features))
+	for name, on := range s.features {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return &Snapshot{
+		SessionID:     s.ID,
+		GatewayURL:    s.config.gatewayURL(s.opts.Provider),
+		Provider:      s.opts.Provider,
+		AffinityToken: s.affinityLocked(),
+		Features:      features,
+		Codec:         s.conn.Codec().Name(),
+		Options:       *s.opts,
+		Utterances:    s.utterances,
+		Offset:        s.lastFinalEnd,
+		TakenAt:       s.clock().Now(),
+	}
+}
+
+// AffinityToken 返回会话亲和令牌：gateway 在 config_done 中返回的值，未返回时为 Config.AffinityToken
+func (s *Session) AffinityToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.affinityLocked()
+}
+
+// affinityLocked 见 AffinityToken（调用方持有 mu）
+func (s *Session) affinityLocked() string {
+	if s.affinity != "" {
+		return s.affinity
+	}
+	return s.config.AffinityToken
+}
+
+// Restore 按快照（通常来自另一进程的 Session.Snapshot）重建等价的会话
+//
+// 连接快照中的 gateway 与提供商，携带亲和令牌、功能标志与编码，识别参数取快照中的 Options；
+// 事件时间戳从 Snapshot.Offset 起、transcript.final 的语句序号从 Snapshot.Utterances 起继续。
+// 其余配置（认证、超时、重连等）沿用本客户端。原会话由原进程在交接后关闭
+func (c *Client) Restore(ctx context.Context, snap *Snapshot) (*Session, error) {
+	if snap == nil {
+		return nil, ErrInvalidConfig("snapshot is nil")
+	}
+	if snap.Offset < 0 || snap.Utterances < 0 {
+		return nil, ErrInvalidConfig("snapshot Offset and Utterances must not be negative")
+	}
+
+	config := *c.config
+	if snap.GatewayURL != "" {
+		config.GatewayURL, config.Gateways = snap.GatewayURL, nil
+	}
+	if snap.AffinityToken != "" {
+		config.AffinityToken = snap.AffinityToken
+	}
+	if len(snap.Features) > 0 {
+		config.Features = snap.Features
+	}
+	if snap.Codec != "" {
+		config.Codec = snap.Codec
+	}
+	opts := snap.Options
+	if snap.Provider != "" {
+		opts.Provider = snap.Provider
+	}
+
+	session, err := (&Client{config: &config, warm: c.warm}).createSession(ctx, &opts)
+	if err != nil {
+		return nil, fmt.Errorf("restore session: %w", err)
+	}
+	// 尚未 Send 音频，不会有 transcript.final 早于此处到达
+	session.mu.Lock()
+	session.origin, session.lastFinalEnd, session.utterances = snap.Offset, snap.Offset, snap.Utterances
+	session.mu.Unlock()
+
+	slog.Info("Session restored", "component", "stt", "id", session.ID, "from", snap.SessionID,
+		"offset_ms", snap.Offset.Milliseconds(), "utterances", snap.Utterances)
+	return session, nil
+}
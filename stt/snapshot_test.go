@@ -0,0 +1,110 @@
+package stt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestSnapshotRestore 验证：快照经 JSON 传到另一个客户端恢复后，携带原会话的亲和令牌重新建会话，
+// transcript.final 的时间戳与语句序号从交接位置继续。
+// WHY：蓝绿发布时长时间转写任务迁移到新实例，下游按时间戳和语句序号拼接结果，不能从零重新计数。
+func TestSnapshotRestore(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		tokens []string
+	)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg protocol.SessionConfig
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch msg.Type {
+			case protocol.MessageTypeSessionConfig:
+				mu.Lock()
+				tokens = append(tokens, msg.Session.AffinityToken)
+				mu.Unlock()
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone, AffinityToken: "node-7"})
+			case protocol.MessageTypeAudioAppend:
+				// 每块音频（100ms）定稿为一句，时间戳相对本 gateway 会话
+				ws.WriteJSON(protocol.TranscriptFinal{Type: protocol.MessageTypeTranscriptFinal, Text: "ok", StartTime: 0, EndTime: 100})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig().WithSampleRate(16000)
+	config.GatewayURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextFinal := func(session *Session) *RecognitionEvent {
+		t.Helper()
+		if err := session.Send(make([]byte, 3200)); err != nil {
+			t.Fatal(err)
+		}
+		for event := range session.Events() {
+			if event.IsTranscriptFinal() {
+				return event
+			}
+		}
+		t.Fatal("events closed before transcript.final")
+		return nil
+	}
+
+	original, err := client.CreateSession(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextFinal(original)
+	data, err := json.Marshal(original.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	original.Close()
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewClient(DefaultConfig().WithGateway("tengen", "ws://unused.invalid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := other.Restore(context.Background(), &snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+
+	final := nextFinal(restored)
+	if final.Utterance != 1 || final.StartTime != snap.Offset || final.EndTime != 2*snap.Offset {
+		t.Fatalf("restored final = utterance %d [%v, %v], want utterance 1 [%v, %v]",
+			final.Utterance, final.StartTime, final.EndTime, snap.Offset, 2*snap.Offset)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(tokens) != 2 || tokens[0] != "" || tokens[1] != "node-7" {
+		t.Fatalf("affinity tokens sent = %q, want [\"\" \"node-7\"]", tokens)
+	}
+	if snap.Offset.Milliseconds() != 100 || snap.Options.SampleRate != 16000 {
+		t.Fatalf("snapshot = %+v, want offset 100ms at 16kHz", snap)
+	}
+}
@@ -0,0 +1,144 @@
+// Package stt WAV 输入校验与格式转换
+package stt
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+)
+
+// PCMToWAV 按 opts 声明的音频格式为 PCM 加上 WAV 头（归档送入会话的音频）
+//
+// 采样率、声道、位深取自 opts（声道、位深为 0 时按 1 / 16），大端输入（BigEndian）转换为 WAV 要求的小端，
+// 归档参数与会话格式一致，不必另记一份格式常量。opts 为 WAV 输入时返回错误（数据已带头部）
+func PCMToWAV(pcm []byte, opts *StreamOptions) ([]byte, error) {
+	switch opts.AudioFormat {
+	case "", "pcm", "raw":
+	default:
+		return nil, fmt.Errorf("audio format %q is not raw PCM", opts.AudioFormat)
+	}
+	channels, bits := opts.Channels, opts.BitsPerSample
+	if channels == 0 {
+		channels = 1
+	}
+	if bits == 0 {
+		bits = 16
+	}
+	if opts.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate: %d", opts.SampleRate)
+	}
+	if err := audio.ValidatePCMSize(len(pcm), channels, bits); err != nil {
+		return nil, err
+	}
+	if opts.BigEndian {
+		pcm = append([]byte(nil), pcm...)
+		audio.SwapPCM(pcm, bits)
+	}
+	return audio.PCMToWAV(pcm, opts.SampleRate, channels, bits)
+}
+
+// WriteWAVFile 按 opts 声明的音频格式把 PCM 写为 WAV 文件（见 PCMToWAV）
+func WriteWAVFile(path string, pcm []byte, opts *StreamOptions) error {
+	wav, err := PCMToWAV(pcm, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, wav, 0o644)
+}
+
+// WriteWAVFile 按会话当前的音频格式把送入 Send 的 PCM 写为 WAV 文件
+func (s *Session) WriteWAVFile(path string, pcm []byte) error {
+	opts := s.Options()
+	return WriteWAVFile(path, pcm, &opts)
+}
+
+// wavSource 校验 WAV 格式，返回输出 rate/channels/bits 格式 PCM 的 Reader（r 需停在 data 块起始处）
+//
+// 32-bit 浮点转换为 16-bit；采样率不同时重采样（仅 16-bit 单声道，见 audio.Resampler）；
+// 声道数或位深不一致时返回错误，避免以错误格式上传、得到无意义的识别结果。
+// data 块长度已知时只读取 data 块，其后的 LIST 等块不会被当作音频发送。
+func wavSource(r io.Reader, h *audio.WAVHeader, rate, channels, bits int) (io.Reader, error) {
+	if err := audio.CheckWAVFormat(h); err != nil {
+		return nil, err
+	}
+	if size := h.Subchunk2Size; size != 0 && size != 0xFFFFFFFF {
+		r = io.LimitReader(r, int64(size))
+	}
+
+	float := h.AudioFormat == audio.WAVFormatFloat
+	srcBits := int(h.BitsPerSample)
+	if float {
+		srcBits = 16
+	}
+	if int(h.NumChannels) != channels {
+		return nil, fmt.Errorf("WAV has %d channels, session configured for %d", h.NumChannels, channels)
+	}
+	if srcBits != bits {
+		return nil, fmt.Errorf("WAV is %d-bit, session configured for %d-bit", h.BitsPerSample, bits)
+	}
+	srcRate := int(h.SampleRate)
+	if srcRate != rate && (bits != 16 || channels != 1) {
+		return nil, fmt.Errorf("WAV sample rate %d Hz, session configured for %d Hz (resampling supports 16-bit mono only)", srcRate, rate)
+	}
+	if !float && srcRate == rate {
+		return r, nil
+	}
+
+	c := &convertReader{
+		src:   r,
+		block: audio.CalculateChunkSize(100, srcRate, channels, int(h.BitsPerSample)),
+		float: float,
+	}
+	if srcRate != rate {
+		slog.Info("WAV sample rate differs from session, resampling", "component", "stt", "wav_rate", srcRate, "session_rate", rate)
+		c.resampler = audio.NewResampler(srcRate, rate)
+	}
+	return c, nil
+}
+
+// convertReader 按块读取源 PCM，转换为 16-bit 并重采样
+type convertReader struct {
+	src       io.Reader
+	block     int  // 每次读取的源字节数（对齐采样帧）
+	float     bool // 源为 32-bit 浮点
+	resampler *audio.Resampler
+
+	out []byte // 已转换、尚未读出的数据
+	eof bool
+}
+
+// Read 实现 io.Reader 接口
+func (c *convertReader) Read(p []byte) (int, error) {
+	for len(c.out) == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		buf := make([]byte, c.block)
+		n, err := io.ReadFull(c.src, buf)
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			c.eof = true
+		default:
+			return 0, err
+		}
+
+		data := buf[:n]
+		if c.float {
+			data = audio.FloatPCMToPCM16(data)
+		}
+		if c.resampler != nil {
+			data = c.resampler.Process(data)
+			if c.eof {
+				data = append(data, c.resampler.Flush()...)
+			}
+		}
+		c.out = data
+	}
+	n := copy(p, c.out)
+	c.out = c.out[n:]
+	return n, nil
+}
@@ -0,0 +1,41 @@
+package stt
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+)
+
+// TestWriteWAVFile 验证：WAV 头的采样率 / 声道 / 位深取自 StreamOptions，大端输入写为小端数据，
+// 调用方的缓冲区不被修改；WAV 输入与未对齐的数据被拒绝。
+// WHY：归档时凭记忆传 WriteWAVFile 的格式常量，与会话实际格式（如 8kHz 双声道大端电话录音）不一致时文件无法播放。
+func TestWriteWAVFile(t *testing.T) {
+	pcm := []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0} // 大端，两帧双声道
+	opts := &StreamOptions{SampleRate: 8000, AudioFormat: "pcm", Channels: 2, BitsPerSample: 16, BigEndian: true}
+	path := filepath.Join(t.TempDir(), "call.wav")
+	if err := WriteWAVFile(path, pcm, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, h, err := audio.ReadWAVFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.SampleRate != 8000 || h.NumChannels != 2 || h.BitsPerSample != 16 {
+		t.Fatalf("header = %d Hz %d ch %d bit, want 8000 Hz 2 ch 16 bit", h.SampleRate, h.NumChannels, h.BitsPerSample)
+	}
+	if want := []byte{0x34, 0x12, 0x78, 0x56, 0xbc, 0x9a, 0xf0, 0xde}; !bytes.Equal(data, want) {
+		t.Fatalf("data = %x, want little-endian %x", data, want)
+	}
+	if pcm[0] != 0x12 {
+		t.Fatal("caller's buffer was swapped in place")
+	}
+
+	if _, err := PCMToWAV(pcm, &StreamOptions{SampleRate: 16000, AudioFormat: "wav"}); err == nil {
+		t.Fatal("PCMToWAV accepted WAV input")
+	}
+	if _, err := PCMToWAV(pcm[:3], opts); err == nil {
+		t.Fatal("PCMToWAV accepted data not aligned to frames")
+	}
+}
@@ -0,0 +1,149 @@
+// Package transport gateway 认证
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// AuthProvider 提供 gateway 认证凭据（API Key 或短期令牌），每次建连前调用
+type AuthProvider interface {
+	// Token 返回凭据；refresh 为 true 表示上一个凭据已被 gateway 拒绝（401/403），须重新获取而不是返回缓存
+	Token(ctx context.Context, refresh bool) (string, error)
+}
+
+// AuthFunc 函数形式的 AuthProvider
+type AuthFunc func(ctx context.Context, refresh bool) (string, error)
+
+// Token 实现 AuthProvider
+func (f AuthFunc) Token(ctx context.Context, refresh bool) (string, error) {
+	return f(ctx, refresh)
+}
+
+// AuthError gateway 拒绝认证（WebSocket 握手返回 401 / 403）
+type AuthError struct {
+	StatusCode int    // HTTP 状态码
+	Body       string // gateway 返回的错误说明（最多 1KB）
+	Refreshed  bool   // 是否已通过 AuthProvider 刷新凭据重试
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("gateway authentication failed: status %d", e.StatusCode)
+	if e.Refreshed {
+		msg += " (after credential refresh)"
+	}
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// maxAuthErrorBody AuthError.Body 的长度上限（与 gorilla/websocket 握手失败时保留的响应体相同）
+const maxAuthErrorBody = 1024
+
+// isAuthStatus 握手失败是否因为凭据无效
+func isAuthStatus(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// dial 建立 WebSocket 连接：配置了 Auth 时以其凭据作为 api_key 参数（BearerAuth 时为 Authorization 头），
+// 握手被拒绝（401/403）时刷新凭据重试一次
+func (c *Conn) dial(ctx context.Context, dialer *websocket.Dialer) (*websocket.Conn, error) {
+	refreshed := false
+	for {
+		token := ""
+		if c.config.Auth != nil {
+			var err error
+			if token, err = c.config.Auth.Token(ctx, refreshed); err != nil {
+				return nil, fmt.Errorf("get credentials: %w", err)
+			}
+		}
+		target, header, err := c.credentials(token)
+		if err != nil {
+			return nil, err
+		}
+
+		ws, resp, err := dialer.DialContext(ctx, target, header)
+		if err == nil {
+			return ws, nil
+		}
+		if resp == nil {
+			return nil, fmt.Errorf("websocket connect failed: %w", err)
+		}
+		if !isAuthStatus(resp.StatusCode) {
+			return nil, fmt.Errorf("websocket connect failed: %w, status: %d", err, resp.StatusCode)
+		}
+		if c.config.Auth != nil && !refreshed {
+			slog.Warn("Gateway rejected credentials, refreshing", "component", "transport", "status", resp.StatusCode)
+			refreshed = true
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxAuthErrorBody))
+		return nil, &AuthError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body)), Refreshed: refreshed}
+	}
+}
+
+// credentials 返回握手使用的 URL 与 HTTP 头：token 非空时覆盖 URL 中的 api_key；
+// BearerAuth 时把凭据从 URL 移到 Authorization: Bearer 头
+func (c *Conn) credentials(token string) (string, http.Header, error) {
+	header := c.config.Header.Clone()
+	if !c.config.BearerAuth {
+		if token == "" {
+			return c.config.URL, header, nil
+		}
+		target, err := withAPIKey(c.config.URL, token)
+		return target, header, err
+	}
+
+	u, err := url.Parse(c.config.URL)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse url: %w", err)
+	}
+	q := u.Query()
+	if token == "" {
+		token = q.Get("api_key")
+	}
+	q.Del("api_key")
+	u.RawQuery = q.Encode()
+	if token != "" {
+		if header == nil {
+			header = make(http.Header)
+		}
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return u.String(), header, nil
+}
+
+// redactURL 隐去 URL 中的 api_key（用于日志）
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	if !q.Has("api_key") {
+		return rawURL
+	}
+	q.Set("api_key", "REDACTED")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// withAPIKey 把凭据写入 URL 的 api_key 参数（覆盖已有值）
+func withAPIKey(rawURL, token string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse url: %w", err)
+	}
+	q := u.Query()
+	q.Set("api_key", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
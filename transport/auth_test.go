@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestAuthRefresh 验证：握手返回 401 时用 AuthProvider 刷新凭据重试一次并成功；刷新后仍被拒绝时
+// 返回带 gateway 错误说明的 *AuthError，且 ConnectWithRetry 不再按退避重试。
+// WHY：短期令牌过期是常态，原先只得到 "connect failed, status 401"，调用方无法区分认证失败与网络故障。
+func TestAuthRefresh(t *testing.T) {
+	var accept atomic.Bool // 是否接受刷新后的凭据
+	var attempts atomic.Int32
+	accept.Store(true)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.URL.Query().Get("api_key") != "fresh" || !accept.Load() {
+			http.Error(w, "token expired", http.StatusUnauthorized)
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+
+	var refreshes []bool
+	auth := AuthFunc(func(_ context.Context, refresh bool) (string, error) {
+		refreshes = append(refreshes, refresh)
+		if refresh {
+			return "fresh", nil
+		}
+		return "stale", nil
+	})
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts?provider=tengen&api_key=stale"
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, Auth: auth})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect with refreshed token: %v", err)
+	}
+	conn.Close()
+	if len(refreshes) != 2 || refreshes[0] || !refreshes[1] {
+		t.Fatalf("Token calls = %v, want [false true]", refreshes)
+	}
+
+	accept.Store(false)
+	attempts.Store(0)
+	conn = NewConn(&Config{URL: url, ConnectTimeout: time.Second, ReconnectBackoff: time.Millisecond, Auth: auth})
+	err := conn.ConnectWithRetry(context.Background())
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.StatusCode != http.StatusUnauthorized || authErr.Body != "token expired" || !authErr.Refreshed {
+		t.Fatalf("ConnectWithRetry error = %#v, want *AuthError with gateway body", err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Fatalf("gateway saw %d handshakes, want 2 (one refresh, no backoff retries)", n)
+	}
+}
+
+// TestBearerAuth 验证：BearerAuth 时凭据（URL 中的 api_key 或 AuthProvider 的令牌）改用 Authorization: Bearer 头发送、
+// 从 URL 中移除，自定义 Header 随握手送达。
+// WHY：api_key 放在 URL 中会记入代理与访问日志；租户还需要在握手上附加链路追踪头。
+func TestBearerAuth(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.URL.Query().Has("api_key") || r.Header.Get("X-Trace-Id") != "trace-1" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts?provider=tengen&api_key=secret"
+	header := http.Header{"X-Trace-Id": {"trace-1"}}
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, Header: header, BearerAuth: true})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect with URL api_key as bearer: %v", err)
+	}
+	conn.Close()
+
+	auth := AuthFunc(func(context.Context, bool) (string, error) { return "secret", nil })
+	conn = NewConn(&Config{URL: url, ConnectTimeout: time.Second, Header: header, BearerAuth: true, Auth: auth})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect with AuthProvider token as bearer: %v", err)
+	}
+	conn.Close()
+	if header.Get("Authorization") != "" {
+		t.Fatal("Config.Header was modified by BearerAuth")
+	}
+}
@@ -0,0 +1,102 @@
+// Package transport 端到端时延预算
+package transport
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LatencyPhase 请求的时延阶段
+type LatencyPhase string
+
+const (
+	// PhaseConnect 建立 WebSocket 连接（含认证与建连重试）
+	PhaseConnect LatencyPhase = "connect"
+	// PhaseConfig 会话握手（session.ready、session.config；TTS 含等待 config_done）
+	PhaseConfig LatencyPhase = "config"
+	// PhaseTTFB 首个结果：TTS 为 input.commit → 首个音频块，STT 为首次 Send → 首个识别结果
+	PhaseTTFB LatencyPhase = "ttfb"
+)
+
+// PhaseLatency 单个阶段的耗时
+type PhaseLatency struct {
+	Phase    LatencyPhase
+	Duration time.Duration
+}
+
+// LatencyBreakdown 请求各阶段的耗时明细（按发生顺序；尚未完成的阶段计到取值时刻）
+//
+// 总耗时为各阶段之和，不含调用方自身在阶段之间的停顿（如建会话后隔一段时间才提交文本）。
+type LatencyBreakdown struct {
+	Budget time.Duration // 时延预算（0 表示未设置）
+	Phases []PhaseLatency
+}
+
+// Total 返回各阶段耗时之和
+func (b LatencyBreakdown) Total() time.Duration {
+	var total time.Duration
+	for _, p := range b.Phases {
+		total += p.Duration
+	}
+	return total
+}
+
+// Exceeded 是否超出预算（未设置预算时为 false）
+func (b LatencyBreakdown) Exceeded() bool {
+	return b.Budget > 0 && b.Total() > b.Budget
+}
+
+// Culprit 返回耗时最长的阶段（无阶段时为空）
+func (b LatencyBreakdown) Culprit() LatencyPhase {
+	var culprit PhaseLatency
+	for _, p := range b.Phases {
+		if p.Duration > culprit.Duration {
+			culprit = p
+		}
+	}
+	return culprit.Phase
+}
+
+// String 形如 "connect=120ms config=80ms ttfb=1.4s total=1.6s budget=1.5s"
+func (b LatencyBreakdown) String() string {
+	var sb strings.Builder
+	for _, p := range b.Phases {
+		fmt.Fprintf(&sb, "%s=%v ", p.Phase, p.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(&sb, "total=%v", b.Total().Round(time.Millisecond))
+	if b.Budget > 0 {
+		fmt.Fprintf(&sb, " budget=%v", b.Budget)
+	}
+	return sb.String()
+}
+
+// BudgetExceededError 请求超出时延预算（通知性质：请求本身仍在继续）
+type BudgetExceededError struct {
+	Phase     LatencyPhase // 耗时最长的阶段
+	Breakdown LatencyBreakdown
+}
+
+// NewBudgetExceededError 按明细创建超预算通知
+func NewBudgetExceededError(b LatencyBreakdown) *BudgetExceededError {
+	return &BudgetExceededError{Phase: b.Culprit(), Breakdown: b}
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("latency budget %v exceeded by %v, mostly in %s (%s)",
+		e.Breakdown.Budget, (e.Breakdown.Total() - e.Breakdown.Budget).Round(time.Millisecond), e.Phase, e.Breakdown)
+}
+
+// LatencyError 设置了时延预算的请求失败时返回：在原错误后附加阶段耗时明细
+type LatencyError struct {
+	Err       error
+	Breakdown LatencyBreakdown
+}
+
+func (e *LatencyError) Error() string {
+	return fmt.Sprintf("%v [latency: %s]", e.Err, e.Breakdown)
+}
+
+func (e *LatencyError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,93 @@
+// Package transport 消息编码
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// Codec 协议消息编码
+//
+// JSON 为默认编码，所有 gateway 都支持。其他编码由客户端在 client_info.codecs 中声明、
+// gateway 在 session.config_done.codec 中选定后，用于此后的消息（见 NegotiateCodec）；
+// 切换前后两种编码的消息都可能到达，解码时按首字节识别编码（见 DecodeMessage）。
+type Codec interface {
+	Name() string                       // 协商名称（如 "json"、"msgpack"）
+	Marshal(v any) ([]byte, error)      // 编码消息
+	Unmarshal(data []byte, v any) error // 解码消息
+	Binary() bool                       // 是否以 WebSocket 二进制帧发送
+}
+
+// 内置编码
+var (
+	JSON        Codec = jsonCodec{}
+	MessagePack Codec = msgpackCodec{}
+)
+
+// codecs 可按名称选择的编码
+var codecs = map[string]Codec{
+	JSON.Name():        JSON,
+	MessagePack.Name(): MessagePack,
+}
+
+// CodecByName 按名称查找编码（不区分大小写；空字符串为 JSON）
+func CodecByName(name string) (Codec, error) {
+	if name == "" {
+		return JSON, nil
+	}
+	if c, ok := codecs[strings.ToLower(name)]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown codec %q (supported: json, msgpack)", name)
+}
+
+// AdvertiseCodec 在 session.config 的 client_info 中声明期望的编码（JSON 无需声明）
+func AdvertiseCodec(msg *protocol.SessionConfig, codec Codec) {
+	if codec == nil || codec == JSON || msg.ClientInfo == nil {
+		return
+	}
+	msg.ClientInfo.Codecs = []string{codec.Name()}
+}
+
+// NegotiateCodec 协商编码：仅当 gateway 选定了客户端声明的编码时启用，否则为 JSON
+// （旧版 gateway 不返回 codec）
+func NegotiateCodec(advertised Codec, selected string) Codec {
+	if advertised != nil && selected != "" && strings.EqualFold(selected, advertised.Name()) {
+		return advertised
+	}
+	return JSON
+}
+
+// DecodeMessage 按首字节识别编码并解码：MessagePack 消息为 map（0x80-0x8f、0xde、0xdf），其余按 JSON
+func DecodeMessage(data []byte, v any) error {
+	return codecOf(data).Unmarshal(data, v)
+}
+
+// codecOf 识别消息的编码
+func codecOf(data []byte) Codec {
+	if len(data) > 0 {
+		if b := data[0]; b&0xf0 == 0x80 || b == 0xde || b == 0xdf {
+			return MessagePack
+		}
+	}
+	return JSON
+}
+
+// jsonCodec JSON 编码（默认）
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Binary() bool                       { return false }
+
+// msgpackCodec MessagePack 编码（字段名沿用 json 标签）
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string                       { return "msgpack" }
+func (msgpackCodec) Marshal(v any) ([]byte, error)      { return marshalMsgpack(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return unmarshalMsgpack(data, v) }
+func (msgpackCodec) Binary() bool                       { return true }
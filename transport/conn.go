@@ -5,54 +5,223 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
 )
 
 // 全局 TLS Session Cache（所有连接共享）
 // 启用 TLS Session Resumption，减少 TLS 握手从 2 RTT 到 1 RTT
 var globalTLSSessionCache = tls.NewLRUClientSessionCache(100)
 
+// 连接配置默认值（Config 字段为 0 时由 Normalize 填充）
+const (
+	DefaultConnectTimeout   = 10 * time.Second
+	DefaultReadTimeout      = 60 * time.Second
+	DefaultWriteTimeout     = 10 * time.Second
+	DefaultReconnectBackoff = 1 * time.Second
+	DefaultMaxReconnects    = 3
+	DefaultReceiveBuffer    = 100 // 接收缓冲区（ReceiveChan / 邮箱）容量，单位为消息数
+)
+
 // Config WebSocket连接配置
+//
+// 零值字段使用 Default* 默认值；ReadTimeout / WriteTimeout <0 不设置读写 deadline，MaxReconnects <0 不重连
 type Config struct {
 	URL              string        // WebSocket URL
 	ConnectTimeout   time.Duration // 连接超时
-	ReadTimeout      time.Duration // 读超时
+	ReadTimeout      time.Duration // 读超时（连接上两条消息之间的最长间隔，Pong 也会刷新）
 	WriteTimeout     time.Duration // 写超时
 	ReconnectBackoff time.Duration // 重连退避基数
 	MaxReconnects    int           // 最大重连次数
+
+	// 空闲超时（0 不启用）：>0 时替代 ReadTimeout，收发任一方向有数据帧或 Ping/Pong 即顺延。
+	// STT 等待用户开口时 gateway 可能长时间不发消息，只要仍在上传音频连接就不会超时
+	IdleTimeout time.Duration
+	// 连接最长存活时间（0 不限制）：从建连完成起计算，不随活动顺延，到期读取以 ErrMaxConnDuration 失败
+	MaxConnDuration time.Duration
+	// Pong 超时（0 不检测）：Ping 之后此时间内未收到 Pong 即判定连接失效，读取以 ErrPongTimeout 失败，
+	// 错误经 ErrorChan 送达，会话按断线处理（续传 / 重连）。半开连接上不必等到 ReadTimeout
+	PongTimeout time.Duration
+
+	Clock clock.Clock // 建连计时与重连退避使用的时钟（nil 使用系统时钟，测试可注入）
+
+	// 握手时附加的 HTTP 头（如租户的链路追踪头）；BearerAuth 时 Authorization 头由凭据覆盖
+	Header http.Header
+	// 凭据放在 Authorization: Bearer 头中，不出现在 URL 里（URL 会记入代理与访问日志）：
+	// Auth 提供的凭据与 URL 中已有的 api_key 都从 URL 移到请求头
+	BearerAuth bool
+
+	// 代理选择（nil 直连）：返回 nil URL 时该次连接直连。固定代理用 ProxyURL，
+	// 跟随 HTTPS_PROXY / NO_PROXY 环境变量用 http.ProxyFromEnvironment
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// 建立 TCP 连接的拨号函数（nil 使用 net.Dialer）：可固定 IP、使用自定义 DNS 解析、设置 socket 选项，
+	// 或路由到 VPC 服务端点。addr 为 URL（或代理）的 host:port，TLS 仍按 URL 的主机名校验证书
+	NetDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// 认证凭据提供方（nil 使用 URL 中已有的 api_key）：凭据作为 api_key 参数，握手返回 401/403 时刷新并重试一次，
+	// 仍被拒绝时返回 *AuthError
+	Auth AuthProvider
+
+	// 异步发送队列（0 关闭，Send 系列方法在调用方 goroutine 上同步写出）：>0 时消息入队即返回，由专用 goroutine 按顺序写出，
+	// 相邻的 audio.append 合并为一帧；排队字节数达到此上限时按 SendQueuePolicy 阻塞发送方或返回 ErrBufferFull。
+	// 写出失败在之后的 Send 返回；Close 先等待已排队的消息写出（最多 1 秒）
+	SendQueueBytes  int
+	SendQueuePolicy SendQueuePolicy
+
+	// 音频上行限速（字节/秒，0 不限速）：audio.append 中的音频按令牌桶限速（空闲后最多突发 250ms 的量），
+	// 超出时 Send 等待配额；开启发送队列时由队列的 writer 等待，背压按 SendQueuePolicy 传导到发送方。
+	// 批量上传文件时避免以远超实时的速度触发 gateway / 提供商的速率限制
+	SendRate int
+
+	// 接收缓冲区容量（消息数，0 使用 DefaultReceiveBuffer）：ReceiveChan 与共享事件循环邮箱的上限，
+	// 满时按 ReceiveOverflow 处理（默认暂停读取）。突发的 audio.delta 扇入较多时调大
+	ReceiveBuffer   int
+	ReceiveOverflow OverflowPolicy
+
+	// 拦截器链（日志、加密、消息改写等），见 Interceptor
+	Interceptors []Interceptor
+
+	// 连接指标回调（nil 不上报）：消息数与字节数、Ping RTT、重连与错误，见 MetricsHook
+	Metrics MetricsHook
+
+	// 共享事件循环（nil 为默认模式：调用方自行从 ReceiveChan 读取）；设置后可用 Handle 改为回调分发
+	EventLoop *EventLoop
 }
 
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
-		ConnectTimeout:   10 * time.Second,
-		ReadTimeout:      60 * time.Second,
-		WriteTimeout:     10 * time.Second,
-		ReconnectBackoff: 1 * time.Second,
-		MaxReconnects:    3,
+		ConnectTimeout:   DefaultConnectTimeout,
+		ReadTimeout:      DefaultReadTimeout,
+		WriteTimeout:     DefaultWriteTimeout,
+		ReconnectBackoff: DefaultReconnectBackoff,
+		MaxReconnects:    DefaultMaxReconnects,
+	}
+}
+
+// Normalize 把零值字段替换为默认值（NewConn 对配置副本调用；负值表示关闭，保持不变）
+func (c *Config) Normalize() {
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = DefaultConnectTimeout
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = DefaultReadTimeout
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = DefaultWriteTimeout
+	}
+	if c.ReconnectBackoff <= 0 {
+		c.ReconnectBackoff = DefaultReconnectBackoff
+	}
+	if c.MaxReconnects == 0 {
+		c.MaxReconnects = DefaultMaxReconnects
+	}
+	if c.ReceiveBuffer <= 0 {
+		c.ReceiveBuffer = DefaultReceiveBuffer
 	}
 }
 
+// ValidateGatewayURL 检查 gateway URL 的协议：只支持 WebSocket（ws:// 与 wss://）
+//
+// grpc:// 等其它传输尚未实现，在配置校验时明确报错，而不是建连时才得到含糊的握手错误
+func ValidateGatewayURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid gateway URL %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "ws", "wss":
+		return nil
+	case "grpc", "grpcs":
+		return fmt.Errorf("gateway URL %q: gRPC transport is not supported, use ws:// or wss://", raw)
+	}
+	return fmt.Errorf("gateway URL %q: unsupported scheme %q, use ws:// or wss://", raw, u.Scheme)
+}
+
+// TimeoutWarnings 检查（已 Normalize 的）读写超时组合中可疑的取值，返回说明；配置仍然生效
+func TimeoutWarnings(connect, read, write time.Duration) []string {
+	var warnings []string
+	if read > 0 && write > read {
+		warnings = append(warnings, fmt.Sprintf("WriteTimeout (%v) exceeds ReadTimeout (%v): a stalled write outlives the read deadline", write, read))
+	}
+	if read > 0 && connect > read {
+		warnings = append(warnings, fmt.Sprintf("ConnectTimeout (%v) exceeds ReadTimeout (%v)", connect, read))
+	}
+	if read > 0 && read < time.Second {
+		warnings = append(warnings, fmt.Sprintf("ReadTimeout (%v) is under 1s: idle connections will time out between messages", read))
+	}
+	return warnings
+}
+
+// ContextTimeout 返回 ctx 剩余的时间预算，ctx 无 deadline 时返回 def
+//
+// 简化 API（RecognizeFile、SynthesizeToBytes 等）以此替代配置中的超时，让调用方的 deadline 成为唯一时限；
+// deadline 已过时返回 1ns 而不是 0，避免下游把 0 当作"使用默认值"
+func ContextTimeout(ctx context.Context, def time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return def
+	}
+	return max(time.Until(deadline), time.Nanosecond)
+}
+
 // Conn WebSocket连接封装
 type Conn struct {
-	config    *Config
-	ws        *websocket.Conn
-	mu        sync.Mutex
-	readCh    chan []byte
-	errorCh   chan error
-	closeCh   chan struct{}
-	closeOnce sync.Once
-	connected bool
+	config        *Config
+	ws            *websocket.Conn
+	mu            sync.Mutex
+	readCh        chan []byte
+	ctrlCh        chan []byte // 终止类消息的提前通知（见 ControlChan）
+	errorCh       chan error
+	closeCh       chan struct{}
+	closeOnce     sync.Once
+	closeHookOnce sync.Once // Interceptor.OnClose 只通知一次
+	connected     bool
+	codec         Codec                       // Send 使用的编码（nil 为 JSON）
+	sendQ         *sendQueue                  // 异步发送队列（Config.SendQueueBytes >0 时）
+	limiter       atomic.Pointer[rateLimiter] // 音频上行限速（Config.SendRate / SetSendRate，nil 不限速）
+	writeSem      chan struct{}               // 直接写出的数据帧逐个进行（容量 1；等待可被 ctx 取消，见 sendFrame）
+
+	// 共享事件循环模式（Handle 之后）：readLoop 把事件放入邮箱，由 EventLoop 的 worker 串行分发
+	dispatchMu   sync.Mutex
+	handler      *Handler
+	handoff      chan struct{} // Handle 开始接管时关闭（见 useMailboxLocked）
+	handoffOnce  sync.Once
+	mailbox      []connEvent
+	scheduled    bool          // 已提交 drain 任务
+	space        chan struct{} // 邮箱腾出空间
+	remoteClosed bool          // Handle 之前对端已正常关闭
+	closeEvent   bool          // 已投递关闭事件
+	dropped      atomic.Int64  // OverflowDropOldest 丢弃的消息数
 
 	// 时间记录
-	connectStartAt time.Time // 建连开始时间（TCP+TLS+WS握手）
-	connectedAt    time.Time // 建连完成时间
+	connectStartAt time.Time                         // 建连开始时间（TCP+TLS+WS握手）
+	connectedAt    time.Time                         // 建连完成时间
+	warm           atomic.Bool                       // 取自 WarmPool（建连不在使用它的会话的关键路径上）
+	expiresAt      time.Time                         // MaxConnDuration 到期时间（系统时钟，零值不限制）
+	pongDue        atomic.Int64                      // 未应答 Ping 的 Pong 截止时间（UnixNano，0 表示没有未应答的 Ping）
+	gatewayClose   atomic.Pointer[GatewayCloseError] // gateway 发来的 Close 帧（未收到时为 nil）
+	attempts       []ConnectAttempt                  // 各次建连尝试（ConnectWithRetry 重试时多于一次）
+}
+
+// ConnectAttempt 一次建连尝试
+type ConnectAttempt struct {
+	StartedAt time.Time
+	Duration  time.Duration // 本次尝试耗时（不含之前的退避等待）
+	Err       error         // nil 表示成功
 }
 
 // NewConn 创建新的WebSocket连接
@@ -60,12 +229,20 @@ func NewConn(config *Config) *Conn {
 	if config == nil {
 		config = DefaultConfig()
 	}
-	return &Conn{
-		config:  config,
-		readCh:  make(chan []byte, 100),
-		errorCh: make(chan error, 10),
-		closeCh: make(chan struct{}),
+	normalized := *config
+	normalized.Normalize()
+	c := &Conn{
+		config:   &normalized,
+		readCh:   make(chan []byte, normalized.ReceiveBuffer),
+		ctrlCh:   make(chan []byte, 16),
+		errorCh:  make(chan error, 10),
+		closeCh:  make(chan struct{}),
+		space:    make(chan struct{}, 1),
+		writeSem: make(chan struct{}, 1),
+		handoff:  make(chan struct{}),
 	}
+	c.SetSendRate(normalized.SendRate)
+	return c
 }
 
 // Connect 建立WebSocket连接
@@ -78,10 +255,12 @@ func (c *Conn) Connect(ctx context.Context) error {
 	}
 
 	// 记录建连开始时间
-	c.connectStartAt = time.Now()
+	c.connectStartAt = clock.Or(c.config.Clock).Now()
 
 	dialer := websocket.Dialer{
 		HandshakeTimeout: c.config.ConnectTimeout,
+		Proxy:            c.config.Proxy,
+		NetDialContext:   c.config.NetDialContext,
 		TLSClientConfig: &tls.Config{
 			ClientSessionCache: globalTLSSessionCache, // 启用 TLS session 复用
 		},
@@ -91,37 +270,54 @@ func (c *Conn) Connect(ctx context.Context) error {
 	connectCtx, cancel := context.WithTimeout(ctx, c.config.ConnectTimeout)
 	defer cancel()
 
-	ws, resp, err := dialer.DialContext(connectCtx, c.config.URL, nil)
+	ws, err := c.dial(connectCtx, &dialer)
+	now := clock.Or(c.config.Clock).Now()
+	c.attempts = append(c.attempts, ConnectAttempt{StartedAt: c.connectStartAt, Duration: now.Sub(c.connectStartAt), Err: err})
 	if err != nil {
-		if resp != nil {
-			return fmt.Errorf("websocket connect failed: %w, status: %d", err, resp.StatusCode)
-		}
-		return fmt.Errorf("websocket connect failed: %w", err)
+		return err
 	}
 
 	c.ws = ws
 	c.connected = true
-	c.connectedAt = time.Now() // 记录建连完成时间
+	c.connectedAt = now // 记录建连完成时间
+	if c.config.MaxConnDuration > 0 {
+		c.expiresAt = time.Now().Add(c.config.MaxConnDuration)
+	}
 
 	// 收到服务端 Ping 时重置 ReadDeadline 并回 Pong。
 	// gorilla 的 ReadMessage() 只在收到数据帧时返回，Ping 控制帧在内部处理，
 	// 不会重置 ReadDeadline。没有这个 handler，空闲超过 ReadTimeout 就会 i/o timeout。
-	if c.config.ReadTimeout > 0 {
-		c.ws.SetPingHandler(func(msg string) error {
-			c.ws.SetReadDeadline(time.Now().Add(c.config.ReadTimeout))
-			err := c.ws.WriteControl(websocket.PongMessage, []byte(msg),
-				time.Now().Add(c.config.WriteTimeout))
+	// handler 在 readLoop 中运行，使用局部的 ws：Close 会在持锁时把 c.ws 置为 nil，读缓冲中的控制帧仍可能在其后处理
+	hasDeadline := !c.readDeadline().IsZero()
+	if hasDeadline {
+		ws.SetPingHandler(func(msg string) error {
+			ws.SetReadDeadline(c.readDeadline())
+			err := ws.WriteControl(websocket.PongMessage, []byte(msg), c.controlDeadline())
 			if err == websocket.ErrCloseSent {
 				return nil
 			}
 			return err
 		})
 	}
+	// 客户端保活 Ping 的 Pong 同样视为连接存活；带回的载荷用于计算 RTT
+	if hasDeadline || c.config.Metrics != nil || c.config.PongTimeout > 0 {
+		ws.SetPongHandler(func(payload string) error {
+			c.observePong(payload)
+			c.pongDue.Store(0)
+			return ws.SetReadDeadline(c.readDeadline())
+		})
+	}
+
+	if c.config.SendQueueBytes > 0 {
+		c.sendQ = newSendQueue(c.config.SendQueueBytes, c.config.SendQueuePolicy)
+		go c.writeLoop(c.sendQ, ws)
+	}
 
 	// 启动读取goroutine
-	go c.readLoop()
+	go c.readLoop(ws)
+	c.interceptConnect()
 
-	slog.Info("WebSocket connected", "component", "transport", "url", c.config.URL, "connect_duration_ms", c.connectedAt.Sub(c.connectStartAt).Milliseconds())
+	slog.Info("WebSocket connected", "component", "transport", "url", redactURL(c.config.URL), "connect_duration_ms", c.connectedAt.Sub(c.connectStartAt).Milliseconds())
 	return nil
 }
 
@@ -129,14 +325,18 @@ func (c *Conn) Connect(ctx context.Context) error {
 func (c *Conn) ConnectWithRetry(ctx context.Context) error {
 	var lastErr error
 	backoff := c.config.ReconnectBackoff
+	retries := max(c.config.MaxReconnects, 0)
 
-	for i := 0; i <= c.config.MaxReconnects; i++ {
+	for i := 0; i <= retries; i++ {
 		if i > 0 {
 			slog.Info("Reconnecting", "component", "transport", "attempt", i, "max", c.config.MaxReconnects, "backoff", backoff)
+			if c.config.Metrics != nil {
+				c.config.Metrics.Reconnect(i, lastErr)
+			}
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(backoff):
+			case <-clock.Or(c.config.Clock).After(backoff):
 			}
 			// 指数退避
 			backoff *= 2
@@ -148,13 +348,18 @@ func (c *Conn) ConnectWithRetry(ctx context.Context) error {
 		}
 		lastErr = err
 		slog.Error("Connect failed", "component", "transport", "error", err)
+		// 凭据被拒绝时重试无意义（AuthProvider 已在 Connect 内刷新过）
+		var authErr *AuthError
+		if errors.As(err, &authErr) {
+			return err
+		}
 	}
 
-	return fmt.Errorf("connect failed after %d retries: %w", c.config.MaxReconnects, lastErr)
+	return fmt.Errorf("connect failed after %d retries: %w", retries, lastErr)
 }
 
-// readLoop 读取消息循环
-func (c *Conn) readLoop() {
+// readLoop 读取消息循环（ws 为本次建立的连接，不读取 c.ws：Close 会将其置为 nil）
+func (c *Conn) readLoop(ws *websocket.Conn) {
 	defer func() {
 		c.mu.Lock()
 		c.connected = false
@@ -169,86 +374,465 @@ func (c *Conn) readLoop() {
 		}
 
 		// 设置读取超时
-		if c.config.ReadTimeout > 0 {
-			c.ws.SetReadDeadline(time.Now().Add(c.config.ReadTimeout))
+		if deadline := c.readDeadline(); !deadline.IsZero() {
+			ws.SetReadDeadline(deadline)
 		}
 
-		_, message, err := c.ws.ReadMessage()
+		_, message, err := ws.ReadMessage()
 		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				c.gatewayClose.Store(&GatewayCloseError{Code: closeErr.Code, Reason: closeErr.Text})
+			}
 			select {
 			case <-c.closeCh:
 				return
 			default:
+				if !c.expiresAt.IsZero() && !time.Now().Before(c.expiresAt) {
+					err = fmt.Errorf("%w (%v): %v", ErrMaxConnDuration, c.config.MaxConnDuration, err)
+				} else if due := c.pongDue.Load(); due != 0 && time.Now().UnixNano() >= due {
+					err = fmt.Errorf("%w (%v): %v", ErrPongTimeout, c.config.PongTimeout, err)
+				}
 				// 区分正常关闭和异常关闭
 				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
-					slog.Info("WebSocket closed normally", "component", "transport")
+					slog.Info("WebSocket closed normally", "component", "transport", "code", closeErr.Code, "reason", closeErr.Text)
 					// 通过 closeOnce 安全关闭 closeCh，通知 session
 					c.closeOnce.Do(func() {
 						close(c.closeCh)
 					})
+					c.dispatchMu.Lock()
+					c.remoteClosed = true
+					c.postCloseLocked()
+					c.dispatchMu.Unlock()
+					c.interceptClose(nil)
 				} else {
+					if gc := c.gatewayClose.Load(); gc != nil {
+						err = gc
+					}
 					slog.Error("WebSocket read error", "component", "transport", "error", err)
-					c.errorCh <- err
+					if c.config.Metrics != nil {
+						c.config.Metrics.Error(err)
+					}
+					c.interceptClose(err)
+					c.dispatchMu.Lock()
+					if c.useMailboxLocked() {
+						c.enqueueLocked(connEvent{err: err})
+					} else {
+						c.sendErrorLocked(err)
+					}
+					c.dispatchMu.Unlock()
 				}
 				return
 			}
 		}
 
+		size := len(message)
+		if message = c.interceptReceive(message); message == nil {
+			continue
+		}
+		if c.config.Metrics != nil {
+			c.config.Metrics.MessageReceived(metricType(message, "unknown"), size)
+		}
+		if isControlMessage(message) {
+			c.notifyControl(message)
+		}
+		if !c.deliver(message) {
+			return
+		}
+	}
+}
+
+// readDeadline 返回此刻起的读 deadline：IdleTimeout（未设置时 ReadTimeout）、MaxConnDuration 到期时间与
+// 未应答 Ping 的 Pong 截止时间中最早者，零值表示不设置
+func (c *Conn) readDeadline() time.Time {
+	var deadline time.Time
+	switch {
+	case c.config.IdleTimeout > 0:
+		deadline = time.Now().Add(c.config.IdleTimeout)
+	case c.config.ReadTimeout > 0:
+		deadline = time.Now().Add(c.config.ReadTimeout)
+	}
+	if !c.expiresAt.IsZero() && (deadline.IsZero() || c.expiresAt.Before(deadline)) {
+		deadline = c.expiresAt
+	}
+	if due := c.pongDue.Load(); due != 0 {
+		if pong := time.Unix(0, due); deadline.IsZero() || pong.Before(deadline) {
+			deadline = pong
+		}
+	}
+	return deadline
+}
+
+// isControlMessage 是否为终止类消息（error、audio.done、session.ended）
+func isControlMessage(data []byte) bool {
+	msgType, err := ParseMessageType(data)
+	if err != nil {
+		return false
+	}
+	switch msgType {
+	case protocol.MessageTypeError, protocol.MessageTypeAudioDone, protocol.MessageTypeSessionEnded:
+		return true
+	}
+	return false
+}
+
+// notifyControl 提前投递终止类消息，不排在积压的音频之后（ControlChan 满时丢弃）
+func (c *Conn) notifyControl(message []byte) {
+	c.dispatchMu.Lock()
+	h := c.handler
+	c.dispatchMu.Unlock()
+	if h != nil {
+		// 不经 worker：worker 可能全被阻塞在背压上的会话占用（控制消息很少，每条一个 goroutine 可接受）
+		if h.OnControl != nil {
+			go h.OnControl(message)
+		}
+		return
+	}
+	select {
+	case c.ctrlCh <- message:
+	default:
+	}
+}
+
+// useMailboxLocked 事件是否放入邮箱（已 Handle，或 Handle 正在接管）；否则写入 readCh / errorCh（调用方持有 dispatchMu）
+//
+// 接管期间放入邮箱的事件排在 readCh 中尚未读取的消息之后，由 Handle 一并转交
+func (c *Conn) useMailboxLocked() bool {
+	if c.handler != nil {
+		return true
+	}
+	select {
+	case <-c.handoff:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendErrorLocked 把错误写入 errorCh（调用方持有 dispatchMu）
+//
+// 不阻塞：调用方不读取 ErrorChan 时，持锁阻塞会卡住 Handle、Post 与 Close；缓冲区满时丢弃并记录日志
+func (c *Conn) sendErrorLocked(err error) {
+	select {
+	case c.errorCh <- err:
+	default:
+		slog.Warn("Error channel full, error dropped", "component", "transport", "error", err)
+	}
+}
+
+// deliver 交付一条消息：Handle 之前写入 readCh，之后放入邮箱；缓冲区满时按 ReceiveOverflow 处理。
+// 连接关闭或按 OverflowError 失败时返回 false
+func (c *Conn) deliver(message []byte) bool {
+	c.dispatchMu.Lock()
+	policy := c.config.ReceiveOverflow
+	if !c.useMailboxLocked() {
+		// 持锁发送：Handle 转移 readCh 时不会漏掉正在发送的消息；Handle 开始接管时改放邮箱并释放锁
+		select {
+		case c.readCh <- message:
+			c.dispatchMu.Unlock()
+			return true
+		default:
+		}
+		switch {
+		case policy == OverflowError:
+			c.sendErrorLocked(c.overflowErr())
+			c.dispatchMu.Unlock()
+			return false
+		case policy == OverflowDropOldest && c.dropOldestLocked():
+			c.readCh <- message
+			c.dispatchMu.Unlock()
+			return true
+		}
 		select {
 		case c.readCh <- message:
+			c.dispatchMu.Unlock()
+			return true
+		case <-c.closeCh:
+			c.dispatchMu.Unlock()
+			return false
+		case <-c.handoff:
+		}
+	}
+	for len(c.mailbox) >= c.config.ReceiveBuffer {
+		if policy == OverflowError {
+			c.enqueueLocked(connEvent{err: c.overflowErr()})
+			c.dispatchMu.Unlock()
+			return false
+		}
+		if policy == OverflowDropOldest && c.dropOldestMailboxLocked() {
+			break
+		}
+		c.dispatchMu.Unlock()
+		select {
+		case <-c.space:
 		case <-c.closeCh:
+			return false
+		}
+		c.dispatchMu.Lock()
+	}
+	c.enqueueLocked(connEvent{data: message})
+	c.dispatchMu.Unlock()
+	return true
+}
+
+// Handle 改为共享事件循环分发：此后的消息、读取错误和关闭在 EventLoop 的 worker 中串行回调 h，
+// ReceiveChan / ErrorChan 不再收到事件。已在 readCh 中尚未读取的消息按顺序转交给 h。
+// 需要 Config.EventLoop；只能调用一次，且调用方此后不得再从 ReceiveChan 读取。
+func (c *Conn) Handle(h Handler) error {
+	if c.config.EventLoop == nil {
+		return fmt.Errorf("handle: no event loop configured")
+	}
+	// readLoop 可能正持锁阻塞在 readCh 发送上（readCh 已满）：先通知它改放邮箱并释放锁
+	c.handoffOnce.Do(func() { close(c.handoff) })
+	c.dispatchMu.Lock()
+	defer c.dispatchMu.Unlock()
+	if c.handler != nil {
+		return fmt.Errorf("handle: handler already set")
+	}
+	var moved []connEvent
+	for drained := false; !drained; {
+		select {
+		case data := <-c.readCh:
+			moved = append(moved, connEvent{data: data})
+		case err := <-c.errorCh:
+			moved = append(moved, connEvent{err: err})
+		default:
+			drained = true
+		}
+	}
+	c.handler = &h
+	c.mailbox = append(moved, c.mailbox...)
+	if c.remoteClosed || c.isClosed() {
+		c.postCloseLocked()
+	}
+	c.scheduleLocked()
+	return nil
+}
+
+// Post 在连接的回调序列中执行 fn（排在已到达的事件之后，与回调串行）；需先调用 Handle
+func (c *Conn) Post(fn func()) {
+	c.dispatchMu.Lock()
+	defer c.dispatchMu.Unlock()
+	c.enqueueLocked(connEvent{fn: fn})
+}
+
+// enqueueLocked 放入邮箱并调度分发（调用方持有 dispatchMu）
+func (c *Conn) enqueueLocked(ev connEvent) {
+	c.mailbox = append(c.mailbox, ev)
+	c.scheduleLocked()
+}
+
+// postCloseLocked 投递一次关闭事件（仅 Handle 之后；调用方持有 dispatchMu）
+func (c *Conn) postCloseLocked() {
+	if c.handler == nil || c.closeEvent {
+		return
+	}
+	c.closeEvent = true
+	c.enqueueLocked(connEvent{closed: true})
+}
+
+// scheduleLocked 邮箱非空且未调度时提交 drain 任务（调用方持有 dispatchMu）
+func (c *Conn) scheduleLocked() {
+	if c.handler == nil || c.scheduled || len(c.mailbox) == 0 {
+		return
+	}
+	c.scheduled = true
+	c.config.EventLoop.submit(c.drain)
+}
+
+// drain 在 worker 中按顺序分发邮箱中的事件
+func (c *Conn) drain() {
+	for i := 0; ; i++ {
+		c.dispatchMu.Lock()
+		if len(c.mailbox) == 0 {
+			c.scheduled = false
+			c.dispatchMu.Unlock()
 			return
 		}
+		if i == drainBatch {
+			// 让出 worker，重新排队
+			c.config.EventLoop.submit(c.drain)
+			c.dispatchMu.Unlock()
+			return
+		}
+		ev := c.mailbox[0]
+		c.mailbox[0] = connEvent{}
+		c.mailbox = c.mailbox[1:]
+		h := c.handler
+		c.dispatchMu.Unlock()
+		select {
+		case c.space <- struct{}{}:
+		default:
+		}
+
+		switch {
+		case ev.fn != nil:
+			ev.fn()
+		case ev.closed:
+			if h.OnClose != nil {
+				h.OnClose()
+			}
+		case ev.err != nil:
+			if h.OnError != nil {
+				h.OnError(ev.err)
+			}
+		default:
+			if h.OnMessage != nil {
+				h.OnMessage(ev.data)
+			}
+		}
 	}
 }
 
-// SendJSON 发送JSON消息
+// isClosed closeCh 是否已关闭
+func (c *Conn) isClosed() bool {
+	select {
+	case <-c.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// SendJSON 发送JSON消息（不受协商的编码影响）
 func (c *Conn) SendJSON(v interface{}) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.SendJSONContext(context.Background(), v)
+}
 
-	if !c.connected || c.ws == nil {
-		return ErrNotConnected
+// SendJSONContext 同 SendJSON，ctx 约束发送：取消时放弃等待限速配额或其它写入，截止时间早于 WriteTimeout 时
+// 作为本次写入的 deadline
+//
+// 写入开始后只受 deadline 约束，不因 ctx 取消而中断；写入失败（含超时）时连接随之关闭，调用方按断线处理
+func (c *Conn) SendJSONContext(ctx context.Context, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
 	}
+	return c.sendFrame(ctx, websocket.TextMessage, data, v, JSON, "unknown")
+}
 
-	// 设置写入超时
-	if c.config.WriteTimeout > 0 {
-		c.ws.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
-	}
+// Send 按当前编码发送消息（默认 JSON 文本帧；协商为二进制编码后使用二进制帧）
+func (c *Conn) Send(v interface{}) error {
+	return c.SendContext(context.Background(), v)
+}
 
-	return c.ws.WriteJSON(v)
+// SendContext 同 Send，ctx 约束等待与写入的截止时间（见 SendJSONContext）
+func (c *Conn) SendContext(ctx context.Context, v interface{}) error {
+	codec := c.Codec()
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", codec.Name(), err)
+	}
+	frame := websocket.TextMessage
+	if codec.Binary() {
+		frame = websocket.BinaryMessage
+	}
+	return c.sendFrame(ctx, frame, data, v, codec, "unknown")
 }
 
-// SendBytes 发送二进制消息
-func (c *Conn) SendBytes(data []byte) error {
+// SetCodec 设置 Send 使用的编码（session.config_done 协商后调用，见 NegotiateCodec）
+func (c *Conn) SetCodec(codec Codec) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.codec = codec
+}
 
-	if !c.connected || c.ws == nil {
-		return ErrNotConnected
+// Codec 返回 Send 使用的编码
+func (c *Conn) Codec() Codec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.codec == nil {
+		return JSON
 	}
+	return c.codec
+}
 
-	if c.config.WriteTimeout > 0 {
-		c.ws.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
-	}
+// SendBytes 发送二进制消息
+func (c *Conn) SendBytes(data []byte) error {
+	return c.SendBytesContext(context.Background(), data)
+}
 
-	return c.ws.WriteMessage(websocket.BinaryMessage, data)
+// SendBytesContext 同 SendBytes，ctx 约束等待与写入的截止时间（见 SendJSONContext）
+func (c *Conn) SendBytesContext(ctx context.Context, data []byte) error {
+	return c.sendFrame(ctx, websocket.BinaryMessage, data, nil, nil, "binary")
 }
 
 // SendText 发送文本消息
 func (c *Conn) SendText(text string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.sendFrame(context.Background(), websocket.TextMessage, []byte(text), nil, nil, "text")
+}
 
-	if !c.connected || c.ws == nil {
+// sendFrame 发送一个数据帧：开启发送队列时入队，否则经拦截器后在调用方 goroutine 上同步写出
+//
+// v 与 codec 为编码前的消息及其编码（用于合并 audio.append），fallback 为无法解析 type 时上报的消息类型
+func (c *Conn) sendFrame(ctx context.Context, frame int, data []byte, v interface{}, codec Codec, fallback string) error {
+	// 先按顺序取得写入资格（等待前一个写入时可被 ctx 取消），连接锁只在检查状态时短暂持有
+	select {
+	case c.writeSem <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("wait for write: %w", context.Cause(ctx))
+	}
+	c.mu.Lock()
+	ws, connected, q := c.ws, c.connected, c.sendQ
+	c.mu.Unlock()
+	if !connected || ws == nil {
+		<-c.writeSem
 		return ErrNotConnected
 	}
+	if q != nil {
+		<-c.writeSem // 入队可能阻塞（背压），写出由 writeLoop 负责
+		return c.enqueue(ctx, q, frame, data, v, codec, fallback)
+	}
+	defer func() { <-c.writeSem }()
+	if l := c.limiter.Load(); l != nil {
+		if n := audioBytes(v); n > 0 {
+			if err := l.wait(ctx, c.closeCh, n); err != nil {
+				return err
+			}
+		}
+	}
 
+	msgType := metricType(data, fallback)
+	data, err := c.interceptSend(data)
+	if err != nil {
+		return err
+	}
+	// 与 writeLoop 相同，写入时不持有连接锁：Ping（WriteControl）与 Close 可与写入并发
+	if err := c.reportSent(msgType, len(data), c.writeFrame(ctx, ws, frame, data)); err != nil {
+		return err
+	}
+	if c.config.IdleTimeout > 0 {
+		ws.SetReadDeadline(c.readDeadline())
+	}
+	return nil
+}
+
+// writeFrame 按 WriteTimeout 与 ctx 的截止时间（取较早者）写一个数据帧（调用方持有 writeSem）
+//
+// 写入开始后只受 deadline 约束：ctx 取消不会中断进行中的写入，也不会关闭连接。写入失败（含超时）后
+// gorilla 的连接不可再写，此时关闭底层连接，读取随之失败并经 ErrorChan 报告
+func (c *Conn) writeFrame(ctx context.Context, ws *websocket.Conn, frame int, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var deadline time.Time
 	if c.config.WriteTimeout > 0 {
-		c.ws.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+		deadline = time.Now().Add(c.config.WriteTimeout)
 	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	ws.SetWriteDeadline(deadline) // 零值清除上一次写入按 ctx 设置的 deadline
 
-	return c.ws.WriteMessage(websocket.TextMessage, []byte(text))
+	err := ws.WriteMessage(frame, data)
+	if err == nil {
+		return nil
+	}
+	ws.NetConn().Close()
+	if d, ok := ctx.Deadline(); ok && !time.Now().Before(d) {
+		return fmt.Errorf("write aborted: %w", context.DeadlineExceeded)
+	}
+	return err
 }
 
 // Receive 阻塞接收一条消息
@@ -265,13 +849,13 @@ func (c *Conn) Receive(ctx context.Context) ([]byte, error) {
 	}
 }
 
-// ReceiveJSON 接收并解析JSON消息
+// ReceiveJSON 接收并解析消息（JSON，或协商后的编码，见 DecodeMessage）
 func (c *Conn) ReceiveJSON(ctx context.Context, v interface{}) error {
 	data, err := c.Receive(ctx)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, v)
+	return DecodeMessage(data, v)
 }
 
 // ReceiveChan 返回消息接收channel
@@ -279,6 +863,14 @@ func (c *Conn) ReceiveChan() <-chan []byte {
 	return c.readCh
 }
 
+// ControlChan 返回终止类消息（error、audio.done、session.ended）的提前通知
+//
+// 消息到达时立即投递，不等 ReceiveChan 中积压的音频（如调用方暂停消费、背压阻塞了消息循环）处理完，
+// 便于尽早发现失败；原消息仍按顺序出现在 ReceiveChan，提前处理必须是幂等的。未及时读取时通知会被丢弃。
+func (c *Conn) ControlChan() <-chan []byte {
+	return c.ctrlCh
+}
+
 // ErrorChan 返回错误channel
 func (c *Conn) ErrorChan() <-chan error {
 	return c.errorCh
@@ -290,30 +882,83 @@ func (c *Conn) CloseChan() <-chan struct{} {
 }
 
 // Close 关闭连接
+// 返回发送 Close 帧或关闭底层连接的错误；连接已被对端关闭/读取已失败时不报告 Close 帧发送失败
 func (c *Conn) Close() error {
+	// 已排队的消息（如 session.end）先写出
+	c.mu.Lock()
+	q := c.sendQ
+	c.mu.Unlock()
+	if q != nil {
+		q.drain(sendDrainTimeout)
+	}
+
 	// 关闭 closeCh 通知其他 goroutine
 	// 注意：closeOnce 可能已被 readLoop 执行（正常关闭场景）
 	c.closeOnce.Do(func() {
 		close(c.closeCh)
 	})
+	c.dispatchMu.Lock()
+	c.postCloseLocked()
+	c.dispatchMu.Unlock()
 
 	// 关闭实际的 ws 连接（总是需要执行）
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	var err error
 	if c.ws != nil {
 		// 发送关闭消息
-		c.ws.WriteControl(websocket.CloseMessage,
+		werr := c.ws.WriteControl(websocket.CloseMessage,
 			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
 			time.Now().Add(time.Second))
-		c.ws.Close()
+		if werr != nil && c.connected && !errors.Is(werr, websocket.ErrCloseSent) {
+			err = fmt.Errorf("send close frame: %w", werr)
+		}
+		if cerr := c.ws.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("close: %w", cerr)
+		}
 		c.ws = nil
 		c.connected = false
 		slog.Info("WebSocket closed", "component", "transport")
 	}
+	c.interceptClose(nil)
+	return err
+}
+
+// Ping 发送 WebSocket Ping 控制帧（会话保活用）
+func (c *Conn) Ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected || c.ws == nil {
+		return ErrNotConnected
+	}
+
+	now := time.Now()
+	if err := c.ws.WriteControl(websocket.PingMessage, pingPayload(now), c.controlDeadline()); err != nil {
+		return c.reportSent("", 0, fmt.Errorf("ping: %w", err))
+	}
+	// 只对最早的未应答 Ping 计时：之后的 Ping 不推迟截止时间
+	if c.config.PongTimeout > 0 && c.pongDue.CompareAndSwap(0, now.Add(c.config.PongTimeout).UnixNano()) {
+		c.ws.SetReadDeadline(c.readDeadline())
+	}
 	return nil
 }
 
+// controlDeadline 控制帧（Ping / Pong）的写 deadline：WriteTimeout <0 时数据帧不设 deadline，
+// 控制帧仍按 DefaultWriteTimeout 限时，避免保活卡在半开连接上
+func (c *Conn) controlDeadline() time.Time {
+	if c.config.WriteTimeout <= 0 {
+		return time.Now().Add(DefaultWriteTimeout)
+	}
+	return time.Now().Add(c.config.WriteTimeout)
+}
+
+// GatewayClose 返回 gateway 关闭连接时 Close 帧的状态码与原因（正常关闭也会记录）；未收到 Close 帧时返回 nil
+func (c *Conn) GatewayClose() *GatewayCloseError {
+	return c.gatewayClose.Load()
+}
+
 // IsConnected 检查连接状态
 func (c *Conn) IsConnected() bool {
 	c.mu.Lock()
@@ -354,13 +999,27 @@ func (c *Conn) Response() *http.Response {
 
 // ConnectDuration 返回建连耗时（TCP+TLS+WS握手）
 func (c *Conn) ConnectDuration() time.Duration {
-	if c.connectedAt.IsZero() || c.connectStartAt.IsZero() {
+	if c.warm.Load() || c.connectedAt.IsZero() || c.connectStartAt.IsZero() {
 		return 0
 	}
 	return c.connectedAt.Sub(c.connectStartAt)
 }
 
+// Warm 连接是否取自预热池（见 WarmPool.Take）
+func (c *Conn) Warm() bool {
+	return c.warm.Load()
+}
+
 // ConnectedAt 返回建连完成时间
 func (c *Conn) ConnectedAt() time.Time {
 	return c.connectedAt
 }
+
+// ConnectAttempts 返回各次建连尝试（按发生顺序，最后一次成功时即为 ConnectDuration 所计的那次）
+//
+// 多于一次说明经过了退避重试：ConnectDuration 只计最后一次尝试，重试到空闲节点的请求看起来会比实际更快。
+func (c *Conn) ConnectAttempts() []ConnectAttempt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ConnectAttempt(nil), c.attempts...)
+}
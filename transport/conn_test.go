@@ -0,0 +1,342 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestConnectAttempts 验证：ConnectWithRetry 每次尝试都记录耗时与错误，最后一次成功的尝试即 ConnectDuration 所计的那次。
+// WHY：ConnectDuration 只计最后一次尝试，压测无法区分"重试到空闲节点所以快"和真正快的请求。
+func TestConnectAttempts(t *testing.T) {
+	var handshakes atomic.Int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handshakes.Add(1) <= 2 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+
+	conn := NewConn(&Config{URL: "ws" + strings.TrimPrefix(srv.URL, "http"), ConnectTimeout: time.Second,
+		ReconnectBackoff: time.Millisecond, MaxReconnects: 3})
+	if err := conn.ConnectWithRetry(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	attempts := conn.ConnectAttempts()
+	if len(attempts) != 3 || attempts[0].Err == nil || attempts[1].Err == nil || attempts[2].Err != nil {
+		t.Fatalf("attempts = %+v, want two failures then success", attempts)
+	}
+	if last := attempts[2]; last.Duration != conn.ConnectDuration() || !last.StartedAt.After(attempts[1].StartedAt) {
+		t.Fatalf("last attempt %+v does not match ConnectDuration %v", last, conn.ConnectDuration())
+	}
+}
+
+// TestIdleTimeoutAndMaxConnDuration 验证：IdleTimeout 随客户端发送顺延，gateway 长时间不发消息也不超时；MaxConnDuration 到期后以 ErrMaxConnDuration 失败。
+// WHY：STT 等待用户开口时只有上行音频，按逐消息的读 deadline 会在 ReadTimeout 后误判断线。
+func TestIdleTimeoutAndMaxConnDuration(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conn := NewConn(&Config{URL: "ws" + strings.TrimPrefix(srv.URL, "http"), ReadTimeout: 50 * time.Millisecond,
+		IdleTimeout: 150 * time.Millisecond, MaxConnDuration: 600 * time.Millisecond})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case err := <-conn.ErrorChan():
+			if elapsed := time.Since(start); elapsed < 500*time.Millisecond || !errors.Is(err, ErrMaxConnDuration) {
+				t.Fatalf("read failed after %v with %v, want ErrMaxConnDuration after ~600ms", elapsed, err)
+			}
+			return
+		case <-tick.C:
+			// 读取失败后连接随即标记为断开，错误可能晚于下一次发送送达
+			if err := conn.SendText("audio"); err != nil && !errors.Is(err, ErrNotConnected) {
+				t.Fatal(err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("connection outlived MaxConnDuration")
+		}
+	}
+}
+
+// TestNetDialContext 验证：设置 NetDialContext 后由它建立 TCP 连接（收到 URL 的 host:port），可把域名固定到指定地址。
+// WHY：固定 IP、自定义解析与 VPC 端点路由都需要接管拨号，原先只能依赖系统 DNS。
+func TestNetDialContext(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+
+	var dialed []string
+	pinned := strings.TrimPrefix(srv.URL, "http://")
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		conn, err := (&net.Dialer{}).DialContext(ctx, network, pinned)
+		if err != nil {
+			return nil, err
+		}
+		conn.(*net.TCPConn).SetNoDelay(true)
+		return conn, nil
+	}
+	conn := NewConn(&Config{URL: "ws://gateway.invalid:8080/ws/tts", ConnectTimeout: time.Second, NetDialContext: dial})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect with pinned dialer: %v", err)
+	}
+	conn.Close()
+	if len(dialed) != 1 || dialed[0] != "gateway.invalid:8080" {
+		t.Fatalf("dialed %v, want [gateway.invalid:8080]", dialed)
+	}
+}
+
+// TestPongTimeout 验证：Ping 之后 PongTimeout 内未收到 Pong 时读取以 ErrPongTimeout 失败并经 ErrorChan 送达，
+// 不等 ReadTimeout；按时收到 Pong 则连接保持。
+// WHY：保活只发 Ping 不检查 Pong，半开连接上要等到 ReadTimeout（默认数十秒）才发现连接已失效。
+func TestPongTimeout(t *testing.T) {
+	var answer atomic.Bool
+	answer.Store(true)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		if !answer.Load() {
+			<-r.Context().Done() // 不读取：Ping 得不到 Pong（半开连接）
+			return
+		}
+		ws.ReadMessage() // 读取时自动回 Pong
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts"
+
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, ReadTimeout: time.Minute, PongTimeout: 50 * time.Millisecond})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	select {
+	case err := <-conn.ErrorChan():
+		t.Fatalf("answered ping failed the connection: %v", err)
+	case <-time.After(150 * time.Millisecond):
+	}
+	conn.Close()
+
+	answer.Store(false)
+	conn = NewConn(&Config{URL: url, ConnectTimeout: time.Second, ReadTimeout: time.Minute, PongTimeout: 50 * time.Millisecond})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	start := time.Now()
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	select {
+	case err := <-conn.ErrorChan():
+		if !errors.Is(err, ErrPongTimeout) {
+			t.Fatalf("error = %v, want ErrPongTimeout", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("pong timeout detected after %v", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("missed pong not detected")
+	}
+}
+
+// TestGatewayCloseError 验证：gateway 以非正常状态码关闭时 ErrorChan 送出带状态码与原因的 *GatewayCloseError；
+// 正常关闭时 CloseChan 关闭且 GatewayClose 可查到原因。
+// WHY：原先只能得到笼统的断线错误，调用方无法区分认证失败（1008）与过载卸载（1013）从而决定是否重连。
+func TestGatewayCloseError(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		code := websocket.ClosePolicyViolation
+		reason := "invalid api key"
+		if r.URL.Query().Get("normal") != "" {
+			code, reason = websocket.CloseNormalClosure, "idle timeout"
+		}
+		ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts"
+
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	select {
+	case err := <-conn.ErrorChan():
+		var gc *GatewayCloseError
+		if !errors.As(err, &gc) || gc.Code != websocket.ClosePolicyViolation || gc.Reason != "invalid api key" || gc.Retryable() {
+			t.Fatalf("error = %#v, want non-retryable *GatewayCloseError 1008", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("policy violation close not reported")
+	}
+
+	normal := NewConn(&Config{URL: url + "?normal=1", ConnectTimeout: time.Second})
+	if err := normal.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer normal.Close()
+	select {
+	case <-normal.CloseChan():
+	case <-time.After(2 * time.Second):
+		t.Fatal("normal close not signalled")
+	}
+	if gc := normal.GatewayClose(); gc == nil || gc.Code != websocket.CloseNormalClosure || gc.Reason != "idle timeout" {
+		t.Fatalf("GatewayClose() = %#v, want 1000 idle timeout", gc)
+	}
+}
+
+// TestSendContext 验证：对端不读取导致写入阻塞时，等待写入的 SendBytesContext 在 ctx 取消后立即返回 context.Canceled，
+// 不影响进行中的写入；进行中的写入按 ctx 的截止时间（早于 WriteTimeout）失败，返回 context.DeadlineExceeded，
+// 连接随之关闭并经 ErrorChan 报告。
+// WHY：调用方已放弃的发送原先要等满 WriteTimeout；而一个调用方取消 ctx 就关闭整个连接，会让同一连接上的其它会话一起断线。
+func TestSendContext(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		<-r.Context().Done() // 不读取：客户端写满缓冲区后阻塞
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts"
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, WriteTimeout: time.Minute})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	writeCtx, cancelWrite := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancelWrite()
+	writeDone := make(chan error, 1)
+	go func() {
+		chunk := make([]byte, 1<<20)
+		for {
+			if err := conn.SendBytesContext(writeCtx, chunk); err != nil {
+				writeDone <- err
+				return
+			}
+		}
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	waitCtx, cancelWait := context.WithCancel(context.Background())
+	waitDone := make(chan error, 1)
+	go func() { waitDone <- conn.SendBytesContext(waitCtx, []byte{1}) }()
+	time.Sleep(50 * time.Millisecond)
+	cancelWait()
+	select {
+	case err := <-waitDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("waiting SendBytesContext error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancelled send still waiting for the write lock")
+	}
+	select {
+	case err := <-writeDone:
+		t.Fatalf("in-flight write ended by another caller's cancel: %v", err)
+	default:
+	}
+
+	select {
+	case err := <-writeDone:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("in-flight SendBytesContext error = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("write not bounded by the ctx deadline")
+	}
+	select {
+	case <-conn.ErrorChan():
+	case <-time.After(time.Second):
+		t.Fatal("failed write did not tear down the connection")
+	}
+}
+
+// TestValidateGatewayURL 验证：只接受 ws:// 与 wss://，http、gRPC、空串与无法解析的 URL 在配置校验时报错。
+// WHY：gRPC 传输尚未实现，非 WebSocket 地址原先要到建连时才以含糊的握手错误失败。
+func TestValidateGatewayURL(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		ok   bool
+		want string // 错误信息应包含的片段
+	}{
+		{"ws://localhost:8080", true, ""},
+		{"wss://gateway.example.com/v1", true, ""},
+		{"http://localhost:8080", false, `unsupported scheme "http"`},
+		{"grpc://localhost:9090", false, "gRPC transport is not supported"},
+		{"grpcs://gateway.example.com", false, "gRPC transport is not supported"},
+		{"", false, `unsupported scheme ""`},
+		{"ws://[::1", false, "invalid gateway URL"},
+	} {
+		err := ValidateGatewayURL(tc.url)
+		if tc.ok {
+			if err != nil {
+				t.Errorf("ValidateGatewayURL(%q) = %v, want nil", tc.url, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ValidateGatewayURL(%q) = %v, want error containing %q", tc.url, err, tc.want)
+		}
+	}
+}
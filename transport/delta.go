@@ -0,0 +1,279 @@
+// Package transport audio.delta 快速解码
+package transport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// audio.delta 占 TTS 消息的绝大多数，通用路径（ParseMessageType + ParseMessage + base64.DecodeString）
+// 对每块音频完整反序列化两次，并分别为 base64 字符串和解码结果分配内存。
+// 快速路径单次扫描 JSON，base64 直接引用原始消息，由调用方解码到自己的（可复用的）缓冲区；
+// 遇到转义字符、MessagePack 等不常见情况回退到通用路径，结果一致。
+
+// errSlowPath 快速扫描无法处理，回退到通用解码
+var errSlowPath = errors.New("transport: fall back to generic decoding")
+
+// AudioDeltaView audio.delta 的解码视图：音频保留为 base64，由调用方决定解码到哪个缓冲区
+type AudioDeltaView struct {
+	RoundID  string
+	OffsetMs int64
+	audio    []byte // base64 数据（快速路径直接引用原始消息，不复制）
+}
+
+// ParseAudioDelta 解析 audio.delta（JSON 或 MessagePack）；视图引用 data，解码音频前不得修改 data
+func ParseAudioDelta(data []byte) (AudioDeltaView, error) {
+	delta, err := scanAudioDelta(data)
+	if err != errSlowPath {
+		return delta, err
+	}
+	var msg protocol.AudioDelta
+	if err := DecodeMessage(data, &msg); err != nil {
+		return AudioDeltaView{}, fmt.Errorf("parse audio.delta: %w", err)
+	}
+	return AudioDeltaView{RoundID: msg.RoundID, OffsetMs: msg.OffsetMs, audio: []byte(msg.Audio)}, nil
+}
+
+// scanAudioDelta 快速路径：单次扫描 JSON 对象，不为音频字段分配内存
+func scanAudioDelta(data []byte) (AudioDeltaView, error) {
+	var delta AudioDeltaView
+	err := scanObject(data, func(key, value []byte) error {
+		switch string(key) {
+		case "audio":
+			s, ok := plainString(value)
+			if !ok {
+				return errSlowPath
+			}
+			delta.audio = s
+		case "round_id":
+			s, ok := plainString(value)
+			if !ok {
+				return errSlowPath
+			}
+			delta.RoundID = string(s)
+		case "offset_ms":
+			n, err := strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return errSlowPath
+			}
+			delta.OffsetMs = n
+		}
+		return nil
+	}, "audio", "round_id", "offset_ms")
+	return delta, err
+}
+
+// DecodedLen 返回解码后音频长度的上限
+func (d AudioDeltaView) DecodedLen() int {
+	return base64.StdEncoding.DecodedLen(len(d.audio))
+}
+
+// AppendAudio 把解码后的音频追加到 buf 并返回（buf 容量足够时不分配内存）
+func (d AudioDeltaView) AppendAudio(buf []byte) ([]byte, error) {
+	n, size := len(buf), d.DecodedLen()
+	if cap(buf)-n < size {
+		grown := make([]byte, n, n+size)
+		copy(grown, buf)
+		buf = grown
+	}
+	m, err := base64.StdEncoding.Decode(buf[n:n+size], d.audio)
+	if err != nil {
+		return buf[:n], fmt.Errorf("decode audio: %w", err)
+	}
+	return buf[:n+m], nil
+}
+
+// serverMessageTypes 服务端消息类型（peekMessageType 复用常量字符串）
+var serverMessageTypes = map[string]protocol.MessageType{}
+
+func init() {
+	for _, t := range []protocol.MessageType{
+		protocol.MessageTypeSessionReady, protocol.MessageTypeSessionConfigDone,
+		protocol.MessageTypeSpeechStarted, protocol.MessageTypeTranscriptPartial, protocol.MessageTypeTranscriptFinal,
+		protocol.MessageTypeAudioDelta, protocol.MessageTypeAudioDone, protocol.MessageTypeEndOfUtterance,
+		protocol.MessageTypeSessionEnded, protocol.MessageTypeError,
+	} {
+		serverMessageTypes[string(t)] = t
+	}
+}
+
+// peekMessageType 快速路径：扫描顶层对象取出 type，不反序列化其余字段
+func peekMessageType(data []byte) (protocol.MessageType, error) {
+	var msgType protocol.MessageType
+	found := false
+	err := scanObject(data, func(key, value []byte) error {
+		if string(key) != "type" {
+			return nil
+		}
+		s, ok := plainString(value)
+		if !ok {
+			return errSlowPath
+		}
+		if known, ok := serverMessageTypes[string(s)]; ok { // 查表不分配内存
+			msgType = known
+		} else {
+			msgType = protocol.MessageType(s)
+		}
+		found = true
+		return nil
+	}, "type")
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", errSlowPath
+	}
+	return msgType, nil
+}
+
+// scanObject 逐个回调顶层 JSON 对象的键和原始值；键含转义、格式不符，或键与 known 仅大小写不同
+// （encoding/json 不区分大小写匹配字段）时返回 errSlowPath
+func scanObject(data []byte, field func(key, value []byte) error, known ...string) error {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return errSlowPath
+	}
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return checkEnd(data, i+1)
+	}
+	for {
+		end, ok := stringEnd(data, i)
+		if !ok {
+			return errSlowPath
+		}
+		key, ok := plainString(data[i:end])
+		if !ok {
+			return errSlowPath
+		}
+		i = skipSpace(data, end)
+		if i >= len(data) || data[i] != ':' {
+			return errSlowPath
+		}
+		i = skipSpace(data, i+1)
+		end, ok = valueEnd(data, i)
+		if !ok {
+			return errSlowPath
+		}
+		for _, k := range known {
+			if len(key) == len(k) && string(key) != k && bytes.EqualFold(key, []byte(k)) {
+				return errSlowPath
+			}
+		}
+		if err := field(key, data[i:end]); err != nil {
+			return err
+		}
+		i = skipSpace(data, end)
+		if i >= len(data) {
+			return errSlowPath
+		}
+		switch data[i] {
+		case ',':
+			i = skipSpace(data, i+1)
+		case '}':
+			return checkEnd(data, i+1)
+		default:
+			return errSlowPath
+		}
+	}
+}
+
+// checkEnd 对象之后只允许空白
+func checkEnd(data []byte, i int) error {
+	if skipSpace(data, i) != len(data) {
+		return errSlowPath
+	}
+	return nil
+}
+
+// skipSpace 跳过 JSON 空白
+func skipSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// stringEnd 返回从 i 开始的 JSON 字符串（含引号）的结束位置
+func stringEnd(data []byte, i int) (int, bool) {
+	if i >= len(data) || data[i] != '"' {
+		return 0, false
+	}
+	for i++; i < len(data); {
+		j := bytes.IndexAny(data[i:], `"\`)
+		if j < 0 {
+			return 0, false
+		}
+		i += j
+		if data[i] == '"' {
+			return i + 1, true
+		}
+		i += 2 // 跳过转义字符
+	}
+	return 0, false
+}
+
+// valueEnd 返回从 i 开始的 JSON 值的结束位置（嵌套对象/数组按括号深度跳过）
+func valueEnd(data []byte, i int) (int, bool) {
+	if i >= len(data) {
+		return 0, false
+	}
+	switch data[i] {
+	case '"':
+		return stringEnd(data, i)
+	case '{', '[':
+		depth := 0
+		for i < len(data) {
+			switch data[i] {
+			case '"':
+				end, ok := stringEnd(data, i)
+				if !ok {
+					return 0, false
+				}
+				i = end
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1, true
+				}
+			}
+			i++
+		}
+		return 0, false
+	default: // 数字、true、false、null
+		start := i
+		for i < len(data) {
+			switch data[i] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return i, i > start
+			}
+			i++
+		}
+		return i, i > start
+	}
+}
+
+// plainString 返回不含转义的 JSON 字符串内容（不分配内存）；含转义或不是字符串时返回 false
+func plainString(value []byte) ([]byte, bool) {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, false
+	}
+	s := value[1 : len(value)-1]
+	if bytes.IndexByte(s, '\\') >= 0 {
+		return nil, false
+	}
+	return s, true
+}
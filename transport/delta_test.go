@@ -0,0 +1,109 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestParseAudioDelta 验证：快速路径与通用路径（json / MessagePack 完整反序列化 + base64 解码）结果一致，
+// 包括转义字符、字段顺序、嵌套的未知字段和只有大小写不同的键；JSON 快速路径解码不为音频分配内存。
+// WHY：快速路径是手写扫描，任何与 encoding/json 语义的偏差都会静默地丢音频或串轮次。
+func TestParseAudioDelta(t *testing.T) {
+	pcm := bytes.Repeat([]byte{0x12, 0x34, 0xfe, 0xff, 0xff, 0xff}, 350) // base64 含 "+" 和 "/"
+	b64 := base64.StdEncoding.EncodeToString(pcm)
+	packed, _ := MessagePack.Marshal(protocol.AudioDelta{Type: protocol.MessageTypeAudioDelta, Audio: b64, RoundID: "r3", OffsetMs: 40})
+	inputs := [][]byte{
+		[]byte(`{"type":"audio.delta","audio":"` + b64 + `","round_id":"r3","offset_ms":40}`),
+		[]byte(` { "offset_ms" : 40 , "meta":{"a":[1,"}\""]}, "round_id":"r3","audio":"` + b64 + `","type":"audio.delta"} `),
+		[]byte(`{"type":"audio.delta","audio":"` + strings.Replace(b64, "/", `\/`, 1) + `","round_id":"r3","offset_ms":40}`),
+		[]byte(`{"type":"audio.delta","Audio":"` + b64 + `","ROUND_ID":"r3","offset_ms":40}`),
+		packed,
+	}
+	for i, data := range inputs {
+		var want protocol.AudioDelta
+		if err := DecodeMessage(data, &want); err != nil {
+			t.Fatalf("input %d: generic decode: %v", i, err)
+		}
+		wantPCM, _ := base64.StdEncoding.DecodeString(want.Audio)
+
+		delta, err := ParseAudioDelta(data)
+		if err != nil {
+			t.Fatalf("input %d: ParseAudioDelta: %v", i, err)
+		}
+		got, err := delta.AppendAudio([]byte("x"))
+		if err != nil {
+			t.Fatalf("input %d: AppendAudio: %v", i, err)
+		}
+		if delta.RoundID != want.RoundID || delta.OffsetMs != want.OffsetMs || !bytes.Equal(got[1:], wantPCM) || got[0] != 'x' {
+			t.Fatalf("input %d: got round=%q offset=%d audio=%d bytes, want %q %d %d bytes",
+				i, delta.RoundID, delta.OffsetMs, len(got)-1, want.RoundID, want.OffsetMs, len(wantPCM))
+		}
+		if msgType, err := ParseMessageType(data); err != nil || msgType != protocol.MessageTypeAudioDelta {
+			t.Fatalf("input %d: ParseMessageType = %q, %v", i, msgType, err)
+		}
+	}
+
+	if _, err := ParseAudioDelta([]byte(`{"type":"audio.delta","audio":"!!"}`)); err != nil {
+		t.Fatalf("ParseAudioDelta defers base64 errors to AppendAudio, got %v", err)
+	}
+	if _, err := ParseAudioDelta([]byte(`{"type":"audio.delta","audio":`)); err == nil {
+		t.Fatal("ParseAudioDelta(truncated) succeeded")
+	}
+
+	buf := make([]byte, 0, len(pcm))
+	allocs := testing.AllocsPerRun(100, func() {
+		delta, _ := ParseAudioDelta(inputs[0])
+		buf, _ = delta.AppendAudio(buf[:0])
+	})
+	if allocs > 1 { // round_id 字符串
+		t.Fatalf("fast path allocates %.0f times per delta, want <= 1", allocs)
+	}
+}
+
+// BenchmarkAudioDelta 对比 audio.delta 的通用解码路径与快速路径（100ms 16kHz PCM）
+func BenchmarkAudioDelta(b *testing.B) {
+	data, _ := json.Marshal(protocol.AudioDelta{
+		Type:     protocol.MessageTypeAudioDelta,
+		Audio:    base64.StdEncoding.EncodeToString(make([]byte, 3200)),
+		RoundID:  "r1",
+		OffsetMs: 1200,
+	})
+	b.Run("ParseMessage", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseMessageType(data); err != nil {
+				b.Fatal(err)
+			}
+			msg, err := ParseMessage(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := base64.StdEncoding.DecodeString(msg.(*protocol.AudioDelta).Audio); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ParseAudioDelta", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		buf := make([]byte, 0, 4096)
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseMessageType(data); err != nil {
+				b.Fatal(err)
+			}
+			delta, err := ParseAudioDelta(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if buf, err = delta.AppendAudio(buf[:0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -1,7 +1,13 @@
 // Package transport 错误定义
 package transport
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
 
 // 预定义错误
 var (
@@ -20,6 +26,12 @@ var (
 	// ErrReadTimeout 读取超时错误
 	ErrReadTimeout = errors.New("websocket read timeout")
 
+	// ErrMaxConnDuration 连接达到最长存活时间（见 Config.MaxConnDuration）
+	ErrMaxConnDuration = errors.New("websocket max connection duration reached")
+
+	// ErrPongTimeout Ping 之后 PongTimeout 内未收到 Pong，连接视为已失效（见 Config.PongTimeout）
+	ErrPongTimeout = errors.New("websocket pong timeout")
+
 	// ErrInvalidMessage 无效消息错误
 	ErrInvalidMessage = errors.New("invalid websocket message")
 
@@ -55,3 +67,82 @@ func NewConnectionError(url, op string, err error, retries int) *ConnectionError
 		Retries: retries,
 	}
 }
+
+// CloseError 会话关闭异常（正常关闭时 Close 返回 nil）
+// 描述 session.end 是否送达、关闭握手是否完成、连接是否正常关闭，便于调用方记录异常断开
+type CloseError struct {
+	SessionID     string
+	EndSent       bool  // session.end 是否已送达 gateway
+	EndErr        error // session.end 发送失败原因（未发送时为 nil）
+	Handshake     bool  // 是否完成关闭握手（TTS：收到 gateway Close 帧；STT：收到 session.ended）
+	SkippedWait   bool  // 按配置或关机跳过了握手等待（未完成握手不视为异常）
+	CloseErr      error // 发送 Close 帧 / 关闭底层连接失败
+	PendingRounds int   // 关闭时仍未完成、被丢弃的合成轮次数（TTS）
+}
+
+func (e *CloseError) Error() string {
+	var parts []string
+	switch {
+	case e.EndErr != nil:
+		parts = append(parts, "session.end not delivered: "+e.EndErr.Error())
+	case !e.EndSent:
+		parts = append(parts, "session.end not sent")
+	}
+	if !e.Handshake && !e.SkippedWait {
+		parts = append(parts, "close handshake not completed")
+	}
+	if e.PendingRounds > 0 {
+		parts = append(parts, fmt.Sprintf("%d pending rounds dropped", e.PendingRounds))
+	}
+	if e.CloseErr != nil {
+		parts = append(parts, "close connection: "+e.CloseErr.Error())
+	}
+	return "close session " + e.SessionID + ": " + strings.Join(parts, "; ")
+}
+
+// Unwrap 返回底层错误（errors.Is / errors.As 可匹配任一）
+func (e *CloseError) Unwrap() []error {
+	var errs []error
+	if e.EndErr != nil {
+		errs = append(errs, e.EndErr)
+	}
+	if e.CloseErr != nil {
+		errs = append(errs, e.CloseErr)
+	}
+	return errs
+}
+
+// Err 全部正常时返回 nil，否则返回 e
+func (e *CloseError) Err() error {
+	if e.EndSent && (e.Handshake || e.SkippedWait) && e.CloseErr == nil && e.PendingRounds == 0 {
+		return nil
+	}
+	return e
+}
+
+// GatewayCloseError gateway 以 Close 帧关闭连接（状态码与原因见 RFC 6455 §7.4）
+//
+// 非正常关闭（如 1008 策略违规 / 认证失败、1011 内部错误、1013 过载卸载）经 ErrorChan 送达；
+// 正常关闭（1000 / 1001）时通过 Conn.GatewayClose 查询原因（如 "idle timeout"）。
+// （CloseError 描述的是本地 Session.Close 的结果，与此无关）
+type GatewayCloseError struct {
+	Code   int    // Close 帧状态码
+	Reason string // Close 帧携带的原因文本（可为空）
+}
+
+func (e *GatewayCloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("gateway close code %d", e.Code)
+	}
+	return fmt.Sprintf("gateway close code %d: %s", e.Code, e.Reason)
+}
+
+// Retryable 是否为 gateway 侧的临时状态（内部错误、重启、过载），稍后重连可能成功；
+// 策略违规（认证失败、参数被拒）等重连无意义
+func (e *GatewayCloseError) Retryable() bool {
+	switch e.Code {
+	case websocket.CloseGoingAway, websocket.CloseInternalServerErr, websocket.CloseServiceRestart, websocket.CloseTryAgainLater:
+		return true
+	}
+	return false
+}
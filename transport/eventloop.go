@@ -0,0 +1,199 @@
+// Package transport 共享事件循环
+package transport
+
+import (
+	"container/heap"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+)
+
+// EventLoop 共享事件循环：高密度部署（数千并发会话）下替代每个会话的消息循环与保活 goroutine
+//
+// 默认模式下每个会话常驻三个 goroutine（读取、消息循环、保活 Ping）。共享模式下读取仍为每连接一个
+// （gorilla/websocket 只提供阻塞读），消息由连接的邮箱暂存，只在有消息待处理时占用 worker 分发；
+// 保活等周期任务由单个定时 goroutine 统一调度。空闲会话只占用读取 goroutine。
+//
+// 同一连接的回调严格串行、按到达顺序执行（见 Conn.Handle）。worker 全忙时临时启动 goroutine 分发，
+// 单个会话阻塞（如 AudioStream 背压）不会拖住其他会话。
+type EventLoop struct {
+	tasks   chan func()
+	clock   clock.Clock
+	closeCh chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	timers timerHeap
+	wake   chan struct{}
+}
+
+// NewEventLoop 创建共享事件循环；workers <= 0 时使用 GOMAXPROCS，c 为 nil 时使用系统时钟
+func NewEventLoop(workers int, c clock.Clock) *EventLoop {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	l := &EventLoop{
+		tasks:   make(chan func(), workers*64),
+		clock:   clock.Or(c),
+		closeCh: make(chan struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+	l.wg.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go l.worker()
+	}
+	go l.timerLoop()
+	return l
+}
+
+// Close 停止 worker 与定时任务；已注册连接的后续回调改为各自启动 goroutine 执行
+func (l *EventLoop) Close() {
+	l.once.Do(func() { close(l.closeCh) })
+	l.wg.Wait()
+}
+
+// submit 提交任务：worker 队列满或已关闭时启动临时 goroutine，保证任务总会执行
+func (l *EventLoop) submit(task func()) {
+	select {
+	case <-l.closeCh:
+		go task()
+		return
+	default:
+	}
+	select {
+	case l.tasks <- task:
+	default:
+		go task()
+	}
+}
+
+// worker 执行任务直到事件循环关闭
+func (l *EventLoop) worker() {
+	defer l.wg.Done()
+	for {
+		select {
+		case <-l.closeCh:
+			// 交还队列中剩余的任务
+			for {
+				select {
+				case task := <-l.tasks:
+					go task()
+				default:
+					return
+				}
+			}
+		case task := <-l.tasks:
+			task()
+		}
+	}
+}
+
+// Every 每隔 interval 在 worker 中执行一次 fn，fn 返回 false 时停止
+func (l *EventLoop) Every(interval time.Duration, fn func() bool) {
+	if interval <= 0 {
+		return
+	}
+	l.mu.Lock()
+	heap.Push(&l.timers, &loopTimer{at: l.clock.Now().Add(interval), interval: interval, fn: fn})
+	l.mu.Unlock()
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// timerLoop 按到期时间调度周期任务（单个 goroutine 管理所有连接的定时器）
+func (l *EventLoop) timerLoop() {
+	defer l.wg.Done()
+	timer := l.clock.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		l.mu.Lock()
+		now := l.clock.Now()
+		for len(l.timers) > 0 && !l.timers[0].at.After(now) {
+			t := heap.Pop(&l.timers).(*loopTimer)
+			l.submit(func() {
+				if t.fn() {
+					l.reschedule(t)
+				}
+			})
+		}
+		wait := time.Hour
+		if len(l.timers) > 0 {
+			wait = l.timers[0].at.Sub(now)
+		}
+		l.mu.Unlock()
+
+		timer.Reset(wait)
+		select {
+		case <-l.closeCh:
+			return
+		case <-l.wake:
+			timer.Stop()
+		case <-timer.C():
+		}
+	}
+}
+
+// reschedule 周期任务执行完后排入下一次（从执行完成起算，慢任务不会堆积）
+func (l *EventLoop) reschedule(t *loopTimer) {
+	select {
+	case <-l.closeCh:
+		return
+	default:
+	}
+	l.mu.Lock()
+	t.at = l.clock.Now().Add(t.interval)
+	heap.Push(&l.timers, t)
+	l.mu.Unlock()
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loopTimer 周期任务
+type loopTimer struct {
+	at       time.Time
+	interval time.Duration
+	fn       func() bool
+}
+
+// timerHeap 按到期时间排列的最小堆
+type timerHeap []*loopTimer
+
+func (h timerHeap) Len() int           { return len(h) }
+func (h timerHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h timerHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *timerHeap) Push(x any)        { *h = append(*h, x.(*loopTimer)) }
+func (h *timerHeap) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return t
+}
+
+// Handler 共享事件循环模式下的连接回调（同一连接的回调串行执行，见 Conn.Handle）
+type Handler struct {
+	OnMessage func(data []byte) // 收到消息
+	OnError   func(err error)   // 读取失败（连接已不可用，之后不再有回调）
+	OnClose   func()            // 连接关闭（对端正常关闭或本地 Close，之后不再有回调）
+
+	// 终止类消息的提前通知（见 Conn.ControlChan）：不经邮箱排队，可能与上面的回调并发执行
+	OnControl func(data []byte)
+}
+
+// connEvent 连接邮箱中的一项
+type connEvent struct {
+	data   []byte
+	err    error
+	closed bool
+	fn     func()
+}
+
+// drainBatch 每次占用 worker 最多处理的事件数，之后重新排队，避免单个连接独占 worker
+const drainBatch = 64
@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestConnHandle 验证：Handle 接管前已到达（readCh 中）和之后到达的消息按原顺序交给回调，
+// 对端正常关闭后 OnClose 恰好回调一次；Every 的周期任务返回 false 后不再执行。
+// WHY：会话从握手阶段的 Receive 切换到共享事件循环时，任何丢失或乱序的消息都会让 config_done / audio.delta 错位。
+func TestConnHandle(t *testing.T) {
+	const total = 150 // 超过 readCh 容量，覆盖接管时 readLoop 阻塞在发送上的情况
+	release := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for i := 0; i < total; i++ {
+			if i == total/2 {
+				<-release
+			}
+			ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprint(i)))
+		}
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+
+	loop := NewEventLoop(2, nil)
+	defer loop.Close()
+	conn := NewConn(&Config{URL: "ws" + strings.TrimPrefix(srv.URL, "http"), ConnectTimeout: time.Second, EventLoop: loop})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	first, err := conn.Receive(context.Background())
+	if err != nil || string(first) != "0" {
+		t.Fatalf("Receive = %q, %v", first, err)
+	}
+	time.Sleep(20 * time.Millisecond) // 让其余前半部分消息进入 readCh
+
+	got := make(chan string, total)
+	closed := make(chan struct{}, 2)
+	if err := conn.Handle(Handler{
+		OnMessage: func(data []byte) { got <- string(data) },
+		OnError:   func(err error) { t.Errorf("OnError(%v)", err) },
+		OnClose:   func() { closed <- struct{}{} },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	for i := 1; i < total; i++ {
+		select {
+		case msg := <-got:
+			if msg != fmt.Sprint(i) {
+				t.Fatalf("message %d = %q, want in-order delivery", i, msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for message %d", i)
+		}
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnClose not called after gateway close")
+	}
+	conn.Close()
+	time.Sleep(20 * time.Millisecond)
+	if len(closed) != 0 {
+		t.Fatal("OnClose called again after local Close")
+	}
+
+	var runs atomic.Int32
+	loop.Every(time.Millisecond, func() bool { return runs.Add(1) < 3 })
+	time.Sleep(50 * time.Millisecond)
+	if n := runs.Load(); n != 3 {
+		t.Fatalf("periodic task ran %d times, want 3", n)
+	}
+}
@@ -0,0 +1,64 @@
+// Package transport 连接拦截器
+package transport
+
+import "fmt"
+
+// Interceptor 连接拦截器（见 Config.Interceptors）：在不修改 Conn 的情况下实现日志、加密、指标或消息改写，各回调均可为 nil
+//
+// 多个拦截器按洋葱模型串联：OnSend 按列表顺序调用，OnReceive 按相反顺序调用，
+// 因此成对的变换（如加密 / 解密）放在同一个拦截器里即可正确配对。
+// 回调在收发路径上同步调用（可能持有连接锁），须快速返回且不能回调 Conn 的方法。
+type Interceptor struct {
+	// OnConnect 建连成功后调用（url 中的 api_key 已打码）
+	OnConnect func(url string)
+	// OnSend 数据帧写出前调用，返回实际写出的载荷（帧类型不变）；返回错误时放弃发送，Send 返回该错误
+	OnSend func(data []byte) ([]byte, error)
+	// OnReceive 收到数据帧、交付前调用，返回交付的载荷；返回 nil 丢弃该消息
+	OnReceive func(data []byte) []byte
+	// OnClose 连接结束时调用一次：本地 Close 或对端正常关闭时 err 为 nil，否则为读取失败的原因
+	OnClose func(err error)
+}
+
+// interceptSend 依次经过各拦截器的 OnSend
+func (c *Conn) interceptSend(data []byte) ([]byte, error) {
+	for i := range c.config.Interceptors {
+		if fn := c.config.Interceptors[i].OnSend; fn != nil {
+			out, err := fn(data)
+			if err != nil {
+				return nil, fmt.Errorf("send interceptor: %w", err)
+			}
+			data = out
+		}
+	}
+	return data, nil
+}
+
+// interceptReceive 按相反顺序经过各拦截器的 OnReceive，返回 nil 表示丢弃
+func (c *Conn) interceptReceive(data []byte) []byte {
+	for i := len(c.config.Interceptors) - 1; i >= 0 && data != nil; i-- {
+		if fn := c.config.Interceptors[i].OnReceive; fn != nil {
+			data = fn(data)
+		}
+	}
+	return data
+}
+
+// interceptConnect 通知各拦截器建连成功
+func (c *Conn) interceptConnect() {
+	for i := range c.config.Interceptors {
+		if fn := c.config.Interceptors[i].OnConnect; fn != nil {
+			fn(redactURL(c.config.URL))
+		}
+	}
+}
+
+// interceptClose 通知各拦截器连接结束（每个连接只通知一次）
+func (c *Conn) interceptClose(err error) {
+	c.closeHookOnce.Do(func() {
+		for i := range c.config.Interceptors {
+			if fn := c.config.Interceptors[i].OnClose; fn != nil {
+				fn(err)
+			}
+		}
+	})
+}
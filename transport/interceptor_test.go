@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestInterceptors 验证：OnSend 按顺序、OnReceive 按相反顺序串联，可改写与丢弃消息；OnConnect 与 OnClose 各通知一次。
+// WHY：日志、加密、消息改写原先只能修改 conn.go，拦截器让这些需求在 SDK 之外实现。
+func TestInterceptors(t *testing.T) {
+	var wire [][]byte
+	var wireMu sync.Mutex
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			frame, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			wireMu.Lock()
+			wire = append(wire, data)
+			wireMu.Unlock()
+			ws.WriteMessage(frame, data) // 原样回显
+		}
+	}))
+	defer srv.Close()
+
+	xor := func(data []byte) []byte {
+		out := make([]byte, len(data))
+		for i, b := range data {
+			out[i] = b ^ 0x5a
+		}
+		return out
+	}
+	var events []string
+	var mu sync.Mutex
+	record := func(e string) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+	cipher := Interceptor{
+		OnSend:    func(data []byte) ([]byte, error) { return xor(data), nil },
+		OnReceive: xor,
+	}
+	logger := Interceptor{
+		OnConnect: func(string) { record("connect") },
+		OnSend: func(data []byte) ([]byte, error) {
+			record("send " + string(data)) // 在加密之后：看到的是密文
+			return data, nil
+		},
+		OnReceive: func(data []byte) []byte {
+			record("receive " + string(data)) // 在解密之前：看到的是密文
+			if string(data) == string(xor([]byte("drop"))) {
+				return nil
+			}
+			return data
+		},
+		OnClose: func(err error) { record("close") },
+	}
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts"
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, Interceptors: []Interceptor{cipher, logger}})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	conn.SendText("drop")
+	conn.SendText("hello")
+	got, err := conn.Receive(context.Background())
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("received %q, want decrypted hello (drop filtered)", got)
+	}
+	conn.Close()
+	conn.Close()
+
+	wireMu.Lock()
+	if len(wire) != 2 || !bytes.Equal(wire[1], xor([]byte("hello"))) {
+		t.Errorf("wire = %q, want encrypted frames", wire)
+	}
+	wireMu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	// 发送与接收在不同 goroutine 上，只比较各自的顺序
+	var sends, receives []string
+	for _, e := range events {
+		switch {
+		case strings.HasPrefix(e, "send "):
+			sends = append(sends, e)
+		case strings.HasPrefix(e, "receive "):
+			receives = append(receives, e)
+		}
+	}
+	wantSends := []string{"send " + string(xor([]byte("drop"))), "send " + string(xor([]byte("hello")))}
+	wantReceives := []string{"receive " + string(xor([]byte("drop"))), "receive " + string(xor([]byte("hello")))}
+	if strings.Join(sends, "|") != strings.Join(wantSends, "|") || strings.Join(receives, "|") != strings.Join(wantReceives, "|") {
+		t.Errorf("events = %q", events)
+	}
+	if len(events) != 6 || events[0] != "connect" || events[5] != "close" {
+		t.Errorf("events = %q, want connect first and a single close last", events)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/version"
 )
 
 // Message 消息接口
@@ -18,16 +19,20 @@ type RawMessage struct {
 	Type protocol.MessageType `json:"type"`
 }
 
-// ParseMessageType 解析消息类型
+// ParseMessageType 解析消息类型（JSON 或 MessagePack，按首字节识别）
 func ParseMessageType(data []byte) (protocol.MessageType, error) {
+	// JSON 快速路径只扫描到 type，不反序列化（audio.delta 的音频字段占消息绝大部分）
+	if msgType, err := peekMessageType(data); err == nil {
+		return msgType, nil
+	}
 	var raw RawMessage
-	if err := json.Unmarshal(data, &raw); err != nil {
+	if err := DecodeMessage(data, &raw); err != nil {
 		return "", fmt.Errorf("parse message type: %w", err)
 	}
 	return raw.Type, nil
 }
 
-// ParseMessage 解析消息为具体类型
+// ParseMessage 解析消息为具体类型（JSON 或 MessagePack）
 func ParseMessage(data []byte) (interface{}, error) {
 	msgType, err := ParseMessageType(data)
 	if err != nil {
@@ -39,6 +44,8 @@ func ParseMessage(data []byte) (interface{}, error) {
 	// 服务端消息
 	case protocol.MessageTypeSessionReady:
 		msg = &protocol.SessionReady{}
+	case protocol.MessageTypeSessionConfigDone:
+		msg = &protocol.SessionConfigDone{}
 	case protocol.MessageTypeTranscriptPartial:
 		msg = &protocol.TranscriptPartial{}
 	case protocol.MessageTypeTranscriptFinal:
@@ -51,18 +58,26 @@ func ParseMessage(data []byte) (interface{}, error) {
 		msg = &protocol.SessionEnded{}
 	case protocol.MessageTypeSpeechStarted:
 		msg = &protocol.SpeechStarted{}
+	case protocol.MessageTypeEndOfUtterance:
+		msg = &protocol.EndOfUtterance{}
 	case protocol.MessageTypeError:
 		msg = &protocol.ErrorMessage{}
 
 	// 客户端消息（通常不需要解析，但保留以便调试）
 	case protocol.MessageTypeSessionConfig:
 		msg = &protocol.SessionConfig{}
+	case protocol.MessageTypeSessionUpdate:
+		msg = &protocol.SessionUpdate{}
+	case protocol.MessageTypeSessionResume:
+		msg = &protocol.SessionResume{}
 	case protocol.MessageTypeAudioAppend:
 		msg = &protocol.AudioAppend{}
 	case protocol.MessageTypeTextAppend:
 		msg = &protocol.TextAppend{}
 	case protocol.MessageTypeInputCommit:
 		msg = &protocol.InputCommit{}
+	case protocol.MessageTypeInputCancel:
+		msg = &protocol.InputCancel{}
 	case protocol.MessageTypeSessionEnd:
 		msg = &protocol.SessionEnd{}
 
@@ -70,7 +85,7 @@ func ParseMessage(data []byte) (interface{}, error) {
 		return nil, fmt.Errorf("unknown message type: %s", msgType)
 	}
 
-	if err := json.Unmarshal(data, msg); err != nil {
+	if err := DecodeMessage(data, msg); err != nil {
 		return nil, fmt.Errorf("parse message body: %w", err)
 	}
 
@@ -82,14 +97,63 @@ func EncodeMessage(msg interface{}) ([]byte, error) {
 	return json.Marshal(msg)
 }
 
-// NewSessionConfig 创建会话配置消息
-func NewSessionConfig(params protocol.SessionParams) *protocol.SessionConfig {
+// NewSessionConfig 创建会话配置消息（附带 SDK 版本信息 client_info，features 为客户端声明的功能）
+func NewSessionConfig(params protocol.SessionParams, features ...string) *protocol.SessionConfig {
 	return &protocol.SessionConfig{
-		Type:    protocol.MessageTypeSessionConfig,
+		Type:       protocol.MessageTypeSessionConfig,
+		Session:    params,
+		ClientInfo: clientInfo(features),
+	}
+}
+
+// clientInfo 返回当前 SDK 的 client_info
+func clientInfo(features []string) *protocol.ClientInfo {
+	info := version.Get()
+	ci := &protocol.ClientInfo{
+		SDK:       info.SDK,
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildDate: info.BuildDate,
+		GoVersion: info.GoVersion,
+		Platform:  info.Platform,
+	}
+	if len(features) > 0 {
+		ci.Features = make(protocol.Features, len(features))
+		for _, name := range features {
+			ci.Features[name] = true
+		}
+	}
+	return ci
+}
+
+// NegotiateFeatures 协商功能标志：仅保留客户端声明且服务端确认启用的功能
+// （服务端不能开启客户端未声明的功能；旧版 gateway 不确认时结果为空）
+func NegotiateFeatures(advertised []string, confirmed protocol.Features) protocol.Features {
+	features := protocol.Features{}
+	for _, name := range advertised {
+		if confirmed.Enabled(name) {
+			features[name] = true
+		}
+	}
+	return features
+}
+
+// NewSessionUpdate 创建会话参数更新消息（TTS 专用）
+func NewSessionUpdate(params protocol.SessionParams) *protocol.SessionUpdate {
+	return &protocol.SessionUpdate{
+		Type:    protocol.MessageTypeSessionUpdate,
 		Session: params,
 	}
 }
 
+// NewSessionResume 创建会话恢复消息（token 为之前 session.ready 返回的 resume_token）
+func NewSessionResume(token string) *protocol.SessionResume {
+	return &protocol.SessionResume{
+		Type:        protocol.MessageTypeSessionResume,
+		ResumeToken: token,
+	}
+}
+
 // NewAudioAppend 创建音频数据消息
 func NewAudioAppend(audioBase64 string) *protocol.AudioAppend {
 	return &protocol.AudioAppend{
@@ -106,13 +170,20 @@ func NewTextAppend(text string) *protocol.TextAppend {
 	}
 }
 
-// NewInputCommit 创建输入提交消息（TTS 专用）
+// NewInputCommit 创建输入提交消息（TTS 触发合成；STT 结束当前语句）
 func NewInputCommit() *protocol.InputCommit {
 	return &protocol.InputCommit{
 		Type: protocol.MessageTypeInputCommit,
 	}
 }
 
+// NewInputCancel 创建取消轮次消息（TTS 专用）
+func NewInputCancel() *protocol.InputCancel {
+	return &protocol.InputCancel{
+		Type: protocol.MessageTypeInputCancel,
+	}
+}
+
 // NewSessionEnd 创建会话结束消息（TTS: 关闭会话；STT: 音频发完，请完成识别）
 func NewSessionEnd() *protocol.SessionEnd {
 	return &protocol.SessionEnd{
@@ -124,12 +195,14 @@ func NewSessionEnd() *protocol.SessionEnd {
 func IsServerMessage(msgType protocol.MessageType) bool {
 	switch msgType {
 	case protocol.MessageTypeSessionReady,
+		protocol.MessageTypeSessionConfigDone,
 		protocol.MessageTypeTranscriptPartial,
 		protocol.MessageTypeTranscriptFinal,
 		protocol.MessageTypeAudioDelta,
 		protocol.MessageTypeAudioDone,
 		protocol.MessageTypeSessionEnded,
 		protocol.MessageTypeSpeechStarted,
+		protocol.MessageTypeEndOfUtterance,
 		protocol.MessageTypeError:
 		return true
 	default:
@@ -141,9 +214,12 @@ func IsServerMessage(msgType protocol.MessageType) bool {
 func IsClientMessage(msgType protocol.MessageType) bool {
 	switch msgType {
 	case protocol.MessageTypeSessionConfig,
+		protocol.MessageTypeSessionUpdate,
+		protocol.MessageTypeSessionResume,
 		protocol.MessageTypeAudioAppend,
 		protocol.MessageTypeTextAppend,
 		protocol.MessageTypeInputCommit,
+		protocol.MessageTypeInputCancel,
 		protocol.MessageTypeSessionEnd:
 		return true
 	default:
@@ -0,0 +1,71 @@
+// Package transport 连接指标回调
+package transport
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// MetricsHook 连接指标回调（见 Config.Metrics）：上报原始计数，由实现方汇总到任意监控系统
+//
+// 回调在收发路径上同步调用（可能持有连接锁），须快速返回且不能回调 Conn 的方法；多个连接共享同一实现时须并发安全。
+// 只关心部分指标时嵌入 NopMetrics。
+type MetricsHook interface {
+	// MessageSent 发送一条消息：msgType 为消息的 type 字段（SendBytes 为 "binary"），bytes 为帧载荷字节数
+	MessageSent(msgType string, bytes int)
+	// MessageReceived 收到一条消息（无法解析 type 时 msgType 为 "unknown"）
+	MessageReceived(msgType string, bytes int)
+	// PingRTT 客户端 Ping（Conn.Ping）到收到对应 Pong 的往返时延
+	PingRTT(rtt time.Duration)
+	// Reconnect 第 attempt 次重连（ConnectWithRetry 的重试与会话断线续传），cause 为触发重连的错误
+	Reconnect(attempt int, cause error)
+	// Error 连接错误：发送失败与读取失败（对端正常关闭不计）
+	Error(err error)
+}
+
+// NopMetrics 不做任何事的 MetricsHook，供嵌入
+type NopMetrics struct{}
+
+func (NopMetrics) MessageSent(string, int)     {}
+func (NopMetrics) MessageReceived(string, int) {}
+func (NopMetrics) PingRTT(time.Duration)       {}
+func (NopMetrics) Reconnect(int, error)        {}
+func (NopMetrics) Error(error)                 {}
+
+// metricType 消息的 type 字段，无法解析时为 fallback
+func metricType(data []byte, fallback string) string {
+	msgType, err := ParseMessageType(data)
+	if err != nil || msgType == "" {
+		return fallback
+	}
+	return string(msgType)
+}
+
+// reportSent 上报一次发送（err 非 nil 时上报错误），返回 err
+func (c *Conn) reportSent(msgType string, bytes int, err error) error {
+	if m := c.config.Metrics; m != nil {
+		if err != nil {
+			m.Error(err)
+		} else {
+			m.MessageSent(msgType, bytes)
+		}
+	}
+	return err
+}
+
+// pingPayload Ping 载荷：发送时刻（纳秒），Pong 原样带回用于计算 RTT
+func pingPayload(now time.Time) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(now.UnixNano()))
+}
+
+// observePong 由 Pong 载荷计算 RTT 并上报（载荷不是 pingPayload 时忽略）
+func (c *Conn) observePong(payload string) {
+	m := c.config.Metrics
+	if m == nil || len(payload) != 8 {
+		return
+	}
+	sent := time.Unix(0, int64(binary.BigEndian.Uint64([]byte(payload))))
+	if rtt := time.Since(sent); rtt >= 0 {
+		m.PingRTT(rtt)
+	}
+}
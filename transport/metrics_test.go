@@ -0,0 +1,103 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// recordingMetrics 记录收到的指标
+type recordingMetrics struct {
+	NopMetrics
+	mu       sync.Mutex
+	sent     map[string]int
+	received map[string]int
+	rtts     []time.Duration
+	errs     []error
+}
+
+func (m *recordingMetrics) MessageSent(msgType string, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent[msgType] += bytes
+}
+
+func (m *recordingMetrics) MessageReceived(msgType string, bytes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received[msgType] += bytes
+}
+
+func (m *recordingMetrics) PingRTT(rtt time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rtts = append(m.rtts, rtt)
+}
+
+func (m *recordingMetrics) Error(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs = append(m.errs, err)
+}
+
+// TestMetricsHook 验证：Config.Metrics 按消息 type 上报收发字节数，Ping 的 Pong 上报 RTT，连接异常断开上报错误。
+// WHY：监控需要每连接的原始计数，原先只能从日志里拼凑。
+func TestMetricsHook(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		if _, _, err := ws.ReadMessage(); err != nil {
+			return
+		}
+		ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"session.ready","session_id":"s1"}`))
+		ws.ReadMessage() // 处理 Ping 并等待第二条消息
+		ws.Close()       // 不发 Close 帧：异常断开
+	}))
+	defer srv.Close()
+
+	metrics := &recordingMetrics{sent: map[string]int{}, received: map[string]int{}}
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts"
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, ReadTimeout: -1, Metrics: metrics})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SendJSON(map[string]string{"type": "session.end"}); err != nil {
+		t.Fatalf("SendJSON: %v", err)
+	}
+	if _, err := conn.Receive(context.Background()); err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	conn.SendBytes([]byte{1, 2, 3})
+	if _, err := conn.Receive(context.Background()); err == nil {
+		t.Fatal("Receive after abnormal close succeeded")
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.sent["session.end"] != len(`{"type":"session.end"}`) || metrics.sent["binary"] != 3 {
+		t.Errorf("sent = %v", metrics.sent)
+	}
+	if metrics.received["session.ready"] == 0 {
+		t.Errorf("received = %v", metrics.received)
+	}
+	if len(metrics.rtts) != 1 {
+		t.Errorf("ping RTTs = %v, want 1", metrics.rtts)
+	}
+	if len(metrics.errs) == 0 {
+		t.Error("abnormal close not reported as error")
+	}
+}
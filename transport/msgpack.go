@@ -0,0 +1,586 @@
+// Package transport MessagePack 编解码（协议消息子集，无第三方依赖）
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 支持协议消息用到的类型：bool、整数、浮点、string、[]byte（bin）、切片/数组、string 键 map、
+// struct（按 json 标签命名，支持 omitempty 与 "-"）、指针和 interface{}。
+
+// errMsgpackShort 数据提前结束
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+// marshalMsgpack 编码 v
+func marshalMsgpack(v any) ([]byte, error) {
+	var e msgpackEncoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// msgpackEncoder 编码缓冲
+type msgpackEncoder struct {
+	buf []byte
+}
+
+// encode 编码一个值
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.uint(v.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, 0xca)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.str(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.bin(v.Bytes())
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		e.header(v.Len(), 0x90, 0xdc, 0xdd, 16)
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("msgpack: unsupported map key type %s", v.Type().Key())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		e.header(len(keys), 0x80, 0xde, 0xdf, 16)
+		for _, k := range keys {
+			e.str(k.String())
+			if err := e.encode(v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		present := make([]reflect.Value, 0, len(fields))
+		var names []string
+		for _, f := range fields {
+			fv := v.Field(f.index)
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			present = append(present, fv)
+			names = append(names, f.name)
+		}
+		e.header(len(present), 0x80, 0xde, 0xdf, 16)
+		for i, fv := range present {
+			e.str(names[i])
+			if err := e.encode(fv); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// int 编码有符号整数（取最短格式）
+func (e *msgpackEncoder) int(n int64) {
+	switch {
+	case n >= 0:
+		e.uint(uint64(n))
+	case n >= -32:
+		e.buf = append(e.buf, byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(n))
+	}
+}
+
+// uint 编码无符号整数（取最短格式）
+func (e *msgpackEncoder) uint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), n)
+	}
+}
+
+// str 编码字符串
+func (e *msgpackEncoder) str(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xda), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdb), uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+// bin 编码字节串
+func (e *msgpackEncoder) bin(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xc5), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xc6), uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+// header 编码数组/map 长度：n < fixLimit 用 fix 格式，否则 16/32 位格式
+func (e *msgpackEncoder) header(n int, fix, code16, code32 byte, fixLimit int) {
+	switch {
+	case n < fixLimit:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, code16), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, code32), uint32(n))
+	}
+}
+
+// isEmptyValue omitempty 的判定（与 encoding/json 相同：false、0、空字符串、nil、长度为 0 的切片/map/数组）
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return false
+	default:
+		return v.IsZero()
+	}
+}
+
+// fieldInfo struct 字段的编码信息
+type fieldInfo struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// fieldCache struct 类型 → 字段信息
+var fieldCache sync.Map
+
+// structFields 按 json 标签解析 struct 的导出字段
+func structFields(t reflect.Type) []fieldInfo {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+	var fields []fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, fieldInfo{name: name, index: i, omitEmpty: strings.Contains(opts, "omitempty")})
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// unmarshalMsgpack 解码 data 到 v（v 须为非 nil 指针）
+func unmarshalMsgpack(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("msgpack: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+	d := msgpackDecoder{data: data}
+	value, err := d.value()
+	if err != nil {
+		return err
+	}
+	if d.pos != len(data) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(data)-d.pos)
+	}
+	return assign(rv.Elem(), value)
+}
+
+// msgpackDecoder 把数据解析为 nil / bool / int64 / uint64 / float64 / string / []byte / []any / map[string]any
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+// next 读取 n 字节
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errMsgpackShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// length 读取 size 字节（1/2/4）的大端长度
+func (d *msgpackDecoder) length(size int) (int, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+// value 解析下一个值
+func (d *msgpackDecoder) value() (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.dict(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		raw, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), raw...), nil
+	case 0xca:
+		raw, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), nil
+	case 0xcb:
+		raw, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		raw, err := d.next(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return beUint(raw), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		raw, err := d.next(1 << (c - 0xd0))
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*len(raw) // 符号扩展
+		return int64(beUint(raw)<<shift) >> shift, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.dict(n)
+	}
+	return nil, fmt.Errorf("msgpack: unsupported format 0x%02x at offset %d", c, d.pos-1)
+}
+
+// beUint 大端无符号整数
+func beUint(b []byte) uint64 {
+	var n uint64
+	for _, x := range b {
+		n = n<<8 | uint64(x)
+	}
+	return n
+}
+
+// str 读取 n 字节字符串
+func (d *msgpackDecoder) str(n int) (any, error) {
+	raw, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+// array 读取 n 个元素
+func (d *msgpackDecoder) array(n int) (any, error) {
+	if n > len(d.data)-d.pos { // 每个元素至少 1 字节
+		return nil, errMsgpackShort
+	}
+	out := make([]any, n)
+	for i := range out {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// dict 读取 n 个键值对（键须为字符串）
+func (d *msgpackDecoder) dict(n int) (any, error) {
+	if 2*n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	out := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key %T is not a string", k)
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// assign 把解析出的值写入 v（类型不匹配时返回错误；struct 中未知的键被忽略，与 encoding/json 一致）
+func assign(v reflect.Value, src any) error {
+	if src == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("msgpack: cannot decode %T into %s", src, v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assign(v.Elem(), src)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(src))
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch x := src.(type) {
+		case int64:
+			n = x
+		case uint64:
+			if x > math.MaxInt64 {
+				return mismatch()
+			}
+			n = int64(x)
+		default:
+			return mismatch()
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch x := src.(type) {
+		case uint64:
+			n = x
+		case int64:
+			if x < 0 {
+				return mismatch()
+			}
+			n = uint64(x)
+		default:
+			return mismatch()
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		switch x := src.(type) {
+		case float64:
+			v.SetFloat(x)
+		case int64:
+			v.SetFloat(float64(x))
+		case uint64:
+			v.SetFloat(float64(x))
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		switch x := src.(type) {
+		case string:
+			v.SetString(x)
+		case []byte:
+			v.SetString(string(x))
+		default:
+			return mismatch()
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			switch x := src.(type) {
+			case []byte:
+				v.SetBytes(x)
+			case string:
+				v.SetBytes([]byte(x))
+			default:
+				return mismatch()
+			}
+			return nil
+		}
+		items, ok := src.([]any)
+		if !ok {
+			return mismatch()
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assign(s.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		items, ok := src.([]any)
+		if !ok || len(items) != v.Len() {
+			return mismatch()
+		}
+		for i, item := range items {
+			if err := assign(v.Index(i), item); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := src.(map[string]any)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		out := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := assign(elem, item); err != nil {
+				return err
+			}
+			out.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+		v.Set(out)
+	case reflect.Struct:
+		m, ok := src.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		for _, f := range structFields(v.Type()) {
+			item, ok := m[f.name]
+			if !ok {
+				continue
+			}
+			if err := assign(v.Field(f.index), item); err != nil {
+				return fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+	default:
+		return mismatch()
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Package transport 接收缓冲区溢出策略
+package transport
+
+import "fmt"
+
+// OverflowPolicy 接收缓冲区（ReceiveChan 或共享事件循环的邮箱）满时的处理方式（见 Config.ReceiveOverflow）
+type OverflowPolicy string
+
+const (
+	// OverflowBlock 暂停从连接读取，直到调用方取走消息（默认；背压传导到 gateway）
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest 丢弃缓冲区中最早的一条数据消息，为新消息腾出位置；终止类消息（error、audio.done、
+	// session.ended）不丢弃。用于宁可跳帧也不能卡住读取的场景（如实时播放），丢弃数见 Conn.DroppedMessages
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowError 读取以 ErrBufferFull 失败，连接随之不可用（调用方消费过慢视为故障）
+	OverflowError OverflowPolicy = "error"
+)
+
+// Valid 是否为已知的策略（空为 OverflowBlock）
+func (p OverflowPolicy) Valid() bool {
+	switch p {
+	case "", OverflowBlock, OverflowDropOldest, OverflowError:
+		return true
+	}
+	return false
+}
+
+// overflowErr 缓冲区满且策略为 OverflowError 时的读取错误
+func (c *Conn) overflowErr() error {
+	return fmt.Errorf("%w: receive buffer of %d messages overflowed", ErrBufferFull, c.config.ReceiveBuffer)
+}
+
+// dropOldestLocked 从 readCh 中丢弃最早的一条数据消息（终止类消息保留，顺序不变），没有可丢弃的消息时返回 false
+// （调用方持有 dispatchMu；readCh 的写入方只有 readLoop，放回时不会阻塞）
+func (c *Conn) dropOldestLocked() bool {
+	n := len(c.readCh)
+	queued := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		select {
+		case m := <-c.readCh:
+			queued = append(queued, m)
+		default:
+		}
+	}
+	dropped := false
+	for _, m := range queued {
+		if !dropped && !isControlMessage(m) {
+			dropped = true
+			continue
+		}
+		c.readCh <- m
+	}
+	if dropped {
+		c.dropped.Add(1)
+	}
+	return dropped
+}
+
+// dropOldestMailboxLocked 从邮箱中丢弃最早的一条数据消息（见 dropOldestLocked；调用方持有 dispatchMu）
+func (c *Conn) dropOldestMailboxLocked() bool {
+	for i, ev := range c.mailbox {
+		if ev.data != nil && !isControlMessage(ev.data) {
+			c.mailbox = append(c.mailbox[:i], c.mailbox[i+1:]...)
+			c.dropped.Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+// DroppedMessages 按 OverflowDropOldest 丢弃的消息数
+func (c *Conn) DroppedMessages() int64 {
+	return c.dropped.Load()
+}
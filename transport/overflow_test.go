@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestReceiveOverflow 验证：接收缓冲区满时 OverflowDropOldest 丢弃最早的数据消息并计数、保留终止类消息且顺序不变；
+// OverflowError 以 ErrBufferFull 结束读取。
+// WHY：固定 100 条的 readCh 在突发 audio.delta 扇入时让读取停顿，实时场景宁可跳帧，另一些场景需要尽早失败。
+func TestReceiveOverflow(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for i := 0; i < 10; i++ {
+			ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"type":"audio.delta","seq":%d}`, i)))
+		}
+		ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"audio.done"}`))
+		ws.ReadMessage()
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/tts"
+
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, ReceiveBuffer: 4, ReceiveOverflow: OverflowDropOldest})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for conn.DroppedMessages() < 7 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := conn.DroppedMessages(); n != 7 {
+		t.Fatalf("DroppedMessages = %d, want 7", n)
+	}
+	var got []string
+	for len(got) < 4 {
+		data, err := conn.Receive(context.Background())
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		got = append(got, string(data))
+	}
+	want := []string{`{"type":"audio.delta","seq":7}`, `{"type":"audio.delta","seq":8}`, `{"type":"audio.delta","seq":9}`, `{"type":"audio.done"}`}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("received %v, want %v", got, want)
+	}
+
+	failing := NewConn(&Config{URL: url, ConnectTimeout: time.Second, ReceiveBuffer: 2, ReceiveOverflow: OverflowError})
+	if err := failing.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer failing.Close()
+	select {
+	case err := <-failing.ErrorChan():
+		if !errors.Is(err, ErrBufferFull) {
+			t.Fatalf("overflow error = %v, want ErrBufferFull", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("overflow not reported")
+	}
+}
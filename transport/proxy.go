@@ -0,0 +1,35 @@
+// Package transport 代理
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ParseProxyURL 解析代理地址：支持 http://[user:pass@]host:port 与 socks5://[user:pass@]host:port
+// （HTTP 代理通过 CONNECT 建立隧道，用户名密码作为 Proxy-Authorization 发送）
+func ParseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (want http or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", raw)
+	}
+	return u, nil
+}
+
+// ProxyURL 返回固定使用 raw 代理的 Config.Proxy（raw 的格式见 ParseProxyURL）
+func ProxyURL(raw string) (func(*http.Request) (*url.URL, error), error) {
+	u, err := ParseProxyURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(u), nil
+}
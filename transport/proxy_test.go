@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestProxyConnect 验证：设置 Proxy 后经 HTTP 代理的 CONNECT 隧道建连，代理的用户名密码作为 Proxy-Authorization 发送；
+// ParseProxyURL 拒绝 http / socks5 以外的协议。
+// WHY：企业内网只能经代理出网，原先拨号器不读代理配置，这类部署完全无法连接。
+func TestProxyConnect(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.ReadMessage()
+	}))
+	defer gateway.Close()
+
+	var tunnels atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect || r.Header.Get("Proxy-Authorization") == "" {
+			http.Error(w, "proxy auth required", http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		client, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		tunnels.Add(1)
+		client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			io.Copy(upstream, client)
+			upstream.Close()
+		}()
+		io.Copy(client, upstream)
+		client.Close()
+	}))
+	defer proxy.Close()
+
+	via, err := ProxyURL("http://user:pass@" + strings.TrimPrefix(proxy.URL, "http://"))
+	if err != nil {
+		t.Fatalf("ProxyURL: %v", err)
+	}
+	url := "ws" + strings.TrimPrefix(gateway.URL, "http") + "/ws/tts"
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, Proxy: via})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect via proxy: %v", err)
+	}
+	conn.Close()
+	if n := tunnels.Load(); n != 1 {
+		t.Fatalf("proxy opened %d tunnels, want 1", n)
+	}
+
+	for _, raw := range []string{"https://proxy:8443", "ftp://proxy", "socks5://"} {
+		if _, err := ParseProxyURL(raw); err == nil {
+			t.Errorf("ParseProxyURL(%q) accepted", raw)
+		}
+	}
+}
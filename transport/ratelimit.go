@@ -0,0 +1,87 @@
+// Package transport 音频上行限速
+package transport
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// rateBurst 令牌桶容量对应的时长：空闲之后最多突发这么多的音频
+const rateBurst = 250 * time.Millisecond
+
+// rateLimiter 按音频字节计的令牌桶：一次消耗超过余额时记为欠额，等欠额按速率还清后再放行
+type rateLimiter struct {
+	clock clock.Clock
+	rate  float64 // 字节/秒
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSec int, c clock.Clock) *rateLimiter {
+	c = clock.Or(c)
+	l := &rateLimiter{clock: c, rate: float64(bytesPerSec), last: c.Now()}
+	l.tokens = l.burst()
+	return l
+}
+
+// burst 令牌桶容量（字节）
+func (l *rateLimiter) burst() float64 {
+	return l.rate * rateBurst.Seconds()
+}
+
+// reserve 扣除 n 字节的配额，返回放行前需要等待的时长
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	l.tokens = min(l.burst(), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait 等待发送 n 字节音频的配额；ctx 取消或 done 关闭（连接关闭）时提前返回错误，已扣除的配额不退还
+func (l *rateLimiter) wait(ctx context.Context, done <-chan struct{}, n int) error {
+	d := l.reserve(n)
+	if d <= 0 {
+		return nil
+	}
+	timer := l.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("send rate limit: %w", ctx.Err())
+	case <-done:
+		return ErrConnectionClosed
+	}
+}
+
+// audioBytes 消息中计入限速的音频字节数（只有 audio.append 计入）
+func audioBytes(v interface{}) int {
+	if audio, ok := v.(*protocol.AudioAppend); ok {
+		return base64.StdEncoding.DecodedLen(len(audio.Audio))
+	}
+	return 0
+}
+
+// SetSendRate 修改音频上行限速（字节/秒，0 不限速，见 Config.SendRate），对之后发送的音频生效
+func (c *Conn) SetSendRate(bytesPerSec int) {
+	if bytesPerSec <= 0 {
+		c.limiter.Store(nil)
+		return
+	}
+	c.limiter.Store(newRateLimiter(bytesPerSec, c.config.Clock))
+}
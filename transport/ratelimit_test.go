@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+)
+
+// TestSendRate 验证：令牌桶允许突发 250ms 的音频，超出部分按 SendRate 等待（欠额累计）；设置 SendRate 的连接上
+// audio.append 被限速而其它消息不受影响，等待中 ctx 取消时 Send 返回错误。
+// WHY：批量识别文件时客户端以几十倍实时的速度上传，触发 gateway / 提供商的速率限制，整批任务因 429 失败。
+func TestSendRate(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	l := newRateLimiter(1000, fake)
+	if d := l.reserve(250); d != 0 {
+		t.Fatalf("burst of 250 bytes waited %v, want 0", d)
+	}
+	if d := l.reserve(500); d != 500*time.Millisecond {
+		t.Fatalf("500 bytes over budget waited %v, want 500ms", d)
+	}
+	fake.Advance(time.Second)
+	if d := l.reserve(250); d != 0 {
+		t.Fatalf("after paying off the debt 250 bytes waited %v, want 0 (bucket refilled to its 250ms burst)", d)
+	}
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conn := NewConn(&Config{URL: "ws" + strings.TrimPrefix(srv.URL, "http"), ConnectTimeout: time.Second, SendRate: 3200})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+	chunk := NewAudioAppend(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 800))) // 250ms
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := conn.Send(chunk); err != nil {
+			t.Fatalf("Send #%d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("500ms of audio at 3200 B/s sent in %v, want the second chunk held ~250ms", elapsed)
+	}
+	start = time.Now()
+	if err := conn.Send(NewInputCommit()); err != nil || time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("input.commit: err %v after %v, want immediate", err, time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := conn.SendJSONContext(ctx, chunk); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SendJSONContext while throttled = %v, want context.DeadlineExceeded", err)
+	}
+}
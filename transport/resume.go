@@ -0,0 +1,49 @@
+// Package transport 会话恢复握手
+package transport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// ResumeSession 在刚收到 session.ready 的 conn 上发送 session.resume 并等待回复
+//
+// gateway 接回会话时返回 resumed 的 session.config_done 原始消息（供调用方协商功能与编码）；
+// gateway 回复错误（RESUME_FAILED，或旧版 gateway 不认识 session.resume）时返回 nil, nil，
+// 调用方在同一连接上改发 session.config 新建会话。
+func ResumeSession(ctx context.Context, conn *Conn, token string) ([]byte, error) {
+	if err := conn.Send(NewSessionResume(token)); err != nil {
+		return nil, fmt.Errorf("send session.resume: %w", err)
+	}
+	for {
+		data, err := conn.Receive(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("wait session.resume reply: %w", err)
+		}
+		msgType, err := ParseMessageType(data)
+		if err != nil {
+			continue
+		}
+		switch msgType {
+		case protocol.MessageTypeSessionConfigDone:
+			msg, err := ParseMessage(data)
+			if err != nil {
+				return nil, err
+			}
+			if !msg.(*protocol.SessionConfigDone).Resumed {
+				return nil, fmt.Errorf("session.resume answered without resumed flag")
+			}
+			return data, nil
+		case protocol.MessageTypeError:
+			var code string
+			if msg, err := ParseMessage(data); err == nil {
+				code = msg.(*protocol.ErrorMessage).Code
+			}
+			slog.Info("Session resume rejected, starting new session", "component", "transport", "code", code)
+			return nil, nil
+		}
+	}
+}
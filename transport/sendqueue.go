@@ -0,0 +1,282 @@
+// Package transport 异步发送队列
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// SendQueuePolicy 发送队列满时的处理方式（见 Config.SendQueueBytes）
+type SendQueuePolicy string
+
+const (
+	// SendQueueBlock 阻塞发送方直到队列腾出空间或 ctx 取消（默认，背压传导到音频源）
+	SendQueueBlock SendQueuePolicy = "block"
+	// SendQueueReject 立即返回 ErrBufferFull，由调用方决定丢弃或重试
+	SendQueueReject SendQueuePolicy = "reject"
+)
+
+// Valid 是否为已知的策略（空为 SendQueueBlock）
+func (p SendQueuePolicy) Valid() bool {
+	switch p {
+	case "", SendQueueBlock, SendQueueReject:
+		return true
+	}
+	return false
+}
+
+// coalesceLimit 合并后单个 audio.append 的最大音频字节数（16kHz 16-bit 单声道约 1 秒）
+const coalesceLimit = 32 * 1024
+
+// sendDrainTimeout Close 时等待队列中的消息写出的上限
+const sendDrainTimeout = time.Second
+
+// queuedMessage 排队中的一条消息：audio.append 保留原始音频以便与后续帧合并，发送时才编码
+type queuedMessage struct {
+	frame   int
+	data    []byte // 已编码的消息（audio 为 nil 时）
+	audio   []byte // 待编码的 audio.append 音频
+	codec   Codec  // audio 的编码（合并只发生在编码相同的相邻帧之间）
+	msgType string
+}
+
+// size 计入队列上限的字节数
+func (m *queuedMessage) size() int {
+	if m.audio != nil {
+		return len(m.audio)
+	}
+	return len(m.data)
+}
+
+// encode 写出前的最终编码
+func (m *queuedMessage) encode() ([]byte, int, error) {
+	if m.audio == nil {
+		return m.data, m.frame, nil
+	}
+	data, err := m.codec.Marshal(NewAudioAppend(base64.StdEncoding.EncodeToString(m.audio)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("encode %s: %w", m.codec.Name(), err)
+	}
+	frame := websocket.TextMessage
+	if m.codec.Binary() {
+		frame = websocket.BinaryMessage
+	}
+	return data, frame, nil
+}
+
+// sendQueue 有界发送队列：调用方入队即返回，专用 goroutine 按顺序写出
+type sendQueue struct {
+	limit  int
+	policy SendQueuePolicy
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	items    []*queuedMessage
+	bytes    int
+	inflight bool  // writer 正在写出一条消息
+	closed   bool  // 不再接受消息（Close 或写入失败）
+	err      error // 写入失败的原因，之后的 Send 返回该错误
+	space    chan struct{}
+}
+
+func newSendQueue(limit int, policy SendQueuePolicy) *sendQueue {
+	q := &sendQueue{limit: limit, policy: policy, space: make(chan struct{})}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push 入队；相邻的 audio.append 合并为一帧。队列满时按 policy 阻塞或返回 ErrBufferFull
+func (q *sendQueue) push(ctx context.Context, m *queuedMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if q.closed {
+			if q.err != nil {
+				return q.err
+			}
+			return ErrConnectionClosed
+		}
+		// 单条消息超过上限时在队列为空后放行，避免永远阻塞
+		if q.bytes+m.size() <= q.limit || len(q.items) == 0 {
+			break
+		}
+		if q.policy == SendQueueReject {
+			return ErrBufferFull
+		}
+		space := q.space
+		q.mu.Unlock()
+		select {
+		case <-space:
+		case <-ctx.Done():
+			q.mu.Lock()
+			return ctx.Err()
+		}
+		q.mu.Lock()
+	}
+
+	if n := len(q.items); n > 0 && m.audio != nil {
+		if last := q.items[n-1]; last.audio != nil && last.codec == m.codec && len(last.audio)+len(m.audio) <= coalesceLimit {
+			last.audio = append(last.audio, m.audio...)
+			q.bytes += len(m.audio)
+			return nil
+		}
+	}
+	q.items = append(q.items, m)
+	q.bytes += m.size()
+	q.cond.Signal()
+	return nil
+}
+
+// pop 取出下一条消息（阻塞到有消息或队列关闭），返回 false 表示 writer 应退出
+func (q *sendQueue) pop() (*queuedMessage, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inflight = false
+	q.cond.Broadcast() // 唤醒等待排空的 drain
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	m := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	q.bytes -= m.size()
+	q.inflight = true
+	q.wakeSendersLocked()
+	return m, true
+}
+
+// wakeSendersLocked 通知等待空间的发送方（调用方持有 mu）
+func (q *sendQueue) wakeSendersLocked() {
+	close(q.space)
+	q.space = make(chan struct{})
+}
+
+// fail 写入失败：丢弃排队的消息，之后的 push 返回 err
+func (q *sendQueue) fail(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err == nil {
+		q.err = err
+	}
+	q.closeLocked()
+}
+
+// closeLocked 停止接受消息并唤醒 writer 与发送方（调用方持有 mu）
+func (q *sendQueue) closeLocked() {
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.items, q.bytes = nil, 0
+	q.wakeSendersLocked()
+	q.cond.Broadcast()
+}
+
+// drain 等待已排队的消息全部写出（最多 timeout），然后关闭队列
+func (q *sendQueue) drain(timeout time.Duration) {
+	timer := time.AfterFunc(timeout, func() {
+		q.mu.Lock()
+		q.closeLocked()
+		q.mu.Unlock()
+	})
+	defer timer.Stop()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closed && (len(q.items) > 0 || q.inflight) {
+		q.cond.Wait()
+	}
+	q.closeLocked()
+}
+
+// depth 排队中的消息数与字节数（不含正在写出的一条）
+func (q *sendQueue) depth() (messages, bytes int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items), q.bytes
+}
+
+// writeLoop 专用 writer：按入队顺序经拦截器写出（音频先等待限速配额），写入失败后关闭队列
+func (c *Conn) writeLoop(q *sendQueue, ws *websocket.Conn) {
+	for {
+		m, ok := q.pop()
+		if !ok {
+			return
+		}
+		if l := c.limiter.Load(); l != nil && m.audio != nil {
+			if err := l.wait(context.Background(), c.closeCh, len(m.audio)); err != nil {
+				q.fail(fmt.Errorf("queued send: %w", err))
+				continue
+			}
+		}
+		data, frame, err := m.encode()
+		if err == nil {
+			data, err = c.interceptSend(data)
+		}
+		if err == nil {
+			if c.config.WriteTimeout > 0 {
+				ws.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+			}
+			err = c.reportSent(m.msgType, len(data), ws.WriteMessage(frame, data))
+		}
+		if err != nil {
+			q.fail(fmt.Errorf("queued send: %w", err))
+			continue
+		}
+		if c.config.IdleTimeout > 0 {
+			ws.SetReadDeadline(c.readDeadline())
+		}
+	}
+}
+
+// SendAudio 发送一帧 audio.append，pcm 为原始音频；ctx 约束等待与写入（见 SendJSONContext）
+//
+// 开启发送队列时原始音频直接入队（写出时才编码，相邻帧合并），免去 Send 先编码 base64、入队时再解码的两次遍历；
+// 未开启时按当前编码写出。
+func (c *Conn) SendAudio(ctx context.Context, pcm []byte) error {
+	c.mu.Lock()
+	q, connected := c.sendQ, c.connected && c.ws != nil
+	c.mu.Unlock()
+	if !connected {
+		return ErrNotConnected
+	}
+	if q == nil {
+		return c.SendContext(ctx, NewAudioAppend(base64.StdEncoding.EncodeToString(pcm)))
+	}
+	// 复制一份：调用方会复用缓冲区，合并时队尾消息还会被追加
+	return q.push(ctx, &queuedMessage{audio: bytes.Clone(pcm), codec: c.Codec(), msgType: string(protocol.MessageTypeAudioAppend)})
+}
+
+// enqueue 把消息交给发送队列（见 sendFrame）；已编码的 audio.append 解码回原始音频以便合并（SendAudio 无需这一步）
+func (c *Conn) enqueue(ctx context.Context, q *sendQueue, frame int, data []byte, v interface{}, codec Codec, fallback string) error {
+	m := &queuedMessage{frame: frame, data: data, codec: codec, msgType: metricType(data, fallback)}
+	if audio, ok := v.(*protocol.AudioAppend); ok {
+		raw, err := base64.StdEncoding.DecodeString(audio.Audio)
+		if err == nil {
+			m.data, m.audio, m.msgType = nil, raw, string(protocol.MessageTypeAudioAppend)
+		}
+	}
+	return q.push(ctx, m)
+}
+
+// SendQueueDepth 发送队列中排队的消息数与字节数（未开启队列时为 0）
+func (c *Conn) SendQueueDepth() (messages, bytes int) {
+	c.mu.Lock()
+	q := c.sendQ
+	c.mu.Unlock()
+	if q == nil {
+		return 0, 0
+	}
+	return q.depth()
+}
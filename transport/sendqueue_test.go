@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestSendQueue 验证：开启发送队列后相邻的 audio.append（SendAudio 或 Send）合并发送且音频内容与顺序不变，其它消息保持入队顺序，
+// Close 前写出已排队的消息；队列满时 SendQueueReject 返回 ErrBufferFull。
+// WHY：每次 Send 都在调用方 goroutine 上持锁写网络，音频采集线程被网络抖动阻塞；小帧逐条发送的开销也大。
+func TestSendQueue(t *testing.T) {
+	var mu sync.Mutex
+	var audio []byte
+	var types []string
+	received := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		defer close(received)
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			msgType, _ := ParseMessageType(data)
+			mu.Lock()
+			types = append(types, string(msgType))
+			if msgType == protocol.MessageTypeAudioAppend {
+				msg, _ := protocol.ParseAudioAppend(data)
+				chunk, _ := base64.StdEncoding.DecodeString(msg.Audio)
+				audio = append(audio, chunk...)
+			}
+			mu.Unlock()
+		}
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/stt"
+	conn := NewConn(&Config{URL: url, ConnectTimeout: time.Second, SendQueueBytes: 1 << 20})
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	var want []byte
+	for i := 0; i < 200; i++ {
+		chunk := bytes.Repeat([]byte{byte(i)}, 320)
+		want = append(want, chunk...)
+		// SendAudio 直接入队原始音频，Send 的已编码消息入队时解码，两者合并结果相同
+		var err error
+		if i%2 == 0 {
+			err = conn.SendAudio(context.Background(), chunk)
+		} else {
+			err = conn.Send(NewAudioAppend(base64.StdEncoding.EncodeToString(chunk)))
+		}
+		if err != nil {
+			t.Fatalf("Send audio %d: %v", i, err)
+		}
+	}
+	if err := conn.Send(NewSessionEnd()); err != nil {
+		t.Fatalf("Send session.end: %v", err)
+	}
+	conn.Close()
+	<-received
+
+	mu.Lock()
+	if !bytes.Equal(audio, want) {
+		t.Errorf("received %d audio bytes, want %d in order", len(audio), len(want))
+	}
+	if n := len(types); n == 0 || n > 201 || types[n-1] != string(protocol.MessageTypeSessionEnd) {
+		t.Errorf("frames = %d, last = %v; want audio followed by session.end", n, types)
+	}
+	mu.Unlock()
+
+	// 没有 writer 取走时，相邻音频帧合并为一条
+	q := newSendQueue(1<<20, SendQueueBlock)
+	for i := 0; i < 3; i++ {
+		q.push(context.Background(), &queuedMessage{audio: make([]byte, 320), codec: JSON})
+	}
+	if messages, n := q.depth(); messages != 1 || n != 960 {
+		t.Errorf("depth = %d messages / %d bytes, want 1 / 960", messages, n)
+	}
+
+	q = newSendQueue(100, SendQueueReject)
+	if err := q.push(context.Background(), &queuedMessage{data: make([]byte, 80)}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if err := q.push(context.Background(), &queuedMessage{data: make([]byte, 80)}); !errors.Is(err, ErrBufferFull) {
+		t.Fatalf("push over limit = %v, want ErrBufferFull", err)
+	}
+}
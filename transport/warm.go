@@ -0,0 +1,192 @@
+// Package transport 预建连接池
+package transport
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+)
+
+// warmPingInterval 池中空闲连接的最长保活间隔（未设置读超时、或读超时较长时；gateway 可能更早回收空闲连接）
+const warmPingInterval = 15 * time.Second
+
+// WarmPool 预先建立的空闲连接（见 tts/stt Client.Warmup）：按 URL 存放，定时 Ping 保活，取用时跳过已断开的连接
+//
+// 零值可直接使用。池中连接已收到（尚未读取）gateway 的 session.ready，取出后按新建连接的方式完成握手即可。
+type WarmPool struct {
+	mu     sync.Mutex
+	idle   map[string][]*warmConn
+	closed bool
+}
+
+// warmConn 池中的一个连接，stop 关闭后保活 goroutine 退出（被取走或池关闭）
+type warmConn struct {
+	conn *Conn
+	stop chan struct{}
+}
+
+// Warm 按 config 并发建立 n 个连接放入池中并开始保活，返回成功放入的数量；一个都没有建立时返回第一个错误
+func (p *WarmPool) Warm(ctx context.Context, config *Config, n int) (int, error) {
+	type result struct {
+		conn *Conn
+		err  error
+	}
+	results := make(chan result, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			conn := NewConn(config)
+			if err := conn.ConnectWithRetry(ctx); err != nil {
+				results <- result{err: err}
+				return
+			}
+			results <- result{conn: conn}
+		}()
+	}
+
+	var added int
+	var firstErr error
+	for i := 0; i < n; i++ {
+		r := <-results
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		if !p.put(r.conn) {
+			r.conn.Close()
+			if firstErr == nil {
+				firstErr = ErrConnectionClosed
+			}
+			continue
+		}
+		added++
+	}
+	if firstErr != nil {
+		slog.Warn("Warmup incomplete", "component", "transport", "url", redactURL(config.URL), "requested", n, "ready", added, "error", firstErr)
+	}
+	if added == 0 && firstErr != nil {
+		return 0, firstErr
+	}
+	return added, nil
+}
+
+// put 放入连接并启动保活；池已关闭时返回 false
+func (p *WarmPool) put(conn *Conn) bool {
+	w := &warmConn{conn: conn, stop: make(chan struct{})}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	if p.idle == nil {
+		p.idle = make(map[string][]*warmConn)
+	}
+	url := conn.URL()
+	p.idle[url] = append(p.idle[url], w)
+	go p.keepalive(w)
+	return true
+}
+
+// keepalive 定时 Ping 空闲连接（Pong 刷新读超时），连接断开后从池中移除
+//
+// 不读取 ErrorChan：读取失败的连接在下一次 Ping 时以 ErrNotConnected 发现，错误留给取走连接的会话
+func (p *WarmPool) keepalive(w *warmConn) {
+	config := w.conn.config
+	interval := warmPingInterval
+	if base := config.IdleTimeout; base > 0 {
+		interval = min(interval, base/3)
+	} else if base := config.ReadTimeout; base > 0 {
+		interval = min(interval, base/3)
+	}
+	timer := clock.Or(config.Clock).NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.conn.CloseChan():
+			p.remove(w)
+			return
+		case <-timer.C():
+			if err := w.conn.Ping(); err != nil {
+				p.remove(w)
+				return
+			}
+			timer.Reset(interval)
+		}
+	}
+}
+
+// remove 移除并关闭已失效的连接（已被取走时不处理）
+func (p *WarmPool) remove(w *warmConn) {
+	p.mu.Lock()
+	url := w.conn.URL()
+	conns := p.idle[url]
+	found := false
+	for i, c := range conns {
+		if c == w {
+			p.idle[url] = append(conns[:i:i], conns[i+1:]...)
+			found = true
+			break
+		}
+	}
+	p.mu.Unlock()
+	if found {
+		slog.Info("Idle warm connection lost", "component", "transport", "url", redactURL(url))
+		w.conn.Close()
+	}
+}
+
+// Take 取出一个发往 url 的空闲连接（最早建立的优先，已断开的丢弃），没有时返回 nil
+//
+// 取出的连接标记为 Warm，ConnectDuration 为 0：建连发生在预热时，不计入会话的建连耗时
+func (p *WarmPool) Take(url string) *Conn {
+	for {
+		p.mu.Lock()
+		conns := p.idle[url]
+		if len(conns) == 0 {
+			p.mu.Unlock()
+			return nil
+		}
+		w := conns[0]
+		p.idle[url] = conns[1:]
+		p.mu.Unlock()
+
+		close(w.stop)
+		if w.conn.IsConnected() {
+			w.conn.warm.Store(true)
+			return w.conn
+		}
+		w.conn.Close()
+	}
+}
+
+// Idle 池中空闲连接数
+func (p *WarmPool) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, conns := range p.idle {
+		n += len(conns)
+	}
+	return n
+}
+
+// Close 关闭池中全部空闲连接，之后 Warm 不再放入连接
+func (p *WarmPool) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mu.Unlock()
+	for _, conns := range idle {
+		for _, w := range conns {
+			close(w.stop)
+			w.conn.Close()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestWarmPoolTake 验证：Take 取出的连接标记为 Warm 且 ConnectDuration 为 0；空闲期间被 gateway 断开的连接
+// 取用时被丢弃，Take 返回 nil。
+// WHY：把已断开的连接交给会话会让首个请求直接失败；预热连接的握手耗时计入会话又会让延迟统计与实际不符。
+func TestWarmPoolTake(t *testing.T) {
+	drop := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		select {
+		case <-drop:
+			ws.NetConn().Close() // 不发 Close 帧，模拟 gateway 回收空闲连接
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	config := &Config{URL: url, ConnectTimeout: time.Second}
+	var pool WarmPool
+	defer pool.Close()
+
+	if n, err := pool.Warm(context.Background(), config, 1); n != 1 || err != nil {
+		t.Fatalf("Warm = %d, %v", n, err)
+	}
+	conn := pool.Take(url)
+	if conn == nil {
+		t.Fatal("Take returned nil for a live connection")
+	}
+	defer conn.Close()
+	if !conn.Warm() || conn.ConnectDuration() != 0 {
+		t.Fatalf("pooled conn: Warm %v, ConnectDuration %v, want true / 0", conn.Warm(), conn.ConnectDuration())
+	}
+
+	if n, err := pool.Warm(context.Background(), config, 1); n != 1 || err != nil {
+		t.Fatalf("Warm = %d, %v", n, err)
+	}
+	close(drop)
+	time.Sleep(100 * time.Millisecond) // 等读取失败
+	if got := pool.Take(url); got != nil {
+		t.Fatal("Take returned a connection the gateway closed while idle")
+	}
+	if n := pool.Idle(); n != 0 {
+		t.Fatalf("Idle = %d after taking the dead connection, want 0", n)
+	}
+}
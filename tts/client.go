@@ -10,12 +10,15 @@ import (
 	"os"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/preset"
 	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
 )
 
 // Client TTS客户端
 type Client struct {
 	config *Config
+	warm   *transport.WarmPool // Warmup 预建的空闲连接
 }
 
 // NewClient 创建TTS客户端
@@ -24,11 +27,30 @@ func NewClient(config *Config) (*Client, error) {
 		config = DefaultConfig()
 	}
 
+	config.Normalize()
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
+	for _, w := range config.Warnings() {
+		slog.Warn("Suspicious config", "component", "tts", "warning", w)
+	}
 
-	return &Client{config: config}, nil
+	return &Client{config: config, warm: &transport.WarmPool{}}, nil
+}
+
+// NewClientFromPreset 按预设名称创建TTS客户端（预设见 preset 包，从 preset.Default 查找）
+// config 提供 GatewayURL、APIKey 等基础配置（nil 使用 DefaultConfig），预设中的非零字段覆盖其合成参数；
+// 传入的 config 不会被修改
+func NewClientFromPreset(config *Config, name string) (*Client, error) {
+	p, err := preset.Get(name, preset.KindTTS)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = DefaultConfig()
+	}
+	c := *config
+	return NewClient(c.ApplyPreset(p))
 }
 
 // SynthesizeToFile 合成到文件（简化API）
@@ -36,10 +58,11 @@ func (c *Client) SynthesizeToFile(ctx context.Context, text, outputPath string)
 	start := time.Now()
 
 	// 创建流式会话
-	stream, err := c.SynthesizeStream(ctx, text)
+	stream, stop, err := c.synthesizeWithin(ctx, text)
 	if err != nil {
 		return err
 	}
+	defer stop()
 	defer stream.Close()
 
 	// 创建输出文件
@@ -61,11 +84,13 @@ func (c *Client) SynthesizeToFile(ctx context.Context, text, outputPath string)
 }
 
 // SynthesizeToBytes 合成到内存（简化API）
+// ctx 带 deadline 时以其为整体时限，替代 ConnectTimeout 与 FirstChunkTimeout（SynthesizeToFile 相同）
 func (c *Client) SynthesizeToBytes(ctx context.Context, text string) ([]byte, error) {
-	stream, err := c.SynthesizeStream(ctx, text)
+	stream, stop, err := c.synthesizeWithin(ctx, text)
 	if err != nil {
 		return nil, err
 	}
+	defer stop()
 	defer stream.Close()
 
 	data, err := stream.ReadAll()
@@ -80,6 +105,25 @@ func (c *Client) SynthesizeToBytes(ctx context.Context, text string) ([]byte, er
 	return data, nil
 }
 
+// synthesizeWithin 简化 API 的合成入口：ctx 带 deadline 时整个请求（建连、首包、读取音频）以 deadline 为准
+//
+// 建连超时取 ctx 剩余时间，FirstChunkTimeout 不再生效；deadline 到达时中止本轮，读取返回 ctx.Err()。
+// 否则 ReadAll 只受 ReadTimeout 约束，会越过调用方的 deadline 继续等待。stop 解除对 ctx 的监听
+func (c *Client) synthesizeWithin(ctx context.Context, text string) (stream *AudioStream, stop func() bool, err error) {
+	if _, ok := ctx.Deadline(); !ok {
+		stream, err = c.SynthesizeStream(ctx, text)
+		return stream, func() bool { return false }, err
+	}
+	config := *c.config
+	config.ConnectTimeout = transport.ContextTimeout(ctx, config.ConnectTimeout)
+	config.FirstChunkTimeout = -1
+	if stream, err = (&Client{config: &config, warm: c.warm}).SynthesizeStream(ctx, text); err != nil {
+		return nil, nil, err
+	}
+	stop = context.AfterFunc(ctx, func() { stream.cancel(ctx.Err()) })
+	return stream, stop, nil
+}
+
 // SynthesizeStream 流式合成（高级API）
 // 返回音频流，可逐块接收或作为io.Reader使用
 func (c *Client) SynthesizeStream(ctx context.Context, text string) (*AudioStream, error) {
@@ -131,6 +175,24 @@ func (c *Client) SynthesizeStreamWithOptions(ctx context.Context, text string, o
 	return stream, nil
 }
 
+// SynthesizeScript 多角色剧本合成（对话、播客等），逐句切换音色并拼接为一个音频流
+// 未指定音色的台词使用 Config.VoiceID；stream.Close() 时关闭会话
+func (c *Client) SynthesizeScript(ctx context.Context, lines []Line) (*ScriptStream, error) {
+	session, err := c.CreateSession(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := session.SynthesizeScript(ctx, lines)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	stream.setSessionCloser(session) // 让 stream.Close() 能关闭 session
+	return stream, nil
+}
+
 // CreateSession 创建TTS会话（支持多轮合成）
 // 会话建立后可重复调用 SynthesizeStream() 进行多次合成
 // 适用于对话TTS、长文本分段合成等场景
@@ -150,48 +212,123 @@ func (c *Client) CreateSession(ctx context.Context, opts *SynthesisOptions) (*Se
 	return c.createSession(ctx, opts)
 }
 
-// createSession 内部创建会话
-func (c *Client) createSession(ctx context.Context, opts *SynthesisOptions) (*Session, error) {
+// clock 返回客户端配置的时钟
+func (c *Client) clock() clock.Clock {
+	return clock.Or(c.config.Clock)
+}
+
+// Warmup 预先建立 n 个到 gateway 的空闲连接并保活，之后的会话直接取用，省去首个请求的 TCP / TLS / WebSocket 握手
+//
+// 连接按 Config.Provider 与 Config.VoiceID 建立（与不指定提供商的会话相同的 URL），取用后不补充；
+// 返回成功建立的数量，一个都没有建立时返回错误。Client.Close 关闭未被取用的连接。
+func (c *Client) Warmup(ctx context.Context, n int) (int, error) {
+	if n <= 0 {
+		return 0, ErrInvalidConfig("warmup count must be positive")
+	}
+	return c.warm.Warm(ctx, c.connConfig(c.config.Provider), n)
+}
+
+// connConfig 按提供商构建连接配置（createSession 与 Warmup 共用，URL 相同的连接可互换）
+func (c *Client) connConfig(provider string) *transport.Config {
 	// 构建WebSocket URL
-	wsURL := fmt.Sprintf("%s/ws/tts?provider=%s", c.config.GatewayURL, c.config.Provider)
+	wsURL := fmt.Sprintf("%s/ws/tts?provider=%s", c.config.gatewayURL(provider), provider)
 	// 添加 voice_id 到 URL 以便 Gateway 精准预热
-	if c.config.VoiceID != "" {
-		wsURL += "&voice_id=" + url.QueryEscape(c.config.VoiceID)
+	if voice, err := c.config.VoiceAliases.resolve(provider, c.config.VoiceID); err == nil && voice != "" {
+		wsURL += "&voice_id=" + url.QueryEscape(voice)
 	}
 	if c.config.APIKey != "" {
 		wsURL += "&api_key=" + url.QueryEscape(c.config.APIKey)
 	}
 
-	// 创建连接
-	connConfig := &transport.Config{
+	return &transport.Config{
 		URL:              wsURL,
 		ConnectTimeout:   c.config.ConnectTimeout,
 		ReadTimeout:      c.config.ReadTimeout,
 		WriteTimeout:     c.config.WriteTimeout,
 		ReconnectBackoff: c.config.ReconnectBackoff,
 		MaxReconnects:    c.config.MaxReconnects,
+		IdleTimeout:      c.config.IdleTimeout,
+		MaxConnDuration:  c.config.MaxConnDuration,
+		PongTimeout:      c.config.PongTimeout,
+		Clock:            c.config.Clock,
+		EventLoop:        c.config.EventLoop,
+		Auth:             c.config.Auth,
+		Header:           c.config.Header,
+		BearerAuth:       c.config.BearerAuth,
+		Proxy:            c.config.proxy(),
+		NetDialContext:   c.config.NetDialContext,
+		Metrics:          c.config.Metrics,
+		Interceptors:     c.config.Interceptors,
+		ReceiveBuffer:    c.config.ReceiveBuffer,
+		ReceiveOverflow:  c.config.ReceiveOverflow,
+		SendQueueBytes:   c.config.SendQueueBytes,
+		SendQueuePolicy:  c.config.SendQueuePolicy,
 	}
+}
 
-	conn := transport.NewConn(connConfig)
-	if err := conn.ConnectWithRetry(ctx); err != nil {
-		return nil, fmt.Errorf("connect to gateway: %w", err)
+// createSession 内部创建会话
+func (c *Client) createSession(ctx context.Context, opts *SynthesisOptions) (*Session, error) {
+	provider := c.config.Provider
+	if opts.Provider != "" {
+		provider = opts.Provider
+	}
+	connConfig := c.connConfig(provider)
+
+	// 时延预算：记录建连与握手耗时，失败时附在错误中
+	var setup []transport.PhaseLatency
+	phaseStart := c.clock().Now()
+	endPhase := func(phase transport.LatencyPhase) {
+		now := c.clock().Now()
+		setup = append(setup, transport.PhaseLatency{Phase: phase, Duration: now.Sub(phaseStart)})
+		phaseStart = now
+	}
+	withLatency := func(err error) error {
+		if c.config.LatencyBudget <= 0 {
+			return err
+		}
+		return &transport.LatencyError{Err: err, Breakdown: transport.LatencyBreakdown{Budget: c.config.LatencyBudget, Phases: setup}}
 	}
 
+	// 优先取用 Warmup 预建的连接
+	conn := c.warm.Take(connConfig.URL)
+	if conn == nil {
+		conn = transport.NewConn(connConfig)
+		if err := conn.ConnectWithRetry(ctx); err != nil {
+			endPhase(transport.PhaseConnect)
+			return nil, withLatency(fmt.Errorf("connect to gateway: %w", err))
+		}
+	}
+	endPhase(transport.PhaseConnect)
+
 	// 创建会话
 	session := newSession(conn, c.config, opts)
+	session.Provider = provider
+	// 断线续传时按相同配置建立新连接
+	session.dial = func(ctx context.Context) (*transport.Conn, error) {
+		conn := transport.NewConn(connConfig)
+		if err := conn.Connect(ctx); err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
 
 	// 启动会话
 	if err := session.start(ctx); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("start session: %w", err)
+		endPhase(transport.PhaseConfig)
+		return nil, withLatency(fmt.Errorf("start session: %w", err))
 	}
+	endPhase(transport.PhaseConfig)
+	session.mu.Lock()
+	session.setup = setup
+	session.mu.Unlock()
 
 	return session, nil
 }
 
-// Close 关闭客户端
+// Close 关闭客户端（关闭 Warmup 预建但未被取用的连接，已创建的会话不受影响）
 func (c *Client) Close() error {
-	return nil
+	return c.warm.Close()
 }
 
 // Config 返回当前配置
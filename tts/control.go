@@ -0,0 +1,65 @@
+// Package tts 终止类消息的提前处理
+package tts
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// controlLoop 读取 conn 的控制消息提前通知，直到连接关闭（续传换连接后为新连接另起一个）
+//
+// 消息循环可能阻塞在 AudioStream 背压上（调用方暂停或消费慢），此时 error / audio.done
+// 排在积压的音频之后，失败要等音频处理完才能发现。提前通知由独立 goroutine 处理，不受背压影响。
+func (s *Session) controlLoop(conn *transport.Conn) {
+	for {
+		select {
+		case data := <-conn.ControlChan():
+			s.handleControl(data)
+		case <-conn.CloseChan():
+			return
+		case <-s.closeCh:
+			return
+		}
+	}
+}
+
+// handleControl 提前处理终止类消息
+//
+// 原消息之后仍会按顺序经 handleMessage 处理（出队、刷新重采样、统计用量等），这里只做幂等的动作：
+// error 立即以错误结束对应轮次，积压中该轮的音频随后被丢弃；audio.done 停止首包计时。
+// 只处理带 round_id 且匹配排队轮次的消息：无 round_id 时"队列头部"要等积压处理完才能确定。
+func (s *Session) handleControl(data []byte) {
+	msg, err := transport.ParseMessage(data)
+	if err != nil {
+		return
+	}
+	switch m := msg.(type) {
+	case *protocol.ErrorMessage:
+		if stream := s.findStream(m.RoundID); stream != nil {
+			stream.pushError(fmt.Errorf("[%s] %s", m.Code, m.Message))
+			slog.Info("Round failed (early notice)", "component", "tts", "round_id", m.RoundID, "code", m.Code, "id", s.ID)
+		}
+	case *protocol.AudioDone:
+		if stream := s.findStream(m.RoundID); stream != nil {
+			stream.markDoneQueued()
+		}
+	}
+}
+
+// findStream 查找 round_id 精确匹配的排队中 stream（不回退到队列头部）
+func (s *Session) findStream(roundID string) *AudioStream {
+	if roundID == "" {
+		return nil
+	}
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	for _, stream := range s.streamQueue {
+		if stream.roundID == roundID {
+			return stream
+		}
+	}
+	return nil
+}
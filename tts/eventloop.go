@@ -0,0 +1,103 @@
+// Package tts 共享事件循环模式
+package tts
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// loopState 共享事件循环模式下一次 startMessageLoop 的状态（对应一个 messageLoop goroutine 的生命周期）
+type loopState struct {
+	ctx   context.Context
+	done  chan struct{}
+	once  sync.Once
+	mu    sync.Mutex
+	stops []func() bool
+}
+
+// finish 结束处理（等同 messageLoop 返回）
+func (l *loopState) finish() {
+	l.once.Do(func() {
+		close(l.done)
+		l.mu.Lock()
+		stops := l.stops
+		l.mu.Unlock()
+		for _, stop := range stops {
+			stop()
+		}
+	})
+}
+
+// exited 是否已结束
+func (l *loopState) exited() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleConn 共享事件循环模式下替代 messageLoop：在连接回调中处理消息，退出条件与断线处理相同
+func (s *Session) handleConn(ctx context.Context, done chan struct{}) {
+	l := &loopState{ctx: ctx, done: done}
+	l.mu.Lock()
+	l.stops = []func() bool{context.AfterFunc(ctx, l.finish), context.AfterFunc(s.ctx, l.finish)}
+	l.mu.Unlock()
+	s.attach(s.currentConn(), l)
+}
+
+// attach 在 conn 上注册回调；续传换到新连接后重新注册
+func (s *Session) attach(conn *transport.Conn, l *loopState) {
+	// 同 messageLoop：会话关闭后退出，旧连接（续传前）的事件忽略
+	active := func() bool {
+		if l.exited() {
+			return false
+		}
+		if s.IsClosed() {
+			l.finish()
+			return false
+		}
+		return conn == s.currentConn()
+	}
+	lost := func(cause error) {
+		// 续传要重新建连和握手，可能阻塞数秒，不占用共享 worker；此时旧连接已无后续事件，处理仍是串行的
+		go func() {
+			if s.connLost(l.ctx, cause) {
+				l.finish()
+				return
+			}
+			s.attach(s.currentConn(), l)
+		}()
+	}
+	err := conn.Handle(transport.Handler{
+		OnMessage: func(data []byte) {
+			if active() {
+				s.handleMessage(data)
+			}
+		},
+		OnError: func(err error) {
+			if active() {
+				lost(err)
+			}
+		},
+		OnControl: func(data []byte) {
+			if active() {
+				s.handleControl(data)
+			}
+		},
+		OnClose: func() {
+			// gateway 主动关闭（如空闲超时）：关闭流程中属正常结束
+			if active() {
+				lost(closedByGateway(conn))
+			}
+		},
+	})
+	if err != nil {
+		slog.Error("Attach to event loop failed", "component", "tts", "id", s.ID, "error", err)
+		l.finish()
+	}
+}
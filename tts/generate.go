@@ -0,0 +1,96 @@
+// Package tts 静音与提示音生成
+package tts
+
+import (
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+)
+
+const (
+	// DefaultToneAmplitude GenerateTone 的峰值幅度（约 -12 dBFS，接近合成语音的响度）
+	DefaultToneAmplitude = 0.25
+
+	// generatedChunk 生成音频每块的时长（与合成音频的块大小相当，背压按块生效）
+	generatedChunk = 100 * time.Millisecond
+)
+
+// GenerateSilence 生成 d 时长的静音，格式与会话输出一致（16-bit 单声道 PCM，采样率同合成结果）
+//
+// 返回的 AudioStream 与合成结果用法相同，可直接播放或与合成音频拼接（见 Line.Duration）；
+// 不占用连接，不计入时延预算。会话输出格式不是 PCM 时返回 ErrInvalidConfig。
+func (s *Session) GenerateSilence(d time.Duration) (*AudioStream, error) {
+	return s.generate(d, func(sampleRate int, d time.Duration) []byte {
+		return audio.Silence(sampleRate, d)
+	})
+}
+
+// GenerateTone 生成 d 时长、频率 freq Hz 的提示音（正弦波，首尾淡入淡出），格式同 GenerateSilence
+func (s *Session) GenerateTone(freq float64, d time.Duration) (*AudioStream, error) {
+	if freq <= 0 {
+		return nil, ErrInvalidConfig("tone frequency must be positive")
+	}
+	return s.generate(d, func(sampleRate int, d time.Duration) []byte {
+		return audio.Tone(sampleRate, freq, d, DefaultToneAmplitude)
+	})
+}
+
+// StreamPCM 把预录音频（16-bit 单声道 PCM，采样率 sampleRate，0 表示与会话输出相同）包装为 AudioStream，
+// 采样率与会话输出不同时重采样，用法同 GenerateSilence（见 Line.Audio）
+func (s *Session) StreamPCM(pcm []byte, sampleRate int) (*AudioStream, error) {
+	if len(pcm) < 2 {
+		return nil, ErrInvalidConfig("audio is empty")
+	}
+	stream, err := s.generatedStream()
+	if err != nil {
+		return nil, err
+	}
+	if sampleRate > 0 && sampleRate != stream.sampleRate {
+		r := audio.NewResampler(sampleRate, stream.sampleRate)
+		pcm = append(r.Process(pcm), r.Flush()...)
+	}
+	s.pushGenerated(stream, pcm)
+	return stream, nil
+}
+
+// generate 按会话输出格式生成 d 时长的音频，分块推送到新的 AudioStream
+func (s *Session) generate(d time.Duration, pcm func(sampleRate int, d time.Duration) []byte) (*AudioStream, error) {
+	if d <= 0 {
+		return nil, ErrInvalidConfig("duration must be positive")
+	}
+	stream, err := s.generatedStream()
+	if err != nil {
+		return nil, err
+	}
+	s.pushGenerated(stream, pcm(stream.sampleRate, d))
+	return stream, nil
+}
+
+// generatedStream 创建承载本地音频的 AudioStream；会话输出不是 PCM 时返回错误
+func (s *Session) generatedStream() (*AudioStream, error) {
+	stream := s.newStream()
+	if stream.audioFormat != "" && stream.audioFormat != "pcm" {
+		return nil, ErrInvalidConfig("generated audio requires PCM output, got " + stream.audioFormat)
+	}
+	if stream.sampleRate <= 0 {
+		return nil, ErrInvalidConfig("generated audio requires a sample rate")
+	}
+	return stream, nil
+}
+
+// pushGenerated 分块推送本地音频并结束 stream
+func (s *Session) pushGenerated(stream *AudioStream, data []byte) {
+	chunk := 2 * int(int64(generatedChunk)*int64(stream.sampleRate)/int64(time.Second))
+
+	now := stream.clock.Now()
+	stream.setCommitSentAt(now)
+	stream.markFirstChunkAt(now)
+	go func() {
+		for seq := 0; len(data) > 0; seq++ {
+			n := min(chunk, len(data))
+			stream.pushData(data[:n], seq)
+			data = data[n:]
+		}
+		stream.pushDone()
+	}()
+}
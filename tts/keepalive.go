@@ -0,0 +1,158 @@
+// Package tts 会话保活与空闲重连
+package tts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// errConnClosedByGateway gateway 主动关闭连接（如空闲超时）
+var errConnClosedByGateway = errors.New("connection closed by gateway")
+
+// closedByGateway gateway 主动关闭连接的错误，附带 Close 帧的状态码与原因（errors.As 可取出 *transport.GatewayCloseError）
+func closedByGateway(conn *transport.Conn) error {
+	if gc := conn.GatewayClose(); gc != nil {
+		return fmt.Errorf("%w: %w", errConnClosedByGateway, gc)
+	}
+	return errConnClosedByGateway
+}
+
+// redialSettleTimeout 连接已断但 messageLoop 尚未处理时，等待其完成断开处理的上限
+const redialSettleTimeout = 100 * time.Millisecond
+
+// startMessageLoop 启动消息处理循环（首次建连与空闲重连后调用）
+func (s *Session) startMessageLoop() {
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.loopDone = done
+	s.mu.Unlock()
+	if s.config.EventLoop != nil {
+		s.handleConn(s.loopCtx, done)
+		return
+	}
+	go s.messageLoop(s.loopCtx, done)
+	go s.controlLoop(s.currentConn())
+}
+
+// connLost 处理连接断开（在 messageLoop goroutine 中调用），返回 true 表示 messageLoop 应退出
+//
+// 有未完成轮次时先尝试续传；空闲时断开或续传失败则标记 disconnected，
+// 由下一次 SynthesizeStream 重连（空闲断开不立即重连，避免池化会话无谓占用 gateway 连接）。
+func (s *Session) connLost(ctx context.Context, cause error) bool {
+	if s.PendingRounds() > 0 && s.resume(ctx, cause) {
+		return false
+	}
+	s.handleStreamError(cause)
+
+	s.mu.Lock()
+	s.disconnected = true
+	s.mu.Unlock()
+
+	slog.Warn("Connection lost, will reconnect on next round", "component", "tts", "id", s.ID, "error", cause)
+	return true
+}
+
+// ensureConnected 连接已断开时重连并重新发送 session.config（SynthesizeStream 前调用）
+func (s *Session) ensureConnected(ctx context.Context) error {
+	s.mu.Lock()
+	conn, done, lost, closed := s.conn, s.loopDone, s.disconnected, s.closed
+	s.mu.Unlock()
+	if closed || (!lost && conn.IsConnected()) {
+		return nil
+	}
+
+	// 连接已断但 messageLoop 尚未处理完：稍等其退出（或续传完成）再判断
+	if !lost {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock().After(redialSettleTimeout):
+		}
+		s.mu.Lock()
+		lost = s.disconnected
+		s.mu.Unlock()
+		if !lost {
+			return nil
+		}
+	}
+
+	if s.dial == nil {
+		return fmt.Errorf("connection lost")
+	}
+
+	s.redialMu.Lock()
+	defer s.redialMu.Unlock()
+
+	// 并发的 SynthesizeStream 可能已完成重连
+	s.mu.Lock()
+	lost = s.disconnected
+	s.mu.Unlock()
+	if !lost {
+		return nil
+	}
+
+	newConn, err := s.reestablish(ctx)
+	if err != nil {
+		return fmt.Errorf("reconnect session: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		newConn.Close()
+		return fmt.Errorf("session closed")
+	}
+	old := s.conn
+	s.conn = newConn
+	s.disconnected = false
+	s.mu.Unlock()
+	old.Close()
+
+	s.startMessageLoop()
+	slog.Info("Session reconnected", "component", "tts", "id", s.ID)
+	return nil
+}
+
+// every 按间隔执行会话的周期任务（保活、卡住检测），fn 返回 false 或会话结束后停止
+// 共享事件循环模式下由 EventLoop.Every 调度，否则启动独立 goroutine
+func (s *Session) every(interval time.Duration, fn func() bool) {
+	if s.config.EventLoop != nil {
+		s.config.EventLoop.Every(interval, fn)
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-s.closeCh:
+				return
+			case <-s.ctx.Done():
+				return
+			case <-s.clock().After(interval):
+			}
+			if !fn() {
+				return
+			}
+		}
+	}()
+}
+
+// keepalive 发送一次保活 Ping（防止空闲连接被 gateway 超时断开），会话结束后返回 false
+func (s *Session) keepalive() bool {
+	if s.IsClosed() || s.ctx.Err() != nil {
+		return false
+	}
+	conn := s.currentConn()
+	if !conn.IsConnected() {
+		return true // 已断开，等下一轮合成时重连
+	}
+	if err := conn.Ping(); err != nil {
+		slog.Warn("Keepalive ping failed", "component", "tts", "id", s.ID, "error", err)
+	}
+	return true
+}
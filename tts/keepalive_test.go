@@ -0,0 +1,101 @@
+package tts
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestSynthesizeReconnectsAfterIdleClose 验证：空闲时 gateway 关闭连接后，下一次 SynthesizeStream
+// 自动重连并重新发送 session.config，调用方无感知。
+// WHY：池化的长连接在 gateway 空闲超时后静默失效，原先下一轮合成直接报 not connected。
+func TestSynthesizeReconnectsAfterIdleClose(t *testing.T) {
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		n := atomic.AddInt32(&conns, 1)
+
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s" + string(rune('0'+n))})
+		var msg map[string]any
+		if ws.ReadJSON(&msg) != nil || msg["type"] != string(protocol.MessageTypeSessionConfig) {
+			return
+		}
+		ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+
+		if n == 1 {
+			// 模拟 gateway 空闲超时：正常关闭连接
+			ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle"))
+			return
+		}
+		for {
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch msg["type"] {
+			case string(protocol.MessageTypeSessionEnd):
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			case string(protocol.MessageTypeInputCommit):
+				roundID, _ := msg["round_id"].(string)
+				ws.WriteJSON(protocol.AudioDelta{Type: protocol.MessageTypeAudioDelta,
+					Audio: base64.StdEncoding.EncodeToString(make([]byte, 320)), RoundID: roundID})
+				ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&Config{GatewayURL: "ws" + strings.TrimPrefix(srv.URL, "http"), Provider: "tengen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		session.mu.Lock()
+		lost := session.disconnected
+		session.mu.Unlock()
+		if lost {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("idle close not detected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stream, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatalf("SynthesizeStream after idle close: %v", err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil || len(data) != 320 {
+		t.Fatalf("read = %d bytes, %v; want 320", len(data), err)
+	}
+	if got := atomic.LoadInt32(&conns); got != 2 {
+		t.Fatalf("connections = %d, want 2", got)
+	}
+}
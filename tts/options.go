@@ -1,7 +1,19 @@
 // Package tts 提供TTS客户端
 package tts
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/preset"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
 
 // Config TTS客户端配置
 type Config struct {
@@ -10,6 +22,44 @@ type Config struct {
 	Provider   string // 提供商: tengen (默认), azure, qwen, voxnexus
 	APIKey     string // API Key 认证（可选，通过URL参数传递）
 
+	// 按提供商指定 gateway URL（不同提供商由不同 gateway 集群服务时），未列出的提供商使用 GatewayURL；
+	// 同一个 Client 按每次会话的提供商选择 gateway
+	Gateways map[string]string
+
+	// 认证凭据提供方（可选，如短期令牌）：设置后每次建连获取凭据并覆盖 APIKey；
+	// gateway 返回 401/403 时刷新重试一次，仍被拒绝时返回 *transport.AuthError（含 gateway 的错误说明）
+	Auth transport.AuthProvider
+
+	// 握手附加的 HTTP 头（如链路追踪头）；BearerAuth 时 APIKey / Auth 的凭据改用 Authorization: Bearer 头发送，
+	// 不再出现在 URL 中（URL 会记入代理与访问日志）。见 transport.Config
+	Header     http.Header
+	BearerAuth bool
+
+	// 代理（企业网络出口）：ProxyURL 为固定代理（http:// 或 socks5://，可带用户名密码），
+	// Proxy 为自定义选择函数（如 http.ProxyFromEnvironment），两者都设置时以 Proxy 为准；均为空时直连
+	ProxyURL string
+	Proxy    func(*http.Request) (*url.URL, error)
+
+	// 自定义 TCP 拨号（固定 IP、自定义 DNS、socket 选项、VPC 端点等），见 transport.Config.NetDialContext
+	NetDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// 异步发送队列（0 关闭）：>0 时发送只入队、由专用 goroutine 写出，相邻音频帧合并，排队达到此字节数时
+	// 按 SendQueuePolicy 阻塞或返回 transport.ErrBufferFull。见 transport.Config.SendQueueBytes
+	SendQueueBytes  int
+	SendQueuePolicy transport.SendQueuePolicy
+
+	// 接收缓冲区（消息数，0 使用 transport.DefaultReceiveBuffer）与满时的处理方式（默认暂停读取），
+	// 见 transport.Config.ReceiveBuffer
+	ReceiveBuffer   int
+	ReceiveOverflow transport.OverflowPolicy
+
+	// 连接拦截器链（日志、加密、消息改写等），作用于本客户端的所有连接，见 transport.Interceptor
+	Interceptors []transport.Interceptor
+
+	// 连接指标回调（可选）：本客户端所有连接（含断线重连建立的新连接）的消息、字节、Ping RTT、重连与错误，
+	// 见 transport.MetricsHook
+	Metrics transport.MetricsHook
+
 	// 合成参数
 	VoiceID     string  // 语音ID（克隆声音）
 	Language    string  // 语言代码: en-NG, sw-TZ 等（用于文本归一化）
@@ -19,12 +69,93 @@ type Config struct {
 	SampleRate  int     // 采样率 (Hz)
 	AudioFormat string  // 音频格式: pcm, wav, mp3
 
-	// 连接配置
+	// 音色别名（见 VoiceAliases）：VoiceID、SynthesisOptions.VoiceID 与剧本台词的音色均可使用逻辑名，
+	// 发送前按会话的提供商解析，按 SynthesisOptions.Provider 切换到其它提供商的会话同样生效
+	VoiceAliases VoiceAliases
+
+	// 长文本切分：单轮最大字符数，超出按句切分为多轮并拼接输出
+	// 0 使用 DefaultMaxTextLength，<0 关闭切分
+	MaxTextLength int
+
+	// 断线续传：合成过程中连接异常断开时的重连次数，重连后重发未完成轮次并跳过已收到的音频
+	// 0 使用 DefaultMaxResumes，<0 关闭
+	MaxResumes int
+
+	// 背压：每个 AudioStream 排队未读取的最大字节数，超出时暂停从连接接收（0 不限制）
+	// 例如 8kHz 16-bit PCM 下 160000 约为 10 秒音频
+	MaxBufferBytes int
+
+	// 保活：空闲时按此间隔发送 WebSocket Ping，避免池化的长连接被 gateway 空闲超时断开（0 关闭）
+	// 连接仍断开时，下一次 SynthesizeStream 会自动重连并重新发送 session.config
+	KeepaliveInterval time.Duration
+
+	// 响度归一化：对 PCM 输出做流式 AGC，使不同提供商/音色的响度一致（仅 16-bit 单声道 PCM 生效）
+	// TargetDBFS 为目标 RMS 电平，0 使用 audio.DefaultTargetDBFS（-20 dBFS）
+	NormalizeOutput bool
+	TargetDBFS      float64
+
+	// 输出重采样：将 PCM 输出流式重采样到此采样率（如电话侧需要 8000），
+	// 与 SampleRate（向提供商请求的采样率）相同或为 0 时不处理；AudioStream.SampleRate() 返回重采样后的采样率
+	OutputSampleRate int
+
+	// 首包超时：轮次开始接收（成为队列头部）后首个 audio.delta 未在此时间内到达时，
+	// 该轮以 ErrTimeout 失败并通知 gateway 取消（0 不限制，仅受 ReadTimeout 约束）
+	FirstChunkTimeout time.Duration
+
+	// 卡住检测：轮次开始接收（或上一个音频块）后超过 StallTimeout 没有任何进展（audio.delta / audio.done / error）、
+	// 连接也未断开时判定为卡住，记录日志并以 *StalledError 回调 OnStall（0 关闭；调用方暂停或背压期间不计时）。
+	// CancelStalled 为 true 时该轮同时以 *StalledError 失败并通知 gateway 取消，否则继续等待（仅受 ReadTimeout 约束）
+	StallTimeout  time.Duration
+	CancelStalled bool
+	OnStall       func(err *StalledError) // 在看门狗 goroutine 中调用，不应阻塞
+
+	// 端到端时延预算（0 关闭）：记录每轮建连、握手、首包各阶段耗时（AudioStream.LatencyBreakdown），
+	// 首包到达时合计超出预算则记录 Warn 日志并以 *transport.BudgetExceededError 回调 OnBudgetExceeded（指出耗时最长的阶段）；
+	// 该轮失败时错误包装为 *transport.LatencyError，附带失败时的阶段明细。建连与握手只计入会话的首轮
+	LatencyBudget    time.Duration
+	OnBudgetExceeded func(err *transport.BudgetExceededError) // 在消息处理 goroutine 中调用，不应阻塞
+
+	// 调度优先级提示（protocol.PriorityRealtime / PriorityBulk），随 session.config 发送，空由 gateway 决定
+	Priority protocol.Priority
+
+	// 会话亲和令牌（之前会话 Session.AffinityToken 的返回值），随 session.config 发送，
+	// 请求 gateway 路由到同一后端实例；空不发送
+	AffinityToken string
+
+	// 功能标志：在 client_info.features 中声明的功能（如 protocol.FeatureBinaryAudio），
+	// gateway 在 config_done 中确认后生效，见 Session.Features
+	Features []string
+
+	// 消息编码："" / "json"（默认）或 "msgpack"。非 JSON 编码在 client_info.codecs 中声明，
+	// gateway 在 config_done 中选定后用于此后的消息；旧版 gateway 不选定时保持 JSON
+	Codec string
+
+	// 关闭握手：Close 发送 session.end 后等待 gateway Close 帧的最长时间
+	// 0 使用 DefaultCloseTimeout，<0 不等待
+	CloseTimeout time.Duration
+
+	// 连接配置（0 使用 transport.Default*；ReadTimeout / WriteTimeout <0 不设置 deadline，MaxReconnects <0 不重试）
 	ConnectTimeout   time.Duration
 	ReadTimeout      time.Duration
 	WriteTimeout     time.Duration
 	ReconnectBackoff time.Duration
 	MaxReconnects    int
+
+	// 空闲超时（0 不启用）：>0 时替代 ReadTimeout，收发任一方向有消息或 Ping/Pong 即顺延；
+	// MaxConnDuration 为连接最长存活时间（0 不限制），从建连起计算、不随活动顺延。见 transport.Config
+	IdleTimeout     time.Duration
+	MaxConnDuration time.Duration
+
+	// Pong 超时（0 不检测）：保活 Ping 之后此时间内未收到 Pong 即判定连接失效并按断线处理（续传 / 重连），
+	// 不必等到 ReadTimeout。见 transport.Config.PongTimeout
+	PongTimeout time.Duration
+
+	// 共享事件循环（transport.NewEventLoop）：高密度部署下多个会话共用分发 worker 与保活定时器，
+	// 替代每个会话常驻的消息循环和保活 goroutine；nil 为每会话独立 goroutine。公开 API 不变
+	EventLoop *transport.EventLoop
+
+	// 时钟：时延计算、Close 握手等待、续传退避使用（nil 使用系统时钟，测试可注入 clock.Fake）
+	Clock clock.Clock
 }
 
 // DefaultConfig 返回默认配置
@@ -38,40 +169,156 @@ func DefaultConfig() *Config {
 		Volume:           1.0,
 		SampleRate:       8000,
 		AudioFormat:      "pcm",
-		ConnectTimeout:   10 * time.Second,
-		ReadTimeout:      60 * time.Second,
-		WriteTimeout:     10 * time.Second,
-		ReconnectBackoff: 1 * time.Second,
-		MaxReconnects:    3,
+		MaxTextLength:    DefaultMaxTextLength,
+		MaxResumes:       DefaultMaxResumes,
+		CloseTimeout:     DefaultCloseTimeout,
+		ConnectTimeout:   transport.DefaultConnectTimeout,
+		ReadTimeout:      transport.DefaultReadTimeout,
+		WriteTimeout:     transport.DefaultWriteTimeout,
+		ReconnectBackoff: transport.DefaultReconnectBackoff,
+		MaxReconnects:    transport.DefaultMaxReconnects,
+	}
+}
+
+// Normalize 把零值字段替换为默认值（NewClient 在 Validate 之前调用）
+//
+// 零值一律表示"使用默认值"，与 DefaultConfig 一致；需要关闭的功能用负值表示（见各字段说明），
+// 例如 ReadTimeout <0 不设置读 deadline、MaxReconnects <0 建连失败不重试。
+func (c *Config) Normalize() {
+	if c.Speed <= 0 {
+		c.Speed = 1.0
+	}
+	if c.Volume <= 0 {
+		c.Volume = 1.0
+	}
+	if c.MaxTextLength == 0 {
+		c.MaxTextLength = DefaultMaxTextLength
+	}
+	if c.MaxResumes == 0 {
+		c.MaxResumes = DefaultMaxResumes
 	}
+	if c.CloseTimeout == 0 {
+		c.CloseTimeout = DefaultCloseTimeout
+	}
+	c.normalizeConn()
 }
 
-// Validate 验证配置
+// normalizeConn 按 transport 的规则补全连接配置
+func (c *Config) normalizeConn() {
+	conn := transport.Config{
+		ConnectTimeout:   c.ConnectTimeout,
+		ReadTimeout:      c.ReadTimeout,
+		WriteTimeout:     c.WriteTimeout,
+		ReconnectBackoff: c.ReconnectBackoff,
+		MaxReconnects:    c.MaxReconnects,
+	}
+	conn.Normalize()
+	c.ConnectTimeout, c.ReadTimeout, c.WriteTimeout = conn.ConnectTimeout, conn.ReadTimeout, conn.WriteTimeout
+	c.ReconnectBackoff, c.MaxReconnects = conn.ReconnectBackoff, conn.MaxReconnects
+}
+
+// Warnings 返回可疑但合法的取值说明（配置仍然生效，NewClient 逐条记录 Warn 日志）
+func (c *Config) Warnings() []string {
+	warnings := transport.TimeoutWarnings(c.ConnectTimeout, c.ReadTimeout, c.WriteTimeout)
+	if c.ReadTimeout > 0 {
+		if c.KeepaliveInterval >= c.ReadTimeout {
+			warnings = append(warnings, fmt.Sprintf("KeepaliveInterval (%v) is not shorter than ReadTimeout (%v): idle sessions time out before the ping", c.KeepaliveInterval, c.ReadTimeout))
+		}
+		if c.FirstChunkTimeout >= c.ReadTimeout {
+			warnings = append(warnings, fmt.Sprintf("FirstChunkTimeout (%v) is not shorter than ReadTimeout (%v) and never fires first", c.FirstChunkTimeout, c.ReadTimeout))
+		}
+		if c.StallTimeout >= c.ReadTimeout {
+			warnings = append(warnings, fmt.Sprintf("StallTimeout (%v) is not shorter than ReadTimeout (%v) and never fires first", c.StallTimeout, c.ReadTimeout))
+		}
+	}
+	if c.CancelStalled && c.StallTimeout <= 0 {
+		warnings = append(warnings, "CancelStalled has no effect without StallTimeout")
+	}
+	if c.OnBudgetExceeded != nil && c.LatencyBudget <= 0 {
+		warnings = append(warnings, "OnBudgetExceeded has no effect without LatencyBudget")
+	}
+	if c.ReceiveOverflow == transport.OverflowDropOldest {
+		warnings = append(warnings, "ReceiveOverflow drop-oldest drops audio.delta frames: synthesized audio has gaps when the reader falls behind")
+	}
+	if c.PongTimeout > 0 && c.KeepaliveInterval <= 0 {
+		warnings = append(warnings, "PongTimeout has no effect without KeepaliveInterval: no pings are sent")
+	}
+	return warnings
+}
+
+// Validate 验证配置（不修改配置；零值字段的默认值由 Normalize 填充）
 func (c *Config) Validate() error {
-	if c.GatewayURL == "" {
+	if c.GatewayURL == "" && c.Gateways[c.Provider] == "" {
 		return ErrInvalidConfig("GatewayURL is required")
 	}
+	if c.GatewayURL != "" {
+		if err := transport.ValidateGatewayURL(c.GatewayURL); err != nil {
+			return ErrInvalidConfig(err.Error())
+		}
+	}
+	for provider, gatewayURL := range c.Gateways {
+		if gatewayURL == "" {
+			return ErrInvalidConfig(fmt.Sprintf("empty gateway URL for provider %q", provider))
+		}
+		if err := transport.ValidateGatewayURL(gatewayURL); err != nil {
+			return ErrInvalidConfig(err.Error())
+		}
+	}
 	if c.Provider == "" {
 		return ErrInvalidConfig("Provider is required")
 	}
-	if c.Speed <= 0 {
-		c.Speed = 1.0
+	if !c.Priority.Valid() {
+		return ErrInvalidConfig("Priority must be realtime or bulk")
 	}
-	if c.Volume <= 0 {
-		c.Volume = 1.0
+	if _, err := transport.CodecByName(c.Codec); err != nil {
+		return ErrInvalidConfig(err.Error())
+	}
+	if c.LatencyBudget < 0 {
+		return ErrInvalidConfig("LatencyBudget must not be negative")
+	}
+	if c.ProxyURL != "" {
+		if _, err := transport.ParseProxyURL(c.ProxyURL); err != nil {
+			return ErrInvalidConfig(err.Error())
+		}
+	}
+	if c.SendQueueBytes < 0 {
+		return ErrInvalidConfig("SendQueueBytes must not be negative")
+	}
+	if !c.SendQueuePolicy.Valid() {
+		return ErrInvalidConfig("SendQueuePolicy must be block or reject")
+	}
+	if c.ReceiveBuffer < 0 {
+		return ErrInvalidConfig("ReceiveBuffer must not be negative")
 	}
-	if c.ConnectTimeout <= 0 {
-		c.ConnectTimeout = 10 * time.Second
+	if !c.ReceiveOverflow.Valid() {
+		return ErrInvalidConfig("ReceiveOverflow must be block, drop-oldest or error")
 	}
-	if c.ReadTimeout <= 0 {
-		c.ReadTimeout = 60 * time.Second
+	if err := c.VoiceAliases.validate(); err != nil {
+		return err
 	}
-	if c.WriteTimeout <= 0 {
-		c.WriteTimeout = 10 * time.Second
+	if _, err := c.VoiceAliases.resolve(c.Provider, c.VoiceID); err != nil {
+		return err
 	}
 	return nil
 }
 
+// WithGateway 指定 provider 使用的 gateway URL
+func (c *Config) WithGateway(provider, gatewayURL string) *Config {
+	if c.Gateways == nil {
+		c.Gateways = make(map[string]string)
+	}
+	c.Gateways[provider] = gatewayURL
+	return c
+}
+
+// gatewayURL 返回 provider 对应的 gateway URL（未在 Gateways 中指定时为 GatewayURL）
+func (c *Config) gatewayURL(provider string) string {
+	if u, ok := c.Gateways[provider]; ok {
+		return u
+	}
+	return c.GatewayURL
+}
+
 // WithProvider 设置提供商
 func (c *Config) WithProvider(provider string) *Config {
 	c.Provider = provider
@@ -84,6 +331,18 @@ func (c *Config) WithVoice(voiceID string) *Config {
 	return c
 }
 
+// WithVoiceAlias 添加音色别名：alias 在 provider 上使用 voiceID
+func (c *Config) WithVoiceAlias(alias, provider, voiceID string) *Config {
+	if c.VoiceAliases == nil {
+		c.VoiceAliases = make(VoiceAliases)
+	}
+	if c.VoiceAliases[alias] == nil {
+		c.VoiceAliases[alias] = make(map[string]string)
+	}
+	c.VoiceAliases[alias][provider] = voiceID
+	return c
+}
+
 // WithLanguage 设置语言代码（用于文本归一化）
 func (c *Config) WithLanguage(language string) *Config {
 	c.Language = language
@@ -108,12 +367,81 @@ func (c *Config) WithVolume(volume float64) *Config {
 	return c
 }
 
+// WithAuth 设置认证凭据提供方
+func (c *Config) WithAuth(auth transport.AuthProvider) *Config {
+	c.Auth = auth
+	return c
+}
+
 // WithAPIKey 设置API Key
 func (c *Config) WithAPIKey(apiKey string) *Config {
 	c.APIKey = apiKey
 	return c
 }
 
+// WithHeader 添加握手时发送的 HTTP 头
+func (c *Config) WithHeader(key, value string) *Config {
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+	c.Header.Add(key, value)
+	return c
+}
+
+// WithBearerAuth 凭据改用 Authorization: Bearer 头发送，不放在 URL 中
+func (c *Config) WithBearerAuth() *Config {
+	c.BearerAuth = true
+	return c
+}
+
+// WithProxy 经 HTTP 或 SOCKS5 代理连接 gateway（格式见 transport.ParseProxyURL）
+func (c *Config) WithProxy(proxyURL string) *Config {
+	c.ProxyURL = proxyURL
+	return c
+}
+
+// WithNetDialContext 使用自定义 TCP 拨号函数建立连接
+func (c *Config) WithNetDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) *Config {
+	c.NetDialContext = dial
+	return c
+}
+
+// WithSendQueue 开启异步发送队列（limit 为排队字节上限，policy 为队列满时的处理方式）
+func (c *Config) WithSendQueue(limit int, policy transport.SendQueuePolicy) *Config {
+	c.SendQueueBytes, c.SendQueuePolicy = limit, policy
+	return c
+}
+
+// WithReceiveBuffer 设置接收缓冲区容量（消息数）与满时的处理方式
+func (c *Config) WithReceiveBuffer(size int, policy transport.OverflowPolicy) *Config {
+	c.ReceiveBuffer, c.ReceiveOverflow = size, policy
+	return c
+}
+
+// WithInterceptor 在拦截器链末尾追加一个拦截器
+func (c *Config) WithInterceptor(i transport.Interceptor) *Config {
+	c.Interceptors = append(c.Interceptors, i)
+	return c
+}
+
+// WithMetrics 设置连接指标回调
+func (c *Config) WithMetrics(hook transport.MetricsHook) *Config {
+	c.Metrics = hook
+	return c
+}
+
+// proxy 连接使用的代理选择函数（nil 直连）
+func (c *Config) proxy() func(*http.Request) (*url.URL, error) {
+	if c.Proxy != nil || c.ProxyURL == "" {
+		return c.Proxy
+	}
+	proxy, err := transport.ProxyURL(c.ProxyURL)
+	if err != nil {
+		return nil // Validate 已拒绝无效的 ProxyURL
+	}
+	return proxy
+}
+
 // WithSampleRate 设置采样率
 func (c *Config) WithSampleRate(sampleRate int) *Config {
 	c.SampleRate = sampleRate
@@ -126,6 +454,152 @@ func (c *Config) WithAudioFormat(audioFormat string) *Config {
 	return c
 }
 
+// WithMaxTextLength 设置单轮最大文本长度（超出自动按句切分）
+func (c *Config) WithMaxTextLength(maxLen int) *Config {
+	c.MaxTextLength = maxLen
+	return c
+}
+
+// WithMaxResumes 设置断线续传重连次数（<0 关闭）
+func (c *Config) WithMaxResumes(n int) *Config {
+	c.MaxResumes = n
+	return c
+}
+
+// WithKeepaliveInterval 设置保活 Ping 间隔（0 关闭）
+func (c *Config) WithKeepaliveInterval(d time.Duration) *Config {
+	c.KeepaliveInterval = d
+	return c
+}
+
+// WithCloseTimeout 设置关闭握手等待时间（<0 不等待）
+func (c *Config) WithCloseTimeout(d time.Duration) *Config {
+	c.CloseTimeout = d
+	return c
+}
+
+// WithNormalizeOutput 开启输出响度归一化（targetDBFS 为 0 时使用默认 -20 dBFS）
+func (c *Config) WithNormalizeOutput(targetDBFS float64) *Config {
+	c.NormalizeOutput = true
+	c.TargetDBFS = targetDBFS
+	return c
+}
+
+// ApplyPreset 应用提供商预设：Provider 总是覆盖，其它字段非零时覆盖
+func (c *Config) ApplyPreset(p preset.Preset) *Config {
+	c.Provider = p.Provider
+	if p.Voice != "" {
+		c.VoiceID = p.Voice
+	}
+	if p.Language != "" {
+		c.Language = p.Language
+	}
+	if p.Speed != 0 {
+		c.Speed = p.Speed
+	}
+	if p.Pitch != 0 {
+		c.Pitch = p.Pitch
+	}
+	if p.Volume != 0 {
+		c.Volume = p.Volume
+	}
+	if p.SampleRate != 0 {
+		c.SampleRate = p.SampleRate
+	}
+	if p.AudioFormat != "" {
+		c.AudioFormat = p.AudioFormat
+	}
+	return c
+}
+
+// WithPriority 设置调度优先级提示
+func (c *Config) WithPriority(p protocol.Priority) *Config {
+	c.Priority = p
+	return c
+}
+
+// WithAffinityToken 设置会话亲和令牌（复用之前会话的后端实例）
+func (c *Config) WithAffinityToken(token string) *Config {
+	c.AffinityToken = token
+	return c
+}
+
+// WithCodec 设置期望的消息编码（"json" 或 "msgpack"，gateway 确认后生效）
+func (c *Config) WithCodec(name string) *Config {
+	c.Codec = name
+	return c
+}
+
+// WithEventLoop 设置共享事件循环（多个 Client 可共用同一个）
+func (c *Config) WithEventLoop(loop *transport.EventLoop) *Config {
+	c.EventLoop = loop
+	return c
+}
+
+// codec 返回期望的消息编码（Validate 已校验名称；未知名称按 JSON 处理）
+func (c *Config) codec() transport.Codec {
+	codec, err := transport.CodecByName(c.Codec)
+	if err != nil {
+		return transport.JSON
+	}
+	return codec
+}
+
+// WithFeatures 声明客户端功能标志（gateway 确认后生效）
+func (c *Config) WithFeatures(names ...string) *Config {
+	c.Features = append(c.Features, names...)
+	return c
+}
+
+// WithOutputSampleRate 设置输出重采样的目标采样率（仅 PCM 生效）
+func (c *Config) WithOutputSampleRate(sampleRate int) *Config {
+	c.OutputSampleRate = sampleRate
+	return c
+}
+
+// WithStallTimeout 开启卡住检测；cancel 为 true 时自动取消卡住的轮次
+func (c *Config) WithStallTimeout(d time.Duration, cancel bool) *Config {
+	c.StallTimeout = d
+	c.CancelStalled = cancel
+	return c
+}
+
+// WithLatencyBudget 设置每轮的端到端时延预算（0 关闭）
+func (c *Config) WithLatencyBudget(d time.Duration) *Config {
+	c.LatencyBudget = d
+	return c
+}
+
+// WithFirstChunkTimeout 设置每轮首包超时（0 不限制）
+func (c *Config) WithFirstChunkTimeout(d time.Duration) *Config {
+	c.FirstChunkTimeout = d
+	return c
+}
+
+// WithMaxBufferBytes 设置每个 AudioStream 的最大缓冲字节数（背压）
+func (c *Config) WithMaxBufferBytes(n int) *Config {
+	c.MaxBufferBytes = n
+	return c
+}
+
+// WithIdleTimeout 设置空闲超时（>0 时替代 ReadTimeout，收发任一方向有活动即顺延；0 不启用）
+func (c *Config) WithIdleTimeout(d time.Duration) *Config {
+	c.IdleTimeout = d
+	return c
+}
+
+// WithMaxConnDuration 设置连接最长存活时间（不随活动顺延；0 不限制）
+func (c *Config) WithMaxConnDuration(d time.Duration) *Config {
+	c.MaxConnDuration = d
+	return c
+}
+
+// WithPongTimeout 设置 Pong 超时（Ping 后未按时收到 Pong 即判定连接失效；0 不检测）
+func (c *Config) WithPongTimeout(d time.Duration) *Config {
+	c.PongTimeout = d
+	return c
+}
+
 // SynthesisOptions 合成选项
 type SynthesisOptions struct {
 	VoiceID     string  // 语音ID
@@ -135,6 +609,13 @@ type SynthesisOptions struct {
 	Volume      float64 // 音量
 	SampleRate  int     // 采样率 (Hz)
 	AudioFormat string  // 音频格式: pcm, wav, mp3
+
+	// Provider 提供商（仅创建会话时生效，空使用 Config.Provider）；按 Config.Gateways 选择 gateway
+	Provider string
+
+	// FirstChunkTimeout 首包超时（仅客户端生效，不发送给 gateway）
+	// 0 使用 Config.FirstChunkTimeout，<0 不限制
+	FirstChunkTimeout time.Duration
 }
 
 // DefaultSynthesisOptions 返回默认合成选项
@@ -0,0 +1,41 @@
+// Package tts 音频播放
+package tts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio/playback"
+)
+
+// PlayOptions 播放选项
+type PlayOptions struct {
+	Device     string // 输出设备（空为系统默认）
+	SampleRate int    // 采样率（0 使用流的采样率）
+	Player     string // 指定系统播放器（aplay/paplay/play/ffplay），空则自动探测
+}
+
+// Play 边接收边播放 AudioStream（16-bit 单声道 PCM），播放完毕后返回
+//
+// 仅支持 AudioFormat 为 pcm 的会话；未安装系统播放器时返回 playback.ErrNoPlayer。
+func Play(ctx context.Context, stream *AudioStream, opts PlayOptions) error {
+	if stream == nil {
+		return fmt.Errorf("play: nil stream")
+	}
+	if stream.audioFormat != "" && stream.audioFormat != "pcm" {
+		return fmt.Errorf("play: unsupported audio format %q (pcm only)", stream.audioFormat)
+	}
+
+	sampleRate := opts.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = stream.SampleRate()
+	}
+
+	return playback.Play(ctx, stream, playback.Options{
+		Device:        opts.Device,
+		SampleRate:    sampleRate,
+		Channels:      1,
+		BitsPerSample: 16,
+		Player:        opts.Player,
+	})
+}
@@ -0,0 +1,45 @@
+// Package tts 合成请求幂等键
+package tts
+
+import (
+	"context"
+	"fmt"
+)
+
+// requestIDKey context 中幂等键的 key
+type requestIDKey struct{}
+
+// WithRequestID 为合成请求附加幂等键（随 input.commit 的 request_id 发送）
+//
+// 调用方在结果不确定的失败（如超时、连接中断）后用同一个键重试，gateway 可据此去重，
+// 避免重复计费或重复写入音频缓存。长文本切分、剧本合成的多轮请求使用 "<id>-1"、"<id>-2" …；
+// 断线续传重发的轮次沿用原键。
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext 返回 ctx 中的幂等键（未设置时为空）
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// innerRoundKey context 中拼接流内部轮次的标记
+type innerRoundKey struct{}
+
+// roundContext 多轮请求中第 i 轮（共 n 轮）使用的 ctx：为每轮派生独立的幂等键，
+// 并标记为拼接流的内部轮次（时延预算只对拼接后的流计算）
+func roundContext(ctx context.Context, i, n int) context.Context {
+	ctx = context.WithValue(ctx, innerRoundKey{}, true)
+	id := RequestIDFromContext(ctx)
+	if id == "" || n <= 1 {
+		return ctx
+	}
+	return WithRequestID(ctx, fmt.Sprintf("%s-%d", id, i+1))
+}
+
+// innerRound ctx 是否属于拼接流的内部轮次
+func innerRound(ctx context.Context) bool {
+	inner, _ := ctx.Value(innerRoundKey{}).(bool)
+	return inner
+}
@@ -0,0 +1,208 @@
+// Package tts 断线续传
+package tts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+// DefaultMaxResumes 连接异常断开时默认的续传重连次数
+const DefaultMaxResumes = 2
+
+// resume 连接异常断开后重连并续传未完成的轮次（在 messageLoop goroutine 中调用）
+//
+// 重连成功后按 FIFO 顺序重发每个未完成轮次的文本（同一 round_id），并携带
+// resume_offset_ms 提示已收到的音频时长；服务端若从该位置继续输出会在 audio.delta 中
+// 回显 offset_ms，否则视为从头重新合成。两种情况下重叠部分都会被丢弃，
+// AudioStream 的消费方看到的是连续无缝的音频。返回 false 表示未续传（已关闭/未开启/无待续传轮次/重连失败）。
+func (s *Session) resume(ctx context.Context, cause error) bool {
+	if s.dial == nil || s.config.MaxResumes < 0 || s.IsClosed() {
+		return false
+	}
+
+	// 已中止（取消 / 首包超时）的轮次不再重发
+	s.streamMu.Lock()
+	pending := s.streamQueue[:0]
+	for _, stream := range s.streamQueue {
+		if !stream.isAborted() {
+			pending = append(pending, stream)
+		}
+	}
+	s.streamQueue = pending
+	pending = append([]*AudioStream(nil), pending...)
+	s.streamMu.Unlock()
+	if len(pending) == 0 {
+		return false
+	}
+
+	slog.Warn("Connection lost, resuming", "component", "tts", "id", s.ID,
+		"pending", len(pending), "error", cause)
+
+	backoff := s.config.ReconnectBackoff
+	for attempt := 1; attempt <= s.config.MaxResumes; attempt++ {
+		if attempt > 1 && backoff > 0 {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-s.closeCh:
+				return false
+			case <-s.clock().After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if s.config.Metrics != nil {
+			s.config.Metrics.Reconnect(attempt, cause)
+		}
+		conn, err := s.reestablish(ctx)
+		if err != nil {
+			slog.Error("Resume attempt failed", "component", "tts", "attempt", attempt,
+				"max", s.config.MaxResumes, "error", err)
+			continue
+		}
+
+		if err := s.resendRounds(conn, pending); err != nil {
+			slog.Error("Resend rounds failed", "component", "tts", "attempt", attempt, "error", err)
+			conn.Close()
+			continue
+		}
+
+		// 替换连接（关闭期间不再替换，避免泄漏新连接）
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return false
+		}
+		old := s.conn
+		s.conn = conn
+		s.mu.Unlock()
+		old.Close()
+		if s.config.EventLoop == nil {
+			go s.controlLoop(conn)
+		}
+
+		slog.Info("Session resumed", "component", "tts", "id", s.ID, "attempt", attempt, "rounds", len(pending))
+		return true
+	}
+	return false
+}
+
+// reestablish 建立新连接并完成握手
+//
+// gateway 在 session.ready 中返回过恢复令牌时先以 session.resume 接回原会话（沿用会话 ID、配置与后端）；
+// 无令牌或恢复被拒绝时走 session.config / config_done 新建会话。未完成轮次在两种情况下都由调用方重发。
+func (s *Session) reestablish(ctx context.Context) (*transport.Conn, error) {
+	estCtx, cancel := establishCtx(ctx, defaultEstablishTimeout)
+	defer cancel()
+
+	conn, err := s.dial(estCtx)
+	if err != nil {
+		return nil, fmt.Errorf("reconnect: %w", err)
+	}
+	ready, err := readReady(estCtx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s.mu.Lock()
+	token := s.resumeToken
+	s.mu.Unlock()
+	if token != "" {
+		data, err := transport.ResumeSession(estCtx, conn, token)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if data != nil {
+			s.setFeatures(conn, data)
+			slog.Info("Gateway session reattached", "component", "tts", "id", s.ID, "gateway_session", ready.SessionID)
+			return conn, nil
+		}
+	}
+	s.adoptReady(ready)
+	if err := s.sendConfig(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("send config: %w", err)
+	}
+
+	// messageLoop 尚未切换到新连接，此处直接读取 config_done
+	for {
+		data, err := conn.Receive(estCtx)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("wait config_done: %w", err)
+		}
+		msgType, err := transport.ParseMessageType(data)
+		if err != nil {
+			continue
+		}
+		switch msgType {
+		case protocol.MessageTypeSessionConfigDone:
+			// 重连后的 gateway 实例可能与之前不同，按新的确认结果更新功能标志
+			s.setFeatures(conn, data)
+			return conn, nil
+		case protocol.MessageTypeError:
+			conn.Close()
+			msg, _ := transport.ParseMessage(data)
+			if errMsg, ok := msg.(*protocol.ErrorMessage); ok {
+				return nil, fmt.Errorf("config rejected: [%s] %s", errMsg.Code, errMsg.Message)
+			}
+			return nil, fmt.Errorf("config rejected")
+		}
+	}
+}
+
+// resendRounds 按原顺序在新连接上重发未完成轮次
+func (s *Session) resendRounds(conn *transport.Conn, pending []*AudioStream) error {
+	for _, stream := range pending {
+		textMsg := transport.NewTextAppend(stream.text)
+		textMsg.RoundID = stream.roundID
+		textMsg.ResumeOffsetMs = s.offsetMs(stream.delivered)
+		if err := conn.Send(textMsg); err != nil {
+			return fmt.Errorf("send text: %w", err)
+		}
+		commitMsg := transport.NewInputCommit()
+		commitMsg.RoundID = stream.roundID
+		commitMsg.RequestID = stream.requestID
+		if err := conn.Send(commitMsg); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+		stream.resumePending = stream.delivered > 0
+
+		slog.Info("Round resent", "component", "tts", "round_id", stream.roundID,
+			"delivered_bytes", stream.delivered, "resume_offset_ms", textMsg.ResumeOffsetMs, "id", s.ID)
+	}
+	return nil
+}
+
+// pcmBytesPerMs 返回 16-bit 单声道 PCM 每毫秒字节数；非 PCM 格式返回 0（无法按时长定位）
+func (s *Session) pcmBytesPerMs() float64 {
+	opts := s.Options()
+	if opts.AudioFormat != "pcm" || opts.SampleRate <= 0 {
+		return 0
+	}
+	return float64(opts.SampleRate) * 2 / 1000
+}
+
+// offsetMs 将已交付字节数换算为续传提示时长（向下取整到毫秒）
+func (s *Session) offsetMs(delivered int64) int64 {
+	perMs := s.pcmBytesPerMs()
+	if perMs == 0 {
+		return 0
+	}
+	return int64(float64(delivered) / perMs)
+}
+
+// offsetBytes 将服务端回显的 offset_ms 换算为字节位置（对齐到采样点）
+func (s *Session) offsetBytes(offsetMs int64) int64 {
+	perMs := s.pcmBytesPerMs()
+	if perMs == 0 || offsetMs <= 0 {
+		return 0
+	}
+	return int64(float64(offsetMs)*perMs) &^ 1
+}
@@ -0,0 +1,59 @@
+package tts
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestResumeSkipsDeliveredAudio 验证：续传后服务端从 offset_ms 处继续（或从头重发）时，
+// 与已交付音频重叠的字节被丢弃，消费方拼接结果连续无重复。
+// WHY：网络抖动重连后若重复交付已播放的音频，电话场景会听到明显的"回放"。
+func TestResumeSkipsDeliveredAudio(t *testing.T) {
+	s := &Session{opts: &SynthesisOptions{AudioFormat: "pcm", SampleRate: 8000}} // 16 字节/ms
+	full := make([]byte, 320)
+	for i := range full {
+		full[i] = byte(i)
+	}
+
+	cases := []struct {
+		name         string
+		honorsOffset bool // 服务端是否采纳 resume_offset_ms（否则从头重发）
+	}{
+		{"server honors offset", true},
+		{"server restarts from zero", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := newAudioStream()
+			var got []byte
+			got = append(got, st.trimResumed(full[:100])...) // 断线前已交付 100 字节
+
+			var offsetMs int64
+			if tc.honorsOffset {
+				offsetMs = s.offsetMs(st.delivered)
+			}
+			start := s.offsetBytes(offsetMs)
+			st.resumeSkip = st.delivered - start
+
+			// 服务端从 start 处分块重发
+			for off := start; off < int64(len(full)); off += 48 {
+				end := off + 48
+				if end > int64(len(full)) {
+					end = int64(len(full))
+				}
+				got = append(got, st.trimResumed(full[off:end])...)
+			}
+			if !bytes.Equal(got, full) {
+				t.Fatalf("stitched audio mismatch: got %d bytes, want %d", len(got), len(full))
+			}
+		})
+	}
+}
+
+// TestResumeOffsetNonPCM 验证：非 PCM 格式不发送续传时长提示（无法按时长定位字节）。
+func TestResumeOffsetNonPCM(t *testing.T) {
+	s := &Session{opts: &SynthesisOptions{AudioFormat: "mp3", SampleRate: 24000}}
+	if got := s.offsetMs(4800); got != 0 {
+		t.Fatalf("expected no offset hint for mp3, got %d ms", got)
+	}
+}
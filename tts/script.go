@@ -0,0 +1,168 @@
+// Package tts 多角色剧本合成
+package tts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Line 剧本中的一句台词
+type Line struct {
+	Voice string // 音色ID（空沿用会话创建时的音色）
+	Text  string // 台词文本（超过 Config.MaxTextLength 时按句切分）
+
+	// Text 为空时插入生成的音频而非合成：Duration 时长的静音，Tone > 0 时为该频率（Hz）的提示音
+	Duration time.Duration
+	Tone     float64
+
+	// Text 为空时插入预录音频：16-bit 单声道 PCM，采样率 AudioSampleRate（0 表示与会话输出相同，不同时重采样）
+	Audio           []byte
+	AudioSampleRate int
+}
+
+// LineOffset 台词在拼接音频中的起始位置
+type LineOffset struct {
+	Line  int           // 台词下标（对应 SynthesizeScript 的 lines）
+	Voice string        // 实际使用的音色
+	Bytes int64         // 起始字节偏移
+	Start time.Duration // 起始时间（仅 16-bit 单声道 PCM，其它格式为 0）
+}
+
+// ScriptStream 剧本合成的拼接音频流
+type ScriptStream struct {
+	*AudioStream
+
+	mu      sync.Mutex
+	offsets []LineOffset
+}
+
+// Offsets 返回已开始输出的台词位置（读完音频后包含全部台词）
+func (s *ScriptStream) Offsets() []LineOffset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offsets := append([]LineOffset(nil), s.offsets...)
+	for i := range offsets {
+		offsets[i].Start = s.pcmDuration(offsets[i].Bytes)
+	}
+	return offsets
+}
+
+// scriptPart 剧本拆分后的单轮合成
+type scriptPart struct {
+	line  int
+	voice string
+	text  string
+	first bool // 是否为该台词的第一轮
+
+	generate func() (*AudioStream, error) // 非 nil 时为生成的静音或提示音，不合成
+}
+
+// SynthesizeScript 多角色剧本合成：逐句切换音色（session.update），拼接为一个连续的音频流
+//
+// 音色切换随轮次按序发送，服务端对已排队的轮次仍使用原音色，因此与合成管道化不冲突。
+// 全部台词提交后恢复会话创建时的音色。空文本的台词插入 Line.Audio 的预录音频或 Line.Duration 的静音、
+// 提示音（需 PCM 输出），都未设置时跳过。
+func (s *Session) SynthesizeScript(ctx context.Context, lines []Line) (*ScriptStream, error) {
+	base := s.Options().VoiceID
+
+	var parts []scriptPart
+	for i, line := range lines {
+		if line.Text == "" && len(line.Audio) > 0 {
+			line := line
+			generate := func() (*AudioStream, error) { return s.StreamPCM(line.Audio, line.AudioSampleRate) }
+			parts = append(parts, scriptPart{line: i, first: true, generate: generate})
+			continue
+		}
+		if line.Text == "" && line.Duration > 0 {
+			line := line
+			generate := func() (*AudioStream, error) { return s.GenerateSilence(line.Duration) }
+			if line.Tone > 0 {
+				generate = func() (*AudioStream, error) { return s.GenerateTone(line.Tone, line.Duration) }
+			}
+			parts = append(parts, scriptPart{line: i, first: true, generate: generate})
+			continue
+		}
+		voice := line.Voice
+		if voice == "" {
+			voice = base
+		}
+		for j, text := range SplitText(line.Text, s.config.MaxTextLength) {
+			parts = append(parts, scriptPart{line: i, voice: voice, text: text, first: j == 0})
+		}
+	}
+	if len(parts) == 0 {
+		return nil, ErrInvalidConfig("script has no text")
+	}
+	if base == "" {
+		// 会话没有默认音色时，切换过音色后无法再切回 gateway 默认音色
+		switched := false
+		for _, p := range parts {
+			if p.generate != nil {
+				continue
+			}
+			if p.voice != "" {
+				switched = true
+			} else if switched {
+				return nil, ErrInvalidConfig(fmt.Sprintf("line %d: Voice is required when the session has no default voice", p.line+1))
+			}
+		}
+	}
+
+	last := -1 // 最后一个合成轮次
+	for i, p := range parts {
+		if p.generate == nil {
+			last = i
+		}
+	}
+
+	current := base
+	submit := func(i int) (*AudioStream, error) {
+		p := parts[i]
+		if p.generate != nil {
+			return p.generate()
+		}
+		if p.voice != current {
+			if err := s.UpdateOptions(&SynthesisOptions{VoiceID: p.voice}); err != nil {
+				return nil, err
+			}
+			current = p.voice
+		}
+		stream, err := s.synthesizeRound(roundContext(ctx, i, len(parts)), p.text)
+		if err != nil {
+			return nil, err
+		}
+		// 最后一轮合成提交后恢复原音色，不影响之后的合成
+		if i == last && current != base && base != "" {
+			if err := s.UpdateOptions(&SynthesisOptions{VoiceID: base}); err != nil {
+				slog.Warn("Restore voice after script failed", "component", "tts", "voice", base, "error", err)
+			}
+		}
+		return stream, nil
+	}
+
+	script := &ScriptStream{}
+	out, err := s.concatRounds(ctx, len(parts), submit, func(i int) string {
+		return fmt.Sprintf("line %d", parts[i].line+1)
+	}, func(i int, offset int64) {
+		if !parts[i].first {
+			return
+		}
+		script.mu.Lock()
+		script.offsets = append(script.offsets, LineOffset{
+			Line:  parts[i].line,
+			Voice: parts[i].voice,
+			Bytes: offset,
+		})
+		script.mu.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+	script.AudioStream = out
+
+	slog.Info("Script submitted", "component", "tts", "lines", len(lines), "rounds", len(parts), "id", s.ID)
+	return script, nil
+}
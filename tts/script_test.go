@@ -0,0 +1,124 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestSynthesizeScriptSwitchesVoices 验证：剧本逐句通过 session.update 切换音色，音频按台词顺序拼接，
+// Offsets 给出每句的起始位置，结束后会话恢复原音色。
+// WHY：对话/播客生成原先需要为每个角色各开一个会话再手工拼接、计算时间轴。
+func TestSynthesizeScriptSwitchesVoices(t *testing.T) {
+	voice := "A"
+	var voices []string
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, msg map[string]any) {
+		switch protocol.MessageType(msgType) {
+		case protocol.MessageTypeSessionUpdate:
+			params, _ := msg["session"].(map[string]any)
+			voice, _ = params["voice_id"].(string)
+		case protocol.MessageTypeInputCommit:
+			voices = append(voices, voice)
+			writeDelta(ws, roundID, voice[0]-'A'+1)
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		}
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", VoiceID: "A", AudioFormat: "pcm", SampleRate: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	stream, err := session.SynthesizeScript(ctx, []Line{
+		{Text: "你好"},
+		{Voice: "B", Text: "你好，我是 B"},
+		{Text: "再见"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(append(bytes.Repeat([]byte{1}, 320), bytes.Repeat([]byte{2}, 320)...), bytes.Repeat([]byte{1}, 320)...)
+	if !bytes.Equal(data, want) {
+		t.Fatalf("concatenated audio mismatch (%d bytes), round voices %v", len(data), voices)
+	}
+	offsets := stream.Offsets()
+	if len(offsets) != 3 || offsets[1].Bytes != 320 || offsets[1].Voice != "B" || offsets[2].Start != 40*time.Millisecond {
+		t.Fatalf("unexpected offsets: %+v", offsets)
+	}
+	if got := session.Options().VoiceID; got != "A" {
+		t.Fatalf("voice after script = %q, want A restored", got)
+	}
+}
+
+// TestSynthesizeScriptMixesGeneratedAudio 验证：剧本中的停顿与提示音按会话输出格式生成并按序拼接，
+// 最后一行为生成音频时仍在最后一轮合成后恢复原音色。
+// WHY：IVR 流程靠同一个拼接流混排提示语、停顿和提示音，长度或顺序错位会让按键提示与语音对不上。
+func TestSynthesizeScriptMixesGeneratedAudio(t *testing.T) {
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, msg map[string]any) {
+		if protocol.MessageType(msgType) == protocol.MessageTypeInputCommit {
+			writeDelta(ws, roundID, 1)
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		}
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", VoiceID: "A", AudioFormat: "pcm", SampleRate: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	stream, err := session.SynthesizeScript(ctx, []Line{
+		{Voice: "B", Text: "请按 1"},
+		{Duration: 250 * time.Millisecond},
+		{Duration: 100 * time.Millisecond, Tone: 1000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 8kHz 16-bit：250ms 静音 4000 字节，100ms 提示音 1600 字节
+	if len(data) != 320+4000+1600 {
+		t.Fatalf("script audio = %d bytes, want %d", len(data), 320+4000+1600)
+	}
+	if !bytes.Equal(data[320:4320], make([]byte, 4000)) {
+		t.Fatal("pause is not silent")
+	}
+	if bytes.Equal(data[4320:], make([]byte, 1600)) {
+		t.Fatal("tone is silent")
+	}
+	offsets := stream.Offsets()
+	if len(offsets) != 3 || offsets[1].Start != 20*time.Millisecond || offsets[2].Start != 270*time.Millisecond {
+		t.Fatalf("unexpected offsets: %+v", offsets)
+	}
+	if got := session.Options().VoiceID; got != "A" {
+		t.Fatalf("voice after script = %q, want A restored", got)
+	}
+}
@@ -0,0 +1,251 @@
+// Package tts 长文本切分与多段拼接
+package tts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxTextLength 单轮合成默认最大文本长度（字符数）
+// 超过该长度的文本会按句切分为多轮合成，再拼接为一个连续的 AudioStream
+const DefaultMaxTextLength = 500
+
+// SplitText 按句子边界切分长文本，每段不超过 maxLen 个字符（rune）
+//
+// 切分优先级：句末标点（. ! ? ; 。！？；… 换行）> 子句标点（, ， 、 : ：）> 空白 > 强制截断。
+// 相邻短句会合并到同一段，尽量减少合成轮次。maxLen <= 0 时不切分。
+func SplitText(text string, maxLen int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if maxLen <= 0 || utf8.RuneCountInString(text) <= maxLen {
+		return []string{text}
+	}
+
+	// 1. 切句；超长句子继续细分
+	var pieces []string
+	for _, sentence := range splitSentences(text) {
+		if utf8.RuneCountInString(sentence) <= maxLen {
+			pieces = append(pieces, sentence)
+			continue
+		}
+		pieces = append(pieces, splitLongSentence(sentence, maxLen)...)
+	}
+
+	// 2. 贪心合并为不超过 maxLen 的段
+	var segments []string
+	var cur strings.Builder
+	curLen := 0
+	flush := func() {
+		if seg := strings.TrimSpace(cur.String()); seg != "" {
+			segments = append(segments, seg)
+		}
+		cur.Reset()
+		curLen = 0
+	}
+	for _, p := range pieces {
+		n := utf8.RuneCountInString(p)
+		if curLen > 0 && curLen+n > maxLen {
+			flush()
+		}
+		cur.WriteString(p)
+		curLen += n
+	}
+	flush()
+
+	return segments
+}
+
+// splitSentences 按句末标点切句（标点、紧随的引号/括号以及空白归属前一句）
+func splitSentences(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !isSentenceEnd(runes, i) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isClosingPunct(runes[end]) {
+			end++
+		}
+		for end < len(runes) && unicode.IsSpace(runes[end]) {
+			end++
+		}
+		sentences = append(sentences, string(runes[start:end]))
+		start = end
+		i = end - 1
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+	return sentences
+}
+
+// splitLongSentence 将超长句子依次按子句标点、空白、字符数切分
+func splitLongSentence(sentence string, maxLen int) []string {
+	runes := []rune(sentence)
+	var parts []string
+	for len(runes) > maxLen {
+		cut := lastBreak(runes[:maxLen], isClauseBreak)
+		if cut <= 0 {
+			cut = lastBreak(runes[:maxLen], unicode.IsSpace)
+		}
+		if cut <= 0 {
+			cut = maxLen
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
+
+// lastBreak 返回最后一个满足条件的字符之后的位置，找不到返回 -1
+func lastBreak(runes []rune, match func(rune) bool) int {
+	for i := len(runes) - 1; i > 0; i-- {
+		if match(runes[i]) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isSentenceEnd 判断 runes[i] 是否为句末
+// ASCII 句点/问号等需后跟空白或文本结束（避免切开 3.14、e.g. 等），中文标点直接断句
+func isSentenceEnd(runes []rune, i int) bool {
+	switch runes[i] {
+	case '。', '！', '？', '；', '…', '\n':
+		return true
+	case '.', '!', '?', ';':
+		j := i + 1
+		for j < len(runes) && isClosingPunct(runes[j]) {
+			j++
+		}
+		return j >= len(runes) || unicode.IsSpace(runes[j])
+	}
+	return false
+}
+
+// isClauseBreak 子句分隔符
+func isClauseBreak(r rune) bool {
+	switch r {
+	case ',', '，', '、', ':', '：':
+		return true
+	}
+	return false
+}
+
+// isClosingPunct 句末标点后可能紧随的闭合符号
+func isClosingPunct(r rune) bool {
+	switch r {
+	case '"', '\'', ')', ']', '”', '’', '）', '」', '』', '》':
+		return true
+	}
+	return false
+}
+
+// synthesizeSegments 多段合成并拼接为一个连续的 AudioStream
+func (s *Session) synthesizeSegments(ctx context.Context, segments []string) (*AudioStream, error) {
+	out, err := s.concatRounds(ctx, len(segments), func(i int) (*AudioStream, error) {
+		return s.synthesizeRound(roundContext(ctx, i, len(segments)), segments[i])
+	}, func(i int) string {
+		return fmt.Sprintf("segment %d/%d", i+1, len(segments))
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Long text segmented", "component", "tts", "segments", len(segments), "id", s.ID)
+	return out, nil
+}
+
+// concatRounds 依次提交 n 轮合成并拼接为一个连续的 AudioStream
+//
+// 始终保持一轮的预提交（当前轮接收音频时，下一轮已在服务端排队），
+// 轮间不产生额外等待；任一轮失败则整体以该错误结束（错误以 name(i) 为前缀）。
+// onStart 在第 i 轮的音频开始写入 out 前调用，offset 为此前已写入的字节数（可为 nil）。
+func (s *Session) concatRounds(ctx context.Context, n int, submit func(i int) (*AudioStream, error),
+	name func(i int) string, onStart func(i int, offset int64)) (*AudioStream, error) {
+	first, err := submit(0)
+	if err != nil {
+		return nil, err
+	}
+
+	out := s.newStream()
+	s.applyBudget(out)
+	out.requestID = RequestIDFromContext(ctx)
+	out.setCommitSentAt(first.CommitSentAt())
+
+	go func() {
+		current := first
+		seq := 0
+		var offset int64
+		for i := 0; i < n; i++ {
+			// 预提交下一轮
+			var next *AudioStream
+			if i+1 < n {
+				var err error
+				next, err = submit(i + 1)
+				if err != nil {
+					current.Close()
+					out.pushError(fmt.Errorf("%s: %w", name(i+1), err))
+					return
+				}
+			}
+
+			if onStart != nil {
+				onStart(i, offset)
+			}
+			written, ok := forwardStream(out, current, &seq, offset)
+			offset += written
+			out.addUsage(current.Usage())
+			if !ok {
+				current.Close()
+				if next != nil {
+					next.Close()
+				}
+				if err := current.Error(); err != nil {
+					out.pushError(fmt.Errorf("%s: %w", name(i), err))
+				}
+				return
+			}
+
+			if next == nil {
+				break
+			}
+			current = next
+		}
+		out.pushDone()
+	}()
+
+	return out, nil
+}
+
+// forwardStream 将分段 stream 的音频转发到拼接后的 out，返回转发的字节数
+// base 为此前已写入 out 的字节数（用于换算 MediaOffset）
+// ok 为 false 表示分段出错或 out 已被关闭，应停止后续转发
+func forwardStream(out, seg *AudioStream, seq *int, base int64) (written int64, ok bool) {
+	for chunk := range seg.Chunks() {
+		if chunk.Error != nil {
+			return written, false
+		}
+		if chunk.IsDone {
+			break
+		}
+		out.markFirstChunkAt(seg.FirstChunkReceivedAt())
+		*seq++
+		if !out.pushChunk(AudioChunk{Data: chunk.Data, Sequence: *seq, ReceivedAt: chunk.ReceivedAt,
+			MediaOffset: out.pcmDuration(base + written)}) {
+			return written, false
+		}
+		written += int64(len(chunk.Data))
+	}
+	return written, seg.Error() == nil
+}
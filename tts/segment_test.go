@@ -0,0 +1,62 @@
+package tts
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestSplitTextShortUnchanged 验证：未超长的文本原样作为单段返回。
+func TestSplitTextShortUnchanged(t *testing.T) {
+	got := SplitText("  Hello world.  ", 100)
+	if len(got) != 1 || got[0] != "Hello world." {
+		t.Fatalf("unexpected segments: %q", got)
+	}
+}
+
+// TestSplitTextSentenceBoundaries 验证：按中英文句末标点切分、相邻短句合并，且每段不超过上限。
+func TestSplitTextSentenceBoundaries(t *testing.T) {
+	text := "第一句话。第二句话！Third sentence here. Fourth one? 第五句。"
+	got := SplitText(text, 12)
+	for _, seg := range got {
+		if n := utf8.RuneCountInString(seg); n > 12 {
+			t.Fatalf("segment %q has %d runes, exceeds limit", seg, n)
+		}
+	}
+	if got[0] != "第一句话。第二句话！" {
+		t.Fatalf("expected short sentences merged into first segment, got %q", got[0])
+	}
+	joined := strings.ReplaceAll(strings.Join(got, ""), " ", "")
+	if joined != strings.ReplaceAll(text, " ", "") {
+		t.Fatalf("content lost: %q", got)
+	}
+}
+
+// TestSplitTextKeepsDecimals 验证：数字中的小数点不被当作句末。
+// WHY：en-NG 文本常含金额（"N2.50"），错误切分会让 provider 把数字读成两段。
+func TestSplitTextKeepsDecimals(t *testing.T) {
+	got := SplitText("The price is 2.50 naira. Pay now.", 28)
+	if len(got) != 2 || got[0] != "The price is 2.50 naira." {
+		t.Fatalf("unexpected segments: %q", got)
+	}
+}
+
+// TestSplitTextHardCut 验证：无任何标点/空白的超长文本按字符数强制切分，不丢字符。
+func TestSplitTextHardCut(t *testing.T) {
+	text := strings.Repeat("字", 25)
+	got := SplitText(text, 10)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 segments, got %d: %q", len(got), got)
+	}
+	if strings.Join(got, "") != text {
+		t.Fatalf("content lost after hard cut")
+	}
+}
+
+// TestSplitTextDisabled 验证：maxLen <= 0 时不切分。
+func TestSplitTextDisabled(t *testing.T) {
+	text := strings.Repeat("a. ", 100)
+	if got := SplitText(text, -1); len(got) != 1 {
+		t.Fatalf("expected no split, got %d segments", len(got))
+	}
+}
@@ -3,12 +3,14 @@ package tts
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
 	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
 	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
 )
@@ -18,6 +20,9 @@ const (
 	// 仅当调用方传入的 ctx 无 deadline 时兜底套用，避免 gateway waitConfigDone 永久挂起（timeout=error）。
 	// 须 > gateway warmupBudget(25s)，否则成功路径会被建连超时误杀。
 	defaultEstablishTimeout = 30 * time.Second
+
+	// DefaultCloseTimeout Close 等待 gateway Close 帧的默认时间
+	DefaultCloseTimeout = 2 * time.Second
 )
 
 // Session TTS会话（支持多轮合成）
@@ -25,10 +30,12 @@ type Session struct {
 	ID        string // 会话ID
 	Provider  string // 提供商
 	conn      *transport.Conn
+	dial      func(ctx context.Context) (*transport.Conn, error) // 建立新连接（断线续传用，可为 nil）
 	config    *Config
 	opts      *SynthesisOptions
 	closeCh   chan struct{}
 	closeOnce sync.Once
+	closeErr  error // 首次 Close 的结果
 	mu        sync.Mutex
 	ready     bool
 	closed    bool
@@ -38,6 +45,9 @@ type Session struct {
 	configDone   bool            // 配置是否完成
 	configDoneCh chan struct{}   // config_done 信号
 	configDoneAt time.Time       // config_done 收到时间
+	features     protocol.Features // 协商后的功能标志（每次 config_done 更新）
+	affinity     string            // gateway 返回的会话亲和令牌（每次 config_done 更新，重连时回传）
+	resumeToken  string            // session.ready 返回的会话恢复令牌（重连时以 session.resume 接回 gateway 侧的会话）
 
 	// 多轮合成支持（管道化：支持快速连续提交，服务端 FIFO 按序合成）
 	ctx         context.Context
@@ -46,6 +56,19 @@ type Session struct {
 	streamMu    sync.Mutex
 	roundCount  int              // 合成轮次计数
 
+	// 连接生命周期：messageLoop 因连接断开退出后置 disconnected，下一轮合成时重连
+	loopCtx      context.Context // messageLoop 的生命周期 ctx（重连后沿用）
+	loopDone     chan struct{}   // 当前 messageLoop 退出时关闭
+	disconnected bool            // 连接已断开且 messageLoop 已退出
+	redialMu     sync.Mutex      // 串行化空闲重连
+
+	stall stallState // 卡住检测（见 watchdog）
+
+	onRawMessage func(msgType protocol.MessageType, data []byte) // 原始消息回调（见 OnRawMessage）
+
+	// 建连与握手的耗时（Config.LatencyBudget），由会话首轮的 stream 取走
+	setup []transport.PhaseLatency
+
 	// 时间记录（TTFB 已下沉到每个 AudioStream 独立追踪）
 }
 
@@ -64,6 +87,11 @@ func newSession(conn *transport.Conn, config *Config, opts *SynthesisOptions) *S
 	}
 }
 
+// clock 返回会话的时钟
+func (s *Session) clock() clock.Clock {
+	return clock.Or(s.config.Clock)
+}
+
 // establishCtx 给建连阶段套默认超时：调用方未带 deadline 时派生带 def 超时的 ctx；
 // 已带 deadline 则原样返回（尊重调用方）。返回的 cancel 始终可安全调用。
 func establishCtx(parent context.Context, def time.Duration) (context.Context, context.CancelFunc) {
@@ -80,72 +108,105 @@ func (s *Session) start(ctx context.Context) error {
 	defer cancel()
 
 	// 等待session.ready
-	if err := s.waitReady(estCtx); err != nil {
+	if err := s.waitReady(estCtx, s.conn); err != nil {
 		return err
 	}
 
 	// 发送session.config
-	if err := s.sendConfig(); err != nil {
+	if err := s.sendConfig(s.conn); err != nil {
 		return err
 	}
 
 	// 启动消息处理循环（需要先启动，才能接收 config_done）
 	// ⚠️ 必须用原始 ctx（会话生命周期），绝不能用 estCtx，否则建连超时会误杀整个消息循环
-	go s.messageLoop(ctx)
+	s.loopCtx = ctx
+	s.startMessageLoop()
 
 	// 等待 session.config_done
 	if err := s.waitConfigDone(estCtx); err != nil {
 		return err
 	}
 
+	if d := s.config.KeepaliveInterval; d > 0 {
+		s.every(d, s.keepalive)
+	}
+	if d := s.config.StallTimeout; d > 0 {
+		s.every(stallCheckInterval(d), s.watchdog)
+	}
+
 	return nil
 }
 
 // waitReady 等待会话就绪
-func (s *Session) waitReady(ctx context.Context) error {
-	data, err := s.conn.Receive(ctx)
+func (s *Session) waitReady(ctx context.Context, conn *transport.Conn) error {
+	ready, err := readReady(ctx, conn)
 	if err != nil {
-		return fmt.Errorf("wait session.ready: %w", err)
+		return err
+	}
+	s.adoptReady(ready)
+	return nil
+}
+
+// adoptReady 记录新 gateway 会话的 ID 与恢复令牌（恢复时在消息循环中调用，须持 s.mu 写入）
+func (s *Session) adoptReady(ready *protocol.SessionReady) {
+	s.mu.Lock()
+	s.ID = ready.SessionID
+	s.ready = true
+	s.resumeToken = ready.ResumeToken
+	s.mu.Unlock()
+
+	slog.Info("Session ready", "component", "tts", "id", ready.SessionID, "provider", s.Provider)
+}
+
+// readReady 接收 conn 上的第一条消息，应为 session.ready
+func readReady(ctx context.Context, conn *transport.Conn) (*protocol.SessionReady, error) {
+	data, err := conn.Receive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("wait session.ready: %w", err)
 	}
 
 	msgType, err := transport.ParseMessageType(data)
 	if err != nil {
-		return fmt.Errorf("parse session.ready: %w", err)
+		return nil, fmt.Errorf("parse session.ready: %w", err)
 	}
 
 	if msgType != protocol.MessageTypeSessionReady {
-		return fmt.Errorf("expected session.ready, got %s", msgType)
+		return nil, fmt.Errorf("expected session.ready, got %s", msgType)
 	}
 
 	msg, err := transport.ParseMessage(data)
 	if err != nil {
-		return fmt.Errorf("parse session.ready body: %w", err)
+		return nil, fmt.Errorf("parse session.ready body: %w", err)
 	}
-
-	ready := msg.(*protocol.SessionReady)
-	s.ID = ready.SessionID
-	s.ready = true
-
-	slog.Info("Session ready", "component", "tts", "id", s.ID, "provider", s.Provider)
-
-	return nil
+	return msg.(*protocol.SessionReady), nil
 }
 
-// sendConfig 发送会话配置
-func (s *Session) sendConfig() error {
+// sendConfig 发送会话配置（使用会话当前参数，含 UpdateOptions 的变更）
+func (s *Session) sendConfig(conn *transport.Conn) error {
+	opts := s.Options()
+	voice, err := s.config.VoiceAliases.resolve(s.Provider, opts.VoiceID)
+	if err != nil {
+		return err
+	}
 	params := protocol.SessionParams{
 		Provider:    s.Provider,
-		VoiceID:     s.opts.VoiceID,
-		Language:    s.opts.Language,
-		Speed:       s.opts.Speed,
-		Pitch:       s.opts.Pitch,
-		Volume:      s.opts.Volume,
-		SampleRate:  s.opts.SampleRate,
-		AudioFormat: s.opts.AudioFormat,
+		VoiceID:     voice,
+		Language:    opts.Language,
+		Speed:       opts.Speed,
+		Pitch:       opts.Pitch,
+		Volume:      opts.Volume,
+		SampleRate:  opts.SampleRate,
+		AudioFormat: opts.AudioFormat,
+		Priority:    s.config.Priority,
+	}
+	params.AffinityToken = s.AffinityToken()
+	if params.AffinityToken == "" {
+		params.AffinityToken = s.config.AffinityToken
 	}
 
-	msg := transport.NewSessionConfig(params)
-	return s.conn.SendJSON(msg)
+	msg := transport.NewSessionConfig(params, s.config.Features...)
+	transport.AdvertiseCodec(msg, s.config.codec())
+	return conn.Send(msg)
 }
 
 // waitConfigDone 等待配置完成
@@ -159,7 +220,8 @@ func (s *Session) waitConfigDone(ctx context.Context) error {
 }
 
 // messageLoop 消息处理循环
-func (s *Session) messageLoop(ctx context.Context) {
+func (s *Session) messageLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
 	for {
 		select {
 		case <-ctx.Done():
@@ -169,14 +231,31 @@ func (s *Session) messageLoop(ctx context.Context) {
 		case <-s.closeCh:
 			return
 		case err := <-s.conn.ErrorChan():
-			s.handleStreamError(err)
-			return
+			if s.connLost(ctx, err) {
+				return
+			}
+		case <-s.conn.CloseChan():
+			// gateway 主动关闭（如空闲超时）：关闭流程中属正常结束
+			if s.IsClosed() || s.connLost(ctx, closedByGateway(s.conn)) {
+				return
+			}
 		case data := <-s.conn.ReceiveChan():
 			s.handleMessage(data)
 		}
 	}
 }
 
+// OnRawMessage 设置原始消息回调：此后每条 gateway 消息在 SDK 处理之前以其类型和原始内容回调（nil 取消）
+//
+// 用于记录、持久化或自行处理 SDK 尚未建模的消息类型（设置回调后这类消息不再记录 Warn 日志）。
+// 在消息处理 goroutine 中同步调用，不应阻塞；data 为线上编码（JSON，或协商后的 MessagePack），回调返回后不得保留，
+// 需要时复制。握手阶段（session.ready、创建会话时的 config_done）的消息在设置之前已处理，不会回调
+func (s *Session) OnRawMessage(fn func(msgType protocol.MessageType, data []byte)) {
+	s.mu.Lock()
+	s.onRawMessage = fn
+	s.mu.Unlock()
+}
+
 // handleMessage 处理消息
 func (s *Session) handleMessage(data []byte) {
 	msgType, err := transport.ParseMessageType(data)
@@ -185,26 +264,36 @@ func (s *Session) handleMessage(data []byte) {
 		return
 	}
 
+	s.mu.Lock()
+	onRaw := s.onRawMessage
+	s.mu.Unlock()
+	if onRaw != nil {
+		onRaw(msgType, data)
+	}
+
 	switch msgType {
 	case protocol.MessageTypeSessionConfigDone:
-		s.handleConfigDone()
+		s.handleConfigDone(data)
 	case protocol.MessageTypeAudioDelta:
 		s.handleAudioDelta(data)
 	case protocol.MessageTypeAudioDone:
-		s.handleAudioDone()
+		s.handleAudioDone(data)
 	case protocol.MessageTypeError:
 		s.handleError(data)
 	default:
-		slog.Warn("Unknown message type", "component", "tts", "type", msgType)
+		if onRaw == nil {
+			slog.Warn("Unknown message type", "component", "tts", "type", msgType)
+		}
 	}
 }
 
 // handleConfigDone 处理配置完成消息
-func (s *Session) handleConfigDone() {
+func (s *Session) handleConfigDone(data []byte) {
+	s.setFeatures(s.conn, data)
 	s.mu.Lock()
 	if !s.configDone {
 		s.configDone = true
-		s.configDoneAt = time.Now()
+		s.configDoneAt = s.clock().Now()
 		close(s.configDoneCh)
 	}
 	s.mu.Unlock()
@@ -214,36 +303,84 @@ func (s *Session) handleConfigDone() {
 
 // handleAudioDelta 处理音频数据块
 func (s *Session) handleAudioDelta(data []byte) {
-	msg, err := transport.ParseMessage(data)
+	delta, err := transport.ParseAudioDelta(data)
 	if err != nil {
 		slog.Error("Parse audio.delta error", "component", "tts", "error", err)
 		return
 	}
 
-	delta := msg.(*protocol.AudioDelta)
-
-	// Base64解码
-	audioData, err := base64.StdEncoding.DecodeString(delta.Audio)
+	// 按 round_id 路由；服务端未回显时推送到队列头部（FIFO：服务端按序返回，头部即当前轮）
+	stream := s.lookupStream(delta.RoundID, false)
+
+	// 解码结果会原样交给调用方时单独分配；丢弃或经重采样/归一化复制时解码到复用缓冲区
+	var decoded []byte
+	if stream == nil || stream.copiesAudio() {
+		buf := deltaBufPool.Get().(*[]byte)
+		defer putDeltaBuf(buf, &decoded)
+		decoded, err = delta.AppendAudio((*buf)[:0])
+	} else {
+		decoded, err = delta.AppendAudio(nil)
+	}
 	if err != nil {
 		slog.Error("Decode audio error", "component", "tts", "error", err)
 		return
 	}
+	audioData := decoded
 
 	s.seqNum++
 
-	// 推送到队列头部的 stream（FIFO：服务端按序返回，头部即当前轮）
-	s.streamMu.Lock()
-	var stream *AudioStream
-	if len(s.streamQueue) > 0 {
-		stream = s.streamQueue[0]
+	if stream != nil {
+		// 续传后的首块：按服务端回显的 offset_ms 计算与已交付音频的重叠量
+		if stream.resumePending {
+			stream.resumePending = false
+			stream.resumeSkip = stream.delivered - s.offsetBytes(delta.OffsetMs)
+			if stream.resumeSkip < 0 {
+				slog.Warn("Resumed audio starts past delivered position", "component", "tts",
+					"round_id", stream.roundID, "gap_bytes", -stream.resumeSkip, "id", s.ID)
+				stream.resumeSkip = 0
+			}
+		}
+		audioData = stream.trimResumed(audioData)
+		if stream.resampler != nil {
+			audioData = stream.resampler.Process(audioData)
+		}
+		s.deliverAudio(stream, audioData)
 	}
-	s.streamMu.Unlock()
+}
 
-	if stream != nil {
-		// 记录本轮首包接收时间（每个 stream 独立追踪）
-		stream.markFirstChunk()
-		stream.pushData(audioData, s.seqNum)
+// deltaBufPool audio.delta 解码缓冲区（跨会话复用，减少高并发下每块音频的分配）
+var deltaBufPool = sync.Pool{New: func() any {
+	buf := make([]byte, 0, 16*1024)
+	return &buf
+}}
+
+// maxPooledDeltaBuf 超过此容量的缓冲区不放回池中，避免偶发的大块音频长期占用内存
+const maxPooledDeltaBuf = 1 << 20
+
+// putDeltaBuf 归还解码缓冲区（保留解码时扩容后的底层数组）
+func putDeltaBuf(buf *[]byte, decoded *[]byte) {
+	if cap(*decoded) > cap(*buf) {
+		*buf = *decoded
+	}
+	if cap(*buf) > maxPooledDeltaBuf {
+		return
 	}
+	*buf = (*buf)[:0]
+	deltaBufPool.Put(buf)
+}
+
+// deliverAudio 归一化后推送一块音频（重采样、续传去重之后）
+func (s *Session) deliverAudio(stream *AudioStream, audioData []byte) {
+	if stream.normalizer != nil {
+		audioData = stream.normalizer.Process(audioData)
+	}
+	if len(audioData) == 0 {
+		return
+	}
+
+	// 记录本轮首包接收时间（每个 stream 独立追踪）
+	stream.markFirstChunk()
+	stream.pushData(audioData, s.seqNum)
 }
 
 // handleError 处理错误消息
@@ -257,36 +394,117 @@ func (s *Session) handleError(data []byte) {
 	errMsg := msg.(*protocol.ErrorMessage)
 	synthErr := fmt.Errorf("[%s] %s", errMsg.Code, errMsg.Message)
 
-	// 推送错误到队列头部的 stream，并弹出
-	s.streamMu.Lock()
-	var stream *AudioStream
-	if len(s.streamQueue) > 0 {
-		stream = s.streamQueue[0]
-		s.streamQueue = s.streamQueue[1:]
-	}
-	s.streamMu.Unlock()
+	// 推送错误到对应轮次（无 round_id 时为队列头部）的 stream，并弹出
+	stream := s.lookupStream(errMsg.RoundID, true)
 
 	if stream != nil {
 		stream.pushError(synthErr)
 	}
 }
 
-// handleAudioDone 处理合成完成（管道化：弹出队列头部 stream）
-func (s *Session) handleAudioDone() {
-	s.streamMu.Lock()
-	var stream *AudioStream
-	if len(s.streamQueue) > 0 {
-		stream = s.streamQueue[0]
-		s.streamQueue = s.streamQueue[1:]
+// handleAudioDone 处理合成完成（管道化：弹出对应轮次的 stream，无 round_id 时为队列头部）
+func (s *Session) handleAudioDone(data []byte) {
+	msg, err := transport.ParseMessage(data)
+	if err != nil {
+		slog.Error("Parse audio.done error", "component", "tts", "error", err)
+		return
 	}
-	pending := len(s.streamQueue)
-	s.streamMu.Unlock()
+	done := msg.(*protocol.AudioDone)
+
+	stream := s.lookupStream(done.RoundID, true)
+	pending := s.PendingRounds()
 
 	if stream != nil {
+		if stream.resampler != nil {
+			s.deliverAudio(stream, stream.resampler.Flush())
+		}
+		stream.addUsage(done.Usage)
 		stream.pushDone()
 	}
 
-	slog.Info("Round completed", "component", "tts", "round", s.roundCount, "pending", pending, "id", s.ID)
+	slog.Info("Round completed", "component", "tts", "round_id", done.RoundID, "pending", pending, "id", s.ID)
+}
+
+// lookupStream 查找 round_id 对应的 stream（roundID 为空时取队列头部；未匹配时返回 nil，不误归到其他轮次）
+// pop 为 true 时同时将其移出队列
+func (s *Session) lookupStream(roundID string, pop bool) *AudioStream {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+
+	if len(s.streamQueue) == 0 {
+		return nil
+	}
+	idx := 0
+	if roundID != "" {
+		idx = -1
+		for i, st := range s.streamQueue {
+			if st.roundID == roundID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			// 未知或已结束的轮次（如取消后迟到的消息）：丢弃，避免串到头部轮次或把它提前结束
+			slog.Warn("Message for unknown round dropped", "component", "tts", "round_id", roundID, "id", s.ID)
+			return nil
+		}
+	}
+	stream := s.streamQueue[idx]
+	if pop {
+		s.removeStreamLocked(idx)
+	}
+	return stream
+}
+
+// removeStreamLocked 从队列中移除第 idx 个 stream（须持 streamMu）
+// 移除的是头部时下一轮开始接收，启动其首包计时
+func (s *Session) removeStreamLocked(idx int) {
+	s.streamQueue = append(s.streamQueue[:idx], s.streamQueue[idx+1:]...)
+	if idx == 0 && len(s.streamQueue) > 0 {
+		s.watchFirstChunk(s.streamQueue[0])
+	}
+}
+
+// dropRound 移除提交失败的 stream（轮次号不回收：失败的 round_id 可能已到达 gateway）
+func (s *Session) dropRound(stream *AudioStream) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	if idx := slices.Index(s.streamQueue, stream); idx >= 0 {
+		s.removeStreamLocked(idx)
+	}
+}
+
+// watchFirstChunk 轮次成为队列头部（服务端开始合成）后启动首包计时
+// 管道化下排队中的轮次需等待前面的轮次合成完，不从 commit 起算
+func (s *Session) watchFirstChunk(stream *AudioStream) {
+	d := stream.firstChunkTimeout
+	if d <= 0 {
+		return
+	}
+	timer := s.clock().NewTimer(d)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+		case <-stream.firstChunkCh:
+			return
+		case <-stream.doneQueuedCh: // 服务端已合成完，音频排在积压中尚未处理
+			return
+		case <-stream.closeCh:
+			return
+		case <-s.closeCh:
+			return
+		}
+
+		err := fmt.Errorf("round %s: no audio within %v: %w", stream.roundID, d, ErrTimeout)
+		if !stream.abort(err) {
+			return
+		}
+		slog.Warn("First chunk timeout", "component", "tts", "round_id", stream.roundID, "timeout", d, "id", s.ID)
+		if err := s.cancelRound(stream); err != nil {
+			slog.Warn("Cancel timed out round failed", "component", "tts", "round_id", stream.roundID, "error", err)
+		}
+	}()
 }
 
 // handleStreamError 处理流错误（连接级错误：所有排队中的 stream 都收到错误）
@@ -304,7 +522,59 @@ func (s *Session) handleStreamError(err error) {
 // SynthesizeStream 合成下一段文本（管道化模式）
 // 可连续快速调用多次，不需要等待上一轮完成。服务端按 FIFO 顺序合成。
 // 每次调用返回独立的 AudioStream，调用方通过 stream.Read 获取对应轮次的音频。
+// 文本超过 Config.MaxTextLength 时自动按句切分为多轮，拼接为一个连续的 AudioStream。
 func (s *Session) SynthesizeStream(ctx context.Context, text string) (*AudioStream, error) {
+	if segments := SplitText(text, s.config.MaxTextLength); len(segments) > 1 {
+		return s.synthesizeSegments(ctx, segments)
+	}
+	return s.synthesizeRound(ctx, text)
+}
+
+// newStream 创建本会话的音频流
+func (s *Session) newStream() *AudioStream {
+	stream := newAudioStream()
+	s.mu.Lock()
+	stream.sessionID = s.ID
+	s.mu.Unlock()
+	stream.maxBuffered = int64(s.config.MaxBufferBytes)
+	opts := s.Options()
+	stream.sampleRate = opts.SampleRate
+	stream.clock = s.clock()
+	stream.audioFormat = opts.AudioFormat
+	if rate := s.config.OutputSampleRate; rate > 0 && opts.AudioFormat == "pcm" {
+		stream.sampleRate = rate
+	}
+	stream.firstChunkTimeout = s.config.FirstChunkTimeout
+	if opts.FirstChunkTimeout != 0 {
+		stream.firstChunkTimeout = opts.FirstChunkTimeout
+	}
+	return stream
+}
+
+// applyBudget 为调用方拿到的 stream 开启时延预算（拼接流的内部轮次不单独计算）；会话首轮计入建连与握手耗时
+func (s *Session) applyBudget(stream *AudioStream) {
+	if s.config.LatencyBudget <= 0 {
+		return
+	}
+	s.mu.Lock()
+	setup := s.setup
+	s.setup = nil
+	s.mu.Unlock()
+
+	stream.timeMu.Lock()
+	stream.budget = s.config.LatencyBudget
+	stream.setup = setup
+	stream.onBudget = s.config.OnBudgetExceeded
+	stream.timeMu.Unlock()
+}
+
+// synthesizeRound 提交单轮合成（text.append + input.commit）
+func (s *Session) synthesizeRound(ctx context.Context, text string) (*AudioStream, error) {
+	// 池化会话空闲时连接可能已被 gateway 断开：透明重连
+	if err := s.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	if s.closed {
 		s.mu.Unlock()
@@ -314,53 +584,209 @@ func (s *Session) SynthesizeStream(ctx context.Context, text string) (*AudioStre
 		s.mu.Unlock()
 		return nil, fmt.Errorf("session not ready")
 	}
+	conn := s.conn
 	s.mu.Unlock()
 
 	// 创建新的音频流并推入队列
-	stream := newAudioStream()
+	stream := s.newStream()
+	if !innerRound(ctx) {
+		s.applyBudget(stream)
+	}
+	stream.text = text
+	stream.requestID = RequestIDFromContext(ctx)
+	if rate := s.Options().SampleRate; rate > 0 && rate != stream.sampleRate {
+		stream.resampler = audio.NewResampler(rate, stream.sampleRate)
+	}
+	if s.config.NormalizeOutput && stream.audioFormat == "pcm" {
+		stream.normalizer = audio.NewNormalizer(s.config.TargetDBFS, stream.sampleRate)
+	}
 	s.streamMu.Lock()
-	s.streamQueue = append(s.streamQueue, stream)
 	s.roundCount++
 	round := s.roundCount
+	stream.roundID = fmt.Sprintf("r%d", round)
+	stream.cancelRound = func() error { return s.cancelRound(stream) }
+	s.streamQueue = append(s.streamQueue, stream)
+	head := len(s.streamQueue) == 1
 	s.streamMu.Unlock()
 
-	// 发送文本
+	// 发送文本（携带 round_id，服务端回显后可多轮并发交错返回）
 	textMsg := transport.NewTextAppend(text)
-	if err := s.conn.SendJSON(textMsg); err != nil {
-		s.streamMu.Lock()
-		// 从队列中移除刚加入的 stream
-		for i, st := range s.streamQueue {
-			if st == stream {
-				s.streamQueue = append(s.streamQueue[:i], s.streamQueue[i+1:]...)
-				break
-			}
-		}
-		s.streamMu.Unlock()
+	textMsg.RoundID = stream.roundID
+	if err := conn.Send(textMsg); err != nil {
+		s.dropRound(stream)
 		return nil, fmt.Errorf("send text: %w", err)
 	}
 
 	// 发送提交（记录 commit 时间到 stream 级别，管道化下每轮独立追踪）
-	commitTime := time.Now()
+	commitTime := s.clock().Now()
 	stream.setCommitSentAt(commitTime)
 
 	commitMsg := transport.NewInputCommit()
-	if err := s.conn.SendJSON(commitMsg); err != nil {
-		s.streamMu.Lock()
-		for i, st := range s.streamQueue {
-			if st == stream {
-				s.streamQueue = append(s.streamQueue[:i], s.streamQueue[i+1:]...)
-				break
-			}
-		}
-		s.streamMu.Unlock()
+	commitMsg.RoundID = stream.roundID
+	commitMsg.RequestID = stream.requestID
+	if err := conn.Send(commitMsg); err != nil {
+		s.dropRound(stream)
 		return nil, fmt.Errorf("commit: %w", err)
 	}
 
-	slog.Info("Round started", "component", "tts", "round", round, "text_len", len(text), "id", s.ID)
+	slog.Info("Round started", "component", "tts", "round", round, "round_id", stream.roundID, "request_id", stream.requestID, "text_len", len(text), "id", s.ID)
+
+	if head {
+		s.watchFirstChunk(stream)
+	}
 
 	return stream, nil
 }
 
+// cancelRound 通知 gateway 取消轮次（input.cancel）
+// stream 保留在队列中直到收到该轮 audio.done / error，期间到达的音频直接丢弃，
+// 旧版 gateway 不支持取消、照常发完剩余音频时 FIFO 归属仍然正确
+func (s *Session) cancelRound(stream *AudioStream) error {
+	s.mu.Lock()
+	conn, closed, lost := s.conn, s.closed, s.disconnected
+	s.mu.Unlock()
+	if closed || lost {
+		return nil
+	}
+
+	msg := transport.NewInputCancel()
+	msg.RoundID = stream.roundID
+	if err := conn.Send(msg); err != nil {
+		return fmt.Errorf("cancel round %s: %w", stream.roundID, err)
+	}
+	slog.Info("Round cancelled", "component", "tts", "round_id", stream.roundID, "id", s.ID)
+	return nil
+}
+
+// UpdateOptions 在轮次之间更新合成参数（语速、音量、音调、音色、语言、首包超时），无需重连
+// 仅 opts 中的非零字段生效，作用于之后提交的轮次；已排队的轮次仍按原参数合成。
+// 采样率与音频格式在会话内不可变更。
+func (s *Session) UpdateOptions(opts *SynthesisOptions) error {
+	if opts == nil {
+		return nil
+	}
+
+	params := protocol.SessionParams{
+		VoiceID:  opts.VoiceID,
+		Language: opts.Language,
+		Speed:    opts.Speed,
+		Pitch:    opts.Pitch,
+		Volume:   opts.Volume,
+	}
+	update := params.VoiceID != "" || params.Language != "" || params.Speed != 0 || params.Pitch != 0 || params.Volume != 0
+	if update {
+		// Options() 保留逻辑名，只有发给 gateway 的是解析后的音色 ID
+		voice, err := s.config.VoiceAliases.resolve(s.Provider, params.VoiceID)
+		if err != nil {
+			return err
+		}
+		params.VoiceID = voice
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return fmt.Errorf("session closed")
+	}
+	if !s.ready {
+		s.mu.Unlock()
+		return fmt.Errorf("session not ready")
+	}
+	if opts.SampleRate != 0 && opts.SampleRate != s.opts.SampleRate {
+		s.mu.Unlock()
+		return ErrInvalidConfig("SampleRate cannot be changed within a session")
+	}
+	if opts.AudioFormat != "" && opts.AudioFormat != s.opts.AudioFormat {
+		s.mu.Unlock()
+		return ErrInvalidConfig("AudioFormat cannot be changed within a session")
+	}
+	// 发送前先合并到会话当前参数：发送期间的重连（sendConfig）即按新值配置新连接
+	prev := *s.opts
+	merged := mergeUpdate(prev, opts)
+	s.opts = &merged
+	conn := s.conn
+	s.mu.Unlock()
+
+	// 发送时不持有 s.mu：写入阻塞（背压、网络抖动）不会卡住 Options() 与接收循环
+	for update {
+		err := conn.Send(transport.NewSessionUpdate(params))
+
+		s.mu.Lock()
+		// 发送期间连接被替换：新连接可能在合并前已完成配置（或恢复了原 gateway 会话），在新连接上重发
+		if cur := s.conn; cur != conn && !s.closed {
+			conn = cur
+			s.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			// 回滚本次合并的字段；并发更新已覆盖的字段保持不变
+			rolled := revertUpdate(*s.opts, prev, merged, opts)
+			s.opts = &rolled
+			s.mu.Unlock()
+			return fmt.Errorf("send session.update: %w", err)
+		}
+		s.mu.Unlock()
+		break
+	}
+
+	slog.Info("Session options updated", "component", "tts", "id", s.ID,
+		"voice", merged.VoiceID, "speed", merged.Speed, "volume", merged.Volume)
+	return nil
+}
+
+// mergeUpdate 把 UpdateOptions 的非零字段合并到 base（返回副本）
+func mergeUpdate(base SynthesisOptions, u *SynthesisOptions) SynthesisOptions {
+	if u.VoiceID != "" {
+		base.VoiceID = u.VoiceID
+	}
+	if u.Language != "" {
+		base.Language = u.Language
+	}
+	if u.Speed != 0 {
+		base.Speed = u.Speed
+	}
+	if u.Pitch != 0 {
+		base.Pitch = u.Pitch
+	}
+	if u.Volume != 0 {
+		base.Volume = u.Volume
+	}
+	if u.FirstChunkTimeout != 0 {
+		base.FirstChunkTimeout = u.FirstChunkTimeout // 仅客户端生效，无需 session.update
+	}
+	return base
+}
+
+// revertUpdate 撤销 mergeUpdate 对 cur 的修改：u 设置的字段仍为 merged 中的值时恢复为 prev（返回副本）
+func revertUpdate(cur, prev, merged SynthesisOptions, u *SynthesisOptions) SynthesisOptions {
+	if u.VoiceID != "" && cur.VoiceID == merged.VoiceID {
+		cur.VoiceID = prev.VoiceID
+	}
+	if u.Language != "" && cur.Language == merged.Language {
+		cur.Language = prev.Language
+	}
+	if u.Speed != 0 && cur.Speed == merged.Speed {
+		cur.Speed = prev.Speed
+	}
+	if u.Pitch != 0 && cur.Pitch == merged.Pitch {
+		cur.Pitch = prev.Pitch
+	}
+	if u.Volume != 0 && cur.Volume == merged.Volume {
+		cur.Volume = prev.Volume
+	}
+	if u.FirstChunkTimeout != 0 && cur.FirstChunkTimeout == merged.FirstChunkTimeout {
+		cur.FirstChunkTimeout = prev.FirstChunkTimeout
+	}
+	return cur
+}
+
+// Options 返回会话当前的合成参数（副本）
+func (s *Session) Options() SynthesisOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.opts
+}
+
 // RoundCount 返回已完成的轮次数
 func (s *Session) RoundCount() int {
 	s.streamMu.Lock()
@@ -395,7 +821,7 @@ func (s *Session) SendText(text string) error {
 	}
 
 	msg := transport.NewTextAppend(text)
-	return s.conn.SendJSON(msg)
+	return s.conn.Send(msg)
 }
 
 // Commit 提交文本，触发合成
@@ -408,47 +834,91 @@ func (s *Session) Commit() error {
 	}
 
 	msg := transport.NewInputCommit()
-	return s.conn.SendJSON(msg)
+	return s.conn.Send(msg)
 }
 
-// Close 关闭会话
+// Close 关闭会话（等待 gateway Close 帧最多 Config.CloseTimeout）
+// 正常关闭返回 nil；session.end 未送达、关闭握手未完成或有未完成轮次被丢弃时返回 *transport.CloseError
 func (s *Session) Close() error {
+	return s.CloseContext(context.Background())
+}
+
+// CloseContext 关闭会话；ctx 或创建会话时的 ctx 已取消（进程退出批量关闭）时跳过关闭握手等待
+// 重复调用返回首次关闭的结果
+func (s *Session) CloseContext(ctx context.Context) error {
 	s.closeOnce.Do(func() {
 		s.mu.Lock()
 		s.closed = true
+		conn := s.conn
+		idle := s.disconnected
 		s.mu.Unlock()
 
-		// 发送 session.end
-		msg := transport.NewSessionEnd()
-		s.conn.SendJSON(msg)
+		res := &transport.CloseError{SessionID: s.ID}
+		if idle {
+			// 空闲断开后尚未重连：没有需要结束的服务端会话
+			res.EndSent, res.SkippedWait = true, true
+		} else if err := conn.Send(transport.NewSessionEnd()); err != nil {
+			// session.end 未送达（连接已断），无需等待握手
+			res.EndErr, res.SkippedWait = err, true
+		} else {
+			res.EndSent = true
+		}
 
-		// 等待 Gateway 的 Close Frame（最多 2 秒）
+		// 等待 Gateway 的 Close Frame（最多 CloseTimeout）
 		// Gateway 会在处理完 session.end 后主动发送 Close Frame
 		// 这是正确的 WebSocket 关闭握手流程（RFC 6455）
-		select {
-		case <-s.conn.CloseChan():
-			// Gateway 已正常关闭连接
-		case <-time.After(2 * time.Second):
-			// 超时，强制关闭
-			slog.Warn("Close timeout, forcing close", "component", "tts", "id", s.ID)
+		// 正在关机时无需等待
+		if timeout := s.config.CloseTimeout; timeout <= 0 || s.shuttingDown(ctx) {
+			res.SkippedWait = true
+		}
+		if !res.SkippedWait {
+			select {
+			case <-conn.CloseChan():
+				// Gateway 已正常关闭连接
+				res.Handshake = true
+			case <-ctx.Done():
+				// 调用方放弃等待
+			case <-s.clock().After(s.config.CloseTimeout):
+				// 超时，强制关闭
+				slog.Warn("Close timeout, forcing close", "component", "tts", "id", s.ID, "timeout", s.config.CloseTimeout)
+			}
 		}
 
 		// 取消 context（通知 messageLoop 退出）
 		s.cancel()
 		// 关闭 session 自己的 closeCh
 		close(s.closeCh)
-		s.conn.Close()
+		if !idle {
+			res.CloseErr = conn.Close()
+		}
 
 		// 清理所有排队中的 stream
 		s.streamMu.Lock()
 		for _, stream := range s.streamQueue {
+			if !stream.isAborted() {
+				res.PendingRounds++
+			}
 			stream.pushDone()
 		}
 		s.streamQueue = nil
 		s.streamMu.Unlock()
+
+		s.closeErr = res.Err()
+		if s.closeErr != nil {
+			slog.Warn("Session closed abnormally", "component", "tts", "id", s.ID, "rounds", s.roundCount, "error", s.closeErr)
+			return
+		}
 		slog.Info("Session closed", "component", "tts", "id", s.ID, "rounds", s.roundCount)
 	})
-	return nil
+	return s.closeErr
+}
+
+// shuttingDown 关闭时的 ctx 或会话生命周期 ctx 已取消
+func (s *Session) shuttingDown(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	return s.loopCtx != nil && s.loopCtx.Err() != nil
 }
 
 // IsReady 检查会话是否就绪
@@ -465,14 +935,78 @@ func (s *Session) IsClosed() bool {
 	return s.closed
 }
 
-// ConnectDuration 返回建连耗时（从 Conn 获取）
+// ConnectDuration 返回建连耗时（从 Conn 获取；续传重连后为新连接的耗时）
 func (s *Session) ConnectDuration() time.Duration {
-	return s.conn.ConnectDuration()
+	return s.currentConn().ConnectDuration()
+}
+
+// Warm 当前连接是否取自预热池（见 Client.Warmup；此时 ConnectDuration 为 0）
+func (s *Session) Warm() bool {
+	return s.currentConn().Warm()
+}
+
+// ConnectAttempts 返回建连的各次尝试（见 transport.Conn.ConnectAttempts；续传重连后为新连接的尝试）
+func (s *Session) ConnectAttempts() []transport.ConnectAttempt {
+	return s.currentConn().ConnectAttempts()
 }
 
 // ConnectedAt 返回建连完成时间
 func (s *Session) ConnectedAt() time.Time {
-	return s.conn.ConnectedAt()
+	return s.currentConn().ConnectedAt()
+}
+
+// currentConn 返回当前连接（续传重连会替换连接）
+func (s *Session) currentConn() *transport.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
+// setFeatures 从 config_done 中读取服务端确认的功能标志、会话亲和令牌与消息编码（编码作用于 conn）
+func (s *Session) setFeatures(conn *transport.Conn, data []byte) {
+	var confirmed protocol.Features
+	var affinity, codec string
+	if msg, err := transport.ParseMessage(data); err == nil {
+		done := msg.(*protocol.SessionConfigDone)
+		confirmed, affinity, codec = done.Features, done.AffinityToken, done.Codec
+	}
+	features := transport.NegotiateFeatures(s.config.Features, confirmed)
+	if advertised := s.config.codec(); advertised != transport.JSON {
+		negotiated := transport.NegotiateCodec(advertised, codec)
+		conn.SetCodec(negotiated)
+		slog.Info("Codec negotiated", "component", "tts", "requested", advertised.Name(), "codec", negotiated.Name(), "id", s.ID)
+	}
+
+	s.mu.Lock()
+	s.features = features
+	if affinity != "" {
+		s.affinity = affinity
+	}
+	s.mu.Unlock()
+
+	if len(s.config.Features) > 0 {
+		slog.Info("Features negotiated", "component", "tts", "requested", s.config.Features, "enabled", features, "id", s.ID)
+	}
+}
+
+// Features 返回协商后的功能标志（客户端声明且 gateway 确认；config_done 之前为空）
+func (s *Session) Features() protocol.Features {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	features := make(protocol.Features, len(s.features))
+	for name, on := range s.features {
+		features[name] = on
+	}
+	return features
+}
+
+// AffinityToken 返回 gateway 在 config_done 中返回的会话亲和令牌（不支持亲和的 gateway 为空）
+//
+// 后续会话以 Config.WithAffinityToken 携带它，gateway 会尽量路由到同一后端。
+func (s *Session) AffinityToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.affinity
 }
 
 // ConfigDoneAt 返回 config_done 收到时间
@@ -1,9 +1,23 @@
 package tts
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
 )
 
 // TestEstablishCtxNoDeadline 验证：调用方未带 deadline 时，establishCtx 给建连阶段套默认超时。
@@ -64,3 +78,1218 @@ func TestEstablishCtxDefaultActuallyFires(t *testing.T) {
 		t.Fatal("derived ctx did not fire within expected window")
 	}
 }
+
+// TestLookupStreamRoutesByRoundID 验证：服务端回显 round_id 时按轮次路由，未回显时退回队列头部；
+// 未知的 round_id 不路由到任何轮次，也不弹出队列。
+// WHY：多轮并发时服务端可能交错返回，按 FIFO 头部归属会把音频串到错误的 stream。
+func TestLookupStreamRoutesByRoundID(t *testing.T) {
+	s := &Session{}
+	r1, r2 := newAudioStream(), newAudioStream()
+	r1.roundID, r2.roundID = "r1", "r2"
+	s.streamQueue = []*AudioStream{r1, r2}
+
+	if got := s.lookupStream("r2", false); got != r2 {
+		t.Fatalf("round r2 routed to %q", got.roundID)
+	}
+	if got := s.lookupStream("", false); got != r1 {
+		t.Fatalf("empty round_id should route to head, got %q", got.roundID)
+	}
+	if got := s.lookupStream("stale", true); got != nil {
+		t.Fatalf("unknown round routed to %q", got.roundID)
+	}
+	if len(s.streamQueue) != 2 {
+		t.Fatalf("unknown round popped a stream, %d left", len(s.streamQueue))
+	}
+	if got := s.lookupStream("r2", true); got != r2 {
+		t.Fatalf("pop r2 returned %q", got.roundID)
+	}
+	if len(s.streamQueue) != 1 || s.streamQueue[0] != r1 {
+		t.Fatalf("expected only r1 left in queue, got %d streams", len(s.streamQueue))
+	}
+}
+
+// TestAudioStreamBackpressure 验证：排队字节数达到 MaxBufferBytes 或 Pause() 后 pushChunk 阻塞，
+// 读取/Resume 后恢复。
+// WHY：8kHz 电话放音远慢于合成速度，无背压时长文本合成会把整段音频堆在内存里。
+func TestAudioStreamBackpressure(t *testing.T) {
+	st := newAudioStream()
+	st.maxBuffered = 100
+
+	if !st.pushChunk(AudioChunk{Data: make([]byte, 80)}) {
+		t.Fatal("first chunk should be accepted")
+	}
+
+	pushed := make(chan bool, 1)
+	go func() { pushed <- st.pushChunk(AudioChunk{Data: make([]byte, 40)}) }()
+	select {
+	case <-pushed:
+		t.Fatal("push over budget should block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	st.Pause()
+	buf := make([]byte, 80)
+	if n, err := st.Read(buf); n != 80 || err != nil {
+		t.Fatalf("read = %d, %v", n, err)
+	}
+	select {
+	case <-pushed:
+		t.Fatal("push should stay blocked while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	st.Resume()
+	select {
+	case ok := <-pushed:
+		if !ok {
+			t.Fatal("push failed after resume")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("push still blocked after resume")
+	}
+	if got := st.BufferedBytes(); got != 40 {
+		t.Fatalf("BufferedBytes = %d, want 40", got)
+	}
+}
+
+// TestAudioStreamDurationAndRTF 验证：AudioDuration 按 16-bit PCM 字节数换算，RTF 为合成耗时/音频时长；
+// 注入 clock.Fake 后 TTFB/RTF 可精确断言。
+// WHY：基准测试和线上监控各自按字节数推算时长，采样率/位深写错时 RTF 会差出整数倍。
+func TestAudioStreamDurationAndRTF(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1700000000, 0))
+	st := newAudioStream()
+	st.clock = fake
+	st.sampleRate = 8000
+	st.audioFormat = "pcm"
+
+	st.setCommitSentAt(fake.Now())
+	fake.Advance(120 * time.Millisecond)
+	st.markFirstChunk()
+	st.pushData(make([]byte, 16000), 1) // 8kHz 16-bit：16000 字节 = 1s
+	fake.Advance(380 * time.Millisecond)
+	st.pushDone()
+
+	if got := st.TTFB(); got != 120 {
+		t.Fatalf("TTFB = %dms, want 120ms", got)
+	}
+	if got := st.AudioDuration(); got != time.Second {
+		t.Fatalf("AudioDuration = %v, want 1s", got)
+	}
+	if rtf := st.RealTimeFactor(); rtf != 0.5 {
+		t.Fatalf("RealTimeFactor = %.3f, want 0.5", rtf)
+	}
+
+	st.audioFormat = "mp3"
+	if got := st.AudioDuration(); got != 0 {
+		t.Fatalf("AudioDuration for mp3 = %v, want 0", got)
+	}
+}
+
+// silentGatewayConn 连接到一个只读不回（从不发送 Close 帧）的 gateway
+func silentGatewayConn(t *testing.T) *transport.Conn {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := transport.DefaultConfig()
+	cfg.URL = "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn := transport.NewConn(cfg)
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// TestCloseWaitsForCloseFrameOnClock 验证：Close 等待 gateway Close 帧的超时由 CloseTimeout 和注入的时钟驱动，
+// 超时后返回 EndSent=true、Handshake=false 的 *transport.CloseError。
+// WHY：超时分支原先只能真实等待 2 秒才能覆盖，注入 Fake 后可在毫秒级确定性地验证；
+// Close 原先总返回 nil，调用方无法记录异常断开。
+func TestCloseWaitsForCloseFrameOnClock(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1700000000, 0))
+	s := newSession(silentGatewayConn(t), &Config{Clock: fake, CloseTimeout: 2 * time.Second}, &SynthesisOptions{})
+
+	done := make(chan struct{})
+	var closeErr error
+	go func() {
+		closeErr = s.Close()
+		close(done)
+	}()
+
+	fake.WaitForTimers(1)
+	fake.Advance(time.Second)
+	select {
+	case <-done:
+		t.Fatal("Close returned before close timeout elapsed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	fake.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return after close timeout")
+	}
+
+	var ce *transport.CloseError
+	if !errors.As(closeErr, &ce) {
+		t.Fatalf("Close error = %v, want *transport.CloseError", closeErr)
+	}
+	if !ce.EndSent || ce.Handshake || ce.EndErr != nil {
+		t.Errorf("CloseError = %+v, want session.end sent and handshake not completed", ce)
+	}
+	if err := s.Close(); err != closeErr {
+		t.Errorf("second Close = %v, want first result %v", err, closeErr)
+	}
+}
+
+// TestCloseSkipsWaitWhenShuttingDown 验证：会话 ctx 已取消时 Close 不等待关闭握手。
+// WHY：进程退出时成百上千个池化会话逐个等 2 秒，批量关闭会拖到分钟级。
+func TestCloseSkipsWaitWhenShuttingDown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := newSession(silentGatewayConn(t), &Config{CloseTimeout: time.Hour}, &SynthesisOptions{})
+	s.loopCtx = ctx
+
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close waited for close handshake during shutdown")
+	}
+}
+
+// TestAudioStreamWriteToAndBuffered 验证：WriteTo 输出 Read 剩余部分 + 后续音频块；Buffered 返回可 Seek 的完整剩余音频。
+// WHY：HTTP Range 响应需要随机访问合成结果，io.Copy 走 WriteTo 时不能丢失已缓冲未读的字节。
+func TestAudioStreamWriteToAndBuffered(t *testing.T) {
+	st := newAudioStream()
+	st.pushData([]byte("abcdef"), 1)
+	st.pushData([]byte("ghij"), 2)
+	st.pushDone()
+
+	head := make([]byte, 2)
+	if n, _ := st.Read(head); n != 2 || string(head) != "ab" {
+		t.Fatalf("Read = %q", head[:n])
+	}
+
+	r, err := st.Buffered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	rest, _ := io.ReadAll(r)
+	if string(rest) != "ghij" {
+		t.Fatalf("after seek = %q, want %q", rest, "ghij")
+	}
+	if r2, _ := st.Buffered(); r2.Size() != 8 {
+		t.Fatalf("second Buffered size = %d, want 8", r2.Size())
+	}
+	if st.TotalSize() != 10 {
+		t.Fatalf("TotalSize = %d, want 10", st.TotalSize())
+	}
+}
+
+// scriptedGateway 启动完成 session.ready / config_done 握手、收到 session.end 后正常关闭的 gateway，
+// 其余客户端消息交给 handle（msg 为原始 JSON 解析结果）；返回 ws:// 地址
+func scriptedGateway(t *testing.T, handle func(ws *websocket.Conn, msgType, roundID string, msg map[string]any)) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			msgType, _ := msg["type"].(string)
+			roundID, _ := msg["round_id"].(string)
+			switch protocol.MessageType(msgType) {
+			case protocol.MessageTypeSessionConfig:
+				// 模拟只支持 binary_audio 的 gateway：仅确认客户端声明的该功能
+				done := protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone}
+				info, _ := msg["client_info"].(map[string]any)
+				if features, _ := info["features"].(map[string]any); features[protocol.FeatureBinaryAudio] == true {
+					done.Features = protocol.Features{protocol.FeatureBinaryAudio: true}
+				}
+				// 会话亲和：回显客户端携带的令牌，未携带时分配 backend-1
+				done.AffinityToken = "backend-1"
+				if params, _ := msg["session"].(map[string]any); params["affinity_token"] != nil {
+					done.AffinityToken = params["affinity_token"].(string)
+				}
+				ws.WriteJSON(done)
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			default:
+				handle(ws, msgType, roundID, msg)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// writeDelta 发送一块填充为 fill 的 320 字节音频
+func writeDelta(ws *websocket.Conn, roundID string, fill byte) {
+	ws.WriteJSON(protocol.AudioDelta{Type: protocol.MessageTypeAudioDelta, RoundID: roundID,
+		Audio: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 320))})
+}
+
+// TestAudioStreamCancel 验证：Cancel 发送 input.cancel（携带 round_id），Read/Error 返回 ErrCancelled；
+// gateway 之后仍发来的该轮音频被丢弃，不会串到下一轮。
+// WHY：原先合成中途 Close 静默丢弃音频、gateway 继续合成，调用方也无法区分"取消"与"正常结束"。
+func TestAudioStreamCancel(t *testing.T) {
+	cancelled := make(chan string, 1)
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		switch protocol.MessageType(msgType) {
+		case protocol.MessageTypeInputCommit:
+			if roundID == "r1" {
+				writeDelta(ws, roundID, 1)
+			} else {
+				writeDelta(ws, roundID, 2)
+				ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+			}
+		case protocol.MessageTypeInputCancel:
+			cancelled <- roundID
+			// 模拟取消生效前已在途的音频
+			writeDelta(ws, roundID, 1)
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		}
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	first, err := session.SynthesizeStream(ctx, "第一轮")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Cancel(); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	select {
+	case id := <-cancelled:
+		if id != first.RoundID() {
+			t.Fatalf("input.cancel round_id = %q, want %q", id, first.RoundID())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("gateway did not receive input.cancel")
+	}
+	if _, err := first.Read(make([]byte, 10)); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("Read after Cancel = %v, want ErrCancelled", err)
+	}
+	if !errors.Is(first.Error(), ErrCancelled) {
+		t.Fatalf("Error() = %v, want ErrCancelled", first.Error())
+	}
+
+	second, err := session.SynthesizeStream(ctx, "第二轮")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(second)
+	if err != nil || !bytes.Equal(data, bytes.Repeat([]byte{2}, 320)) {
+		t.Fatalf("second round = %d bytes (%v), want 320 bytes of round 2 audio only", len(data), err)
+	}
+}
+
+// TestFirstChunkTimeout 验证：首个 audio.delta 未在 FirstChunkTimeout 内到达时，Read 返回 ErrTimeout，
+// 并通知 gateway 取消该轮。
+// WHY：gateway 卡住时轮次原先要等到 ReadTimeout（默认 60s）才失败，交互式通话早已冷场。
+func TestFirstChunkTimeout(t *testing.T) {
+	cancelled := make(chan string, 1)
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		if protocol.MessageType(msgType) == protocol.MessageTypeInputCancel {
+			cancelled <- roundID
+		}
+	})
+
+	fake := clock.NewFake(time.Unix(1700000000, 0))
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", Clock: fake, CloseTimeout: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, &SynthesisOptions{FirstChunkTimeout: 3 * time.Second})
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	stream, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.WaitForTimers(1)
+	fake.Advance(3 * time.Second)
+
+	if _, err := stream.Read(make([]byte, 10)); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Read = %v, want ErrTimeout", err)
+	}
+	select {
+	case id := <-cancelled:
+		if id != stream.RoundID() {
+			t.Fatalf("input.cancel round_id = %q, want %q", id, stream.RoundID())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("gateway did not receive input.cancel for timed out round")
+	}
+}
+
+// TestStallWatchdog 验证：收到部分音频后 gateway 既不再发音频也不报错时，StallTimeout 后 OnStall 收到 *StalledError；
+// 开启 CancelStalled 时 Read 返回同一错误并通知 gateway 取消该轮。
+// WHY：合成中途卡住时首包超时不生效，原先只能等 ReadTimeout（默认 60s），且调用方无法区分卡住与普通读超时。
+func TestStallWatchdog(t *testing.T) {
+	cancelled := make(chan string, 1)
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		switch protocol.MessageType(msgType) {
+		case protocol.MessageTypeInputCommit:
+			writeDelta(ws, roundID, 1) // 之后卡住
+		case protocol.MessageTypeInputCancel:
+			cancelled <- roundID
+		}
+	})
+
+	fake := clock.NewFake(time.Unix(1700000000, 0))
+	stalls := make(chan *StalledError, 1)
+	config := (&Config{GatewayURL: url, Provider: "tengen", Clock: fake, CloseTimeout: -1}).WithStallTimeout(2*time.Second, true)
+	config.OnStall = func(err *StalledError) { stalls <- err }
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	stream, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := stream.Read(make([]byte, 320)); n != 320 || err != nil {
+		t.Fatalf("first chunk = %d bytes, %v", n, err)
+	}
+	for i := 0; i < 12 && len(stalls) == 0; i++ {
+		fake.WaitForTimers(1)
+		fake.Advance(500 * time.Millisecond)
+		time.Sleep(time.Millisecond) // 让看门狗处理本次检查
+	}
+
+	var stalled *StalledError
+	select {
+	case stalled = <-stalls:
+	case <-time.After(time.Second):
+		t.Fatal("OnStall not called for stalled round")
+	}
+	if stalled.RoundID != stream.RoundID() || stalled.Received != 320 || stalled.Idle < 2*time.Second {
+		t.Fatalf("StalledError = %+v, want round %s, 320 bytes, idle >= 2s", stalled, stream.RoundID())
+	}
+	if _, err := stream.Read(make([]byte, 10)); !errors.As(err, &stalled) {
+		t.Fatalf("Read = %v, want *StalledError", err)
+	}
+	select {
+	case id := <-cancelled:
+		if id != stream.RoundID() {
+			t.Fatalf("input.cancel round_id = %q, want %q", id, stream.RoundID())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("gateway did not receive input.cancel for stalled round")
+	}
+}
+
+// TestOutputSampleRate 验证：开启 OutputSampleRate 后 audio.delta 被流式重采样，
+// 轮次结束时冲刷重采样器尾部，输出长度与采样率换算一致，SampleRate() 返回目标采样率。
+// WHY：提供商返回 16/24kHz 而电话侧需要 8kHz，原先只能读完整段后再调用 audio.Resample。
+func TestOutputSampleRate(t *testing.T) {
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		if protocol.MessageType(msgType) == protocol.MessageTypeInputCommit {
+			for i := 0; i < 3; i++ {
+				writeDelta(ws, roundID, 1)
+			}
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		}
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", AudioFormat: "pcm",
+		SampleRate: 16000, OutputSampleRate: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	stream, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3*320/2 {
+		t.Fatalf("output %d bytes, want %d (16kHz → 8kHz)", len(data), 3*320/2)
+	}
+	if got := stream.SampleRate(); got != 8000 {
+		t.Fatalf("SampleRate() = %d, want 8000", got)
+	}
+}
+
+// TestFeaturesNegotiated 验证：Config.Features 在 client_info.features 中声明，
+// Session.Features 只包含 gateway 在 config_done 中确认的功能。
+// WHY：新旧 gateway 混部时，新功能必须双方都支持才能启用，否则旧实例会收到无法处理的消息。
+func TestFeaturesNegotiated(t *testing.T) {
+	url := scriptedGateway(t, func(*websocket.Conn, string, string, map[string]any) {})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen",
+		Features: []string{protocol.FeatureBinaryAudio, protocol.FeatureWordTimestamps}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	features := session.Features()
+	if !features.Enabled(protocol.FeatureBinaryAudio) || features.Enabled(protocol.FeatureWordTimestamps) || len(features) != 1 {
+		t.Fatalf("Features() = %v, want only %s", features, protocol.FeatureBinaryAudio)
+	}
+}
+
+// TestAffinityToken 验证：Session.AffinityToken 返回 config_done 中的亲和令牌；
+// Config.AffinityToken 随 session.config 发送（模拟 gateway 回显收到的令牌）。
+// WHY：压测需要把上一次会话的令牌带到下一次会话，验证 gateway 粘性路由与热缓存是否生效。
+func TestAffinityToken(t *testing.T) {
+	url := scriptedGateway(t, func(*websocket.Conn, string, string, map[string]any) {})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, tc := range []struct{ sent, want string }{{"", "backend-1"}, {"backend-7", "backend-7"}} {
+		client, err := NewClient((&Config{GatewayURL: url, Provider: "tengen"}).WithAffinityToken(tc.sent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := client.CreateSession(ctx, nil)
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		if got := session.AffinityToken(); got != tc.want {
+			t.Errorf("sent %q: AffinityToken() = %q, want %q", tc.sent, got, tc.want)
+		}
+		session.Close()
+	}
+}
+
+// TestWarmup 验证：Warmup 预建的连接被之后的会话直接取用（不再新建连接），Close 关闭未被取用的连接。
+// WHY：首个请求的 TCP / TLS / WebSocket 握手占 100-300ms（tts_detailed_timing），对话场景的首句延迟对此最敏感。
+func TestWarmup(t *testing.T) {
+	var mu sync.Mutex
+	dials := 0
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		mu.Lock()
+		dials++
+		mu.Unlock()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg map[string]any
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch protocol.MessageType(msg["type"].(string)) {
+			case protocol.MessageTypeSessionConfig:
+				ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	connects := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return dials
+	}
+
+	client, err := NewClient(&Config{GatewayURL: "ws" + strings.TrimPrefix(srv.URL, "http"), Provider: "tengen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if n, err := client.Warmup(ctx, 2); n != 2 || err != nil {
+		t.Fatalf("Warmup(2) = %d, %v", n, err)
+	}
+	if got := connects(); got != 2 {
+		t.Fatalf("gateway saw %d connections after Warmup(2), want 2", got)
+	}
+
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+	if got := connects(); got != 2 {
+		t.Fatalf("CreateSession opened a new connection (%d total), want a warmed one", got)
+	}
+	if got := client.warm.Idle(); got != 1 {
+		t.Fatalf("Idle() = %d after one session, want 1", got)
+	}
+	client.Close()
+	if got := client.warm.Idle(); got != 0 {
+		t.Fatalf("Idle() = %d after Close, want 0", got)
+	}
+}
+
+// TestStreamUsage 验证：audio.done 携带的用量通过 stream.Usage() 暴露；拼接流为各轮之和，
+// 请求ID 以逗号连接；gateway 未提供时为 nil。
+// WHY：多租户成本分摊需要每次合成的计费字符数和提供商请求ID，原先 SDK 直接丢弃。
+func TestStreamUsage(t *testing.T) {
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, msg map[string]any) {
+		if protocol.MessageType(msgType) != protocol.MessageTypeInputCommit {
+			return
+		}
+		writeDelta(ws, roundID, 1)
+		done := protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID}
+		if roundID != "r1" {
+			done.Usage = &protocol.Usage{Characters: 2, RequestID: "req-" + roundID, Model: "m1"}
+		}
+		ws.WriteJSON(done)
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", VoiceID: "A"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	plain, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(plain); err != nil {
+		t.Fatal(err)
+	}
+	if u := plain.Usage(); u != nil {
+		t.Fatalf("Usage() = %+v, want nil when gateway sends none", u)
+	}
+
+	script, err := session.SynthesizeScript(ctx, []Line{{Text: "你好"}, {Text: "再见"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(script); err != nil {
+		t.Fatal(err)
+	}
+	u := script.Usage()
+	if u == nil || u.Characters != 4 || u.RequestID != "req-r2,req-r3" || u.Model != "m1" {
+		t.Fatalf("Usage() = %+v, want 4 characters from req-r2,req-r3", u)
+	}
+}
+
+// TestChunkMediaOffset 验证：Chunks() 中每块带接收时间和按累计采样计算的媒体时间偏移，
+// 拼接流（剧本/长文本）的偏移跨轮连续。
+// WHY：口型同步等渲染端需要精确安排每块的播放时间，原先 AudioChunk 只有 Data/Sequence。
+func TestChunkMediaOffset(t *testing.T) {
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		if protocol.MessageType(msgType) == protocol.MessageTypeInputCommit {
+			writeDelta(ws, roundID, 1)
+			writeDelta(ws, roundID, 2)
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		}
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", VoiceID: "A", AudioFormat: "pcm", SampleRate: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	offsets := func(stream *AudioStream) []time.Duration {
+		var got []time.Duration
+		for chunk := range stream.Chunks() {
+			if chunk.IsDone || chunk.Error != nil {
+				break
+			}
+			if chunk.ReceivedAt.IsZero() {
+				t.Fatalf("chunk %d has no ReceivedAt", chunk.Sequence)
+			}
+			got = append(got, chunk.MediaOffset)
+		}
+		return got
+	}
+
+	stream, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := offsets(stream); len(got) != 2 || got[0] != 0 || got[1] != 20*time.Millisecond {
+		t.Fatalf("single round offsets = %v, want [0 20ms]", got)
+	}
+
+	script, err := session.SynthesizeScript(ctx, []Line{{Text: "你好"}, {Text: "再见"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{0, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond}
+	if got := offsets(script.AudioStream); len(got) != len(want) || got[2] != want[2] || got[3] != want[3] {
+		t.Fatalf("concatenated offsets = %v, want %v", got, want)
+	}
+}
+
+// TestRequestIDSentWithCommit 验证：WithRequestID 设置的幂等键随 input.commit 发送，
+// 长文本切分的各轮派生为 "<id>-1"、"<id>-2"，未设置时不发送。
+// WHY：超时等结果不确定的失败后重试会在 gateway 侧重复计费、重复缓存，需要幂等键去重。
+func TestRequestIDSentWithCommit(t *testing.T) {
+	ids := make(chan string, 4)
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, msg map[string]any) {
+		if protocol.MessageType(msgType) == protocol.MessageTypeInputCommit {
+			id, _ := msg["request_id"].(string)
+			ids <- id
+			writeDelta(ws, roundID, 1)
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		}
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", MaxTextLength: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	for _, tc := range []struct {
+		ctx  context.Context
+		text string
+		want []string
+	}{
+		{WithRequestID(ctx, "job-1"), "你好", []string{"job-1"}},
+		{WithRequestID(ctx, "job-2"), "你好。再见。", []string{"job-2-1", "job-2-2"}},
+		{ctx, "你好", []string{""}},
+	} {
+		stream, err := session.SynthesizeStream(tc.ctx, tc.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(stream); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tc.want {
+			if got := <-ids; got != want {
+				t.Fatalf("%q: request_id = %q, want %q", tc.text, got, want)
+			}
+		}
+	}
+}
+
+// TestCodecNegotiated 验证：Config.Codec 为 msgpack 时在 client_info.codecs 中声明；gateway 在 config_done
+// 中选定后，客户端此后的消息以 MessagePack 二进制帧发送，并能解码 gateway 发来的 MessagePack 消息。
+// WHY：高频 audio.delta 的 JSON 编解码开销可观；编码必须经协商切换，旧版 gateway 仍按 JSON 工作。
+func TestCodecNegotiated(t *testing.T) {
+	frames := make(chan int, 10) // config 之后客户端消息的帧类型
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		write := func(v any) {
+			data, _ := transport.MessagePack.Marshal(v)
+			ws.WriteMessage(websocket.BinaryMessage, data)
+		}
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		var config protocol.SessionConfig
+		if ws.ReadJSON(&config) != nil {
+			return
+		}
+		if codecs := config.ClientInfo.Codecs; len(codecs) != 1 || codecs[0] != "msgpack" {
+			t.Errorf("client_info.codecs = %v, want [msgpack]", codecs)
+		}
+		write(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone, Codec: "msgpack"})
+		for {
+			frame, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			frames <- frame
+			var msg struct {
+				Type    protocol.MessageType `json:"type"`
+				RoundID string               `json:"round_id"`
+			}
+			if err := transport.DecodeMessage(data, &msg); err != nil {
+				t.Errorf("decode client message: %v", err)
+				return
+			}
+			switch msg.Type {
+			case protocol.MessageTypeInputCommit:
+				write(protocol.AudioDelta{Type: protocol.MessageTypeAudioDelta, RoundID: msg.RoundID,
+					Audio: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 320))})
+				write(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: msg.RoundID})
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClient((&Config{GatewayURL: "ws" + strings.TrimPrefix(srv.URL, "http"), Provider: "tengen"}).WithCodec("msgpack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	stream, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio, err := io.ReadAll(stream)
+	if err != nil || len(audio) != 320 {
+		t.Fatalf("ReadAll = %d bytes, %v; want 320 bytes", len(audio), err)
+	}
+	for len(frames) > 0 {
+		if frame := <-frames; frame != websocket.BinaryMessage {
+			t.Fatalf("client frame type = %d after codec negotiation, want binary", frame)
+		}
+	}
+}
+
+// TestSharedEventLoop 验证：Config.EventLoop 下多个会话共用一个 worker，各轮音频仍按 round_id 完整、有序地交付，
+// 关闭会话后不再回调。
+// WHY：共享模式替代每会话的 messageLoop，公开 API 与投递语义必须与默认模式一致，高密度部署才能直接切换。
+func TestSharedEventLoop(t *testing.T) {
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, msg map[string]any) {
+		if protocol.MessageType(msgType) != protocol.MessageTypeInputCommit {
+			return
+		}
+		fill := roundID[len(roundID)-1]
+		for i := 0; i < 3; i++ {
+			writeDelta(ws, roundID, fill)
+		}
+		ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+	})
+	loop := transport.NewEventLoop(1, nil)
+	defer loop.Close()
+	client, err := NewClient((&Config{GatewayURL: url, Provider: "tengen"}).WithEventLoop(loop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, err := client.CreateSession(ctx, nil)
+			if err != nil {
+				t.Errorf("CreateSession: %v", err)
+				return
+			}
+			defer session.Close()
+			for round := 1; round <= 2; round++ {
+				stream, err := session.SynthesizeStream(ctx, "你好")
+				if err != nil {
+					t.Errorf("SynthesizeStream: %v", err)
+					return
+				}
+				audio, err := io.ReadAll(stream)
+				want := bytes.Repeat([]byte{byte('0' + round)}, 3*320)
+				if err != nil || !bytes.Equal(audio, want) {
+					t.Errorf("round %d: %d bytes, %v; want %d bytes of %q", round, len(audio), err, len(want), '0'+round)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestErrorBypassesAudioBacklog 验证：调用方暂停消费、消息循环阻塞在背压上时，排在积压音频之后的 error
+// 经控制消息快速通道立即结束对应轮次；恢复后积压中该轮的音频被丢弃，下一轮不受影响。
+// WHY：error / audio.done 原先要等前面的音频逐个处理完才被看到，调用方暂停期间完全发现不了失败。
+func TestErrorBypassesAudioBacklog(t *testing.T) {
+	paused := make(chan struct{})
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		if protocol.MessageType(msgType) != protocol.MessageTypeInputCommit {
+			return
+		}
+		if roundID == "r1" {
+			<-paused
+			for i := 0; i < 50; i++ {
+				writeDelta(ws, roundID, 1)
+			}
+			ws.WriteJSON(protocol.ErrorMessage{Type: protocol.MessageTypeError, Code: "E_PROVIDER", Message: "provider failed", RoundID: roundID})
+			return
+		}
+		writeDelta(ws, roundID, 2)
+		ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	first, err := session.SynthesizeStream(ctx, "第一轮")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Pause()
+	close(paused)
+
+	deadline := time.Now().Add(time.Second)
+	for first.Error() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("error not reported while audio backlog is blocked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(first.Error().Error(), "E_PROVIDER") {
+		t.Fatalf("Error() = %v, want provider error", first.Error())
+	}
+	first.Resume()
+
+	second, err := session.SynthesizeStream(ctx, "第二轮")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(second)
+	if err != nil || !bytes.Equal(data, bytes.Repeat([]byte{2}, 320)) {
+		t.Fatalf("second round = %d bytes (%v), want 320 bytes of round 2 audio only", len(data), err)
+	}
+}
+
+// TestConfigNormalize 验证：Normalize 把零值字段补全为与 DefaultConfig 相同的默认值，负值（关闭）保持不变；
+// Warnings 报告可疑组合，默认配置没有警告。
+// WHY：零值曾经各处各自解释（ReadTimeout 0 在 transport 中关闭 deadline、在 Validate 中又补为 60s），
+// 同一份配置走不同入口行为不同。
+func TestConfigNormalize(t *testing.T) {
+	c := &Config{GatewayURL: "ws://gw", Provider: "tengen"}
+	c.Normalize()
+	def := DefaultConfig()
+	if c.ConnectTimeout != def.ConnectTimeout || c.ReadTimeout != def.ReadTimeout || c.WriteTimeout != def.WriteTimeout ||
+		c.ReconnectBackoff != def.ReconnectBackoff || c.MaxReconnects != def.MaxReconnects ||
+		c.MaxTextLength != def.MaxTextLength || c.CloseTimeout != def.CloseTimeout || c.Speed != def.Speed {
+		t.Fatalf("Normalize() = %+v, want defaults %+v", c, def)
+	}
+	if w := def.Warnings(); len(w) != 0 {
+		t.Fatalf("DefaultConfig warnings = %q, want none", w)
+	}
+
+	c = &Config{GatewayURL: "ws://gw", Provider: "tengen", ReadTimeout: -1, MaxReconnects: -1}
+	c.Normalize()
+	if c.ReadTimeout >= 0 || c.MaxReconnects >= 0 {
+		t.Fatalf("Normalize() overrode disabled values: ReadTimeout=%v MaxReconnects=%d", c.ReadTimeout, c.MaxReconnects)
+	}
+
+	c = DefaultConfig()
+	c.ConnectTimeout = 3 * time.Second
+	c.ReadTimeout = 5 * time.Second
+	c.WriteTimeout = 10 * time.Second
+	c.KeepaliveInterval = 5 * time.Second
+	if w := c.Warnings(); len(w) != 2 {
+		t.Fatalf("Warnings() = %q, want WriteTimeout and KeepaliveInterval warnings", w)
+	}
+}
+
+// TestSynthesizeToBytesDeadline 验证：ctx 带 deadline 时 SynthesizeToBytes 以 deadline 为整体时限——
+// 配置的 FirstChunkTimeout 更短也不提前失败，gateway 发出部分音频后卡住时在 deadline 返回
+// context.DeadlineExceeded 并通知 gateway 取消本轮。
+// WHY：原先读取阶段只受 ReadTimeout（默认 60s）约束，调用方的请求预算被忽略；两套超时谁先到算谁。
+func TestSynthesizeToBytesDeadline(t *testing.T) {
+	cancelled := make(chan string, 1)
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		switch protocol.MessageType(msgType) {
+		case protocol.MessageTypeInputCommit:
+			time.Sleep(100 * time.Millisecond) // 慢于 FirstChunkTimeout，早于 deadline
+			writeDelta(ws, roundID, 1)         // 之后卡住，不再发送
+		case protocol.MessageTypeInputCancel:
+			cancelled <- roundID
+		}
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen", FirstChunkTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.SynthesizeToBytes(ctx, "你好"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SynthesizeToBytes error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("SynthesizeToBytes returned after %v, want it bounded by the ctx deadline", elapsed)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("input.cancel not sent after deadline")
+	}
+}
+
+// TestGatewayPerProvider 验证：Config.Gateways 为指定提供商选择各自的 gateway，未列出的提供商使用 GatewayURL；
+// SynthesisOptions.Provider 按次选择提供商。
+// WHY：不同提供商部署在不同 gateway 集群时，多提供商应用原先只能为每个提供商各建一个 Client。
+func TestGatewayPerProvider(t *testing.T) {
+	gateway := func(fill byte) string {
+		return scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+			if protocol.MessageType(msgType) == protocol.MessageTypeInputCommit {
+				writeDelta(ws, roundID, fill)
+				ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+			}
+		})
+	}
+	config := &Config{GatewayURL: gateway(1), Provider: "tengen"}
+	client, err := NewClient(config.WithGateway("azure", gateway(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	data, err := client.SynthesizeToBytes(ctx, "你好")
+	if err != nil || len(data) == 0 || data[0] != 1 {
+		t.Fatalf("default provider: %d bytes (first %v), %v; want audio from GatewayURL", len(data), data[:min(len(data), 1)], err)
+	}
+
+	stream, err := client.SynthesizeStreamWithOptions(ctx, "你好", &SynthesisOptions{Provider: "azure", SampleRate: 8000, AudioFormat: "pcm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	data, err = stream.ReadAll()
+	if err != nil || len(data) == 0 || data[0] != 2 {
+		t.Fatalf("azure: %d bytes (first %v), %v; want audio from the azure gateway", len(data), data[:min(len(data), 1)], err)
+	}
+	if stream.session.Provider != "azure" {
+		t.Fatalf("session provider = %q, want azure", stream.session.Provider)
+	}
+}
+
+// TestLatencyBudget 验证：首包到达时建连 + 握手 + TTFB 超出 LatencyBudget 即回调 OnBudgetExceeded，指出耗时最长的
+// ttfb 阶段；会话后续轮次只计 TTFB；该轮失败时 Error() 为 *transport.LatencyError，仍可 errors.Is 原因。
+// WHY：线上"合成慢"的反馈原先无法分辨是建连、握手还是提供商首包慢，排查只能逐层抓日志。
+func TestLatencyBudget(t *testing.T) {
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		if protocol.MessageType(msgType) != protocol.MessageTypeInputCommit {
+			return
+		}
+		switch roundID {
+		case "r1":
+			time.Sleep(80 * time.Millisecond) // 提供商首包慢
+			writeDelta(ws, roundID, 1)
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		case "r2":
+			writeDelta(ws, roundID, 2)
+			ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+		default:
+			ws.WriteJSON(protocol.ErrorMessage{Type: protocol.MessageTypeError, RoundID: roundID, Code: "provider_error", Message: "boom"})
+		}
+	})
+
+	exceeded := make(chan *transport.BudgetExceededError, 3)
+	config := (&Config{GatewayURL: url, Provider: "tengen"}).WithLatencyBudget(50 * time.Millisecond)
+	config.OnBudgetExceeded = func(err *transport.BudgetExceededError) { exceeded <- err }
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	first, err := session.SynthesizeStream(ctx, "第一轮")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(first); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-exceeded:
+		if err.Phase != transport.PhaseTTFB || err.Breakdown.Budget != 50*time.Millisecond {
+			t.Fatalf("BudgetExceededError = %v, want ttfb phase and 50ms budget", err)
+		}
+	default:
+		t.Fatal("OnBudgetExceeded not called for the slow first round")
+	}
+	var phases []transport.LatencyPhase
+	for _, p := range first.LatencyBreakdown().Phases {
+		phases = append(phases, p.Phase)
+	}
+	if len(phases) != 3 || phases[0] != transport.PhaseConnect || phases[1] != transport.PhaseConfig || phases[2] != transport.PhaseTTFB {
+		t.Fatalf("first round phases = %v, want [connect config ttfb]", phases)
+	}
+
+	second, err := session.SynthesizeStream(ctx, "第二轮")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(second)
+	if b := second.LatencyBreakdown(); len(b.Phases) != 1 || b.Phases[0].Phase != transport.PhaseTTFB || b.Exceeded() {
+		t.Fatalf("second round breakdown = %v, want a single ttfb phase within budget", b)
+	}
+
+	third, err := session.SynthesizeStream(ctx, "第三轮")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(third)
+	var latencyErr *transport.LatencyError
+	if !errors.As(third.Error(), &latencyErr) || !strings.Contains(latencyErr.Error(), "provider_error") || !strings.Contains(latencyErr.Error(), "ttfb=") {
+		t.Fatalf("third round Error() = %v, want *transport.LatencyError with the ttfb breakdown", third.Error())
+	}
+	if len(exceeded) != 0 {
+		t.Fatalf("OnBudgetExceeded called %d extra times", len(exceeded))
+	}
+}
+
+// TestOnRawMessage 验证：OnRawMessage 按到达顺序收到每条 gateway 消息的类型和原始内容，包括 SDK 未建模的类型；
+// 未知消息不影响本轮合成。
+// WHY：gateway 新增的消息类型（如提供商元数据）原先只记一条 "Unknown message type" 日志就被丢弃，调用方无从获取。
+func TestOnRawMessage(t *testing.T) {
+	url := scriptedGateway(t, func(ws *websocket.Conn, msgType, roundID string, _ map[string]any) {
+		if protocol.MessageType(msgType) != protocol.MessageTypeInputCommit {
+			return
+		}
+		ws.WriteJSON(map[string]any{"type": "provider.metadata", "round_id": roundID, "model": "v2"})
+		writeDelta(ws, roundID, 1)
+		ws.WriteJSON(protocol.AudioDone{Type: protocol.MessageTypeAudioDone, RoundID: roundID})
+	})
+
+	client, err := NewClient(&Config{GatewayURL: url, Provider: "tengen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer session.Close()
+
+	var (
+		mu       sync.Mutex
+		types    []protocol.MessageType
+		metadata string
+	)
+	session.OnRawMessage(func(msgType protocol.MessageType, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		types = append(types, msgType)
+		if msgType == "provider.metadata" {
+			metadata = string(data)
+		}
+	})
+
+	stream, err := session.SynthesizeStream(ctx, "你好")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(stream); err != nil || len(data) != 320 {
+		t.Fatalf("ReadAll = %d bytes, %v; want 320 bytes", len(data), err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []protocol.MessageType{"provider.metadata", protocol.MessageTypeAudioDelta, protocol.MessageTypeAudioDone}
+	if len(types) != len(want) {
+		t.Fatalf("raw message types = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("raw message types = %v, want %v", types, want)
+		}
+	}
+	if !strings.Contains(metadata, `"model":"v2"`) {
+		t.Fatalf("provider.metadata data = %s, want the original JSON", metadata)
+	}
+}
@@ -3,10 +3,25 @@ package tts
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"log/slog"
 	"os"
 	"sync"
 	"time"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/audio"
+	"github.com/jinbozhan/tengen-speech-sdk-go/clock"
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+	"github.com/jinbozhan/tengen-speech-sdk-go/transport"
+)
+
+var (
+	// ErrCancelled 合成已被 AudioStream.Cancel（或合成完成前的 Close）取消
+	ErrCancelled = errors.New("synthesis cancelled")
+
+	// ErrTimeout 本轮首个音频块未在 FirstChunkTimeout 内到达
+	ErrTimeout = errors.New("first audio chunk timeout")
 )
 
 // AudioStream 音频流
@@ -21,13 +36,51 @@ type AudioStream struct {
 	chunkChMu      sync.Mutex
 	totalSize      int64
 	err            error
+	aborted        bool         // 已在客户端中止（Cancel / 完成前 Close / 首包超时），err 为原因
+	cancelRound    func() error // 通知 gateway 取消本轮（单轮 stream 由 Session 设置）
 	sessionCloser  io.Closer  // 用于关闭底层 session
 	session        *Session   // 用于访问 session 连接时间信息
+	roundID        string     // 合成轮次ID（随 text.append/input.commit 发送，服务端回显）
+	requestID      string     // 幂等键（WithRequestID，随 input.commit 发送）
+	sessionID      string     // 所属会话ID（用于与 gateway 侧日志关联）
+	sampleRate     int        // 本轮音频采样率（会话创建时协商；开启输出重采样时为目标采样率，用于播放）
+	audioFormat    string     // 本轮音频格式
+	bufferedData   []byte     // Buffered() 读取的剩余音频（首次调用后缓存）
+	normalizer     *audio.Normalizer // 响度归一化（Config.NormalizeOutput，仅 messageLoop 访问）
+	resampler      *audio.Resampler  // 输出重采样（Config.OutputSampleRate，仅 messageLoop 访问）
+	usage          *protocol.Usage   // audio.done 携带的用量（拼接流为各轮之和），受 mu 保护
+
+	// 断线续传状态（仅 messageLoop goroutine 访问）
+	text          string // 本轮合成文本（续传时重发）
+	delivered     int64  // 本轮已交付给调用方的字节数
+	resumeSkip    int64  // 续传后待丢弃的重复字节数
+	resumePending bool   // 已重发，等待续传后的首个 audio.delta 确定重叠量
+
+	// 背压：排队未读取字节数超过 maxBuffered 或 Pause() 时 pushChunk 阻塞，
+	// messageLoop 随之停止从连接读取，由 TCP 窗口把压力传回 gateway
+	flowMu      sync.Mutex
+	maxBuffered int64         // 排队未读取的最大字节数（0 不限制，仅受块数上限约束）
+	paused      bool          // Pause() 后暂停接收
+	queuedSizes []int         // 已推入 chunksCh 的块大小（末尾 len(chunksCh) 个仍在排队）
+	flowCh      chan struct{} // 状态变化时关闭并替换，唤醒阻塞中的 pushChunk
 
 	// 每轮独立的时间记录（管道化下每个 stream 有自己的 TTFB）
+	clock                clock.Clock
 	commitSentAt         time.Time // 本轮 input.commit 发送时间
 	firstChunkReceivedAt time.Time // 本轮首个 audio.delta 收到时间
+	lastChunkReceivedAt  time.Time // 本轮最后一个 audio.delta 收到时间
+	completedAt          time.Time // 本轮 audio.done 收到时间
+	receivedBytes        int64     // 已接收的音频字节数（不论调用方是否已读取）
+	firstChunkCh         chan struct{} // 首个音频块到达时关闭
+	firstChunkTimeout    time.Duration // 首包超时（<=0 不限制）
+	doneQueuedCh         chan struct{} // audio.done 已到达（音频可能仍在连接积压中）时关闭
+	doneQueuedOnce       sync.Once
 	timeMu               sync.Mutex
+
+	// 时延预算（Config.LatencyBudget，受 timeMu 保护）：setup 为会话建立阶段的耗时（只计入会话首轮）
+	budget   time.Duration
+	setup    []transport.PhaseLatency
+	onBudget func(err *transport.BudgetExceededError)
 }
 
 // AudioChunk 音频数据块
@@ -36,6 +89,11 @@ type AudioChunk struct {
 	Sequence  int    // 序列号
 	IsDone    bool   // 是否完成
 	Error     error  // 错误
+
+	// 播放调度（口型同步等）：ReceivedAt 为客户端收到该块 audio.delta 的时间，
+	// MediaOffset 为该块首个采样在本流音频中的时间位置（按累计采样数计算，仅 16-bit 单声道 PCM，其它格式为 0）
+	ReceivedAt  time.Time
+	MediaOffset time.Duration
 }
 
 // newAudioStream 创建音频流
@@ -44,9 +102,17 @@ func newAudioStream() *AudioStream {
 		chunksCh: make(chan AudioChunk, 100),
 		buffer:   new(bytes.Buffer),
 		closeCh:  make(chan struct{}),
+		flowCh:   make(chan struct{}),
+		clock:    clock.System,
+
+		firstChunkCh: make(chan struct{}),
+		doneQueuedCh: make(chan struct{}),
 	}
 }
 
+// flowPollInterval 缓冲区满时检查消费进度的间隔（Chunks() 消费方无法主动通知）
+const flowPollInterval = 10 * time.Millisecond
+
 // Read 实现io.Reader接口
 func (s *AudioStream) Read(p []byte) (n int, err error) {
 	s.mu.Lock()
@@ -57,29 +123,105 @@ func (s *AudioStream) Read(p []byte) (n int, err error) {
 		return s.buffer.Read(p)
 	}
 
-	// 检查是否已关闭
+	// 等待新的数据块
+	data, err := s.recvChunkLocked()
+	if err != nil {
+		return 0, err
+	}
+
+	// 写入缓冲区并读取
+	s.buffer.Write(data)
+	return s.buffer.Read(p)
+}
+
+// WriteTo 实现 io.WriterTo 接口：音频块直接写入 w，不经过内部缓冲区拷贝
+// io.Copy(file/conn, stream) 会自动使用
+func (s *AudioStream) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 先写出 Read 未读完的部分
+	written, err := s.buffer.WriteTo(w)
+	if err != nil {
+		return written, err
+	}
+
+	for {
+		data, err := s.recvChunkLocked()
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+
+		// 写入期间释放锁（w 可能是慢速网络连接）
+		s.mu.Unlock()
+		n, werr := w.Write(data)
+		s.mu.Lock()
+		written += int64(n)
+		if werr != nil {
+			return written, werr
+		}
+	}
+}
+
+// Buffered 读取剩余全部音频并返回可随机访问的 Reader（io.ReadSeeker + io.ReaderAt）
+// 适用于 HTTP Range 响应（http.ServeContent）等需要随机访问的场景。
+// 仅包含尚未通过 Read/WriteTo 读取的部分；在任何读取之前调用即为完整音频。
+// 可重复调用，每次返回独立的 Reader。
+func (s *AudioStream) Buffered() (*bytes.Reader, error) {
+	s.mu.Lock()
+	if s.bufferedData != nil {
+		defer s.mu.Unlock()
+		return bytes.NewReader(s.bufferedData), nil
+	}
+	s.mu.Unlock()
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bufferedData = buf.Bytes()
+	if s.bufferedData == nil {
+		s.bufferedData = []byte{}
+	}
+	return bytes.NewReader(s.bufferedData), nil
+}
+
+// recvChunkLocked 等待下一个音频块（调用方持有 s.mu，等待期间释放）
+// 流结束返回 io.EOF
+func (s *AudioStream) recvChunkLocked() ([]byte, error) {
+	// 检查是否已中止 / 关闭
+	if s.aborted {
+		return nil, s.err
+	}
 	if s.closed {
-		return 0, io.EOF
+		return nil, io.EOF
 	}
 
-	// 等待新的数据块
 	s.mu.Unlock()
 	chunk, ok := <-s.chunksCh
 	s.mu.Lock()
 
+	if s.aborted {
+		return nil, s.err
+	}
 	if !ok || chunk.IsDone {
 		s.closed = true
-		return 0, io.EOF
+		return nil, io.EOF
 	}
 
 	if chunk.Error != nil {
-		return 0, chunk.Error
+		return nil, chunk.Error
 	}
+	s.notifyFlow()
 
-	// 写入缓冲区并读取
-	s.buffer.Write(chunk.Data)
 	s.totalSize += int64(len(chunk.Data))
-	return s.buffer.Read(p)
+	return chunk.Data, nil
 }
 
 // Chunks 返回音频块channel（逐块接收）
@@ -88,6 +230,7 @@ func (s *AudioStream) Chunks() <-chan AudioChunk {
 }
 
 // pushChunk 推送音频块（内部使用）
+// 暂停或排队字节数超限时阻塞，直到调用方消费 / Resume() / stream 关闭
 func (s *AudioStream) pushChunk(chunk AudioChunk) bool {
 	s.chunkChMu.Lock()
 	if s.chunkChClosed {
@@ -96,6 +239,16 @@ func (s *AudioStream) pushChunk(chunk AudioChunk) bool {
 	}
 	s.chunkChMu.Unlock()
 
+	if !s.waitFlow(len(chunk.Data)) {
+		return false
+	}
+
+	// 持锁发送：错误可能由其他 goroutine 推送（取消、控制消息提前通知），发送不能与 close(chunksCh) 并发
+	s.chunkChMu.Lock()
+	defer s.chunkChMu.Unlock()
+	if s.chunkChClosed {
+		return false
+	}
 	select {
 	case s.chunksCh <- chunk:
 		return true
@@ -106,15 +259,141 @@ func (s *AudioStream) pushChunk(chunk AudioChunk) bool {
 
 // pushData 推送音频数据（内部使用）
 func (s *AudioStream) pushData(data []byte, seq int) {
+	now := s.clock.Now()
+	s.timeMu.Lock()
+	offset := s.receivedBytes
+	s.receivedBytes += int64(len(data))
+	s.lastChunkReceivedAt = now
+	s.timeMu.Unlock()
+
 	s.pushChunk(AudioChunk{
-		Data:     data,
-		Sequence: seq,
+		Data:        data,
+		Sequence:    seq,
+		ReceivedAt:  now,
+		MediaOffset: s.pcmDuration(offset),
 	})
 }
 
+// copiesAudio 推送前是否经重采样或归一化复制数据（为 true 时 handleAudioDelta 可复用解码缓冲区）
+func (s *AudioStream) copiesAudio() bool {
+	return s.resampler != nil || s.normalizer != nil
+}
+
+// trimResumed 丢弃续传后服务端重复发送的字节，并累计已交付字节数（内部使用）
+func (s *AudioStream) trimResumed(data []byte) []byte {
+	if s.resumeSkip > 0 {
+		if int64(len(data)) <= s.resumeSkip {
+			s.resumeSkip -= int64(len(data))
+			return nil
+		}
+		data = data[s.resumeSkip:]
+		s.resumeSkip = 0
+	}
+	s.delivered += int64(len(data))
+	return data
+}
+
+// waitFlow 等待可推送 n 字节（背压），stream 关闭时返回 false
+// 队列为空时总是放行，避免单块大于 maxBuffered 时永久阻塞
+func (s *AudioStream) waitFlow(n int) bool {
+	for {
+		s.flowMu.Lock()
+		buffered := s.bufferedLocked()
+		blocked := s.paused || (s.maxBuffered > 0 && buffered > 0 && buffered+int64(n) > s.maxBuffered)
+		if !blocked {
+			s.queuedSizes = append(s.queuedSizes, n)
+			s.flowMu.Unlock()
+			return true
+		}
+		wake := s.flowCh
+		paused := s.paused
+		s.flowMu.Unlock()
+
+		// 暂停时只等 Resume；缓冲区满时还需轮询消费进度
+		var timer clock.Timer
+		var poll <-chan time.Time
+		if !paused {
+			timer = s.clock.NewTimer(flowPollInterval)
+			poll = timer.C()
+		}
+		select {
+		case <-wake:
+		case <-poll:
+		case <-s.closeCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return false
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// bufferedLocked 返回排队未读取的字节数（调用方持有 flowMu）
+func (s *AudioStream) bufferedLocked() int64 {
+	queued := len(s.chunksCh)
+	if drop := len(s.queuedSizes) - queued; drop > 0 {
+		s.queuedSizes = s.queuedSizes[drop:]
+	}
+	var total int64
+	for _, n := range s.queuedSizes {
+		total += int64(n)
+	}
+	return total
+}
+
+// notifyFlow 唤醒阻塞中的 pushChunk
+func (s *AudioStream) notifyFlow() {
+	s.flowMu.Lock()
+	close(s.flowCh)
+	s.flowCh = make(chan struct{})
+	s.flowMu.Unlock()
+}
+
+// Pause 暂停接收音频：已排队的数据仍可读取，但不再从连接拉取新数据
+// 注意：同一会话的所有轮次共用一个连接，暂停期间其它轮次的音频也会停止接收；
+// 长时间暂停可能触发 gateway 侧超时
+func (s *AudioStream) Pause() {
+	s.flowMu.Lock()
+	s.paused = true
+	s.flowMu.Unlock()
+}
+
+// Resume 恢复接收音频
+func (s *AudioStream) Resume() {
+	s.flowMu.Lock()
+	s.paused = false
+	s.flowMu.Unlock()
+	s.notifyFlow()
+}
+
+// IsPaused 返回是否处于暂停状态
+func (s *AudioStream) IsPaused() bool {
+	s.flowMu.Lock()
+	defer s.flowMu.Unlock()
+	return s.paused
+}
+
+// BufferedBytes 返回已接收但尚未被读取的字节数（不含 Read 内部半读的数据块）
+func (s *AudioStream) BufferedBytes() int64 {
+	s.flowMu.Lock()
+	defer s.flowMu.Unlock()
+	return s.bufferedLocked()
+}
+
 // pushDone 推送完成信号（内部使用）
 func (s *AudioStream) pushDone() {
+	s.timeMu.Lock()
+	if s.completedAt.IsZero() {
+		s.completedAt = s.clock.Now()
+	}
+	s.timeMu.Unlock()
+
 	s.closeOnce.Do(func() {
+		close(s.closeCh) // 先唤醒阻塞在发送上的 pushChunk（其持有 chunkChMu）
+
 		// 先标记channel为已关闭，再发送最后的消息
 		s.chunkChMu.Lock()
 		if !s.chunkChClosed {
@@ -127,14 +406,18 @@ func (s *AudioStream) pushDone() {
 			close(s.chunksCh)
 		}
 		s.chunkChMu.Unlock()
-		close(s.closeCh)
 	})
 }
 
 // pushError 推送错误（内部使用）
 func (s *AudioStream) pushError(err error) {
-	s.err = err
+	err = s.withLatency(err)
 	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		close(s.closeCh) // 同 pushDone
+
 		s.chunkChMu.Lock()
 		if !s.chunkChClosed {
 			// 尝试发送错误信号（非阻塞）
@@ -146,10 +429,45 @@ func (s *AudioStream) pushError(err error) {
 			close(s.chunksCh)
 		}
 		s.chunkChMu.Unlock()
-		close(s.closeCh)
 	})
 }
 
+// Usage 返回本轮用量与计费元数据（gateway 未提供时为 nil）
+//
+// 流结束（收到 audio.done）后可用；长文本切分、剧本合成的拼接流为各轮之和，
+// RequestID 为各轮请求ID以逗号连接。
+func (s *AudioStream) Usage() *protocol.Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usage == nil {
+		return nil
+	}
+	usage := *s.usage
+	return &usage
+}
+
+// addUsage 累加用量（内部使用）
+func (s *AudioStream) addUsage(u *protocol.Usage) {
+	if u == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.usage == nil {
+		s.usage = &protocol.Usage{}
+	}
+	s.usage.Characters += u.Characters
+	if u.RequestID != "" {
+		if s.usage.RequestID != "" {
+			s.usage.RequestID += ","
+		}
+		s.usage.RequestID += u.RequestID
+	}
+	if s.usage.Model == "" {
+		s.usage.Model = u.Model
+	}
+}
+
 // SaveToFile 保存到文件
 func (s *AudioStream) SaveToFile(path string) error {
 	file, err := os.Create(path)
@@ -190,12 +508,31 @@ func (s *AudioStream) setSessionCloser(closer io.Closer) {
 	}
 }
 
-// Close 关闭流
-func (s *AudioStream) Close() error {
+// Cancel 取消合成：通知 gateway 停止本轮合成（input.cancel），丢弃剩余音频
+// 之后 Read 与 Error() 返回 ErrCancelled。流已结束（EOF / 出错）时无效果。
+func (s *AudioStream) Cancel() error {
+	return s.cancel(ErrCancelled)
+}
+
+// cancel 以 err 中止本轮并通知 gateway（Cancel 与简化 API 的 ctx 到期共用）
+func (s *AudioStream) cancel(err error) error {
+	if !s.abort(err) || s.cancelRound == nil {
+		return nil
+	}
+	return s.cancelRound()
+}
+
+// abort 在客户端中止本轮：结束 stream，之后 Read 与 Error() 返回 err；流已结束时返回 false
+func (s *AudioStream) abort(err error) bool {
+	aborted := false
+	err = s.withLatency(err)
 	s.closeOnce.Do(func() {
+		aborted = true
 		s.mu.Lock()
-		s.closed = true
+		s.aborted = true
+		s.err = err
 		s.mu.Unlock()
+		close(s.closeCh) // 同 pushDone
 
 		s.chunkChMu.Lock()
 		if !s.chunkChClosed {
@@ -203,15 +540,38 @@ func (s *AudioStream) Close() error {
 			close(s.chunksCh)
 		}
 		s.chunkChMu.Unlock()
+	})
+	return aborted
+}
 
-		close(s.closeCh)
+// Close 关闭流
+// 合成完成前调用等同于 Cancel；之后关闭底层 session（客户端级 stream），返回其关闭错误（见 Session.Close）
+func (s *AudioStream) Close() error {
+	err := s.Cancel()
 
-		// 关闭底层 session（会关闭 WebSocket 连接）
-		if s.sessionCloser != nil {
-			s.sessionCloser.Close()
-		}
-	})
-	return nil
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	// 关闭底层 session（会关闭 WebSocket 连接）；Session.Close 可重复调用
+	if s.sessionCloser != nil {
+		err = errors.Join(err, s.sessionCloser.Close())
+	}
+	return err
+}
+
+// IsCancelled 检查是否已取消
+func (s *AudioStream) IsCancelled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aborted && errors.Is(s.err, ErrCancelled)
+}
+
+// isAborted 是否已在客户端中止（等待 gateway 结束该轮，期间音频丢弃）
+func (s *AudioStream) isAborted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aborted
 }
 
 // IsClosed 检查是否已关闭
@@ -221,6 +581,26 @@ func (s *AudioStream) IsClosed() bool {
 	return s.closed
 }
 
+// SessionID 返回所属会话ID（gateway 分配，可用于关联服务端日志）
+func (s *AudioStream) SessionID() string {
+	return s.sessionID
+}
+
+// RequestID 返回本轮的幂等键（未通过 WithRequestID 设置时为空）
+func (s *AudioStream) RequestID() string {
+	return s.requestID
+}
+
+// SampleRate 返回音频采样率
+func (s *AudioStream) SampleRate() int {
+	return s.sampleRate
+}
+
+// RoundID 返回本轮的合成轮次ID（分段拼接的 stream 为空）
+func (s *AudioStream) RoundID() string {
+	return s.roundID
+}
+
 // CommitSentAt 返回本轮 input.commit 发送时间
 func (s *AudioStream) CommitSentAt() time.Time {
 	s.timeMu.Lock()
@@ -247,6 +627,48 @@ func (s *AudioStream) TTFB() int64 {
 	return s.firstChunkReceivedAt.Sub(s.commitSentAt).Milliseconds()
 }
 
+// AudioDuration 返回已接收音频的播放时长（按字节数、采样率和 16-bit 单声道 PCM 计算）
+// 非 PCM 格式（mp3 等）无法从字节数推算，返回 0
+func (s *AudioStream) AudioDuration() time.Duration {
+	s.timeMu.Lock()
+	received := s.receivedBytes
+	s.timeMu.Unlock()
+	return s.pcmDuration(received)
+}
+
+// pcmDuration 将 PCM 字节数换算为时长
+func (s *AudioStream) pcmDuration(n int64) time.Duration {
+	if s.sampleRate <= 0 || (s.audioFormat != "" && s.audioFormat != "pcm") {
+		return 0
+	}
+	bytesPerSecond := int64(s.sampleRate) * 2
+	return time.Duration(n * int64(time.Second) / bytesPerSecond)
+}
+
+// SynthesisDuration 返回本轮合成耗时（commit 发送到 audio.done；未完成时到最后一个音频块）
+func (s *AudioStream) SynthesisDuration() time.Duration {
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+	end := s.completedAt
+	if end.IsZero() {
+		end = s.lastChunkReceivedAt
+	}
+	if s.commitSentAt.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(s.commitSentAt)
+}
+
+// RealTimeFactor 返回实时率 RTF = 合成耗时 / 音频时长（小于 1 表示快于实时）
+// 尚无音频或无法计算音频时长时返回 0
+func (s *AudioStream) RealTimeFactor() float64 {
+	audioDur := s.AudioDuration()
+	if audioDur <= 0 {
+		return 0
+	}
+	return float64(s.SynthesisDuration()) / float64(audioDur)
+}
+
 // setCommitSentAt 记录本轮 commit 发送时间（内部使用）
 func (s *AudioStream) setCommitSentAt(t time.Time) {
 	s.timeMu.Lock()
@@ -256,11 +678,84 @@ func (s *AudioStream) setCommitSentAt(t time.Time) {
 
 // markFirstChunk 记录本轮首包接收时间（内部使用，仅第一次调用生效）
 func (s *AudioStream) markFirstChunk() {
+	s.markFirstChunkAt(s.clock.Now())
+}
+
+// markFirstChunkAt 以指定时间记录本轮首包（内部使用，用于分段拼接沿用首段的首包时间）
+func (s *AudioStream) markFirstChunkAt(t time.Time) {
+	var exceeded *transport.BudgetExceededError
 	s.timeMu.Lock()
-	if s.firstChunkReceivedAt.IsZero() {
-		s.firstChunkReceivedAt = time.Now()
+	if s.firstChunkReceivedAt.IsZero() && !t.IsZero() {
+		s.firstChunkReceivedAt = t
+		close(s.firstChunkCh)
+		if b := s.breakdownLocked(t); b.Exceeded() {
+			exceeded = transport.NewBudgetExceededError(b)
+		}
 	}
+	onBudget := s.onBudget
 	s.timeMu.Unlock()
+
+	if exceeded != nil {
+		slog.Warn("Latency budget exceeded", "component", "tts", "id", s.sessionID, "round_id", s.roundID,
+			"phase", exceeded.Phase, "breakdown", exceeded.Breakdown.String())
+		if onBudget != nil {
+			onBudget(exceeded)
+		}
+	}
+}
+
+// LatencyBreakdown 返回本轮各阶段耗时（会话首轮含建连与握手；首包未到时 TTFB 计到当前时刻）
+func (s *AudioStream) LatencyBreakdown() transport.LatencyBreakdown {
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+	return s.breakdownLocked(s.clock.Now())
+}
+
+// breakdownLocked 以 now 为未完成阶段的截止时间计算明细（调用方持有 timeMu）
+func (s *AudioStream) breakdownLocked(now time.Time) transport.LatencyBreakdown {
+	b := transport.LatencyBreakdown{Budget: s.budget, Phases: append([]transport.PhaseLatency(nil), s.setup...)}
+	if !s.commitSentAt.IsZero() {
+		end := s.firstChunkReceivedAt
+		if end.IsZero() {
+			end = now
+		}
+		b.Phases = append(b.Phases, transport.PhaseLatency{Phase: transport.PhaseTTFB, Duration: end.Sub(s.commitSentAt)})
+	}
+	return b
+}
+
+// withLatency 设置了时延预算时为本轮的失败原因附加阶段明细（调用方主动取消除外）
+func (s *AudioStream) withLatency(err error) error {
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+	if s.budget <= 0 || err == nil || errors.Is(err, ErrCancelled) {
+		return err
+	}
+	return &transport.LatencyError{Err: err, Breakdown: s.breakdownLocked(s.clock.Now())}
+}
+
+// markDoneQueued 记录本轮 audio.done 已到达（控制消息提前通知，内部使用）
+func (s *AudioStream) markDoneQueued() {
+	s.doneQueuedOnce.Do(func() { close(s.doneQueuedCh) })
+}
+
+// received 返回已接收的音频字节数（内部使用）
+func (s *AudioStream) received() int64 {
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+	return s.receivedBytes
+}
+
+// finished 本轮是否已结束，或 audio.done 已到达（内部使用）
+func (s *AudioStream) finished() bool {
+	select {
+	case <-s.closeCh:
+		return true
+	case <-s.doneQueuedCh:
+		return true
+	default:
+		return false
+	}
 }
 
 // ConnectDuration 返回建连耗时（TCP+TLS+WS握手）
@@ -271,6 +766,30 @@ func (s *AudioStream) ConnectDuration() time.Duration {
 	return s.session.ConnectDuration()
 }
 
+// Warm 会话是否使用预热连接（见 Session.Warm）
+func (s *AudioStream) Warm() bool {
+	if s.session == nil {
+		return false
+	}
+	return s.session.Warm()
+}
+
+// ConnectAttempts 返回会话建连的各次尝试（多于一次说明经过重试，见 Session.ConnectAttempts）
+func (s *AudioStream) ConnectAttempts() []transport.ConnectAttempt {
+	if s.session == nil {
+		return nil
+	}
+	return s.session.ConnectAttempts()
+}
+
+// AffinityToken 返回会话亲和令牌（见 Session.AffinityToken）
+func (s *AudioStream) AffinityToken() string {
+	if s.session == nil {
+		return ""
+	}
+	return s.session.AffinityToken()
+}
+
 // ConnectedAt 返回建连完成时间
 func (s *AudioStream) ConnectedAt() time.Time {
 	if s.session == nil {
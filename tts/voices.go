@@ -0,0 +1,37 @@
+// Package tts 音色别名
+package tts
+
+import "fmt"
+
+// VoiceAliases 音色别名：逻辑名 → 提供商 → 该提供商的音色 ID
+//
+// 应用代码使用稳定的逻辑名（如 "support-female-en-NG"），SDK 按会话实际连接的提供商解析为对应的音色 ID；
+// 不是别名的 VoiceID 原样发送。
+type VoiceAliases map[string]map[string]string
+
+// resolve 把 voice 解析为 provider 的音色 ID；别名未配置该提供商时返回错误
+func (a VoiceAliases) resolve(provider, voice string) (string, error) {
+	ids, ok := a[voice]
+	if !ok {
+		return voice, nil
+	}
+	if id := ids[provider]; id != "" {
+		return id, nil
+	}
+	return "", ErrInvalidConfig(fmt.Sprintf("voice alias %q has no voice for provider %q", voice, provider))
+}
+
+// validate 校验别名：逻辑名与各提供商的音色 ID 均非空
+func (a VoiceAliases) validate() error {
+	for alias, ids := range a {
+		if alias == "" {
+			return ErrInvalidConfig("VoiceAliases must not contain an empty alias")
+		}
+		for provider, id := range ids {
+			if id == "" {
+				return ErrInvalidConfig(fmt.Sprintf("voice alias %q has an empty voice for provider %q", alias, provider))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package tts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jinbozhan/tengen-speech-sdk-go/protocol"
+)
+
+// TestVoiceAliases 验证：音色别名按会话的提供商解析后才发给 gateway（session.config 与 session.update），
+// Options() 保留逻辑名；别名未配置当前提供商时 NewClient 报错。
+// WHY：应用代码只认稳定的逻辑音色名，切换提供商时若把别名原样发出，gateway 会因未知音色拒绝合成。
+func TestVoiceAliases(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		voices []string // 按顺序记录 "<消息类型> <voice_id>"
+	)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		ws.WriteJSON(protocol.SessionReady{Type: protocol.MessageTypeSessionReady, SessionID: "s1"})
+		for {
+			var msg protocol.SessionConfig
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			switch msg.Type {
+			case protocol.MessageTypeSessionConfig, protocol.MessageTypeSessionUpdate:
+				mu.Lock()
+				voices = append(voices, string(msg.Type)+" "+msg.Session.VoiceID)
+				mu.Unlock()
+				if msg.Type == protocol.MessageTypeSessionConfig {
+					ws.WriteJSON(protocol.SessionConfigDone{Type: protocol.MessageTypeSessionConfigDone})
+				}
+			case protocol.MessageTypeSessionEnd:
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	config := (&Config{GatewayURL: "ws" + strings.TrimPrefix(srv.URL, "http"), Provider: "tengen", VoiceID: "support"}).
+		WithVoiceAlias("support", "tengen", "tg-female-07").
+		WithVoiceAlias("support", "azure", "en-NG-EzinneNeural").
+		WithVoiceAlias("sales", "tengen", "tg-male-02")
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	session, err := client.CreateSession(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.UpdateOptions(&SynthesisOptions{VoiceID: "sales"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := session.Options().VoiceID; got != "sales" {
+		t.Fatalf("Options().VoiceID = %q, want the logical name", got)
+	}
+	session.Close()
+
+	// 切到其它提供商的会话（如应用层的提供商降级）使用该提供商的音色
+	fallback, err := client.CreateSession(ctx, &SynthesisOptions{Provider: "azure", VoiceID: "support", SampleRate: 8000, AudioFormat: "pcm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback.Close()
+
+	mu.Lock()
+	got := append([]string(nil), voices...)
+	mu.Unlock()
+	want := []string{"session.config tg-female-07", "session.update tg-male-02", "session.config en-NG-EzinneNeural"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("voices sent = %q, want %q", got, want)
+	}
+
+	if _, err := NewClient(config.WithProvider("minimax")); err == nil {
+		t.Fatal("NewClient accepted an alias without a voice for the provider")
+	}
+}
@@ -0,0 +1,94 @@
+// Package tts 卡住轮次检测
+package tts
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// StalledError 轮次卡住：开始接收（或上一个音频块）之后超过 Config.StallTimeout 没有
+// audio.delta、audio.done 或 error，gateway 也没有断开连接
+type StalledError struct {
+	SessionID string
+	RoundID   string
+	Idle      time.Duration // 最后一次进展至今的时间
+	Received  int64         // 已收到的音频字节数（0 表示首包都未到达）
+}
+
+func (e *StalledError) Error() string {
+	if e.Received == 0 {
+		return fmt.Sprintf("round %s stalled: no audio for %v since commit", e.RoundID, e.Idle)
+	}
+	return fmt.Sprintf("round %s stalled: no audio for %v after %d bytes", e.RoundID, e.Idle, e.Received)
+}
+
+// stallState 看门狗对队列头部轮次的观察（仅在看门狗中访问）
+type stallState struct {
+	stream   *AudioStream
+	received int64
+	since    time.Time // 最后一次观察到进展的时间
+	reported bool      // 本次卡住已上报
+}
+
+// stallCheckInterval 看门狗检查间隔（检测延迟不超过 StallTimeout 的 1/4）
+func stallCheckInterval(timeout time.Duration) time.Duration {
+	return timeout / 4
+}
+
+// watchdog 检查队列头部轮次是否卡住（按 stallCheckInterval 周期调用），会话结束后返回 false
+//
+// 调用方暂停或背压阻塞接收、连接已断开（等待续传或重连）时不计时：此时没有进展不是 gateway 的问题。
+func (s *Session) watchdog() bool {
+	if s.IsClosed() || s.ctx.Err() != nil {
+		return false
+	}
+	now := s.clock().Now()
+	head := s.headStream()
+	st := &s.stall
+
+	s.mu.Lock()
+	lost := s.disconnected || !s.conn.IsConnected()
+	s.mu.Unlock()
+
+	if head == nil || lost || head.finished() || head.IsPaused() || head.BufferedBytes() > 0 {
+		*st = stallState{stream: head, since: now}
+		if head != nil {
+			st.received = head.received()
+		}
+		return true
+	}
+	if received := head.received(); head != st.stream || received != st.received {
+		*st = stallState{stream: head, received: received, since: now}
+		return true
+	}
+
+	idle := now.Sub(st.since)
+	if st.reported || idle < s.config.StallTimeout {
+		return true
+	}
+	st.reported = true
+
+	err := &StalledError{SessionID: s.ID, RoundID: head.roundID, Idle: idle, Received: st.received}
+	slog.Warn("Round stalled", "component", "tts", "round_id", head.roundID, "idle", idle,
+		"received", st.received, "cancel", s.config.CancelStalled, "id", s.ID)
+	if s.config.OnStall != nil {
+		s.config.OnStall(err)
+	}
+	if s.config.CancelStalled && head.abort(err) {
+		if cerr := s.cancelRound(head); cerr != nil {
+			slog.Warn("Cancel stalled round failed", "component", "tts", "round_id", head.roundID, "error", cerr)
+		}
+	}
+	return true
+}
+
+// headStream 返回队列头部（正在接收音频）的 stream
+func (s *Session) headStream() *AudioStream {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	if len(s.streamQueue) == 0 {
+		return nil
+	}
+	return s.streamQueue[0]
+}
@@ -0,0 +1,14 @@
+// Package client SDK 版本信息
+package client
+
+import "github.com/jinbozhan/tengen-speech-sdk-go/version"
+
+// Version 返回 SDK 版本号（构建时通过 -ldflags 注入，见 version 包）
+func Version() string {
+	return version.Get().Version
+}
+
+// BuildInfo 返回完整的构建信息
+func BuildInfo() version.Info {
+	return version.Get()
+}
@@ -0,0 +1,96 @@
+// Package version SDK 版本与构建信息
+//
+// Version、Commit、BuildDate 在构建时通过 -ldflags 注入：
+//
+//	go build -ldflags "-X github.com/jinbozhan/tengen-speech-sdk-go/version.Version=v1.2.0 \
+//	  -X github.com/jinbozhan/tengen-speech-sdk-go/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/jinbozhan/tengen-speech-sdk-go/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// 未注入时（SDK 作为依赖被引用），Version 取 go.mod 中记录的模块版本。
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// SDKName SDK 标识（上报给 gateway）
+const SDKName = "tengen-speech-sdk-go"
+
+// modulePath SDK 模块路径（用于从构建信息中查找依赖版本）
+const modulePath = "github.com/jinbozhan/tengen-speech-sdk-go"
+
+// 构建时注入的版本信息
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info 构建信息
+type Info struct {
+	SDK       string `json:"sdk"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+	Platform  string `json:"platform,omitempty"` // GOOS/GOARCH
+}
+
+var (
+	infoOnce sync.Once
+	info     Info
+)
+
+// Get 返回构建信息
+func Get() Info {
+	infoOnce.Do(func() {
+		info = Info{
+			SDK:       SDKName,
+			Version:   Version,
+			Commit:    Commit,
+			BuildDate: BuildDate,
+			GoVersion: runtime.Version(),
+			Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		}
+		if info.Version == "dev" {
+			if v := moduleVersion(); v != "" {
+				info.Version = v
+			}
+		}
+	})
+	return info
+}
+
+// String 返回版本字符串，如 "v1.2.0 (abc1234, 2024-01-05T08:00:00Z)"
+func (i Info) String() string {
+	s := i.Version
+	switch {
+	case i.Commit != "" && i.BuildDate != "":
+		s += " (" + i.Commit + ", " + i.BuildDate + ")"
+	case i.Commit != "":
+		s += " (" + i.Commit + ")"
+	}
+	return s
+}
+
+// moduleVersion 从构建信息中读取 SDK 模块版本（作为依赖或 go install 时有效）
+func moduleVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if bi.Main.Path == modulePath && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return bi.Main.Version
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}